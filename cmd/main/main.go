@@ -9,13 +9,18 @@ import (
 	"log"
 	"os"
 	"os/signal"
+	"sync"
 	"syscall"
 	"vk-inter/internal/config"
 	"vk-inter/internal/repository"
 	"vk-inter/internal/service"
 	rest "vk-inter/internal/transport"
+	"vk-inter/pkg/blob"
 	"vk-inter/pkg/db/mongo"
 	"vk-inter/pkg/logger"
+	"vk-inter/pkg/money"
+	"vk-inter/pkg/pubsub"
+	"vk-inter/pkg/utils"
 
 	"go.uber.org/zap"
 )
@@ -41,14 +46,140 @@ func main() {
 		mainLogger.Fatal("Create MongoDB instanse error", zap.Error(err))
 	}
 	mainLogger.Debug("DB connected")
+	// Без replica set операции с предложениями и другие транзакции завершаются ошибкой
+	if ok, err := db.SupportsTransactions(ctx); err != nil {
+		mainLogger.Warn("Check MongoDB transactions support error", zap.Error(err))
+	} else if !ok {
+		mainLogger.Warn("MongoDB is not a replica set, offers and other transactional operations will fail")
+	}
 
 	authRepo := repository.NewAuthRepo(ctx, db)
 	authService := service.NewAuthService(authRepo, cfg.Secret)
 
+	categoryRepo := repository.NewCategoryRepo(ctx, db)
+
+	blobStore, err := blob.New(ctx, cfg.BlobConfig)
+	if err != nil {
+		mainLogger.Fatal("Create blob store error", zap.Error(err))
+	}
+	uploadRepo := repository.NewUploadRepo(ctx, db)
+
 	listingRepo := repository.NewListingRepo(ctx, db)
-	listingService := service.NewListingService(listingRepo)
+	imageFetcher := utils.NewImageFetcher(cfg.ImageFetchConfig)
+
+	rates, err := money.NewStaticRates(cfg.RatesFile)
+	if err != nil {
+		mainLogger.Warn("Exchange rates are not loaded, only RUB prices are supported", zap.Error(err))
+		rates, _ = money.NewStaticRatesFrom("RUB", nil)
+	}
+	ps, err := pubsub.New(cfg.PubSubConfig)
+	if err != nil {
+		mainLogger.Fatal("Create pubsub error", zap.Error(err))
+	}
+	eventHub := service.NewEventHub(ps, cfg.RealtimeConfig)
+
+	imageCache := service.NewImageCache(repository.NewImageRepo(ctx, db), listingRepo, blobStore, imageFetcher, cfg.ImageCacheConfig, cfg.UploadConfig)
+	duplicateDetector := service.NewDuplicateDetector(listingRepo, repository.NewDuplicateRepo(ctx, db), cfg.DuplicateConfig)
+	mediaValidator := service.NewMediaValidator(repository.NewMediaJobRepo(ctx, db), listingRepo, imageCache, duplicateDetector, eventHub, cfg.MediaConfig)
+	notificationService := service.NewNotificationService(repository.NewNotificationRepo(ctx, db), eventHub)
+	favoriteRepo := repository.NewFavoriteRepo(ctx, db)
+	priceAlerts := service.NewPriceAlerts(repository.NewPriceHistoryRepo(ctx, db), repository.NewPriceWatchRepo(ctx, db), favoriteRepo, listingRepo, notificationService, cfg.PriceAlertConfig)
+	auctionService := service.NewAuctionService(repository.NewAuctionRepo(ctx, db), listingRepo, notificationService, eventHub, service.SystemClock{}, cfg.AuctionConfig)
+	moderationService := service.NewModerationService(repository.NewModerationRepo(ctx, db), listingRepo, eventHub, cfg.ModerationConfig)
+	contentFilter := service.NewContentFilter(ctx, cfg.ContentFilterConfig)
+	analyticsRepo := repository.NewAnalyticsRepo(ctx, db)
+	viewCounter := service.NewViewCounter(analyticsRepo, service.SystemClock{}, cfg.ViewsConfig)
+	listingService := service.NewListingService(listingRepo, categoryRepo, uploadRepo, mediaValidator, duplicateDetector, priceAlerts, auctionService, contentFilter, moderationService, viewCounter, rates, cfg.ListingConfig, cfg.Secret)
+	if err := listingService.SyncExchangeRates(ctx); err != nil {
+		mainLogger.Fatal("Recalculate listing prices error", zap.Error(err))
+	}
+
+	imageProcessor := service.NewImageProcessor(uploadRepo, listingRepo, blobStore, cfg.VariantsConfig, cfg.UploadConfig)
+	uploadService := service.NewUploadService(uploadRepo, blobStore, imageProcessor, cfg.UploadConfig)
+
+	savedSearchRepo := repository.NewSavedSearchRepo(ctx, db)
+	searchMatcher := service.NewSearchMatcher(savedSearchRepo, listingRepo, categoryRepo, notificationService, imageFetcher, cfg.SavedSearchConfig)
+	offerService := service.NewOfferService(repository.NewOfferRepo(ctx, db), listingRepo, notificationService, eventHub, cfg.OfferConfig)
+
+	workersCtx, stopWorkers := context.WithCancel(ctx)
+	var workers sync.WaitGroup
+	workers.Add(10)
+	go func() {
+		defer workers.Done()
+		eventHub.Run(workersCtx)
+	}()
+	go func() {
+		defer workers.Done()
+		imageProcessor.Run(workersCtx)
+	}()
+	go func() {
+		defer workers.Done()
+		mediaValidator.Run(workersCtx)
+	}()
+	go func() {
+		defer workers.Done()
+		imageCache.Run(workersCtx)
+	}()
+	go func() {
+		defer workers.Done()
+		searchMatcher.Run(workersCtx)
+	}()
+	go func() {
+		defer workers.Done()
+		priceAlerts.Run(workersCtx)
+	}()
+	go func() {
+		defer workers.Done()
+		offerService.Run(workersCtx)
+	}()
+	go func() {
+		defer workers.Done()
+		auctionService.Run(workersCtx)
+	}()
+	go func() {
+		defer workers.Done()
+		contentFilter.Run(workersCtx)
+	}()
+	go func() {
+		defer workers.Done()
+		viewCounter.Run(workersCtx)
+	}()
+
+	categoryService := service.NewCategoryService(categoryRepo, listingRepo)
+
+	userService := service.NewUserService(authRepo, listingRepo)
+
+	favoriteService := service.NewFavoriteService(favoriteRepo, listingRepo)
+
+	savedSearchService := service.NewSavedSearchService(savedSearchRepo, listingService, imageFetcher, cfg.SavedSearchConfig)
+
+	analyticsService := service.NewAnalyticsService(analyticsRepo, service.SystemClock{})
+
+	reviewService := service.NewReviewService(repository.NewReviewRepo(ctx, db), listingRepo, authRepo, notificationService)
+
+	messagingService := service.NewMessagingService(repository.NewThreadRepo(ctx, db), repository.NewMessageRepo(ctx, db), listingRepo, eventHub, cfg.MessagingConfig, cfg.Secret)
+
+	restServer := rest.New(&ctx, cfg.RestConfig, cfg.Debug, cfg.Secret, rest.Services{
+		Auth:      authService,
+		Listing:   listingService,
+		Category:  categoryService,
+		User:      userService,
+		Upload:    uploadService,
+		Image:     imageCache,
+		Duplicate: duplicateDetector,
+		Favorite:  favoriteService,
 
-	restServer := rest.New(&ctx, cfg.RestConfig, cfg.Debug, cfg.Secret, authService, listingService)
+		SavedSearch:  savedSearchService,
+		Notification: notificationService,
+		PriceAlert:   priceAlerts,
+		Messaging:    messagingService,
+		Realtime:     eventHub,
+		Offer:        offerService,
+		Auction:      auctionService,
+		Review:       reviewService,
+		Moderation:   moderationService,
+		Analytics:    analyticsService,
+	})
 
 	graceChannel := make(chan os.Signal, 1)
 	signal.Notify(graceChannel, syscall.SIGINT, syscall.SIGTERM)
@@ -59,6 +190,8 @@ func main() {
 		}
 	}()
 	<-graceChannel
+	stopWorkers()
+	workers.Wait()
 	db.Disconnect(ctx)
 
 	mainLogger.Debug("MongoDB disconnected")
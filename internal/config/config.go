@@ -3,8 +3,13 @@ package config
 
 import (
 	"log"
+	"vk-inter/internal/service"
 	rest "vk-inter/internal/transport"
+	"vk-inter/pkg/blob"
 	"vk-inter/pkg/db/mongo"
+	"vk-inter/pkg/money"
+	"vk-inter/pkg/pubsub"
+	"vk-inter/pkg/utils"
 
 	"github.com/ilyakaznacheev/cleanenv"
 )
@@ -17,6 +22,25 @@ const ConfigKey KeyString = "config"
 type Config struct {
 	mongo.MongoConfig
 	rest.RestConfig
+	money.RatesConfig
+	service.ListingConfig
+	service.UploadConfig
+	service.VariantsConfig
+	service.MediaConfig
+	service.ImageCacheConfig
+	service.DuplicateConfig
+	service.SavedSearchConfig
+	service.PriceAlertConfig
+	service.MessagingConfig
+	service.RealtimeConfig
+	service.OfferConfig
+	service.AuctionConfig
+	service.ModerationConfig
+	service.ContentFilterConfig
+	service.ViewsConfig
+	blob.BlobConfig
+	pubsub.PubSubConfig
+	utils.ImageFetchConfig
 	Debug  bool   `env:"DEBUG" env-default:"true"`
 	Secret string `env:"SECRET" env-default:"test_key"`
 }
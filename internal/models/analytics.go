@@ -0,0 +1,56 @@
+package models
+
+import "go.mongodb.org/mongo-driver/bson/primitive"
+
+// DayLayout is the format of days in analytics, days are in UTC
+const DayLayout = "2006-01-02"
+
+// ViewCount is the number of unique views of the listing in the day
+type ViewCount struct {
+	ListingID primitive.ObjectID
+	OwnerID   primitive.ObjectID
+	Day       string
+	Views     int64
+}
+
+// DailyCount is a number of events of the listing in the day
+type DailyCount struct {
+	ListingID primitive.ObjectID `bson:"listing_id"`
+	Day       string             `bson:"day"`
+	Count     int64              `bson:"count"`
+}
+
+// AnalyticsDay is the activity in the day
+type AnalyticsDay struct {
+	Date      string `json:"date" example:"2025-01-31"`
+	Views     int64  `json:"views"`
+	Favorites int64  `json:"favorites"`
+	Messages  int64  `json:"messages"`
+}
+
+// ListingAnalytics is the activity of the listing over the period.
+//
+// Days without activity are omitted, TotalViews is the number of views for all time.
+type ListingAnalytics struct {
+	ListingID  primitive.ObjectID `json:"listing_id"`
+	Title      string             `json:"title"`
+	Status     string             `json:"status"`
+	TotalViews int64              `json:"total_views"`
+	Views      int64              `json:"views"`
+	Favorites  int64              `json:"favorites"`
+	Messages   int64              `json:"messages"`
+	Days       []AnalyticsDay     `json:"days"`
+}
+
+// SellerAnalytics is a result of GET /me/analytics.
+//
+// Days has every day of the period with activity of all listings.
+type SellerAnalytics struct {
+	From      string              `json:"from" example:"2025-01-01"`
+	To        string              `json:"to" example:"2025-01-31"`
+	Views     int64               `json:"views"`
+	Favorites int64               `json:"favorites"`
+	Messages  int64               `json:"messages"`
+	Days      []AnalyticsDay      `json:"days"`
+	Listings  []*ListingAnalytics `json:"listings"`
+}
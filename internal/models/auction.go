@@ -0,0 +1,53 @@
+package models
+
+import (
+	"time"
+	"vk-inter/pkg/money"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+const (
+	SaleModeFixed   = "fixed"
+	SaleModeAuction = "auction"
+)
+
+const (
+	AuctionStatusOpen   = "open"
+	AuctionStatusClosed = "closed"
+)
+
+// Auction is the state of the listing sold by auction, the listing price is the start price
+type Auction struct {
+	// ReservePrice is the minimal price to sell, lower winning bid closes the auction without winner
+	ReservePrice *money.Decimal `bson:"reserve_price,omitempty" json:"reserve_price,omitempty" swaggertype:"string" example:"5000.00"`
+	MinIncrement money.Decimal  `bson:"min_increment" json:"min_increment" swaggertype:"string" example:"100.00"`
+	EndsAt       time.Time      `bson:"ends_at" json:"ends_at"`
+	// ExtendSeconds protects from sniping: a bid placed closer to the end moves the end to ExtendSeconds after the bid
+	ExtendSeconds int    `bson:"extend_seconds" json:"extend_seconds" example:"120"`
+	Status        string `bson:"status" json:"status" enums:"open,closed" readonly:"true"`
+
+	CurrentBid  *money.Decimal      `bson:"current_bid,omitempty" json:"current_bid,omitempty" swaggertype:"string" readonly:"true"`
+	BidsCount   int64               `bson:"bids_count" json:"bids_count" readonly:"true"`
+	LeaderID    *primitive.ObjectID `bson:"leader_id,omitempty" json:"leader_id,omitempty" swaggertype:"string" readonly:"true"`
+	LeaderLogin string              `bson:"leader_login,omitempty" json:"leader_login,omitempty" readonly:"true"`
+	WinnerID    *primitive.ObjectID `bson:"winner_id,omitempty" json:"winner_id,omitempty" swaggertype:"string" readonly:"true"`
+	ClosedAt    *time.Time          `bson:"closed_at,omitempty" json:"closed_at,omitempty" readonly:"true"`
+}
+
+// Bid is an accepted bid of the auction
+type Bid struct {
+	ID          primitive.ObjectID `bson:"_id,omitempty" json:"_id"`
+	ListingID   primitive.ObjectID `bson:"listing_id" json:"listing_id"`
+	BidderID    primitive.ObjectID `bson:"bidder_id" json:"bidder_id"`
+	BidderLogin string             `bson:"bidder_login" json:"bidder_login"`
+	Amount      money.Decimal      `bson:"amount" json:"amount" swaggertype:"string" example:"5100.00"`
+	Currency    string             `bson:"currency" json:"currency" example:"RUB"`
+	CreatedAt   time.Time          `bson:"created_at" json:"created_at"`
+}
+
+// BidResult is the placed bid and the auction state after it
+type BidResult struct {
+	Bid     *Bid     `json:"bid"`
+	Auction *Auction `json:"auction"`
+}
@@ -6,9 +6,32 @@ import (
 	"go.mongodb.org/mongo-driver/bson/primitive"
 )
 
+const (
+	RoleUser      = "user"
+	RoleModerator = "moderator"
+	RoleAdmin     = "admin"
+)
+
 type User struct {
 	ID        primitive.ObjectID `bson:"_id,omitempty"`
 	Login     string             `bson:"login" validate:"required,min=3,max=32,regexp=^[\\p{L}\\p{N}_-]+$"`
 	Password  string             `bson:"hashed_password" validate:"required,min=8"`
+	Role      string             `bson:"role,omitempty"`
 	CreatedAt time.Time          `bson:"created_at"`
+
+	// RatingSum and RatingCount aggregate visible reviews about the user
+	RatingSum   int64 `bson:"rating_sum,omitempty"`
+	RatingCount int64 `bson:"rating_count,omitempty"`
+	// BannedAt is set when moderators ban the user, banned users cannot log in
+	BannedAt *time.Time `bson:"banned_at,omitempty"`
+}
+
+// IsAdmin reports whether the user may manage shared dictionaries such as categories
+func (u *User) IsAdmin() bool {
+	return u != nil && u.Role == RoleAdmin
+}
+
+// IsModerator reports whether the user may handle reports and moderation queues, admins are moderators too
+func (u *User) IsModerator() bool {
+	return u != nil && (u.Role == RoleModerator || u.Role == RoleAdmin)
 }
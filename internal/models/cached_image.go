@@ -0,0 +1,20 @@
+package models
+
+import "time"
+
+// CachedImage is a copy of a remote listing image kept in blob storage.
+//
+// Images are addressed by SHA-256 of their content, so identical images are stored once.
+// CheckedAt is updated when the image is cached again or found referenced by garbage collector.
+type CachedImage struct {
+	Hash      string    `bson:"_id" json:"hash"`
+	Key       string    `bson:"key" json:"-"`
+	Mime      string    `bson:"mime" json:"mime"`
+	Size      int64     `bson:"size" json:"size"`
+	Width     int       `bson:"width,omitempty" json:"width,omitempty"`
+	Height    int       `bson:"height,omitempty" json:"height,omitempty"`
+	PHash     int64     `bson:"phash,omitempty" json:"-"`
+	SourceURL string    `bson:"source_url" json:"-"`
+	CreatedAt time.Time `bson:"created_at" json:"created_at"`
+	CheckedAt time.Time `bson:"checked_at" json:"-"`
+}
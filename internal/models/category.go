@@ -0,0 +1,49 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+const (
+	AttributeTypeString  = "string"
+	AttributeTypeInteger = "integer"
+	AttributeTypeNumber  = "number"
+	AttributeTypeBoolean = "boolean"
+)
+
+type Category struct {
+	ID        primitive.ObjectID   `bson:"_id,omitempty" json:"_id,omitempty"`
+	Name      string               `bson:"name" json:"name" validate:"required,min=2,max=64"`
+	Slug      string               `bson:"slug" json:"slug" validate:"required,min=2,max=64,regexp=^[a-z0-9-]+$"`
+	ParentID  *primitive.ObjectID  `bson:"parent_id,omitempty" json:"parent_id,omitempty"`
+	Ancestors []primitive.ObjectID `bson:"ancestors" json:"ancestors"`
+	// Attributes describes structured listing attributes of the category,
+	// subcategories inherit attributes of their ancestors
+	Attributes []AttributeSchema `bson:"attributes,omitempty" json:"attributes,omitempty"`
+	CreatedAt  time.Time         `bson:"created_at" json:"created_at"`
+}
+
+// AttributeSchema describes one listing attribute, e.g. {name: "rooms", type: "integer", min: 1}.
+//
+// Enum is allowed only for strings, Min and Max only for integers and numbers.
+type AttributeSchema struct {
+	Name     string   `bson:"name" json:"name"`
+	Type     string   `bson:"type" json:"type" enums:"string,integer,number,boolean"`
+	Required bool     `bson:"required,omitempty" json:"required,omitempty"`
+	Enum     []string `bson:"enum,omitempty" json:"enum,omitempty"`
+	Min      *float64 `bson:"min,omitempty" json:"min,omitempty"`
+	Max      *float64 `bson:"max,omitempty" json:"max,omitempty"`
+}
+
+// CategoryNode is a category with its subtree and listing counters.
+//
+// ListingCount counts listings placed directly into the category,
+// TotalCount also includes listings of all descendant categories.
+type CategoryNode struct {
+	Category
+	ListingCount int64           `json:"listing_count"`
+	TotalCount   int64           `json:"total_count"`
+	Children     []*CategoryNode `json:"children"`
+}
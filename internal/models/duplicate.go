@@ -0,0 +1,40 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Fingerprint is used to find near-duplicate listings.
+//
+// Text is MinHash signature of title and description, Bands are lookup keys of
+// text signature and image hashes, similar listings share at least one band.
+type Fingerprint struct {
+	Text  []uint32 `bson:"text,omitempty"`
+	Bands []string `bson:"bands"`
+}
+
+// DuplicateReport is a probable duplicate of other owner's listing waiting for moderation.
+//
+// ImageDistance is the smallest number of differing bits of image hashes, nil if no images matched.
+type DuplicateReport struct {
+	ID               primitive.ObjectID `bson:"_id,omitempty" json:"_id"`
+	ListingID        primitive.ObjectID `bson:"listing_id" json:"listing_id"`
+	OwnerID          primitive.ObjectID `bson:"owner_id" json:"owner_id"`
+	DuplicateOfID    primitive.ObjectID `bson:"duplicate_of_id" json:"duplicate_of_id"`
+	DuplicateOwnerID primitive.ObjectID `bson:"duplicate_owner_id" json:"duplicate_owner_id"`
+	TextSimilarity   float64            `bson:"text_similarity" json:"text_similarity"`
+	ImageDistance    *int               `bson:"image_distance,omitempty" json:"image_distance,omitempty"`
+	CreatedAt        time.Time          `bson:"created_at" json:"created_at"`
+}
+
+// MediaResult is the outcome of background images check of a listing
+type MediaResult struct {
+	Status       string
+	RejectReason string
+	Images       []ListingImage
+	Check        *MediaCheck
+	Fingerprint  *Fingerprint
+	DuplicateOf  *primitive.ObjectID
+}
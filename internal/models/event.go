@@ -0,0 +1,29 @@
+package models
+
+import (
+	"encoding/json"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+const (
+	EventTypeMessage       = "message"
+	EventTypeNotification  = "notification"
+	EventTypeListingStatus = "listing_status"
+)
+
+// Event is pushed to user's realtime connections
+type Event struct {
+	Type string `json:"type" enums:"message,notification,listing_status"`
+	// Data is ThreadMessage, Notification or ListingStatusEvent depending on type
+	Data      json.RawMessage `json:"data" swaggertype:"object"`
+	CreatedAt time.Time       `json:"created_at"`
+}
+
+// ListingStatusEvent tells the owner that listing status has changed
+type ListingStatusEvent struct {
+	ListingID    primitive.ObjectID `json:"listing_id"`
+	Status       string             `json:"status"`
+	RejectReason string             `json:"reject_reason,omitempty"`
+}
@@ -0,0 +1,22 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Favorite is a listing bookmarked by user
+type Favorite struct {
+	ID        primitive.ObjectID `bson:"_id,omitempty" json:"_id"`
+	UserID    primitive.ObjectID `bson:"user_id" json:"user_id"`
+	ListingID primitive.ObjectID `bson:"listing_id" json:"listing_id"`
+	CreatedAt time.Time          `bson:"created_at" json:"created_at"`
+}
+
+// FavoriteState is the result of adding or removing a favorite
+type FavoriteState struct {
+	ListingID      primitive.ObjectID `json:"listing_id"`
+	IsFavorite     bool               `json:"is_favorite"`
+	FavoritesCount int64              `json:"favorites_count"`
+}
@@ -0,0 +1,21 @@
+package models
+
+const GeoTypePoint = "Point"
+
+// GeoPoint is a GeoJSON point, coordinates are [longitude, latitude]
+type GeoPoint struct {
+	Type        string    `bson:"type" json:"type" example:"Point"`
+	Coordinates []float64 `bson:"coordinates" json:"coordinates" example:"37.6173,55.7558"`
+}
+
+func NewGeoPoint(lat, lon float64) *GeoPoint {
+	return &GeoPoint{Type: GeoTypePoint, Coordinates: []float64{lon, lat}}
+}
+
+func (p *GeoPoint) Lon() float64 {
+	return p.Coordinates[0]
+}
+
+func (p *GeoPoint) Lat() float64 {
+	return p.Coordinates[1]
+}
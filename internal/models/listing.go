@@ -2,18 +2,201 @@ package models
 
 import (
 	"time"
+	"vk-inter/pkg/money"
 
 	"go.mongodb.org/mongo-driver/bson/primitive"
 )
 
+const (
+	ListingStatusPublished = "published"
+	// ListingStatusPendingMedia listings wait for validation of external images
+	ListingStatusPendingMedia = "pending_media"
+	ListingStatusRejected     = "rejected"
+	// ListingStatusReserved listings have an accepted offer or a won auction and are hidden from search
+	ListingStatusReserved = "reserved"
+	// ListingStatusSold listings are sold to the buyer of the reservation
+	ListingStatusSold = "sold"
+	// ListingStatusHidden listings are hidden by moderators or by reports, ListingStatusRemoved are removed by moderators
+	ListingStatusHidden  = "hidden"
+	ListingStatusRemoved = "removed"
+)
+
+const (
+	TagsModeAny = "any"
+	TagsModeAll = "all"
+)
+
+const (
+	AttributeOpEq  = "eq"
+	AttributeOpGt  = "gt"
+	AttributeOpGte = "gte"
+	AttributeOpLt  = "lt"
+	AttributeOpLte = "lte"
+)
+
 type Listing struct {
 	ID          primitive.ObjectID `bson:"_id,omitempty" json:"_id,omitempty"`
 	Title       string             `bson:"title" json:"title" validate:"required,min=3,max=100"`
 	Description string             `bson:"description" json:"description" validate:"required,min=10,max=5000"`
-	ImageURL    string             `bson:"image_url" json:"image_url" validate:"required,url,max=500"`
-	Price       float64            `bson:"price" json:"price" validate:"required,gte=0,lte=1000000000"`
+	// ImageURL is a read-only alias of the cover image, the first of Images
+	ImageURL    string             `bson:"image_url" json:"image_url" validate:"required,url,max=500" readonly:"true"`
+	Images      []ListingImage     `bson:"images" json:"images" validate:"required,min=1,dive"`
+	Price       money.Decimal      `bson:"price" json:"price" swaggertype:"string" example:"1999.90"`
+	Currency    string             `bson:"currency" json:"currency" example:"RUB"`
+	CategoryID  primitive.ObjectID `bson:"category_id,omitempty" json:"category_id,omitempty" validate:"required"`
+	Tags        []string           `bson:"tags,omitempty" json:"tags,omitempty" validate:"max=10,dive,min=1,max=32"`
+	Attributes  map[string]any     `bson:"attributes,omitempty" json:"attributes,omitempty"`
+	Location    *GeoPoint          `bson:"location,omitempty" json:"location,omitempty"`
+	Address     string             `bson:"address,omitempty" json:"address,omitempty" validate:"max=200"`
 	OwnerID     primitive.ObjectID `bson:"owner_id" json:"owner_id"`
 	OwnerLogin  string             `bson:"owner_login" json:"owner_login"`
+	Status      string             `bson:"status" json:"status" enums:"published,pending_media,rejected,reserved,sold,hidden,removed"`
 	IsMyListing *bool              `bson:"is_my_listing,omitempty" json:"is_my_listing,omitempty"`
 	CreatedAt   time.Time          `bson:"created_at" json:"created_at"`
+
+	// FavoritesCount is the number of users bookmarked the listing,
+	// IsFavorite is set for authenticated users like IsMyListing
+	FavoritesCount int64 `bson:"favorites_count,omitempty" json:"favorites_count"`
+	IsFavorite     *bool `bson:"-" json:"is_favorite,omitempty"`
+	// ViewsCount is the number of unique views, updated in batches with a delay
+	ViewsCount int64 `bson:"views_count,omitempty" json:"views_count"`
+	// PriceDroppedAt is the time of the last price decrease, reset when the price goes up
+	PriceDroppedAt *time.Time `bson:"price_dropped_at,omitempty" json:"price_dropped_at,omitempty"`
+	// SaleMode is fixed or auction, Auction is set for auctions
+	SaleMode string   `bson:"sale_mode,omitempty" json:"sale_mode,omitempty" enums:"fixed,auction"`
+	Auction  *Auction `bson:"auction,omitempty" json:"auction,omitempty"`
+	// ReservedOfferID is the accepted offer of the reserved listing
+	ReservedOfferID *primitive.ObjectID `bson:"reserved_offer_id,omitempty" json:"reserved_offer_id,omitempty" swaggertype:"string"`
+	// BuyerID is the buyer of the reserved or sold listing
+	BuyerID *primitive.ObjectID `bson:"buyer_id,omitempty" json:"buyer_id,omitempty" swaggertype:"string"`
+	SoldAt  *time.Time          `bson:"sold_at,omitempty" json:"sold_at,omitempty"`
+
+	// RejectReason explains why listing is rejected, MediaCheck is the state of images validation
+	RejectReason string      `bson:"reject_reason,omitempty" json:"reject_reason,omitempty"`
+	MediaCheck   *MediaCheck `bson:"media_check,omitempty" json:"media_check,omitempty"`
+	// DuplicateOf is the owner's earlier listing this one reposts, when reposts are flagged instead of rejected
+	DuplicateOf *primitive.ObjectID `bson:"duplicate_of,omitempty" json:"duplicate_of,omitempty" swaggertype:"string"`
+	Fingerprint *Fingerprint        `bson:"fingerprint,omitempty" json:"-"`
+
+	// PriceBase is the price converted to the base currency, used for filtering and sorting.
+	// DisplayPrice is the price converted to the currency requested by client.
+	PriceBase       money.Decimal  `bson:"price_base" json:"-"`
+	DisplayPrice    *money.Decimal `bson:"-" json:"display_price,omitempty" swaggertype:"string"`
+	DisplayCurrency string         `bson:"-" json:"display_currency,omitempty"`
+	// Distance is set only when searching near a point
+	Distance *float64 `bson:"distance,omitempty" json:"distance_km,omitempty"`
+}
+
+// IsPublic reports whether the listing page is visible to everyone.
+//
+// Reserved and sold listings are shown, but only published ones are searched.
+func (l *Listing) IsPublic() bool {
+	return l.Status == ListingStatusPublished || l.Status == ListingStatusReserved || l.Status == ListingStatusSold
+}
+
+// IsAuction reports whether the listing is sold by auction
+func (l *Listing) IsAuction() bool {
+	return l.SaleMode == SaleModeAuction
+}
+
+// ListingImage is one of listing images, Mime, Width and Height are detected on upload.
+//
+// UploadID is set for images uploaded via POST /uploads. External images are copied
+// to our storage, Hash and CachedURL point to the copy served by GET /images/{hash}.
+type ListingImage struct {
+	URL      string              `bson:"url" json:"url" validate:"required,url,max=500"`
+	UploadID *primitive.ObjectID `bson:"upload_id,omitempty" json:"upload_id,omitempty" swaggertype:"string"`
+	Width    int                 `bson:"width,omitempty" json:"width,omitempty"`
+	Height   int                 `bson:"height,omitempty" json:"height,omitempty"`
+	Mime     string              `bson:"mime,omitempty" json:"mime,omitempty"`
+	Alt      string              `bson:"alt,omitempty" json:"alt,omitempty" validate:"max=200"`
+
+	Hash      string `bson:"hash,omitempty" json:"hash,omitempty"`
+	CachedURL string `bson:"cached_url,omitempty" json:"cached_url,omitempty"`
+	// PHash is perceptual hash of the image used to find duplicates
+	PHash int64 `bson:"phash,omitempty" json:"-"`
+	// Variants are thumb, medium and large copies, available only for uploaded images
+	Variants map[string]ImageVariant `bson:"variants,omitempty" json:"variants,omitempty"`
+}
+
+// ListingFilter describes GET /listings query
+type ListingFilter struct {
+	Page   int
+	Limit  int
+	SortBy string
+	Order  string
+	// MinPrice and MaxPrice are in Currency, or in the base currency if Currency is empty
+	MinPrice *money.Decimal
+	MaxPrice *money.Decimal
+	Currency string
+
+	// CategoryID is the requested category, CategoryIDs is filled by service
+	// with the category itself and all its descendants
+	CategoryID  primitive.ObjectID
+	CategoryIDs []primitive.ObjectID
+
+	Tags     []string
+	TagsMode string
+
+	Attributes []AttributeFilter
+
+	OwnerID    primitive.ObjectID
+	OwnerLogin string
+
+	// SaleMode selects fixed price listings or auctions, empty for both
+	SaleMode string
+
+	// PriceDroppedSince selects listings which became cheaper after the time
+	PriceDroppedSince *time.Time
+
+	// Near with optional RadiusKm searches listings around the point,
+	// BBox is [minLon, minLat, maxLon, maxLat]
+	Near     *GeoPoint
+	RadiusKm float64
+	BBox     []float64
+
+	// Cursor is decoded keyset position, if set Page is ignored
+	Cursor *ListingCursor
+	// CountTotal requests total number of matching listings
+	CountTotal bool
+
+	CurrentUserID primitive.ObjectID
+}
+
+// AttributeFilter is a condition on listing attribute, e.g. attr.year_gte=2015.
+//
+// Param and Values hold raw query parameter ("year_gte") and its values,
+// service splits Param into Name and Op and converts Values to typed Parsed values
+// according to category schema. For eq operator any of values matches.
+type AttributeFilter struct {
+	Param  string
+	Name   string
+	Op     string
+	Values []string
+	Parsed []any
+}
+
+// ListingCursor is a keyset pagination position: sort key of the boundary listing
+// with its _id as a tiebreaker. Backward cursors point to the previous page.
+type ListingCursor struct {
+	SortBy   string             `json:"s"`
+	Order    string             `json:"o"`
+	Value    any                `json:"v"`
+	ID       primitive.ObjectID `json:"id"`
+	Backward bool               `json:"b,omitempty"`
+}
+
+// ListingPage is a result of GET /listings.
+//
+// Page is set only in page mode, Total only when it was requested.
+// TotalEstimated means Total is capped (too many matching listings).
+type ListingPage struct {
+	Items          []*Listing `json:"items"`
+	Page           int        `json:"page,omitempty"`
+	Limit          int        `json:"limit"`
+	Total          *int64     `json:"total,omitempty"`
+	TotalEstimated bool       `json:"total_estimated,omitempty"`
+	HasNext        bool       `json:"has_next"`
+	NextCursor     string     `json:"next_cursor,omitempty"`
+	PrevCursor     string     `json:"prev_cursor,omitempty"`
 }
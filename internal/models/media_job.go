@@ -0,0 +1,37 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+const (
+	MediaJobQueued  = "queued"
+	MediaJobRunning = "running"
+	MediaJobDone    = "done"
+	MediaJobFailed  = "failed"
+)
+
+// MediaJob is a durable job validating external images of a listing.
+//
+// ID is the id of the listing, so there is at most one job per listing.
+// Running job with expired LockedUntil is considered abandoned and is claimed again.
+type MediaJob struct {
+	ID          primitive.ObjectID `bson:"_id"`
+	Status      string             `bson:"status"`
+	Attempts    int                `bson:"attempts"`
+	Error       string             `bson:"error,omitempty"`
+	RunAfter    time.Time          `bson:"run_after"`
+	LockedUntil time.Time          `bson:"locked_until,omitempty"`
+	CreatedAt   time.Time          `bson:"created_at"`
+	UpdatedAt   time.Time          `bson:"updated_at"`
+}
+
+// MediaCheck is the state of listing images validation shown on the listing
+type MediaCheck struct {
+	Status    string    `bson:"status" json:"status" enums:"queued,running,done,failed"`
+	Attempts  int       `bson:"attempts" json:"attempts"`
+	Error     string    `bson:"error,omitempty" json:"error,omitempty"`
+	UpdatedAt time.Time `bson:"updated_at" json:"updated_at"`
+}
@@ -0,0 +1,40 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+const (
+	NotificationTypeSavedSearchMatch = "saved_search_match"
+	NotificationTypePriceDrop        = "price_drop"
+	NotificationTypeOffer            = "offer"
+	NotificationTypeAuction          = "auction"
+	NotificationTypeReview           = "review"
+)
+
+// Notification is an item of the in-app notification feed
+type Notification struct {
+	ID            primitive.ObjectID  `bson:"_id,omitempty" json:"_id"`
+	UserID        primitive.ObjectID  `bson:"user_id" json:"user_id"`
+	Type          string              `bson:"type" json:"type" enums:"saved_search_match,price_drop,offer,auction,review"`
+	Title         string              `bson:"title" json:"title"`
+	ListingID     *primitive.ObjectID `bson:"listing_id,omitempty" json:"listing_id,omitempty" swaggertype:"string"`
+	SavedSearchID *primitive.ObjectID `bson:"saved_search_id,omitempty" json:"saved_search_id,omitempty" swaggertype:"string"`
+	OfferID       *primitive.ObjectID `bson:"offer_id,omitempty" json:"offer_id,omitempty" swaggertype:"string"`
+	Read          bool                `bson:"read" json:"read"`
+	CreatedAt     time.Time           `bson:"created_at" json:"created_at"`
+	// DedupKey prevents the same event from being notified twice
+	DedupKey string `bson:"dedup_key,omitempty" json:"-"`
+}
+
+// NotificationPage is a result of GET /me/notifications
+type NotificationPage struct {
+	Items   []*Notification `json:"items"`
+	Page    int             `json:"page"`
+	Limit   int             `json:"limit"`
+	Total   int64           `json:"total"`
+	Unread  int64           `json:"unread"`
+	HasNext bool            `json:"has_next"`
+}
@@ -0,0 +1,65 @@
+package models
+
+import (
+	"time"
+	"vk-inter/pkg/money"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+const (
+	OfferStatusPending   = "pending"
+	OfferStatusAccepted  = "accepted"
+	OfferStatusRejected  = "rejected"
+	OfferStatusCountered = "countered"
+	OfferStatusWithdrawn = "withdrawn"
+	OfferStatusExpired   = "expired"
+)
+
+const (
+	OfferByBuyer  = "buyer"
+	OfferBySeller = "seller"
+)
+
+// Offer is a price proposal of the buyer or a counter-offer of the seller.
+//
+// Offers of one negotiation form a chain: a counter-offer refers to the
+// countered offer by ParentID, all offers of the chain share RootID.
+type Offer struct {
+	ID           primitive.ObjectID  `bson:"_id,omitempty" json:"_id"`
+	ListingID    primitive.ObjectID  `bson:"listing_id" json:"listing_id"`
+	ListingTitle string              `bson:"listing_title" json:"listing_title"`
+	BuyerID      primitive.ObjectID  `bson:"buyer_id" json:"buyer_id"`
+	BuyerLogin   string              `bson:"buyer_login" json:"buyer_login"`
+	SellerID     primitive.ObjectID  `bson:"seller_id" json:"seller_id"`
+	RootID       primitive.ObjectID  `bson:"root_id" json:"root_id"`
+	ParentID     *primitive.ObjectID `bson:"parent_id,omitempty" json:"parent_id,omitempty" swaggertype:"string"`
+	// ProposedBy is buyer or seller, only the other party can accept, reject or counter the offer
+	ProposedBy string        `bson:"proposed_by" json:"proposed_by" enums:"buyer,seller"`
+	Amount     money.Decimal `bson:"amount" json:"amount" swaggertype:"string" example:"1500.00"`
+	Currency   string        `bson:"currency" json:"currency" example:"RUB"`
+	Message    string        `bson:"message,omitempty" json:"message,omitempty"`
+	Status     string        `bson:"status" json:"status" enums:"pending,accepted,rejected,countered,withdrawn,expired"`
+	ExpiresAt  time.Time     `bson:"expires_at" json:"expires_at"`
+	CreatedAt  time.Time     `bson:"created_at" json:"created_at"`
+	UpdatedAt  time.Time     `bson:"updated_at" json:"updated_at"`
+}
+
+// RoleOf returns buyer or seller for the participants of the negotiation, empty string otherwise
+func (o *Offer) RoleOf(userID primitive.ObjectID) string {
+	switch userID {
+	case o.BuyerID:
+		return OfferByBuyer
+	case o.SellerID:
+		return OfferBySeller
+	}
+	return ""
+}
+
+// OfferPage is a result of offers listing
+type OfferPage struct {
+	Items   []*Offer `json:"items"`
+	Page    int      `json:"page"`
+	Limit   int      `json:"limit"`
+	HasNext bool     `json:"has_next"`
+}
@@ -0,0 +1,37 @@
+package models
+
+import (
+	"time"
+	"vk-inter/pkg/money"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// PriceChange is a record of listing price history.
+//
+// DropPercent is how much the price in the base currency decreased, 0 for increases.
+// AlertsSent is set when users watching the listing are notified about the drop.
+type PriceChange struct {
+	ID           primitive.ObjectID `bson:"_id,omitempty" json:"_id"`
+	ListingID    primitive.ObjectID `bson:"listing_id" json:"listing_id"`
+	OldPrice     money.Decimal      `bson:"old_price" json:"old_price" swaggertype:"string" example:"1999.90"`
+	OldCurrency  string             `bson:"old_currency" json:"old_currency" example:"RUB"`
+	Price        money.Decimal      `bson:"price" json:"price" swaggertype:"string" example:"1499.90"`
+	Currency     string             `bson:"currency" json:"currency" example:"RUB"`
+	DropPercent  float64            `bson:"drop_percent" json:"drop_percent" example:"25"`
+	ChangedAt    time.Time          `bson:"changed_at" json:"changed_at"`
+	OldPriceBase money.Decimal      `bson:"old_price_base" json:"-"`
+	PriceBase    money.Decimal      `bson:"price_base" json:"-"`
+	AlertsSent   bool               `bson:"alerts_sent" json:"-"`
+}
+
+// PriceWatch subscribes user to price drops of the listing by more than ThresholdPercent.
+//
+// Favorites are watched too, with the default threshold unless the user set their own.
+type PriceWatch struct {
+	ID               primitive.ObjectID `bson:"_id,omitempty" json:"_id"`
+	UserID           primitive.ObjectID `bson:"user_id" json:"user_id"`
+	ListingID        primitive.ObjectID `bson:"listing_id" json:"listing_id"`
+	ThresholdPercent float64            `bson:"threshold_percent" json:"threshold_percent" example:"10"`
+	CreatedAt        time.Time          `bson:"created_at" json:"created_at"`
+}
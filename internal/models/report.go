@@ -0,0 +1,109 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+const (
+	ReportReasonScam          = "scam"
+	ReportReasonProhibited    = "prohibited"
+	ReportReasonSpam          = "spam"
+	ReportReasonOffensive     = "offensive"
+	ReportReasonWrongCategory = "wrong_category"
+	ReportReasonOther         = "other"
+)
+
+const (
+	CaseStatusOpen     = "open"
+	CaseStatusClaimed  = "claimed"
+	CaseStatusResolved = "resolved"
+)
+
+const (
+	CaseActionDismiss  = "dismiss"
+	CaseActionHide     = "hide"
+	CaseActionRemove   = "remove"
+	CaseActionBanOwner = "ban_owner"
+)
+
+// Actions of the moderation audit trail besides case resolutions
+const (
+	AuditActionAutoHidden = "auto_hidden"
+	AuditActionFlagged    = "flagged"
+	AuditActionClaimed    = "claimed"
+)
+
+// Report is a complaint of the user about the listing
+type Report struct {
+	ID            primitive.ObjectID `bson:"_id,omitempty" json:"_id"`
+	ListingID     primitive.ObjectID `bson:"listing_id" json:"listing_id"`
+	CaseID        primitive.ObjectID `bson:"case_id" json:"case_id"`
+	ReporterID    primitive.ObjectID `bson:"reporter_id" json:"reporter_id"`
+	ReporterLogin string             `bson:"reporter_login" json:"reporter_login"`
+	Reason        string             `bson:"reason" json:"reason" enums:"scam,prohibited,spam,offensive,wrong_category,other"`
+	Comment       string             `bson:"comment,omitempty" json:"comment,omitempty"`
+	CreatedAt     time.Time          `bson:"created_at" json:"created_at"`
+}
+
+// ModerationCase groups reports and content filter flags about the listing until a moderator resolves them.
+//
+// A listing has at most one active (open or claimed) case.
+type ModerationCase struct {
+	ID           primitive.ObjectID `bson:"_id,omitempty" json:"_id"`
+	ListingID    primitive.ObjectID `bson:"listing_id" json:"listing_id"`
+	ListingTitle string             `bson:"listing_title" json:"listing_title"`
+	OwnerID      primitive.ObjectID `bson:"owner_id" json:"owner_id"`
+	Status       string             `bson:"status" json:"status" enums:"open,claimed,resolved"`
+	ReportsCount int64              `bson:"reports_count" json:"reports_count"`
+	// Reasons is the number of reports by reason
+	Reasons map[string]int64 `bson:"reasons" json:"reasons"`
+	// Flags are names of content filter rules matched by the listing
+	Flags []string `bson:"flags,omitempty" json:"flags,omitempty"`
+	// AutoHidden is set when the listing was hidden by the number of reports,
+	// dismissing the case returns the listing to RestoreStatus
+	AutoHidden    bool   `bson:"auto_hidden,omitempty" json:"auto_hidden,omitempty"`
+	RestoreStatus string `bson:"restore_status,omitempty" json:"-"`
+
+	ClaimedBy      *primitive.ObjectID `bson:"claimed_by,omitempty" json:"claimed_by,omitempty" swaggertype:"string"`
+	ClaimedByLogin string              `bson:"claimed_by_login,omitempty" json:"claimed_by_login,omitempty"`
+	ClaimedAt      *time.Time          `bson:"claimed_at,omitempty" json:"claimed_at,omitempty"`
+
+	Resolution string              `bson:"resolution,omitempty" json:"resolution,omitempty" enums:"dismiss,hide,remove,ban_owner"`
+	Comment    string              `bson:"comment,omitempty" json:"comment,omitempty"`
+	ResolvedBy *primitive.ObjectID `bson:"resolved_by,omitempty" json:"resolved_by,omitempty" swaggertype:"string"`
+	ResolvedAt *time.Time          `bson:"resolved_at,omitempty" json:"resolved_at,omitempty"`
+
+	CreatedAt time.Time `bson:"created_at" json:"created_at"`
+	UpdatedAt time.Time `bson:"updated_at" json:"updated_at"`
+}
+
+// AuditEntry records an action on the moderation case
+type AuditEntry struct {
+	ID        primitive.ObjectID `bson:"_id,omitempty" json:"_id"`
+	CaseID    primitive.ObjectID `bson:"case_id" json:"case_id"`
+	ListingID primitive.ObjectID `bson:"listing_id" json:"listing_id"`
+	// ActorID is empty for automatic actions
+	ActorID    *primitive.ObjectID `bson:"actor_id,omitempty" json:"actor_id,omitempty" swaggertype:"string"`
+	ActorLogin string              `bson:"actor_login,omitempty" json:"actor_login,omitempty"`
+	Action     string              `bson:"action" json:"action" enums:"auto_hidden,flagged,claimed,dismiss,hide,remove,ban_owner"`
+	Comment    string              `bson:"comment,omitempty" json:"comment,omitempty"`
+	CreatedAt  time.Time           `bson:"created_at" json:"created_at"`
+}
+
+// CaseDetails is a result of GET /moderation/cases/{id}
+type CaseDetails struct {
+	Case    *ModerationCase `json:"case"`
+	Reports []*Report       `json:"reports"`
+	Audit   []*AuditEntry   `json:"audit"`
+}
+
+// CasePage is a result of GET /moderation/cases
+type CasePage struct {
+	Items   []*ModerationCase `json:"items"`
+	Page    int               `json:"page"`
+	Limit   int               `json:"limit"`
+	Total   int64             `json:"total"`
+	HasNext bool              `json:"has_next"`
+}
@@ -0,0 +1,40 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+const (
+	ReviewTargetSeller = "seller"
+	ReviewTargetBuyer  = "buyer"
+)
+
+// Review is a rating left by one side of the completed deal to the other
+type Review struct {
+	ID           primitive.ObjectID `bson:"_id,omitempty" json:"_id"`
+	ListingID    primitive.ObjectID `bson:"listing_id" json:"listing_id"`
+	ListingTitle string             `bson:"listing_title" json:"listing_title"`
+	AuthorID     primitive.ObjectID `bson:"author_id" json:"author_id"`
+	AuthorLogin  string             `bson:"author_login" json:"author_login"`
+	TargetID     primitive.ObjectID `bson:"target_id" json:"target_id"`
+	// TargetRole is the role of the reviewed user in the deal
+	TargetRole string    `bson:"target_role" json:"target_role" enums:"seller,buyer"`
+	Rating     int       `bson:"rating" json:"rating" minimum:"1" maximum:"5"`
+	Text       string    `bson:"text" json:"text"`
+	CreatedAt  time.Time `bson:"created_at" json:"created_at"`
+
+	// Hidden reviews are not shown on the profile and not counted in the rating
+	Hidden       bool   `bson:"hidden" json:"hidden,omitempty"`
+	HiddenReason string `bson:"hidden_reason,omitempty" json:"hidden_reason,omitempty"`
+}
+
+// ReviewPage is a result of GET /users/{login}/reviews
+type ReviewPage struct {
+	Items   []*Review `json:"items"`
+	Page    int       `json:"page"`
+	Limit   int       `json:"limit"`
+	Total   int64     `json:"total"`
+	HasNext bool      `json:"has_next"`
+}
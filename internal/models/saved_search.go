@@ -0,0 +1,54 @@
+package models
+
+import (
+	"time"
+	"vk-inter/pkg/money"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// SavedSearch is a named GET /listings query, new listings matching it are sent to the user.
+//
+// Query is the original query string, Filter is its normalized form used by the matcher.
+// CategoryID and PriceBuckets index searches, so the matcher fetches only candidates
+// for a listing instead of checking every saved search.
+type SavedSearch struct {
+	ID     primitive.ObjectID `bson:"_id,omitempty" json:"_id"`
+	UserID primitive.ObjectID `bson:"user_id" json:"user_id"`
+	Name   string             `bson:"name" json:"name"`
+	Query  string             `bson:"query" json:"query" example:"category_id=65f0c0ffee0000000000000a&max_price=50000"`
+	// WebhookURL receives matches in addition to notifications, requests are signed with WebhookSecret
+	WebhookURL    string     `bson:"webhook_url,omitempty" json:"webhook_url,omitempty"`
+	WebhookSecret string     `bson:"webhook_secret,omitempty" json:"webhook_secret,omitempty"`
+	MatchCount    int64      `bson:"match_count" json:"match_count"`
+	LastMatchAt   *time.Time `bson:"last_match_at,omitempty" json:"last_match_at,omitempty"`
+	CreatedAt     time.Time  `bson:"created_at" json:"created_at"`
+
+	Filter       SearchFilter        `bson:"filter" json:"-"`
+	CategoryID   *primitive.ObjectID `bson:"category_id" json:"-"`
+	PriceBuckets []int               `bson:"price_buckets" json:"-"`
+}
+
+// SearchFilter is ListingFilter prepared by service: prices are in the base currency,
+// attribute values are parsed. Category matches the category and its subcategories.
+type SearchFilter struct {
+	MinPrice   *money.Decimal          `bson:"min_price,omitempty"`
+	MaxPrice   *money.Decimal          `bson:"max_price,omitempty"`
+	Tags       []string                `bson:"tags,omitempty"`
+	TagsMode   string                  `bson:"tags_mode,omitempty"`
+	Attributes []SearchAttributeFilter `bson:"attributes,omitempty"`
+	OwnerID    *primitive.ObjectID     `bson:"owner_id,omitempty"`
+	OwnerLogin string                  `bson:"owner_login,omitempty"`
+	Near       *GeoPoint               `bson:"near,omitempty"`
+	RadiusKm   float64                 `bson:"radius_km,omitempty"`
+	BBox       []float64               `bson:"bbox,omitempty"`
+	SaleMode   string                  `bson:"sale_mode,omitempty"`
+
+	PriceDroppedSince *time.Time `bson:"price_dropped_since,omitempty"`
+}
+
+type SearchAttributeFilter struct {
+	Name   string `bson:"name"`
+	Op     string `bson:"op"`
+	Values []any  `bson:"values"`
+}
@@ -0,0 +1,91 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+const (
+	ThreadRoleBuyer  = "buyer"
+	ThreadRoleSeller = "seller"
+)
+
+// Thread is a conversation of a buyer with the seller about a listing.
+//
+// There is one thread per listing and buyer. Unread counters are kept per participant,
+// BuyerReadAt and SellerReadAt are read receipts: messages created before are read.
+type Thread struct {
+	ID           primitive.ObjectID   `bson:"_id,omitempty" json:"_id"`
+	ListingID    primitive.ObjectID   `bson:"listing_id" json:"listing_id"`
+	ListingTitle string               `bson:"listing_title" json:"listing_title"`
+	BuyerID      primitive.ObjectID   `bson:"buyer_id" json:"buyer_id"`
+	BuyerLogin   string               `bson:"buyer_login" json:"buyer_login"`
+	SellerID     primitive.ObjectID   `bson:"seller_id" json:"seller_id"`
+	SellerLogin  string               `bson:"seller_login" json:"seller_login"`
+	Participants []primitive.ObjectID `bson:"participants" json:"-"`
+
+	LastMessage   *MessagePreview `bson:"last_message,omitempty" json:"last_message,omitempty"`
+	LastMessageAt time.Time       `bson:"last_message_at" json:"last_message_at"`
+	BuyerUnread   int64           `bson:"buyer_unread" json:"-"`
+	SellerUnread  int64           `bson:"seller_unread" json:"-"`
+	BuyerReadAt   *time.Time      `bson:"buyer_read_at,omitempty" json:"buyer_read_at,omitempty"`
+	SellerReadAt  *time.Time      `bson:"seller_read_at,omitempty" json:"seller_read_at,omitempty"`
+	CreatedAt     time.Time       `bson:"created_at" json:"created_at"`
+
+	// Role and Unread are set for the current user
+	Role   string `bson:"-" json:"role,omitempty" enums:"buyer,seller"`
+	Unread int64  `bson:"-" json:"unread"`
+}
+
+// RoleOf returns participant role of the user or empty string for strangers
+func (t *Thread) RoleOf(userID primitive.ObjectID) string {
+	switch userID {
+	case t.BuyerID:
+		return ThreadRoleBuyer
+	case t.SellerID:
+		return ThreadRoleSeller
+	}
+	return ""
+}
+
+type MessagePreview struct {
+	Text      string             `bson:"text" json:"text"`
+	SenderID  primitive.ObjectID `bson:"sender_id" json:"sender_id"`
+	CreatedAt time.Time          `bson:"created_at" json:"created_at"`
+}
+
+type Message struct {
+	ID        primitive.ObjectID `bson:"_id,omitempty" json:"_id"`
+	ThreadID  primitive.ObjectID `bson:"thread_id" json:"thread_id"`
+	SenderID  primitive.ObjectID `bson:"sender_id" json:"sender_id"`
+	Text      string             `bson:"text" json:"text"`
+	CreatedAt time.Time          `bson:"created_at" json:"created_at"`
+	// Read is set for messages of the current user: whether the other participant has read it
+	Read *bool `bson:"-" json:"read,omitempty"`
+}
+
+// TimeCursor is a keyset position of lists sorted by time with _id as a tiebreaker
+type TimeCursor struct {
+	Time time.Time          `json:"t"`
+	ID   primitive.ObjectID `json:"id"`
+}
+
+// ThreadPage is a result of GET /me/threads, Unread is the total over all threads
+type ThreadPage struct {
+	Items      []*Thread `json:"items"`
+	Unread     int64     `json:"unread"`
+	NextCursor string    `json:"next_cursor,omitempty"`
+}
+
+// MessagePage is a result of GET /threads/{id}/messages, newest messages first
+type MessagePage struct {
+	Items      []*Message `json:"items"`
+	NextCursor string     `json:"next_cursor,omitempty"`
+}
+
+// ThreadMessage is a result of posting a message: the updated thread and the new message
+type ThreadMessage struct {
+	Thread  *Thread  `json:"thread"`
+	Message *Message `json:"message"`
+}
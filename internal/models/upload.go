@@ -0,0 +1,47 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+const (
+	ImageVariantThumb  = "thumb"
+	ImageVariantMedium = "medium"
+	ImageVariantLarge  = "large"
+)
+
+const (
+	VariantsStatusPending = "pending"
+	VariantsStatusReady   = "ready"
+	VariantsStatusFailed  = "failed"
+)
+
+// Upload is an image uploaded by user and kept in blob storage.
+//
+// Variants are resized copies generated in background, VariantsStatus tracks their generation.
+type Upload struct {
+	ID        primitive.ObjectID `bson:"_id,omitempty" json:"_id"`
+	OwnerID   primitive.ObjectID `bson:"owner_id" json:"owner_id"`
+	Key       string             `bson:"key" json:"-"`
+	URL       string             `bson:"url" json:"url"`
+	Mime      string             `bson:"mime" json:"mime"`
+	Size      int64              `bson:"size" json:"size"`
+	Width     int                `bson:"width,omitempty" json:"width,omitempty"`
+	Height    int                `bson:"height,omitempty" json:"height,omitempty"`
+	PHash     int64              `bson:"phash,omitempty" json:"-"`
+	CreatedAt time.Time          `bson:"created_at" json:"created_at"`
+
+	Variants       map[string]ImageVariant `bson:"variants,omitempty" json:"variants,omitempty"`
+	VariantsStatus string                  `bson:"variants_status" json:"variants_status"`
+}
+
+// ImageVariant is a resized JPEG copy of an image without metadata
+type ImageVariant struct {
+	Key    string `bson:"key" json:"-"`
+	URL    string `bson:"url" json:"url"`
+	Width  int    `bson:"width" json:"width"`
+	Height int    `bson:"height" json:"height"`
+	Size   int64  `bson:"size" json:"size"`
+}
@@ -0,0 +1,15 @@
+package models
+
+import "time"
+
+// UserProfile is a public view of the seller
+type UserProfile struct {
+	Login        string    `json:"login"`
+	RegisteredAt time.Time `json:"registered_at"`
+	// ListingCounts has only published and sold listings
+	ListingCounts map[string]int64 `json:"listing_counts"`
+	TotalListings int64            `json:"total_listings"`
+	// Rating is the average of visible reviews, absent until the first review
+	Rating       *float64 `json:"rating,omitempty" example:"4.75"`
+	ReviewsCount int64    `json:"reviews_count"`
+}
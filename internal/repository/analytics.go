@@ -0,0 +1,250 @@
+package repository
+
+import (
+	"context"
+	"time"
+	"vk-inter/internal/models"
+	"vk-inter/pkg/db/mongo"
+	"vk-inter/pkg/logger"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	mongoDriver "go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"go.uber.org/zap"
+)
+
+type AnalyticsRepo struct {
+	*mongo.MongoDB
+	stats     mongoDriver.Collection
+	listings  mongoDriver.Collection
+	favorites mongoDriver.Collection
+	threads   mongoDriver.Collection
+	messages  mongoDriver.Collection
+}
+
+func NewAnalyticsRepo(ctx context.Context, db *mongo.MongoDB) *AnalyticsRepo {
+	log := logger.FromContext(ctx)
+
+	// Один документ статистики на объявление за день
+	_, err := db.Collection("listing_stats").Indexes().CreateOne(ctx, mongoDriver.IndexModel{
+		Keys:    bson.D{{Key: "listing_id", Value: 1}, {Key: "day", Value: 1}},
+		Options: options.Index().SetUnique(true),
+	})
+	if err != nil {
+		log.Fatal("Failed to create index for listing stats", zap.Error(err))
+	}
+	indexes := map[string]bson.D{
+		"listing_stats": {{Key: "owner_id", Value: 1}, {Key: "day", Value: 1}},
+		"threads":       {{Key: "seller_id", Value: 1}},
+	}
+	for collection, keys := range indexes {
+		if err := db.CreateIndex(ctx, collection, keys); err != nil {
+			log.Fatal("Failed to create index for "+collection, zap.Error(err))
+		}
+	}
+
+	return &AnalyticsRepo{
+		MongoDB:   db,
+		stats:     *db.Collection("listing_stats"),
+		listings:  *db.Collection("listings"),
+		favorites: *db.Collection("favorites"),
+		threads:   *db.Collection("threads"),
+		messages:  *db.Collection("messages"),
+	}
+}
+
+// viewBatchesKept is the number of latest batch ids remembered in a document, only the last
+// failed batch is retried, so a few are enough
+const viewBatchesKept = 8
+
+// AddViews adds the batch of views to daily stats and view counters of listings.
+//
+// Writes are not atomic, but every document remembers ids of applied batches,
+// so the batch failed midway may be written again without counting views twice.
+func (ar *AnalyticsRepo) AddViews(ctx context.Context, batchID primitive.ObjectID, views []models.ViewCount) error {
+	if len(views) == 0 {
+		return nil
+	}
+	statWrites := make([]mongoDriver.WriteModel, 0, len(views))
+	totals := map[primitive.ObjectID]int64{}
+	for _, v := range views {
+		statWrites = append(statWrites, mongoDriver.NewUpdateOneModel().
+			SetFilter(bson.M{"listing_id": v.ListingID, "day": v.Day}).
+			SetUpdate(mongoDriver.Pipeline{
+				{{Key: "$set", Value: bson.M{"owner_id": bson.M{"$ifNull": bson.A{"$owner_id", v.OwnerID}}}}},
+				{{Key: "$set", Value: batchIncrement("views", "batches", batchID, v.Views)}},
+			}).
+			SetUpsert(true))
+		totals[v.ListingID] += v.Views
+	}
+	listingWrites := make([]mongoDriver.WriteModel, 0, len(totals))
+	for id, n := range totals {
+		listingWrites = append(listingWrites, mongoDriver.NewUpdateOneModel().
+			SetFilter(bson.M{"_id": id}).
+			SetUpdate(mongoDriver.Pipeline{
+				{{Key: "$set", Value: batchIncrement("views_count", "views_batches", batchID, n)}},
+			}))
+	}
+
+	if _, err := ar.stats.BulkWrite(ctx, statWrites, options.BulkWrite().SetOrdered(false)); err != nil {
+		return err
+	}
+	_, err := ar.listings.BulkWrite(ctx, listingWrites, options.BulkWrite().SetOrdered(false))
+	return err
+}
+
+// batchIncrement returns $set stage which adds n to the field unless the batch was already applied
+func batchIncrement(field, batchesField string, batchID primitive.ObjectID, n int64) bson.M {
+	batches := bson.M{"$ifNull": bson.A{"$" + batchesField, bson.A{}}}
+	applied := bson.M{"$in": bson.A{batchID, batches}}
+	return bson.M{
+		field: bson.M{"$cond": bson.A{applied,
+			"$" + field,
+			bson.M{"$add": bson.A{bson.M{"$ifNull": bson.A{"$" + field, 0}}, n}},
+		}},
+		batchesField: bson.M{"$cond": bson.A{applied,
+			batches,
+			bson.M{"$slice": bson.A{bson.M{"$concatArrays": bson.A{batches, bson.A{batchID}}}, -viewBatchesKept}},
+		}},
+	}
+}
+
+// GetSellerListings returns listings of the owner except removed ones, latest first
+func (ar *AnalyticsRepo) GetSellerListings(ctx context.Context, ownerID primitive.ObjectID) ([]*models.Listing, error) {
+	cursor, err := ar.listings.Find(ctx,
+		bson.M{"owner_id": ownerID, "status": bson.M{"$ne": models.ListingStatusRemoved}},
+		options.Find().
+			SetSort(bson.D{{Key: "created_at", Value: -1}, {Key: "_id", Value: -1}}).
+			SetProjection(bson.M{"title": 1, "status": 1, "views_count": 1, "created_at": 1}),
+	)
+	if err != nil {
+		return nil, err
+	}
+	listings := []*models.Listing{}
+	if err := cursor.All(ctx, &listings); err != nil {
+		return nil, err
+	}
+	return listings, nil
+}
+
+// GetDailyViews returns views of the owner's listings by day, days are inclusive
+func (ar *AnalyticsRepo) GetDailyViews(ctx context.Context, ownerID primitive.ObjectID, from, to string) ([]models.DailyCount, error) {
+	cursor, err := ar.stats.Find(ctx,
+		bson.M{"owner_id": ownerID, "day": bson.M{"$gte": from, "$lte": to}},
+		options.Find().SetProjection(bson.M{"listing_id": 1, "day": 1, "views": 1}),
+	)
+	if err != nil {
+		return nil, err
+	}
+	var stats []struct {
+		ListingID primitive.ObjectID `bson:"listing_id"`
+		Day       string             `bson:"day"`
+		Views     int64              `bson:"views"`
+	}
+	if err := cursor.All(ctx, &stats); err != nil {
+		return nil, err
+	}
+	counts := make([]models.DailyCount, 0, len(stats))
+	for _, s := range stats {
+		counts = append(counts, models.DailyCount{ListingID: s.ListingID, Day: s.Day, Count: s.Views})
+	}
+	return counts, nil
+}
+
+// GetDailyFavorites returns the number of users who bookmarked the listings by day
+// and still keep them, days are taken in UTC
+func (ar *AnalyticsRepo) GetDailyFavorites(ctx context.Context, listingIDs []primitive.ObjectID, from, to string) ([]models.DailyCount, error) {
+	start, end, err := dayRange(from, to)
+	if err != nil {
+		return nil, err
+	}
+	return ar.countByDay(ctx, &ar.favorites, bson.M{
+		"listing_id": bson.M{"$in": listingIDs},
+		"created_at": bson.M{"$gte": start, "$lt": end},
+	}, "$listing_id", nil)
+}
+
+// GetDailyMessages returns the number of messages from buyers to the seller by listing and day
+func (ar *AnalyticsRepo) GetDailyMessages(ctx context.Context, sellerID primitive.ObjectID, from, to string) ([]models.DailyCount, error) {
+	start, end, err := dayRange(from, to)
+	if err != nil {
+		return nil, err
+	}
+
+	cursor, err := ar.threads.Find(ctx, bson.M{"seller_id": sellerID}, options.Find().SetProjection(bson.M{"listing_id": 1}))
+	if err != nil {
+		return nil, err
+	}
+	var threads []models.Thread
+	if err := cursor.All(ctx, &threads); err != nil {
+		return nil, err
+	}
+	if len(threads) == 0 {
+		return []models.DailyCount{}, nil
+	}
+	threadListing := make(map[primitive.ObjectID]primitive.ObjectID, len(threads))
+	threadIDs := make([]primitive.ObjectID, 0, len(threads))
+	for _, t := range threads {
+		threadListing[t.ID] = t.ListingID
+		threadIDs = append(threadIDs, t.ID)
+	}
+
+	// Группировка по переписке, объявление подставляется после
+	return ar.countByDay(ctx, &ar.messages, bson.M{
+		"thread_id":  bson.M{"$in": threadIDs},
+		"sender_id":  bson.M{"$ne": sellerID},
+		"created_at": bson.M{"$gte": start, "$lt": end},
+	}, "$thread_id", threadListing)
+}
+
+// countByDay counts documents matching the filter by the key field and day of created_at.
+// Keys are replaced with listings by keyListing if it is set.
+func (ar *AnalyticsRepo) countByDay(ctx context.Context, collection *mongoDriver.Collection, filter bson.M, key string, keyListing map[primitive.ObjectID]primitive.ObjectID) ([]models.DailyCount, error) {
+	cursor, err := collection.Aggregate(ctx, mongoDriver.Pipeline{
+		{{Key: "$match", Value: filter}},
+		{{Key: "$group", Value: bson.M{
+			"_id": bson.M{
+				"key": key,
+				"day": bson.M{"$dateToString": bson.M{"format": "%Y-%m-%d", "date": "$created_at", "timezone": "UTC"}},
+			},
+			"count": bson.M{"$sum": 1},
+		}}},
+	})
+	if err != nil {
+		return nil, err
+	}
+	var rows []struct {
+		ID struct {
+			Key primitive.ObjectID `bson:"key"`
+			Day string             `bson:"day"`
+		} `bson:"_id"`
+		Count int64 `bson:"count"`
+	}
+	if err := cursor.All(ctx, &rows); err != nil {
+		return nil, err
+	}
+
+	counts := make([]models.DailyCount, 0, len(rows))
+	for _, r := range rows {
+		listingID := r.ID.Key
+		if keyListing != nil {
+			listingID = keyListing[r.ID.Key]
+		}
+		counts = append(counts, models.DailyCount{ListingID: listingID, Day: r.ID.Day, Count: r.Count})
+	}
+	return counts, nil
+}
+
+// dayRange converts inclusive range of days to [start, end) time range in UTC
+func dayRange(from, to string) (time.Time, time.Time, error) {
+	start, err := time.Parse(models.DayLayout, from)
+	if err != nil {
+		return time.Time{}, time.Time{}, err
+	}
+	end, err := time.Parse(models.DayLayout, to)
+	if err != nil {
+		return time.Time{}, time.Time{}, err
+	}
+	return start, end.AddDate(0, 0, 1), nil
+}
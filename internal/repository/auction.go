@@ -0,0 +1,165 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+	"vk-inter/internal/models"
+	"vk-inter/pkg/db/mongo"
+	"vk-inter/pkg/errs"
+	"vk-inter/pkg/logger"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	mongoDriver "go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"go.uber.org/zap"
+)
+
+type AuctionRepo struct {
+	*mongo.MongoDB
+	collection mongoDriver.Collection
+	listings   mongoDriver.Collection
+}
+
+func NewAuctionRepo(ctx context.Context, db *mongo.MongoDB) *AuctionRepo {
+	log := logger.FromContext(ctx)
+
+	if err := db.CreateIndex(ctx, "bids", bson.D{{Key: "listing_id", Value: 1}, {Key: "created_at", Value: -1}}); err != nil {
+		log.Fatal("Failed to create index for bids", zap.Error(err))
+	}
+	// Планировщик ищет только открытые аукционы
+	_, err := db.Collection("listings").Indexes().CreateOne(ctx, mongoDriver.IndexModel{
+		Keys:    bson.D{{Key: "auction.ends_at", Value: 1}},
+		Options: options.Index().SetPartialFilterExpression(bson.M{"auction.status": models.AuctionStatusOpen}),
+	})
+	if err != nil {
+		log.Fatal("Failed to create index for auctions", zap.Error(err))
+	}
+
+	return &AuctionRepo{
+		MongoDB:    db,
+		collection: *db.Collection("bids"),
+		listings:   *db.Collection("listings"),
+	}
+}
+
+// PlaceBid makes the bid leading.
+//
+// The bid is accepted only if the auction still has seenBids bids, otherwise it was
+// validated against an outdated state and ErrBidConflict is returned. The conditional
+// update is atomic on its own, the bid is saved to history by InsertBid after it.
+func (ar *AuctionRepo) PlaceBid(ctx context.Context, bid *models.Bid, seenBids int64, endsAt, now time.Time) (*models.Listing, error) {
+	bid.ID = primitive.NewObjectID()
+	var listing models.Listing
+	err := ar.listings.FindOneAndUpdate(ctx,
+		bson.M{
+			"_id":                bid.ListingID,
+			"status":             models.ListingStatusPublished,
+			"auction.status":     models.AuctionStatusOpen,
+			"auction.bids_count": seenBids,
+			"auction.ends_at":    bson.M{"$gt": now},
+		},
+		bson.M{
+			"$set": bson.M{
+				"auction.current_bid":  bid.Amount,
+				"auction.leader_id":    bid.BidderID,
+				"auction.leader_login": bid.BidderLogin,
+				"auction.ends_at":      endsAt,
+			},
+			"$inc": bson.M{"auction.bids_count": 1},
+		},
+		options.FindOneAndUpdate().SetReturnDocument(options.After),
+	).Decode(&listing)
+	if errors.Is(err, mongoDriver.ErrNoDocuments) {
+		return nil, errs.ErrBidConflict
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &listing, nil
+}
+
+// InsertBid saves the accepted bid to the bid history
+func (ar *AuctionRepo) InsertBid(ctx context.Context, bid *models.Bid) error {
+	_, err := ar.collection.InsertOne(ctx, bid)
+	return err
+}
+
+// GetBids returns bids of the listing, latest first
+func (ar *AuctionRepo) GetBids(ctx context.Context, listingID primitive.ObjectID, limit int) ([]*models.Bid, error) {
+	cursor, err := ar.collection.Find(ctx, bson.M{"listing_id": listingID},
+		options.Find().
+			SetSort(bson.D{{Key: "created_at", Value: -1}, {Key: "_id", Value: -1}}).
+			SetLimit(int64(limit)),
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	bids := []*models.Bid{}
+	for cursor.Next(ctx) {
+		var b models.Bid
+		if err := cursor.Decode(&b); err != nil {
+			continue
+		}
+		bids = append(bids, &b)
+	}
+	return bids, nil
+}
+
+// GetEndedAuctions returns open auctions which ended by now, the earliest first
+func (ar *AuctionRepo) GetEndedAuctions(ctx context.Context, now time.Time, limit int) ([]*models.Listing, error) {
+	cursor, err := ar.listings.Find(ctx,
+		bson.M{"auction.status": models.AuctionStatusOpen, "auction.ends_at": bson.M{"$lte": now}},
+		options.Find().
+			SetSort(bson.D{{Key: "auction.ends_at", Value: 1}}).
+			SetLimit(int64(limit)),
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	listings := []*models.Listing{}
+	if err := cursor.All(ctx, &listings); err != nil {
+		return nil, err
+	}
+	return listings, nil
+}
+
+// CloseAuction closes the ended auction if it still has seenBids bids, otherwise returns ErrBidConflict.
+//
+// With a winner the published listing becomes reserved.
+func (ar *AuctionRepo) CloseAuction(ctx context.Context, id primitive.ObjectID, seenBids int64, winnerID *primitive.ObjectID, now time.Time) (*models.Listing, error) {
+	set := bson.M{
+		"auction.status":    models.AuctionStatusClosed,
+		"auction.closed_at": now,
+	}
+	if winnerID != nil {
+		set["auction.winner_id"] = *winnerID
+		published := bson.M{"$eq": bson.A{"$status", models.ListingStatusPublished}}
+		set["status"] = bson.M{"$cond": bson.A{published, models.ListingStatusReserved, "$status"}}
+		set["buyer_id"] = bson.M{"$cond": bson.A{published, *winnerID, "$buyer_id"}}
+	}
+
+	var listing models.Listing
+	err := ar.listings.FindOneAndUpdate(ctx,
+		bson.M{
+			"_id":                id,
+			"auction.status":     models.AuctionStatusOpen,
+			"auction.bids_count": seenBids,
+			"auction.ends_at":    bson.M{"$lte": now},
+		},
+		mongoDriver.Pipeline{{{Key: "$set", Value: set}}},
+		options.FindOneAndUpdate().SetReturnDocument(options.After),
+	).Decode(&listing)
+	if errors.Is(err, mongoDriver.ErrNoDocuments) {
+		return nil, errs.ErrBidConflict
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &listing, nil
+}
@@ -0,0 +1,267 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"regexp"
+	"time"
+	"vk-inter/internal/models"
+	"vk-inter/pkg/db/mongo"
+	"vk-inter/pkg/errs"
+	"vk-inter/pkg/logger"
+
+	"github.com/go-playground/validator/v10"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	mongoDriver "go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"go.uber.org/zap"
+)
+
+type CategoryRepo struct {
+	*mongo.MongoDB
+	collection mongoDriver.Collection
+	validate   *validator.Validate
+}
+
+func NewCategoryRepo(ctx context.Context, db *mongo.MongoDB) *CategoryRepo {
+	log := logger.FromContext(ctx)
+
+	err := db.CreateUniqueIndex(ctx, "categories", "slug", true)
+	if err != nil {
+		log.Fatal("Failed to create index for categories", zap.Error(err))
+	}
+
+	// Индекс по предкам нужен для выборки всего поддерева категории
+	err = db.CreateUniqueIndex(ctx, "categories", "ancestors", false)
+	if err != nil {
+		log.Fatal("Failed to create index for categories", zap.Error(err))
+	}
+
+	schema := bson.M{
+		"bsonType": "object",
+		"required": []string{"name", "slug", "ancestors"},
+		"properties": bson.M{
+			"name": bson.M{
+				"bsonType":    "string",
+				"minLength":   2,
+				"maxLength":   64,
+				"description": "must be 2-64 chars",
+			},
+			"slug": bson.M{
+				"bsonType":    "string",
+				"minLength":   2,
+				"maxLength":   64,
+				"pattern":     "^[a-z0-9-]+$",
+				"description": "must be 2-64 lowercase latin letters, numbers and '-'",
+			},
+			"parent_id": bson.M{
+				"bsonType": "objectId",
+			},
+			"ancestors": bson.M{
+				"bsonType": "array",
+				"items": bson.M{
+					"bsonType": "objectId",
+				},
+			},
+			"attributes": bson.M{
+				"bsonType": "array",
+				"items": bson.M{
+					"bsonType": "object",
+					"required": []string{"name", "type"},
+				},
+			},
+		},
+	}
+
+	err = db.SetupValidation(ctx, schema, "categories")
+	if err != nil {
+		log.Fatal("Failed to setup validation for categories", zap.Error(err))
+	}
+
+	validate := validator.New()
+	validate.RegisterValidation("regexp", func(fl validator.FieldLevel) bool {
+		re := regexp.MustCompile(fl.Param())
+		return re.MatchString(fl.Field().String())
+	})
+
+	return &CategoryRepo{
+		MongoDB:    db,
+		collection: *db.Collection("categories"),
+		validate:   validate,
+	}
+}
+
+func (cr *CategoryRepo) validateCategory(category *models.Category) error {
+	if err := cr.validate.Struct(category); err != nil {
+		var ve validator.ValidationErrors
+		if errors.As(err, &ve) {
+			for _, fe := range ve {
+				switch fe.Field() {
+				case "Name":
+					return errs.ErrCategoryInvalidName
+				case "Slug":
+					return errs.ErrCategoryInvalidSlug
+				}
+			}
+		}
+		return err
+	}
+	return nil
+}
+
+func (cr *CategoryRepo) CreateCategory(ctx context.Context, category *models.Category) (*models.Category, error) {
+	if err := cr.validateCategory(category); err != nil {
+		return nil, err
+	}
+	if category.Ancestors == nil {
+		category.Ancestors = []primitive.ObjectID{}
+	}
+	category.CreatedAt = time.Now()
+
+	res, err := cr.collection.InsertOne(ctx, category)
+	if err != nil {
+		if cr.MongoDB.IsDuplicateKeyError(err) {
+			return nil, errs.ErrCategoryAlreadyExsist
+		}
+		return nil, err
+	}
+	category.ID = res.InsertedID.(primitive.ObjectID)
+
+	return category, nil
+}
+
+// UpdateCategory replaces name, slug and position of the category in the tree.
+//
+// If the category was moved, ancestors of the whole subtree are rewritten too.
+func (cr *CategoryRepo) UpdateCategory(ctx context.Context, category *models.Category) (*models.Category, error) {
+	if err := cr.validateCategory(category); err != nil {
+		return nil, err
+	}
+	if category.Ancestors == nil {
+		category.Ancestors = []primitive.ObjectID{}
+	}
+
+	update := bson.M{
+		"$set": bson.M{
+			"name":      category.Name,
+			"slug":      category.Slug,
+			"ancestors": category.Ancestors,
+		},
+		"$unset": bson.M{},
+	}
+	if len(category.Attributes) > 0 {
+		update["$set"].(bson.M)["attributes"] = category.Attributes
+	} else {
+		update["$unset"].(bson.M)["attributes"] = ""
+	}
+	if category.ParentID != nil {
+		update["$set"].(bson.M)["parent_id"] = *category.ParentID
+	} else {
+		update["$unset"].(bson.M)["parent_id"] = ""
+	}
+
+	res, err := cr.collection.UpdateByID(ctx, category.ID, update)
+	if err != nil {
+		if cr.MongoDB.IsDuplicateKeyError(err) {
+			return nil, errs.ErrCategoryAlreadyExsist
+		}
+		return nil, err
+	}
+	if res.MatchedCount == 0 {
+		return nil, errs.ErrCategoryNotFound
+	}
+
+	descendants, err := cr.GetDescendants(ctx, category.ID)
+	if err != nil {
+		return nil, err
+	}
+	if len(descendants) == 0 {
+		return category, nil
+	}
+
+	writes := make([]mongoDriver.WriteModel, 0, len(descendants))
+	for _, d := range descendants {
+		// Путь потомка = новый путь категории + сама категория + хвост пути после неё
+		ancestors := append([]primitive.ObjectID{}, category.Ancestors...)
+		ancestors = append(ancestors, category.ID)
+		for i, a := range d.Ancestors {
+			if a == category.ID {
+				ancestors = append(ancestors, d.Ancestors[i+1:]...)
+				break
+			}
+		}
+		writes = append(writes, mongoDriver.NewUpdateOneModel().
+			SetFilter(bson.M{"_id": d.ID}).
+			SetUpdate(bson.M{"$set": bson.M{"ancestors": ancestors}}))
+	}
+
+	if _, err := cr.collection.BulkWrite(ctx, writes); err != nil {
+		return nil, err
+	}
+
+	return category, nil
+}
+
+func (cr *CategoryRepo) DeleteCategory(ctx context.Context, id primitive.ObjectID) error {
+	res, err := cr.collection.DeleteOne(ctx, bson.M{"_id": id})
+	if err != nil {
+		return err
+	}
+	if res.DeletedCount == 0 {
+		return errs.ErrCategoryNotFound
+	}
+	return nil
+}
+
+func (cr *CategoryRepo) GetByID(ctx context.Context, id primitive.ObjectID) (*models.Category, error) {
+	var category models.Category
+	err := cr.collection.FindOne(ctx, bson.M{"_id": id}).Decode(&category)
+	if err != nil {
+		if errors.Is(err, mongoDriver.ErrNoDocuments) {
+			return nil, errs.ErrCategoryNotFound
+		}
+		return nil, err
+	}
+	return &category, nil
+}
+
+func (cr *CategoryRepo) GetByIDs(ctx context.Context, ids []primitive.ObjectID) ([]*models.Category, error) {
+	return cr.find(ctx, bson.M{"_id": bson.M{"$in": ids}})
+}
+
+func (cr *CategoryRepo) GetAll(ctx context.Context) ([]*models.Category, error) {
+	return cr.find(ctx, bson.M{})
+}
+
+// GetDescendants returns all categories of the subtree except the root itself
+func (cr *CategoryRepo) GetDescendants(ctx context.Context, id primitive.ObjectID) ([]*models.Category, error) {
+	return cr.find(ctx, bson.M{"ancestors": id})
+}
+
+func (cr *CategoryRepo) HasChildren(ctx context.Context, id primitive.ObjectID) (bool, error) {
+	count, err := cr.collection.CountDocuments(ctx, bson.M{"parent_id": id}, options.Count().SetLimit(1))
+	if err != nil {
+		return false, err
+	}
+	return count > 0, nil
+}
+
+func (cr *CategoryRepo) find(ctx context.Context, filter bson.M) ([]*models.Category, error) {
+	cursor, err := cr.collection.Find(ctx, filter, options.Find().SetSort(bson.D{{Key: "name", Value: 1}}))
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	categories := []*models.Category{}
+	for cursor.Next(ctx) {
+		var c models.Category
+		if err := cursor.Decode(&c); err != nil {
+			continue
+		}
+		categories = append(categories, &c)
+	}
+
+	return categories, nil
+}
@@ -0,0 +1,75 @@
+package repository
+
+import (
+	"context"
+	"time"
+	"vk-inter/internal/models"
+	"vk-inter/pkg/db/mongo"
+	"vk-inter/pkg/logger"
+
+	"go.mongodb.org/mongo-driver/bson"
+	mongoDriver "go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"go.uber.org/zap"
+)
+
+type DuplicateRepo struct {
+	*mongo.MongoDB
+	collection mongoDriver.Collection
+}
+
+func NewDuplicateRepo(ctx context.Context, db *mongo.MongoDB) *DuplicateRepo {
+	log := logger.FromContext(ctx)
+
+	err := db.CreateIndex(ctx, "duplicate_reports", bson.D{{Key: "listing_id", Value: 1}, {Key: "duplicate_of_id", Value: 1}})
+	if err != nil {
+		log.Fatal("Failed to create index for duplicate reports", zap.Error(err))
+	}
+	err = db.CreateUniqueIndex(ctx, "duplicate_reports", "created_at", false)
+	if err != nil {
+		log.Fatal("Failed to create index for duplicate reports", zap.Error(err))
+	}
+
+	return &DuplicateRepo{
+		MongoDB:    db,
+		collection: *db.Collection("duplicate_reports"),
+	}
+}
+
+// SaveDuplicateReport stores report, a pair of listings is reported once
+func (dr *DuplicateRepo) SaveDuplicateReport(ctx context.Context, report *models.DuplicateReport) error {
+	report.CreatedAt = time.Now()
+	_, err := dr.collection.UpdateOne(ctx,
+		bson.M{"listing_id": report.ListingID, "duplicate_of_id": report.DuplicateOfID},
+		bson.M{"$setOnInsert": bson.M{
+			"owner_id":           report.OwnerID,
+			"duplicate_owner_id": report.DuplicateOwnerID,
+			"text_similarity":    report.TextSimilarity,
+			"image_distance":     report.ImageDistance,
+			"created_at":         report.CreatedAt,
+		}},
+		options.Update().SetUpsert(true),
+	)
+	return err
+}
+
+// GetDuplicateReports returns latest reports first
+func (dr *DuplicateRepo) GetDuplicateReports(ctx context.Context, limit int) ([]*models.DuplicateReport, error) {
+	cursor, err := dr.collection.Find(ctx, bson.M{},
+		options.Find().SetSort(bson.D{{Key: "created_at", Value: -1}}).SetLimit(int64(limit)),
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	reports := []*models.DuplicateReport{}
+	for cursor.Next(ctx) {
+		var r models.DuplicateReport
+		if err := cursor.Decode(&r); err != nil {
+			continue
+		}
+		reports = append(reports, &r)
+	}
+	return reports, nil
+}
@@ -0,0 +1,179 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+	"vk-inter/internal/models"
+	"vk-inter/pkg/db/mongo"
+	"vk-inter/pkg/errs"
+	"vk-inter/pkg/logger"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	mongoDriver "go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"go.uber.org/zap"
+)
+
+type FavoriteRepo struct {
+	*mongo.MongoDB
+	collection mongoDriver.Collection
+	listings   mongoDriver.Collection
+}
+
+func NewFavoriteRepo(ctx context.Context, db *mongo.MongoDB) *FavoriteRepo {
+	log := logger.FromContext(ctx)
+
+	// Уникальность пары не даёт посчитать одно избранное дважды
+	_, err := db.Collection("favorites").Indexes().CreateOne(ctx, mongoDriver.IndexModel{
+		Keys:    bson.D{{Key: "user_id", Value: 1}, {Key: "listing_id", Value: 1}},
+		Options: options.Index().SetUnique(true),
+	})
+	if err != nil {
+		log.Fatal("Failed to create index for favorites", zap.Error(err))
+	}
+	err = db.CreateIndex(ctx, "favorites", bson.D{{Key: "user_id", Value: 1}, {Key: "created_at", Value: -1}})
+	if err != nil {
+		log.Fatal("Failed to create index for favorites", zap.Error(err))
+	}
+	err = db.CreateIndex(ctx, "favorites", bson.D{{Key: "listing_id", Value: 1}, {Key: "_id", Value: 1}})
+	if err != nil {
+		log.Fatal("Failed to create index for favorites", zap.Error(err))
+	}
+
+	return &FavoriteRepo{
+		MongoDB:    db,
+		collection: *db.Collection("favorites"),
+		listings:   *db.Collection("listings"),
+	}
+}
+
+// AddFavorite inserts favorite and increments listing's favorites counter in one transaction.
+//
+// Returns false if the listing is already in user's favorites, the counter is returned only for added favorite.
+func (fr *FavoriteRepo) AddFavorite(ctx context.Context, userID, listingID primitive.ObjectID) (bool, int64, error) {
+	var count int64
+	err := fr.WithTransaction(ctx, func(ctx context.Context) error {
+		_, err := fr.collection.InsertOne(ctx, models.Favorite{
+			UserID:    userID,
+			ListingID: listingID,
+			CreatedAt: time.Now(),
+		})
+		if err != nil {
+			return err
+		}
+		count, err = fr.incFavorites(ctx, listingID, 1)
+		return err
+	})
+	if fr.IsDuplicateKeyError(err) {
+		return false, 0, nil
+	}
+	if err != nil {
+		return false, 0, err
+	}
+	return true, count, nil
+}
+
+// RemoveFavorite deletes favorite and decrements listing's favorites counter in one transaction.
+//
+// Returns false if the listing was not in user's favorites. Favorite of deleted listing
+// is removed too, its counter is 0.
+func (fr *FavoriteRepo) RemoveFavorite(ctx context.Context, userID, listingID primitive.ObjectID) (bool, int64, error) {
+	var removed bool
+	var count int64
+	err := fr.WithTransaction(ctx, func(ctx context.Context) error {
+		res, err := fr.collection.DeleteOne(ctx, bson.M{"user_id": userID, "listing_id": listingID})
+		if err != nil {
+			return err
+		}
+		if removed = res.DeletedCount > 0; !removed {
+			return nil
+		}
+		count, err = fr.incFavorites(ctx, listingID, -1)
+		if errors.Is(err, errs.ErrListingNotFound) {
+			return nil
+		}
+		return err
+	})
+	if err != nil {
+		return false, 0, err
+	}
+	return removed, count, nil
+}
+
+// incFavorites atomically changes favorites counter of the listing and returns its new value
+func (fr *FavoriteRepo) incFavorites(ctx context.Context, listingID primitive.ObjectID, delta int64) (int64, error) {
+	filter := bson.M{"_id": listingID}
+	if delta < 0 {
+		filter["favorites_count"] = bson.M{"$gte": -delta}
+	}
+
+	var listing models.Listing
+	err := fr.listings.FindOneAndUpdate(ctx, filter,
+		bson.M{"$inc": bson.M{"favorites_count": delta}},
+		options.FindOneAndUpdate().SetReturnDocument(options.After).SetProjection(bson.M{"favorites_count": 1}),
+	).Decode(&listing)
+	if errors.Is(err, mongoDriver.ErrNoDocuments) {
+		return 0, errs.ErrListingNotFound
+	}
+	if err != nil {
+		return 0, err
+	}
+	return listing.FavoritesCount, nil
+}
+
+// GetFavoriteListingIDs returns ids of user's favorite listings, recently added first
+func (fr *FavoriteRepo) GetFavoriteListingIDs(ctx context.Context, userID primitive.ObjectID, skip, limit int) ([]primitive.ObjectID, error) {
+	cursor, err := fr.collection.Find(ctx,
+		bson.M{"user_id": userID},
+		options.Find().
+			SetSort(bson.D{{Key: "created_at", Value: -1}, {Key: "_id", Value: -1}}).
+			SetSkip(int64(skip)).
+			SetLimit(int64(limit)).
+			SetProjection(bson.M{"listing_id": 1}),
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var ids []primitive.ObjectID
+	for cursor.Next(ctx) {
+		var f models.Favorite
+		if err := cursor.Decode(&f); err != nil {
+			continue
+		}
+		ids = append(ids, f.ListingID)
+	}
+	return ids, nil
+}
+
+func (fr *FavoriteRepo) CountFavorites(ctx context.Context, userID primitive.ObjectID) (int64, error) {
+	return fr.collection.CountDocuments(ctx, bson.M{"user_id": userID})
+}
+
+// GetListingFavorites returns favorites of the listing ordered by _id, next batch starts after the last returned id
+func (fr *FavoriteRepo) GetListingFavorites(ctx context.Context, listingID, after primitive.ObjectID, limit int) ([]*models.Favorite, error) {
+	filter := bson.M{"listing_id": listingID}
+	if after != primitive.NilObjectID {
+		filter["_id"] = bson.M{"$gt": after}
+	}
+	cursor, err := fr.collection.Find(ctx, filter,
+		options.Find().SetSort(bson.D{{Key: "_id", Value: 1}}).SetLimit(int64(limit)),
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var favorites []*models.Favorite
+	for cursor.Next(ctx) {
+		var f models.Favorite
+		if err := cursor.Decode(&f); err != nil {
+			continue
+		}
+		favorites = append(favorites, &f)
+	}
+	return favorites, nil
+}
@@ -0,0 +1,113 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+	"vk-inter/internal/models"
+	"vk-inter/pkg/db/mongo"
+	"vk-inter/pkg/errs"
+	"vk-inter/pkg/logger"
+
+	"go.mongodb.org/mongo-driver/bson"
+	mongoDriver "go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"go.uber.org/zap"
+)
+
+type ImageRepo struct {
+	*mongo.MongoDB
+	collection mongoDriver.Collection
+}
+
+func NewImageRepo(ctx context.Context, db *mongo.MongoDB) *ImageRepo {
+	log := logger.FromContext(ctx)
+
+	err := db.CreateUniqueIndex(ctx, "images", "checked_at", false)
+	if err != nil {
+		log.Fatal("Failed to create index for images", zap.Error(err))
+	}
+
+	return &ImageRepo{
+		MongoDB:    db,
+		collection: *db.Collection("images"),
+	}
+}
+
+// SaveImage inserts image record, if the image is already stored only its check time is updated
+func (ir *ImageRepo) SaveImage(ctx context.Context, image *models.CachedImage) (*models.CachedImage, error) {
+	now := time.Now()
+	opts := options.FindOneAndUpdate().SetUpsert(true).SetReturnDocument(options.After)
+
+	var saved models.CachedImage
+	err := ir.collection.FindOneAndUpdate(ctx,
+		bson.M{"_id": image.Hash},
+		bson.M{
+			"$setOnInsert": bson.M{
+				"key":        image.Key,
+				"mime":       image.Mime,
+				"size":       image.Size,
+				"width":      image.Width,
+				"height":     image.Height,
+				"phash":      image.PHash,
+				"source_url": image.SourceURL,
+				"created_at": now,
+			},
+			"$set": bson.M{"checked_at": now},
+		},
+		opts,
+	).Decode(&saved)
+	if err != nil {
+		return nil, err
+	}
+	return &saved, nil
+}
+
+func (ir *ImageRepo) GetImage(ctx context.Context, hash string) (*models.CachedImage, error) {
+	var image models.CachedImage
+	err := ir.collection.FindOne(ctx, bson.M{"_id": hash}).Decode(&image)
+	if err != nil {
+		if errors.Is(err, mongoDriver.ErrNoDocuments) {
+			return nil, errs.ErrImageNotFound
+		}
+		return nil, err
+	}
+	return &image, nil
+}
+
+// GetUncheckedImages returns images checked before the time, oldest first
+func (ir *ImageRepo) GetUncheckedImages(ctx context.Context, before time.Time, limit int) ([]*models.CachedImage, error) {
+	cursor, err := ir.collection.Find(ctx,
+		bson.M{"checked_at": bson.M{"$lt": before}},
+		options.Find().SetSort(bson.D{{Key: "checked_at", Value: 1}}).SetLimit(int64(limit)),
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var images []*models.CachedImage
+	for cursor.Next(ctx) {
+		var image models.CachedImage
+		if err := cursor.Decode(&image); err != nil {
+			continue
+		}
+		images = append(images, &image)
+	}
+	return images, nil
+}
+
+func (ir *ImageRepo) TouchImage(ctx context.Context, hash string) error {
+	_, err := ir.collection.UpdateOne(ctx, bson.M{"_id": hash}, bson.M{"$set": bson.M{"checked_at": time.Now()}})
+	return err
+}
+
+// DeleteImage deletes image record if it was not checked after the time,
+// so an image cached again during garbage collection is kept
+func (ir *ImageRepo) DeleteImage(ctx context.Context, hash string, checkedBefore time.Time) (bool, error) {
+	res, err := ir.collection.DeleteOne(ctx, bson.M{"_id": hash, "checked_at": bson.M{"$lt": checkedBefore}})
+	if err != nil {
+		return false, err
+	}
+	return res.DeletedCount > 0, nil
+}
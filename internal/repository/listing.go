@@ -3,13 +3,14 @@ package repository
 import (
 	"context"
 	"errors"
-	"math"
 	"regexp"
+	"strconv"
 	"time"
 	"vk-inter/internal/models"
 	"vk-inter/pkg/db/mongo"
 	"vk-inter/pkg/errs"
 	"vk-inter/pkg/logger"
+	"vk-inter/pkg/money"
 
 	"github.com/go-playground/validator/v10"
 	"go.mongodb.org/mongo-driver/bson"
@@ -19,6 +20,8 @@ import (
 	"go.uber.org/zap"
 )
 
+const earthRadiusKm = 6378.1
+
 type ListingRepo struct {
 	*mongo.MongoDB
 	collection mongoDriver.Collection
@@ -33,6 +36,65 @@ func NewListingRepo(ctx context.Context, db *mongo.MongoDB) *ListingRepo {
 		log.Fatal("Failed to create index for listings", zap.Error(err))
 	}
 
+	for _, field := range []string{"category_id", "tags"} {
+		err = db.CreateUniqueIndex(ctx, "listings", field, false)
+		if err != nil {
+			log.Fatal("Failed to create index for listings", zap.Error(err))
+		}
+	}
+
+	// Индексы под сортировку с _id в качестве tiebreaker для курсорной пагинации
+	for _, field := range []string{"created_at", "price_base"} {
+		err = db.CreateIndex(ctx, "listings", bson.D{{Key: field, Value: 1}, {Key: "_id", Value: 1}})
+		if err != nil {
+			log.Fatal("Failed to create index for listings", zap.Error(err))
+		}
+	}
+
+	// Объявления продавца: фильтры owner_id/owner_login и счётчики по статусам в профиле
+	ownerIndexes := []bson.D{
+		{{Key: "owner_id", Value: 1}, {Key: "created_at", Value: 1}, {Key: "_id", Value: 1}},
+		{{Key: "owner_login", Value: 1}, {Key: "created_at", Value: 1}, {Key: "_id", Value: 1}},
+		{{Key: "owner_id", Value: 1}, {Key: "status", Value: 1}},
+	}
+	for _, keys := range ownerIndexes {
+		err = db.CreateIndex(ctx, "listings", keys)
+		if err != nil {
+			log.Fatal("Failed to create index for listings", zap.Error(err))
+		}
+	}
+
+	// Набор атрибутов зависит от категории, поэтому индекс wildcard
+	err = db.CreateIndex(ctx, "listings", bson.D{{Key: "attributes.$**", Value: 1}})
+	if err != nil {
+		log.Fatal("Failed to create index for listings", zap.Error(err))
+	}
+
+	err = db.CreateIndex(ctx, "listings", bson.D{{Key: "location", Value: "2dsphere"}})
+	if err != nil {
+		log.Fatal("Failed to create index for listings", zap.Error(err))
+	}
+
+	err = db.CreateUniqueIndex(ctx, "listings", "images.hash", false)
+	if err != nil {
+		log.Fatal("Failed to create index for listings", zap.Error(err))
+	}
+
+	err = db.CreateIndex(ctx, "listings", bson.D{{Key: "fingerprint.bands", Value: 1}, {Key: "created_at", Value: 1}})
+	if err != nil {
+		log.Fatal("Failed to create index for listings", zap.Error(err))
+	}
+
+	err = db.CreateIndex(ctx, "listings", bson.D{{Key: "price_dropped_at", Value: 1}})
+	if err != nil {
+		log.Fatal("Failed to create index for listings", zap.Error(err))
+	}
+
+	err = db.CreateIndex(ctx, "listings", bson.D{{Key: "search_matched", Value: 1}, {Key: "status", Value: 1}, {Key: "_id", Value: 1}})
+	if err != nil {
+		log.Fatal("Failed to create index for listings", zap.Error(err))
+	}
+
 	schema := bson.M{
 		"bsonType": "object",
 		"required": []string{"title", "description", "image_url", "price", "owner_id", "owner_login"},
@@ -54,11 +116,77 @@ func NewListingRepo(ctx context.Context, db *mongo.MongoDB) *ListingRepo {
 				"maxLength":   500,
 				"description": "must be valid URL",
 			},
+			"images": bson.M{
+				"bsonType": "array",
+				"minItems": 1,
+				"items": bson.M{
+					"bsonType": "object",
+					"required": []string{"url"},
+					"properties": bson.M{
+						"url": bson.M{
+							"bsonType":  "string",
+							"maxLength": 500,
+						},
+						"width":  bson.M{"bsonType": []string{"int", "long"}},
+						"height": bson.M{"bsonType": []string{"int", "long"}},
+						"mime":   bson.M{"bsonType": "string"},
+						"hash":   bson.M{"bsonType": "string"},
+						"alt": bson.M{
+							"bsonType":  "string",
+							"maxLength": 200,
+						},
+					},
+				},
+				"description": "must be non-empty array of images",
+			},
 			"price": bson.M{
-				"bsonType":    "double",
+				"bsonType":    "decimal",
 				"minimum":     0,
 				"maximum":     1000000000,
-				"description": "must be a decimal with up to 2 decimal places",
+				"description": "must be a decimal with up to currency minor units decimal places",
+			},
+			"currency": bson.M{
+				"bsonType":    "string",
+				"pattern":     "^[A-Z]{3}$",
+				"description": "must be ISO 4217 code",
+			},
+			"price_base": bson.M{
+				"bsonType": "decimal",
+			},
+			"category_id": bson.M{
+				"bsonType": "objectId",
+			},
+			"tags": bson.M{
+				"bsonType": "array",
+				"maxItems": 10,
+				"items": bson.M{
+					"bsonType":  "string",
+					"minLength": 1,
+					"maxLength": 32,
+				},
+			},
+			"attributes": bson.M{
+				"bsonType": "object",
+			},
+			"location": bson.M{
+				"bsonType": "object",
+				"required": []string{"type", "coordinates"},
+				"properties": bson.M{
+					"type": bson.M{
+						"enum": []string{models.GeoTypePoint},
+					},
+					"coordinates": bson.M{
+						"bsonType": "array",
+						"minItems": 2,
+						"maxItems": 2,
+						"items":    bson.M{"bsonType": "double"},
+					},
+				},
+				"description": "must be GeoJSON point",
+			},
+			"address": bson.M{
+				"bsonType":  "string",
+				"maxLength": 200,
 			},
 			"owner_id": bson.M{
 				"bsonType": "objectId",
@@ -66,6 +194,64 @@ func NewListingRepo(ctx context.Context, db *mongo.MongoDB) *ListingRepo {
 			"owner_login": bson.M{
 				"bsonType": "string",
 			},
+			"status": bson.M{
+				"enum": []string{models.ListingStatusPublished, models.ListingStatusPendingMedia, models.ListingStatusRejected, models.ListingStatusReserved, models.ListingStatusSold, models.ListingStatusHidden, models.ListingStatusRemoved},
+			},
+			"reject_reason": bson.M{
+				"bsonType": "string",
+			},
+			"media_check": bson.M{
+				"bsonType": "object",
+			},
+			"fingerprint": bson.M{
+				"bsonType": "object",
+			},
+			"duplicate_of": bson.M{
+				"bsonType": "objectId",
+			},
+			"price_dropped_at": bson.M{
+				"bsonType": "date",
+			},
+			"reserved_offer_id": bson.M{
+				"bsonType": "objectId",
+			},
+			"buyer_id": bson.M{
+				"bsonType": "objectId",
+			},
+			"sold_at": bson.M{
+				"bsonType": "date",
+			},
+			"sale_mode": bson.M{
+				"enum": []string{models.SaleModeFixed, models.SaleModeAuction},
+			},
+			"auction": bson.M{
+				"bsonType": "object",
+				"required": []string{"min_increment", "ends_at", "status", "bids_count"},
+				"properties": bson.M{
+					"min_increment": bson.M{"bsonType": "decimal"},
+					"reserve_price": bson.M{"bsonType": "decimal"},
+					"current_bid":   bson.M{"bsonType": "decimal"},
+					"ends_at":       bson.M{"bsonType": "date"},
+					"status": bson.M{
+						"enum": []string{models.AuctionStatusOpen, models.AuctionStatusClosed},
+					},
+					"bids_count": bson.M{
+						"bsonType": []string{"int", "long"},
+						"minimum":  0,
+					},
+				},
+			},
+			"search_matched": bson.M{
+				"bsonType": "bool",
+			},
+			"favorites_count": bson.M{
+				"bsonType": []string{"int", "long"},
+				"minimum":  0,
+			},
+			"views_count": bson.M{
+				"bsonType": []string{"int", "long"},
+				"minimum":  0,
+			},
 		},
 	}
 
@@ -74,6 +260,56 @@ func NewListingRepo(ctx context.Context, db *mongo.MongoDB) *ListingRepo {
 		log.Fatal("Failed to setup validation for listings", zap.Error(err))
 	}
 
+	// Цены раньше хранились в double, переводим их в Decimal128.
+	// Миграция идёт после новой схемы: strict-валидация старой схемы не пропустила бы decimal
+	_, err = db.Collection("listings").UpdateMany(ctx,
+		bson.M{"price": bson.M{"$type": "double"}},
+		mongoDriver.Pipeline{{{Key: "$set", Value: bson.M{
+			"price": bson.M{"$round": bson.A{bson.M{"$toDecimal": "$price"}, 2}},
+		}}}},
+	)
+	if err != nil {
+		log.Fatal("Failed to migrate listings price", zap.Error(err))
+	}
+
+	// Раньше у объявления было одно изображение, image_url остаётся ссылкой на обложку
+	_, err = db.Collection("listings").UpdateMany(ctx,
+		bson.M{"images": bson.M{"$exists": false}, "image_url": bson.M{"$type": "string"}},
+		mongoDriver.Pipeline{{{Key: "$set", Value: bson.M{
+			"images": bson.A{bson.M{"url": "$image_url"}},
+		}}}},
+	)
+	if err != nil {
+		log.Fatal("Failed to migrate listings images", zap.Error(err))
+	}
+
+	// Объявления, созданные до появления статусов, считаются опубликованными
+	_, err = db.Collection("listings").UpdateMany(ctx,
+		bson.M{"status": bson.M{"$exists": false}},
+		bson.M{"$set": bson.M{"status": models.ListingStatusPublished}},
+	)
+	if err != nil {
+		log.Fatal("Failed to migrate listings status", zap.Error(err))
+	}
+
+	// До появления аукционов все объявления продавались по фиксированной цене
+	_, err = db.Collection("listings").UpdateMany(ctx,
+		bson.M{"sale_mode": bson.M{"$exists": false}},
+		bson.M{"$set": bson.M{"sale_mode": models.SaleModeFixed}},
+	)
+	if err != nil {
+		log.Fatal("Failed to migrate listings sale_mode", zap.Error(err))
+	}
+
+	// Старые объявления не рассылаются по сохранённым поискам
+	_, err = db.Collection("listings").UpdateMany(ctx,
+		bson.M{"search_matched": bson.M{"$exists": false}},
+		bson.M{"$set": bson.M{"search_matched": true}},
+	)
+	if err != nil {
+		log.Fatal("Failed to migrate listings search_matched", zap.Error(err))
+	}
+
 	validate := validator.New()
 	validate.RegisterValidation("regexp", func(fl validator.FieldLevel) bool {
 		re := regexp.MustCompile(fl.Param())
@@ -99,8 +335,16 @@ func (lr *ListingRepo) CreateListing(ctx context.Context, listing *models.Listin
 					return nil, errs.ErrListingInvalidDescription
 				case "ImageURL":
 					return nil, errs.ErrListingInvalidImageURL
+				case "Images", "URL", "Alt":
+					return nil, errs.ErrListingInvalidImages
 				case "Price":
 					return nil, errs.ErrListingInvalidPrice
+				case "CategoryID":
+					return nil, errs.ErrListingInvalidCategory
+				case "Tags":
+					return nil, errs.ErrListingInvalidTags
+				case "Address":
+					return nil, errs.ErrListingInvalidAddress
 				}
 			}
 		}
@@ -108,16 +352,47 @@ func (lr *ListingRepo) CreateListing(ctx context.Context, listing *models.Listin
 	}
 
 	listing.CreatedAt = time.Now()
-	listing.Price = math.Round(listing.Price*100) / 100
 
 	doc := bson.M{
 		"title":       listing.Title,
 		"description": listing.Description,
 		"image_url":   listing.ImageURL,
+		"images":      listing.Images,
 		"price":       listing.Price,
+		"currency":    listing.Currency,
+		"price_base":  listing.PriceBase,
+		"category_id": listing.CategoryID,
 		"owner_id":    listing.OwnerID,
 		"owner_login": listing.OwnerLogin,
+		"status":      listing.Status,
+		"sale_mode":   listing.SaleMode,
 		"created_at":  listing.CreatedAt,
+		// Сохранённые поиски проверяются фоновым воркером после публикации
+		"search_matched": false,
+	}
+	if len(listing.Tags) > 0 {
+		doc["tags"] = listing.Tags
+	}
+	if len(listing.Attributes) > 0 {
+		doc["attributes"] = listing.Attributes
+	}
+	if listing.Location != nil {
+		doc["location"] = listing.Location
+	}
+	if listing.Address != "" {
+		doc["address"] = listing.Address
+	}
+	if listing.MediaCheck != nil {
+		doc["media_check"] = listing.MediaCheck
+	}
+	if listing.Fingerprint != nil {
+		doc["fingerprint"] = listing.Fingerprint
+	}
+	if listing.DuplicateOf != nil {
+		doc["duplicate_of"] = listing.DuplicateOf
+	}
+	if listing.Auction != nil {
+		doc["auction"] = listing.Auction
 	}
 
 	res, err := lr.collection.InsertOne(ctx, doc)
@@ -130,33 +405,287 @@ func (lr *ListingRepo) CreateListing(ctx context.Context, listing *models.Listin
 	return listing, nil
 }
 
-func (lr *ListingRepo) GetListings(
-	ctx context.Context,
-	page, limit int,
-	sortBy, order string,
-	minPrice, maxPrice float64,
-	currentUserID primitive.ObjectID,
-) ([]*models.Listing, error) {
-	skip := (page - 1) * limit
+func (lr *ListingRepo) GetListingByID(ctx context.Context, id primitive.ObjectID) (*models.Listing, error) {
+	var listing models.Listing
+	err := lr.collection.FindOne(ctx, bson.M{"_id": id}).Decode(&listing)
+	if err != nil {
+		if errors.Is(err, mongoDriver.ErrNoDocuments) {
+			return nil, errs.ErrListingNotFound
+		}
+		return nil, err
+	}
+	return &listing, nil
+}
 
-	sortOrder := 1
-	if order == "desc" {
-		sortOrder = -1
+// UpdateImages replaces listing images and points image_url to the new cover
+func (lr *ListingRepo) UpdateImages(ctx context.Context, id primitive.ObjectID, images []models.ListingImage) (*models.Listing, error) {
+	var listing models.Listing
+	err := lr.collection.FindOneAndUpdate(ctx,
+		bson.M{"_id": id},
+		bson.M{"$set": bson.M{"images": images, "image_url": images[0].URL}},
+		options.FindOneAndUpdate().SetReturnDocument(options.After),
+	).Decode(&listing)
+	if err != nil {
+		if errors.Is(err, mongoDriver.ErrNoDocuments) {
+			return nil, errs.ErrListingNotFound
+		}
+		return nil, err
+	}
+	return &listing, nil
+}
+
+// MarkSold moves reserved listing with a known buyer to sold
+func (lr *ListingRepo) MarkSold(ctx context.Context, id primitive.ObjectID, at time.Time) (*models.Listing, error) {
+	var listing models.Listing
+	err := lr.collection.FindOneAndUpdate(ctx,
+		bson.M{"_id": id, "status": models.ListingStatusReserved, "buyer_id": bson.M{"$exists": true}},
+		bson.M{"$set": bson.M{"status": models.ListingStatusSold, "sold_at": at}},
+		options.FindOneAndUpdate().SetReturnDocument(options.After),
+	).Decode(&listing)
+	if err != nil {
+		if errors.Is(err, mongoDriver.ErrNoDocuments) {
+			return nil, errs.ErrListingNotReserved
+		}
+		return nil, err
 	}
+	return &listing, nil
+}
+
+// UpdatePrice sets price of the change and records the change to the price history in one transaction.
+//
+// The price is set only if the listing still has the price of old, otherwise the change was computed
+// from an outdated state and ErrListingPriceConflict is returned. droppedAt is stored as price_dropped_at, nil removes it.
+func (lr *ListingRepo) UpdatePrice(ctx context.Context, old *models.Listing, change *models.PriceChange, droppedAt *time.Time) error {
+	set := bson.M{"price": change.Price, "currency": change.Currency, "price_base": change.PriceBase}
+	update := bson.M{"$set": set}
+	if droppedAt != nil {
+		set["price_dropped_at"] = *droppedAt
+	} else {
+		update["$unset"] = bson.M{"price_dropped_at": ""}
+	}
+
+	change.ID = primitive.NewObjectID()
+	return lr.WithTransaction(ctx, func(ctx context.Context) error {
+		res, err := lr.collection.UpdateOne(ctx,
+			bson.M{"_id": old.ID, "price": old.Price, "currency": old.Currency, "price_base": old.PriceBase},
+			update,
+		)
+		if err != nil {
+			return err
+		}
+		if res.MatchedCount == 0 {
+			return errs.ErrListingPriceConflict
+		}
+		_, err = lr.Collection("price_history").InsertOne(ctx, change)
+		return err
+	})
+}
 
-	filter := bson.M{
-		"price": bson.M{
-			"$gte": minPrice,
-			"$lte": maxPrice,
+// GetPendingMediaIDs returns ids of listings waiting for images validation
+func (lr *ListingRepo) GetPendingMediaIDs(ctx context.Context) ([]primitive.ObjectID, error) {
+	cursor, err := lr.collection.Find(ctx,
+		bson.M{"status": models.ListingStatusPendingMedia},
+		options.Find().SetProjection(bson.M{"_id": 1}),
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var ids []primitive.ObjectID
+	for cursor.Next(ctx) {
+		var row struct {
+			ID primitive.ObjectID `bson:"_id"`
+		}
+		if err := cursor.Decode(&row); err != nil {
+			continue
+		}
+		ids = append(ids, row.ID)
+	}
+	return ids, nil
+}
+
+func (lr *ListingRepo) SetMediaCheck(ctx context.Context, id primitive.ObjectID, check *models.MediaCheck) error {
+	_, err := lr.collection.UpdateOne(ctx, bson.M{"_id": id}, bson.M{"$set": bson.M{"media_check": check}})
+	return err
+}
+
+// SetMediaResult sets result of images validation.
+//
+// Detected mime and dimensions are written to images by URL, so owner can reorder images meanwhile.
+// Only pending listings are updated.
+func (lr *ListingRepo) SetMediaResult(ctx context.Context, id primitive.ObjectID, result *models.MediaResult) error {
+	set := bson.M{"status": result.Status, "media_check": result.Check}
+	if result.RejectReason != "" {
+		set["reject_reason"] = result.RejectReason
+	}
+	if result.Fingerprint != nil {
+		set["fingerprint"] = result.Fingerprint
+	}
+	if result.DuplicateOf != nil {
+		set["duplicate_of"] = result.DuplicateOf
+	}
+	var filters []any
+	for i, img := range result.Images {
+		name := "img" + strconv.Itoa(i)
+		set["images.$["+name+"].mime"] = img.Mime
+		set["images.$["+name+"].width"] = img.Width
+		set["images.$["+name+"].height"] = img.Height
+		set["images.$["+name+"].hash"] = img.Hash
+		set["images.$["+name+"].cached_url"] = img.CachedURL
+		set["images.$["+name+"].phash"] = img.PHash
+		filters = append(filters, bson.M{name + ".url": img.URL})
+	}
+
+	opts := options.Update()
+	if len(filters) > 0 {
+		opts.SetArrayFilters(options.ArrayFilters{Filters: filters})
+	}
+	_, err := lr.collection.UpdateOne(ctx,
+		bson.M{"_id": id, "status": models.ListingStatusPendingMedia},
+		bson.M{"$set": set},
+		opts,
+	)
+	return err
+}
+
+// FindDuplicateCandidates returns latest published and pending listings created since the time
+// which share a fingerprint band with one of bands
+func (lr *ListingRepo) FindDuplicateCandidates(ctx context.Context, exclude primitive.ObjectID, bands []string, since time.Time, limit int) ([]*models.Listing, error) {
+	cursor, err := lr.collection.Find(ctx,
+		bson.M{
+			"_id":               bson.M{"$ne": exclude},
+			"fingerprint.bands": bson.M{"$in": bands},
+			"created_at":        bson.M{"$gte": since},
+			"status":            bson.M{"$in": bson.A{models.ListingStatusPublished, models.ListingStatusPendingMedia}},
 		},
+		options.Find().SetSort(bson.D{{Key: "created_at", Value: -1}}).SetLimit(int64(limit)),
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var listings []*models.Listing
+	for cursor.Next(ctx) {
+		var l models.Listing
+		if err := cursor.Decode(&l); err != nil {
+			continue
+		}
+		listings = append(listings, &l)
+	}
+	return listings, nil
+}
+
+// GetUnmatchedListings returns published listings not yet checked against saved searches, oldest first
+func (lr *ListingRepo) GetUnmatchedListings(ctx context.Context, limit int) ([]*models.Listing, error) {
+	cursor, err := lr.collection.Find(ctx,
+		bson.M{"search_matched": false, "status": models.ListingStatusPublished},
+		options.Find().SetSort(bson.D{{Key: "_id", Value: 1}}).SetLimit(int64(limit)),
+	)
+	if err != nil {
+		return nil, err
 	}
+	defer cursor.Close(ctx)
 
-	opts := options.Find().
-		SetSort(bson.D{{Key: sortBy, Value: sortOrder}}).
-		SetSkip(int64(skip)).
-		SetLimit(int64(limit))
+	var listings []*models.Listing
+	for cursor.Next(ctx) {
+		var l models.Listing
+		if err := cursor.Decode(&l); err != nil {
+			continue
+		}
+		listings = append(listings, &l)
+	}
+	return listings, nil
+}
 
-	cursor, err := lr.collection.Find(ctx, filter, opts)
+func (lr *ListingRepo) SetSearchMatched(ctx context.Context, id primitive.ObjectID) error {
+	_, err := lr.collection.UpdateOne(ctx, bson.M{"_id": id}, bson.M{"$set": bson.M{"search_matched": true}})
+	return err
+}
+
+// IsImageReferenced reports whether any listing uses cached image with the hash
+func (lr *ListingRepo) IsImageReferenced(ctx context.Context, hash string) (bool, error) {
+	count, err := lr.collection.CountDocuments(ctx, bson.M{"images.hash": hash}, options.Count().SetLimit(1))
+	if err != nil {
+		return false, err
+	}
+	return count > 0, nil
+}
+
+// SetImageVariants sets variants and perceptual hash of the uploaded image in all listings using it.
+//
+// Bands of the hash are added to fingerprints of listings which were already checked for duplicates.
+func (lr *ListingRepo) SetImageVariants(ctx context.Context, uploadID primitive.ObjectID, variants map[string]models.ImageVariant, phash int64, bands []string) error {
+	set := bson.M{"images.$[img].variants": variants}
+	if phash != 0 {
+		set["images.$[img].phash"] = phash
+	}
+	_, err := lr.collection.UpdateMany(ctx,
+		bson.M{"images.upload_id": uploadID},
+		bson.M{"$set": set},
+		options.Update().SetArrayFilters(options.ArrayFilters{
+			Filters: []any{bson.M{"img.upload_id": uploadID}},
+		}),
+	)
+	if err != nil || len(bands) == 0 {
+		return err
+	}
+	_, err = lr.collection.UpdateMany(ctx,
+		bson.M{"images.upload_id": uploadID, "fingerprint": bson.M{"$exists": true}},
+		bson.M{"$addToSet": bson.M{"fingerprint.bands": bson.M{"$each": bands}}},
+	)
+	return err
+}
+
+// GetListings fetches one listing more than filter.Limit, so caller can tell
+// whether there is a next page. With backward cursor listings are returned
+// in reversed sort order, starting from the cursor.
+func (lr *ListingRepo) GetListings(ctx context.Context, f *models.ListingFilter) ([]*models.Listing, error) {
+	sortOrder := 1
+	if f.Order == "desc" {
+		sortOrder = -1
+	}
+	// Объявления в разных валютах сравниваются по цене в базовой валюте
+	sortField := f.SortBy
+	if sortField == "price" {
+		sortField = "price_base"
+	}
+
+	filter := listingsFilter(f)
+
+	var keyset bson.M
+	if f.Cursor != nil {
+		if f.Cursor.Backward {
+			sortOrder = -sortOrder
+		}
+		cmp := "$gt"
+		if sortOrder == -1 {
+			cmp = "$lt"
+		}
+		// (sort_key, _id) строго после курсора в порядке сортировки
+		keyset = bson.M{"$or": bson.A{
+			bson.M{sortField: bson.M{cmp: f.Cursor.Value}},
+			bson.M{sortField: f.Cursor.Value, "_id": bson.M{cmp: f.Cursor.ID}},
+		}}
+	}
+	// _id делает порядок однозначным при одинаковых значениях ключа сортировки
+	sort := bson.D{{Key: sortField, Value: sortOrder}, {Key: "_id", Value: sortOrder}}
+
+	var cursor *mongoDriver.Cursor
+	var err error
+	if f.Near != nil {
+		cursor, err = lr.findNear(ctx, f, filter, keyset, sort)
+	} else {
+		opts := options.Find().SetLimit(int64(f.Limit + 1)).SetSort(sort)
+		if keyset != nil {
+			and, _ := filter["$and"].(bson.A)
+			filter["$and"] = append(and, keyset)
+		} else {
+			opts.SetSkip(int64((f.Page - 1) * f.Limit))
+		}
+		cursor, err = lr.collection.Find(ctx, filter, opts)
+	}
 	if err != nil {
 		return nil, err
 	}
@@ -169,13 +698,292 @@ func (lr *ListingRepo) GetListings(
 			continue
 		}
 
-		if currentUserID != primitive.NilObjectID {
-			val := l.OwnerID == currentUserID
+		if f.CurrentUserID != primitive.NilObjectID {
+			val := l.OwnerID == f.CurrentUserID
 			l.IsMyListing = &val
 		}
 
 		listings = append(listings, &l)
 	}
 
+	if f.CurrentUserID != primitive.NilObjectID {
+		if err := lr.MarkFavorites(ctx, listings, f.CurrentUserID); err != nil {
+			return nil, err
+		}
+	}
+
+	return listings, nil
+}
+
+// MarkFavorites sets IsFavorite of listings for the user
+func (lr *ListingRepo) MarkFavorites(ctx context.Context, listings []*models.Listing, userID primitive.ObjectID) error {
+	if len(listings) == 0 {
+		return nil
+	}
+	ids := make([]primitive.ObjectID, 0, len(listings))
+	for _, l := range listings {
+		ids = append(ids, l.ID)
+	}
+
+	cursor, err := lr.Collection("favorites").Find(ctx,
+		bson.M{"user_id": userID, "listing_id": bson.M{"$in": ids}},
+		options.Find().SetProjection(bson.M{"listing_id": 1}),
+	)
+	if err != nil {
+		return err
+	}
+	defer cursor.Close(ctx)
+
+	favorites := make(map[primitive.ObjectID]bool, len(listings))
+	for cursor.Next(ctx) {
+		var f models.Favorite
+		if err := cursor.Decode(&f); err != nil {
+			continue
+		}
+		favorites[f.ListingID] = true
+	}
+	for _, l := range listings {
+		val := favorites[l.ID]
+		l.IsFavorite = &val
+	}
+	return nil
+}
+
+// GetListingsByIDs returns found listings in order of ids
+func (lr *ListingRepo) GetListingsByIDs(ctx context.Context, ids []primitive.ObjectID) ([]*models.Listing, error) {
+	if len(ids) == 0 {
+		return []*models.Listing{}, nil
+	}
+	cursor, err := lr.collection.Find(ctx, bson.M{"_id": bson.M{"$in": ids}})
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	byID := make(map[primitive.ObjectID]*models.Listing, len(ids))
+	for cursor.Next(ctx) {
+		var l models.Listing
+		if err := cursor.Decode(&l); err != nil {
+			continue
+		}
+		byID[l.ID] = &l
+	}
+
+	listings := make([]*models.Listing, 0, len(ids))
+	for _, id := range ids {
+		if l, ok := byID[id]; ok {
+			listings = append(listings, l)
+		}
+	}
 	return listings, nil
 }
+
+// findNear finds listings with distance to f.Near in km.
+//
+// $geoNear must be the first stage, so keyset condition on distance is applied after it.
+func (lr *ListingRepo) findNear(ctx context.Context, f *models.ListingFilter, filter, keyset bson.M, sort bson.D) (*mongoDriver.Cursor, error) {
+	geoNear := bson.M{
+		"near":               f.Near,
+		"key":                "location",
+		"distanceField":      "distance",
+		"distanceMultiplier": 0.001,
+		"spherical":          true,
+		"query":              filter,
+	}
+	if f.RadiusKm > 0 {
+		geoNear["maxDistance"] = f.RadiusKm * 1000
+	}
+
+	pipeline := mongoDriver.Pipeline{
+		{{Key: "$geoNear", Value: geoNear}},
+		// Точность до метра, иначе курсор зависит от погрешности вычислений
+		{{Key: "$set", Value: bson.M{"distance": bson.M{"$round": bson.A{"$distance", 3}}}}},
+	}
+	if keyset != nil {
+		pipeline = append(pipeline, bson.D{{Key: "$match", Value: keyset}})
+	}
+	pipeline = append(pipeline, bson.D{{Key: "$sort", Value: sort}})
+	if keyset == nil && f.Page > 1 {
+		pipeline = append(pipeline, bson.D{{Key: "$skip", Value: int64((f.Page - 1) * f.Limit)}})
+	}
+	pipeline = append(pipeline, bson.D{{Key: "$limit", Value: int64(f.Limit + 1)}})
+
+	return lr.collection.Aggregate(ctx, pipeline)
+}
+
+// listingsFilter builds Mongo filter from listing filter without pagination conditions
+func listingsFilter(f *models.ListingFilter) bson.M {
+	filter := bson.M{"status": models.ListingStatusPublished}
+
+	// Радиус дублируется через $centerSphere, чтобы по фильтру можно было посчитать total
+	var geo bson.A
+	if f.Near != nil && f.RadiusKm > 0 {
+		geo = append(geo, bson.M{"location": bson.M{"$geoWithin": bson.M{
+			"$centerSphere": bson.A{f.Near.Coordinates, f.RadiusKm / earthRadiusKm},
+		}}})
+	}
+	if len(f.BBox) == 4 {
+		minLon, minLat, maxLon, maxLat := f.BBox[0], f.BBox[1], f.BBox[2], f.BBox[3]
+		geo = append(geo, bson.M{"location": bson.M{"$geoWithin": bson.M{
+			"$geometry": bson.M{
+				"type": "Polygon",
+				"coordinates": bson.A{bson.A{
+					bson.A{minLon, minLat}, bson.A{maxLon, minLat}, bson.A{maxLon, maxLat},
+					bson.A{minLon, maxLat}, bson.A{minLon, minLat},
+				}},
+			},
+		}}})
+	}
+	if len(geo) > 0 {
+		filter["$and"] = geo
+	}
+
+	// Границы цены приходят уже в базовой валюте
+	if f.MinPrice != nil || f.MaxPrice != nil {
+		price := bson.M{}
+		if f.MinPrice != nil {
+			price["$gte"] = *f.MinPrice
+		}
+		if f.MaxPrice != nil {
+			price["$lte"] = *f.MaxPrice
+		}
+		filter["price_base"] = price
+	}
+	if f.PriceDroppedSince != nil {
+		filter["price_dropped_at"] = bson.M{"$gte": *f.PriceDroppedSince}
+	}
+	if f.OwnerID != primitive.NilObjectID {
+		filter["owner_id"] = f.OwnerID
+	}
+	if f.OwnerLogin != "" {
+		filter["owner_login"] = f.OwnerLogin
+	}
+	if f.SaleMode != "" {
+		filter["sale_mode"] = f.SaleMode
+	}
+	if len(f.CategoryIDs) > 0 {
+		filter["category_id"] = bson.M{"$in": f.CategoryIDs}
+	}
+	if len(f.Tags) > 0 {
+		if f.TagsMode == models.TagsModeAll {
+			filter["tags"] = bson.M{"$all": f.Tags}
+		} else {
+			filter["tags"] = bson.M{"$in": f.Tags}
+		}
+	}
+	for _, af := range f.Attributes {
+		key := "attributes." + af.Name
+		cond, ok := filter[key].(bson.M)
+		if !ok {
+			cond = bson.M{}
+			filter[key] = cond
+		}
+		if af.Op == models.AttributeOpEq {
+			cond["$in"] = af.Parsed
+		} else {
+			cond["$"+af.Op] = af.Parsed[0]
+		}
+	}
+
+	return filter
+}
+
+// CountListings counts listings matching the filter.
+//
+// Counts are capped at maxExactCount, then estimated is true.
+func (lr *ListingRepo) CountListings(ctx context.Context, f *models.ListingFilter) (total int64, estimated bool, err error) {
+	const maxExactCount = 100_000
+
+	// Метаданные коллекции не подходят: в ней есть объявления во всех статусах
+	filter := listingsFilter(f)
+	total, err = lr.collection.CountDocuments(ctx, filter, options.Count().SetLimit(maxExactCount))
+	if err != nil {
+		return 0, false, err
+	}
+	return total, total == maxExactCount, nil
+}
+
+// SetMissingCurrency assigns currency to listings created before currencies were introduced
+func (lr *ListingRepo) SetMissingCurrency(ctx context.Context, currency string) error {
+	_, err := lr.collection.UpdateMany(ctx,
+		bson.M{"currency": bson.M{"$exists": false}},
+		bson.M{"$set": bson.M{"currency": currency}},
+	)
+	return err
+}
+
+// RecalculateBasePrices rewrites price_base of listings in each currency using rates to the base currency
+func (lr *ListingRepo) RecalculateBasePrices(ctx context.Context, rates map[string]money.Decimal) error {
+	for currency, rate := range rates {
+		_, err := lr.collection.UpdateMany(ctx,
+			bson.M{"currency": currency},
+			mongoDriver.Pipeline{{{Key: "$set", Value: bson.M{
+				"price_base": bson.M{"$multiply": bson.A{"$price", rate}},
+			}}}},
+		)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// CountByOwnerStatus returns number of owner's listings in each of the statuses
+func (lr *ListingRepo) CountByOwnerStatus(ctx context.Context, ownerID primitive.ObjectID, statuses []string) (map[string]int64, error) {
+	pipeline := mongoDriver.Pipeline{
+		{{Key: "$match", Value: bson.M{"owner_id": ownerID, "status": bson.M{"$in": statuses}}}},
+		{{Key: "$group", Value: bson.M{"_id": "$status", "count": bson.M{"$sum": 1}}}},
+	}
+
+	cursor, err := lr.collection.Aggregate(ctx, pipeline)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	counts := map[string]int64{}
+	for cursor.Next(ctx) {
+		var row struct {
+			Status string `bson:"_id"`
+			Count  int64  `bson:"count"`
+		}
+		if err := cursor.Decode(&row); err != nil {
+			continue
+		}
+		counts[row.Status] = row.Count
+	}
+
+	return counts, nil
+}
+
+// CountByCategory returns number of published listings placed directly into each category
+func (lr *ListingRepo) CountByCategory(ctx context.Context) (map[primitive.ObjectID]int64, error) {
+	pipeline := mongoDriver.Pipeline{
+		{{Key: "$match", Value: bson.M{"category_id": bson.M{"$exists": true}, "status": models.ListingStatusPublished}}},
+		{{Key: "$group", Value: bson.M{"_id": "$category_id", "count": bson.M{"$sum": 1}}}},
+	}
+
+	cursor, err := lr.collection.Aggregate(ctx, pipeline)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	counts := map[primitive.ObjectID]int64{}
+	for cursor.Next(ctx) {
+		var row struct {
+			ID    primitive.ObjectID `bson:"_id"`
+			Count int64              `bson:"count"`
+		}
+		if err := cursor.Decode(&row); err != nil {
+			continue
+		}
+		counts[row.ID] = row.Count
+	}
+
+	return counts, nil
+}
+
+func (lr *ListingRepo) CountInCategory(ctx context.Context, categoryID primitive.ObjectID) (int64, error) {
+	return lr.collection.CountDocuments(ctx, bson.M{"category_id": categoryID})
+}
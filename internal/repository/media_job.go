@@ -0,0 +1,105 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+	"vk-inter/internal/models"
+	"vk-inter/pkg/db/mongo"
+	"vk-inter/pkg/logger"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	mongoDriver "go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"go.uber.org/zap"
+)
+
+type MediaJobRepo struct {
+	*mongo.MongoDB
+	collection mongoDriver.Collection
+}
+
+func NewMediaJobRepo(ctx context.Context, db *mongo.MongoDB) *MediaJobRepo {
+	log := logger.FromContext(ctx)
+
+	err := db.CreateIndex(ctx, "media_jobs", bson.D{{Key: "status", Value: 1}, {Key: "run_after", Value: 1}})
+	if err != nil {
+		log.Fatal("Failed to create index for media jobs", zap.Error(err))
+	}
+
+	return &MediaJobRepo{
+		MongoDB:    db,
+		collection: *db.Collection("media_jobs"),
+	}
+}
+
+// EnqueueJob creates queued job for the listing, existing job is left as is
+func (mr *MediaJobRepo) EnqueueJob(ctx context.Context, listingID primitive.ObjectID) error {
+	now := time.Now()
+	_, err := mr.collection.UpdateOne(ctx,
+		bson.M{"_id": listingID},
+		bson.M{"$setOnInsert": bson.M{
+			"status":     models.MediaJobQueued,
+			"attempts":   0,
+			"run_after":  now,
+			"created_at": now,
+			"updated_at": now,
+		}},
+		options.Update().SetUpsert(true),
+	)
+	return err
+}
+
+// ClaimJob locks the next due job for lease duration and increments its attempts.
+//
+// Returns nil job if there is nothing to do.
+func (mr *MediaJobRepo) ClaimJob(ctx context.Context, lease time.Duration) (*models.MediaJob, error) {
+	now := time.Now()
+	filter := bson.M{"$or": bson.A{
+		bson.M{"status": models.MediaJobQueued, "run_after": bson.M{"$lte": now}},
+		// Воркер упал, не завершив задачу
+		bson.M{"status": models.MediaJobRunning, "locked_until": bson.M{"$lt": now}},
+	}}
+	update := bson.M{
+		"$set": bson.M{"status": models.MediaJobRunning, "locked_until": now.Add(lease), "updated_at": now},
+		"$inc": bson.M{"attempts": 1},
+	}
+	opts := options.FindOneAndUpdate().
+		SetSort(bson.D{{Key: "run_after", Value: 1}}).
+		SetReturnDocument(options.After)
+
+	var job models.MediaJob
+	err := mr.collection.FindOneAndUpdate(ctx, filter, update, opts).Decode(&job)
+	if errors.Is(err, mongoDriver.ErrNoDocuments) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &job, nil
+}
+
+// FinishJob marks job done or failed
+func (mr *MediaJobRepo) FinishJob(ctx context.Context, id primitive.ObjectID, status, errMsg string) error {
+	_, err := mr.collection.UpdateOne(ctx,
+		bson.M{"_id": id},
+		bson.M{
+			"$set":   bson.M{"status": status, "error": errMsg, "updated_at": time.Now()},
+			"$unset": bson.M{"locked_until": ""},
+		},
+	)
+	return err
+}
+
+// RetryJob returns job to the queue to run after runAfter
+func (mr *MediaJobRepo) RetryJob(ctx context.Context, id primitive.ObjectID, runAfter time.Time, errMsg string) error {
+	_, err := mr.collection.UpdateOne(ctx,
+		bson.M{"_id": id},
+		bson.M{
+			"$set":   bson.M{"status": models.MediaJobQueued, "run_after": runAfter, "error": errMsg, "updated_at": time.Now()},
+			"$unset": bson.M{"locked_until": ""},
+		},
+	)
+	return err
+}
@@ -0,0 +1,74 @@
+package repository
+
+import (
+	"context"
+	"time"
+	"vk-inter/internal/models"
+	"vk-inter/pkg/db/mongo"
+	"vk-inter/pkg/logger"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	mongoDriver "go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"go.uber.org/zap"
+)
+
+type MessageRepo struct {
+	*mongo.MongoDB
+	collection mongoDriver.Collection
+}
+
+func NewMessageRepo(ctx context.Context, db *mongo.MongoDB) *MessageRepo {
+	log := logger.FromContext(ctx)
+
+	err := db.CreateIndex(ctx, "messages", bson.D{{Key: "thread_id", Value: 1}, {Key: "created_at", Value: -1}, {Key: "_id", Value: -1}})
+	if err != nil {
+		log.Fatal("Failed to create index for messages", zap.Error(err))
+	}
+
+	return &MessageRepo{
+		MongoDB:    db,
+		collection: *db.Collection("messages"),
+	}
+}
+
+func (mr *MessageRepo) CreateMessage(ctx context.Context, message *models.Message) (*models.Message, error) {
+	message.CreatedAt = time.Now()
+	res, err := mr.collection.InsertOne(ctx, message)
+	if err != nil {
+		return nil, err
+	}
+	message.ID = res.InsertedID.(primitive.ObjectID)
+	return message, nil
+}
+
+// GetMessages returns messages of the thread, newest first, starting after the cursor
+func (mr *MessageRepo) GetMessages(ctx context.Context, threadID primitive.ObjectID, after *models.TimeCursor, limit int) ([]*models.Message, error) {
+	filter := bson.M{"thread_id": threadID}
+	if after != nil {
+		filter["$or"] = bson.A{
+			bson.M{"created_at": bson.M{"$lt": after.Time}},
+			bson.M{"created_at": after.Time, "_id": bson.M{"$lt": after.ID}},
+		}
+	}
+	cursor, err := mr.collection.Find(ctx, filter,
+		options.Find().
+			SetSort(bson.D{{Key: "created_at", Value: -1}, {Key: "_id", Value: -1}}).
+			SetLimit(int64(limit)),
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	messages := []*models.Message{}
+	for cursor.Next(ctx) {
+		var m models.Message
+		if err := cursor.Decode(&m); err != nil {
+			continue
+		}
+		messages = append(messages, &m)
+	}
+	return messages, nil
+}
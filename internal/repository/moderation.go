@@ -0,0 +1,396 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"time"
+	"vk-inter/internal/models"
+	"vk-inter/pkg/db/mongo"
+	"vk-inter/pkg/errs"
+	"vk-inter/pkg/logger"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	mongoDriver "go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"go.uber.org/zap"
+)
+
+// Статусы объявлений, которые модератор может скрыть
+var hideableStatuses = bson.A{
+	models.ListingStatusPublished,
+	models.ListingStatusPendingMedia,
+	models.ListingStatusReserved,
+}
+
+type ModerationRepo struct {
+	*mongo.MongoDB
+	reports  mongoDriver.Collection
+	cases    mongoDriver.Collection
+	audit    mongoDriver.Collection
+	listings mongoDriver.Collection
+	users    mongoDriver.Collection
+}
+
+func NewModerationRepo(ctx context.Context, db *mongo.MongoDB) *ModerationRepo {
+	log := logger.FromContext(ctx)
+
+	// Пользователь жалуется на объявление один раз
+	_, err := db.Collection("reports").Indexes().CreateOne(ctx, mongoDriver.IndexModel{
+		Keys:    bson.D{{Key: "listing_id", Value: 1}, {Key: "reporter_id", Value: 1}},
+		Options: options.Index().SetUnique(true),
+	})
+	if err != nil {
+		log.Fatal("Failed to create index for reports", zap.Error(err))
+	}
+	if err := db.CreateIndex(ctx, "reports", bson.D{{Key: "case_id", Value: 1}, {Key: "created_at", Value: 1}}); err != nil {
+		log.Fatal("Failed to create index for reports", zap.Error(err))
+	}
+
+	// У объявления не больше одного активного дела
+	_, err = db.Collection("moderation_cases").Indexes().CreateOne(ctx, mongoDriver.IndexModel{
+		Keys:    bson.D{{Key: "listing_id", Value: 1}},
+		Options: options.Index().SetUnique(true).SetPartialFilterExpression(bson.M{"active": true}),
+	})
+	if err != nil {
+		log.Fatal("Failed to create index for moderation cases", zap.Error(err))
+	}
+	err = db.CreateIndex(ctx, "moderation_cases", bson.D{{Key: "status", Value: 1}, {Key: "reports_count", Value: -1}, {Key: "created_at", Value: 1}})
+	if err != nil {
+		log.Fatal("Failed to create index for moderation cases", zap.Error(err))
+	}
+	if err := db.CreateIndex(ctx, "moderation_audit", bson.D{{Key: "case_id", Value: 1}, {Key: "created_at", Value: 1}}); err != nil {
+		log.Fatal("Failed to create index for moderation audit", zap.Error(err))
+	}
+
+	return &ModerationRepo{
+		MongoDB:  db,
+		reports:  *db.Collection("reports"),
+		cases:    *db.Collection("moderation_cases"),
+		audit:    *db.Collection("moderation_audit"),
+		listings: *db.Collection("listings"),
+		users:    *db.Collection("users"),
+	}
+}
+
+// CreateReport adds the report to the active case of the listing in one transaction.
+//
+// When the case collects autoHideAt reports, the published listing is hidden until the case is resolved.
+func (mr *ModerationRepo) CreateReport(ctx context.Context, report *models.Report, listing *models.Listing, autoHideAt int64) (*models.ModerationCase, error) {
+	var c models.ModerationCase
+	create := func(ctx context.Context) error {
+		now := report.CreatedAt
+		err := mr.cases.FindOneAndUpdate(ctx,
+			bson.M{"listing_id": listing.ID, "active": true},
+			bson.M{
+				"$inc": bson.M{"reports_count": 1, "reasons." + report.Reason: 1},
+				"$set": bson.M{"updated_at": now},
+				"$setOnInsert": bson.M{
+					"listing_title": listing.Title,
+					"owner_id":      listing.OwnerID,
+					"status":        models.CaseStatusOpen,
+					"created_at":    now,
+				},
+			},
+			options.FindOneAndUpdate().SetUpsert(true).SetReturnDocument(options.After),
+		).Decode(&c)
+		if err != nil {
+			return err
+		}
+
+		report.ID = primitive.NewObjectID()
+		report.CaseID = c.ID
+		if _, err := mr.reports.InsertOne(ctx, report); err != nil {
+			if mr.IsDuplicateKeyError(err) {
+				return errs.ErrReportExists
+			}
+			return err
+		}
+
+		if c.AutoHidden || c.ReportsCount < autoHideAt {
+			return nil
+		}
+		res, err := mr.listings.UpdateOne(ctx,
+			bson.M{"_id": listing.ID, "status": models.ListingStatusPublished},
+			bson.M{"$set": bson.M{"status": models.ListingStatusHidden}},
+		)
+		if err != nil || res.ModifiedCount == 0 {
+			return err
+		}
+		c.AutoHidden = true
+		c.RestoreStatus = models.ListingStatusPublished
+		_, err = mr.cases.UpdateOne(ctx, bson.M{"_id": c.ID},
+			bson.M{"$set": bson.M{"auto_hidden": true, "restore_status": c.RestoreStatus}},
+		)
+		if err != nil {
+			return err
+		}
+		return mr.addAudit(ctx, &c, nil, models.AuditActionAutoHidden, "", now)
+	}
+
+	err := mr.WithTransaction(ctx, create)
+	if mr.IsDuplicateKeyError(err) {
+		// Параллельная жалоба уже открыла дело, добавляем жалобу в него
+		err = mr.WithTransaction(ctx, create)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &c, nil
+}
+
+// FlagListing adds names of content filter rules matched by the listing to its active case
+func (mr *ModerationRepo) FlagListing(ctx context.Context, listing *models.Listing, rules []string, now time.Time) (*models.ModerationCase, error) {
+	var c models.ModerationCase
+	flag := func(ctx context.Context) error {
+		err := mr.cases.FindOneAndUpdate(ctx,
+			bson.M{"listing_id": listing.ID, "active": true},
+			bson.M{
+				"$addToSet": bson.M{"flags": bson.M{"$each": rules}},
+				"$set":      bson.M{"updated_at": now},
+				"$setOnInsert": bson.M{
+					"listing_title": listing.Title,
+					"owner_id":      listing.OwnerID,
+					"status":        models.CaseStatusOpen,
+					"reports_count": 0,
+					"created_at":    now,
+				},
+			},
+			options.FindOneAndUpdate().SetUpsert(true).SetReturnDocument(options.After),
+		).Decode(&c)
+		if err != nil {
+			return err
+		}
+		return mr.addAudit(ctx, &c, nil, models.AuditActionFlagged, strings.Join(rules, ", "), now)
+	}
+
+	err := mr.WithTransaction(ctx, flag)
+	if mr.IsDuplicateKeyError(err) {
+		err = mr.WithTransaction(ctx, flag)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &c, nil
+}
+
+// GetCases returns cases in the status, the most reported first, and their total number
+func (mr *ModerationRepo) GetCases(ctx context.Context, status string, skip, limit int) ([]*models.ModerationCase, int64, error) {
+	filter := bson.M{"status": status}
+	total, err := mr.cases.CountDocuments(ctx, filter)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	cursor, err := mr.cases.Find(ctx, filter,
+		options.Find().
+			SetSort(bson.D{{Key: "reports_count", Value: -1}, {Key: "created_at", Value: 1}, {Key: "_id", Value: 1}}).
+			SetSkip(int64(skip)).
+			SetLimit(int64(limit)),
+	)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer cursor.Close(ctx)
+
+	cases := []*models.ModerationCase{}
+	if err := cursor.All(ctx, &cases); err != nil {
+		return nil, 0, err
+	}
+	return cases, total, nil
+}
+
+func (mr *ModerationRepo) GetCase(ctx context.Context, id primitive.ObjectID) (*models.ModerationCase, error) {
+	var c models.ModerationCase
+	err := mr.cases.FindOne(ctx, bson.M{"_id": id}).Decode(&c)
+	if err != nil {
+		if errors.Is(err, mongoDriver.ErrNoDocuments) {
+			return nil, errs.ErrCaseNotFound
+		}
+		return nil, err
+	}
+	return &c, nil
+}
+
+// GetCaseReports returns reports of the case, the earliest first
+func (mr *ModerationRepo) GetCaseReports(ctx context.Context, caseID primitive.ObjectID) ([]*models.Report, error) {
+	cursor, err := mr.reports.Find(ctx, bson.M{"case_id": caseID},
+		options.Find().SetSort(bson.D{{Key: "created_at", Value: 1}}),
+	)
+	if err != nil {
+		return nil, err
+	}
+	reports := []*models.Report{}
+	if err := cursor.All(ctx, &reports); err != nil {
+		return nil, err
+	}
+	return reports, nil
+}
+
+// GetAudit returns audit trail of the case, the earliest first
+func (mr *ModerationRepo) GetAudit(ctx context.Context, caseID primitive.ObjectID) ([]*models.AuditEntry, error) {
+	cursor, err := mr.audit.Find(ctx, bson.M{"case_id": caseID},
+		options.Find().SetSort(bson.D{{Key: "created_at", Value: 1}, {Key: "_id", Value: 1}}),
+	)
+	if err != nil {
+		return nil, err
+	}
+	entries := []*models.AuditEntry{}
+	if err := cursor.All(ctx, &entries); err != nil {
+		return nil, err
+	}
+	return entries, nil
+}
+
+// ClaimCase assigns the case to the moderator. Claims made before staleBefore can be taken over.
+func (mr *ModerationRepo) ClaimCase(ctx context.Context, id primitive.ObjectID, moderator *models.User, now, staleBefore time.Time) (*models.ModerationCase, error) {
+	var c models.ModerationCase
+	err := mr.WithTransaction(ctx, func(ctx context.Context) error {
+		err := mr.cases.FindOneAndUpdate(ctx,
+			bson.M{"_id": id, "$or": bson.A{
+				bson.M{"status": models.CaseStatusOpen},
+				bson.M{"status": models.CaseStatusClaimed, "claimed_by": moderator.ID},
+				bson.M{"status": models.CaseStatusClaimed, "claimed_at": bson.M{"$lte": staleBefore}},
+			}},
+			bson.M{"$set": bson.M{
+				"status":           models.CaseStatusClaimed,
+				"claimed_by":       moderator.ID,
+				"claimed_by_login": moderator.Login,
+				"claimed_at":       now,
+				"updated_at":       now,
+			}},
+			options.FindOneAndUpdate().SetReturnDocument(options.After),
+		).Decode(&c)
+		if errors.Is(err, mongoDriver.ErrNoDocuments) {
+			return mr.caseError(ctx, id, errs.ErrCaseClaimed)
+		}
+		if err != nil {
+			return err
+		}
+		return mr.addAudit(ctx, &c, moderator, models.AuditActionClaimed, "", now)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &c, nil
+}
+
+// ResolveCase resolves the case claimed by the moderator and applies the action
+// to the listing and its owner in one transaction
+func (mr *ModerationRepo) ResolveCase(ctx context.Context, id primitive.ObjectID, moderator *models.User, action, comment string, now time.Time) (*models.ModerationCase, error) {
+	var c models.ModerationCase
+	err := mr.WithTransaction(ctx, func(ctx context.Context) error {
+		err := mr.cases.FindOneAndUpdate(ctx,
+			bson.M{"_id": id, "status": models.CaseStatusClaimed, "claimed_by": moderator.ID},
+			bson.M{
+				"$set": bson.M{
+					"status":      models.CaseStatusResolved,
+					"resolution":  action,
+					"comment":     comment,
+					"resolved_by": moderator.ID,
+					"resolved_at": now,
+					"updated_at":  now,
+				},
+				// Следующие жалобы на объявление откроют новое дело
+				"$unset": bson.M{"active": ""},
+			},
+			options.FindOneAndUpdate().SetReturnDocument(options.After),
+		).Decode(&c)
+		if errors.Is(err, mongoDriver.ErrNoDocuments) {
+			return mr.caseError(ctx, id, errs.ErrCaseNotClaimed)
+		}
+		if err != nil {
+			return err
+		}
+
+		if err := mr.applyAction(ctx, &c, action, now); err != nil {
+			return err
+		}
+		return mr.addAudit(ctx, &c, moderator, action, comment, now)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &c, nil
+}
+
+func (mr *ModerationRepo) applyAction(ctx context.Context, c *models.ModerationCase, action string, now time.Time) error {
+	switch action {
+	case models.CaseActionDismiss:
+		if !c.AutoHidden {
+			return nil
+		}
+		_, err := mr.listings.UpdateOne(ctx,
+			bson.M{"_id": c.ListingID, "status": models.ListingStatusHidden},
+			bson.M{"$set": bson.M{"status": c.RestoreStatus}},
+		)
+		return err
+	case models.CaseActionHide:
+		_, err := mr.listings.UpdateOne(ctx,
+			bson.M{"_id": c.ListingID, "status": bson.M{"$in": hideableStatuses}},
+			bson.M{"$set": bson.M{"status": models.ListingStatusHidden}},
+		)
+		return err
+	case models.CaseActionRemove:
+		return mr.removeListing(ctx, c.ListingID)
+	case models.CaseActionBanOwner:
+		if err := mr.removeListing(ctx, c.ListingID); err != nil {
+			return err
+		}
+		return mr.banOwner(ctx, c.OwnerID, now)
+	}
+	return errs.ErrCaseInvalidAction
+}
+
+func (mr *ModerationRepo) removeListing(ctx context.Context, id primitive.ObjectID) error {
+	_, err := mr.listings.UpdateOne(ctx,
+		bson.M{"_id": id},
+		bson.M{"$set": bson.M{"status": models.ListingStatusRemoved}},
+	)
+	return err
+}
+
+// banOwner bans the user and hides the rest of their listings
+func (mr *ModerationRepo) banOwner(ctx context.Context, ownerID primitive.ObjectID, now time.Time) error {
+	_, err := mr.users.UpdateOne(ctx,
+		bson.M{"_id": ownerID, "banned_at": bson.M{"$exists": false}},
+		bson.M{"$set": bson.M{"banned_at": now}},
+	)
+	if err != nil {
+		return err
+	}
+	_, err = mr.listings.UpdateMany(ctx,
+		bson.M{"owner_id": ownerID, "status": bson.M{"$in": hideableStatuses}},
+		bson.M{"$set": bson.M{"status": models.ListingStatusHidden}},
+	)
+	return err
+}
+
+func (mr *ModerationRepo) addAudit(ctx context.Context, c *models.ModerationCase, actor *models.User, action, comment string, now time.Time) error {
+	entry := &models.AuditEntry{
+		CaseID:    c.ID,
+		ListingID: c.ListingID,
+		Action:    action,
+		Comment:   comment,
+		CreatedAt: now,
+	}
+	if actor != nil {
+		entry.ActorID = &actor.ID
+		entry.ActorLogin = actor.Login
+	}
+	_, err := mr.audit.InsertOne(ctx, entry)
+	return err
+}
+
+// caseError explains why the conditional update of the case did not match
+func (mr *ModerationRepo) caseError(ctx context.Context, id primitive.ObjectID, otherwise error) error {
+	c, err := mr.GetCase(ctx, id)
+	if err != nil {
+		return err
+	}
+	if c.Status == models.CaseStatusResolved {
+		return errs.ErrCaseResolved
+	}
+	return otherwise
+}
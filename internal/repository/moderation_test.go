@@ -0,0 +1,86 @@
+package repository
+
+import (
+	"context"
+	"os"
+	"sync"
+	"testing"
+	"time"
+
+	"vk-inter/internal/models"
+	"vk-inter/pkg/db/mongo"
+	"vk-inter/pkg/logger"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// testDB connects to the replica set from MONGO_TEST_HOST, every test gets its own database
+func testDB(t *testing.T) (context.Context, *mongo.MongoDB) {
+	t.Helper()
+	host := os.Getenv("MONGO_TEST_HOST")
+	if host == "" {
+		t.Skip("MONGO_TEST_HOST is not set")
+	}
+	ctx := context.WithValue(context.Background(), logger.LoggerKey, logger.New(false))
+	db, err := mongo.New(ctx, mongo.MongoConfig{
+		Host:     host,
+		Port:     27017,
+		User:     os.Getenv("MONGO_TEST_USER"),
+		Password: os.Getenv("MONGO_TEST_PASS"),
+		DBName:   "test_" + primitive.NewObjectID().Hex(),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		db.Database.Drop(context.Background())
+		db.Disconnect(context.Background())
+	})
+	return ctx, db
+}
+
+func TestCreateReportConcurrently(t *testing.T) {
+	ctx, db := testDB(t)
+	repo := NewModerationRepo(ctx, db)
+	listing := &models.Listing{ID: primitive.NewObjectID(), OwnerID: primitive.NewObjectID(), Title: "Велосипед", Status: models.ListingStatusPublished}
+
+	// Первые жалобы одновременно открывают дело, проигравшие гонку upsert добавляются в него
+	const reporters = 8
+	var wg sync.WaitGroup
+	results := make([]error, reporters)
+	start := make(chan struct{})
+	for i := range reporters {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			<-start
+			report := &models.Report{
+				ListingID:  listing.ID,
+				ReporterID: primitive.NewObjectID(),
+				Reason:     "spam",
+				CreatedAt:  time.Now(),
+			}
+			_, results[i] = repo.CreateReport(ctx, report, listing, reporters+1)
+		}()
+	}
+	close(start)
+	wg.Wait()
+
+	for i, err := range results {
+		if err != nil {
+			t.Errorf("CreateReport() of reporter %d = %v", i, err)
+		}
+	}
+	var cases []models.ModerationCase
+	cursor, err := db.Collection("moderation_cases").Find(ctx, bson.M{"listing_id": listing.ID})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := cursor.All(ctx, &cases); err != nil {
+		t.Fatal(err)
+	}
+	if len(cases) != 1 || cases[0].ReportsCount != reporters {
+		t.Fatalf("cases = %+v, want one case with %d reports", cases, reporters)
+	}
+}
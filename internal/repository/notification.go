@@ -0,0 +1,122 @@
+package repository
+
+import (
+	"context"
+	"time"
+	"vk-inter/internal/models"
+	"vk-inter/pkg/db/mongo"
+	"vk-inter/pkg/errs"
+	"vk-inter/pkg/logger"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	mongoDriver "go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"go.uber.org/zap"
+)
+
+type NotificationRepo struct {
+	*mongo.MongoDB
+	collection mongoDriver.Collection
+}
+
+func NewNotificationRepo(ctx context.Context, db *mongo.MongoDB) *NotificationRepo {
+	log := logger.FromContext(ctx)
+
+	// Повторная обработка события не создаёт второе уведомление
+	_, err := db.Collection("notifications").Indexes().CreateOne(ctx, mongoDriver.IndexModel{
+		Keys: bson.D{{Key: "dedup_key", Value: 1}},
+		Options: options.Index().SetUnique(true).
+			SetPartialFilterExpression(bson.M{"dedup_key": bson.M{"$type": "string"}}),
+	})
+	if err != nil {
+		log.Fatal("Failed to create index for notifications", zap.Error(err))
+	}
+	err = db.CreateIndex(ctx, "notifications", bson.D{{Key: "user_id", Value: 1}, {Key: "read", Value: 1}, {Key: "created_at", Value: -1}})
+	if err != nil {
+		log.Fatal("Failed to create index for notifications", zap.Error(err))
+	}
+
+	return &NotificationRepo{
+		MongoDB:    db,
+		collection: *db.Collection("notifications"),
+	}
+}
+
+// CreateNotification returns false if notification with the same dedup key already exists
+func (nr *NotificationRepo) CreateNotification(ctx context.Context, n *models.Notification) (bool, error) {
+	n.CreatedAt = time.Now()
+	res, err := nr.collection.InsertOne(ctx, n)
+	if nr.IsDuplicateKeyError(err) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	n.ID = res.InsertedID.(primitive.ObjectID)
+	return true, nil
+}
+
+// GetNotifications returns user's notifications, latest first
+func (nr *NotificationRepo) GetNotifications(ctx context.Context, userID primitive.ObjectID, unreadOnly bool, skip, limit int) ([]*models.Notification, error) {
+	cursor, err := nr.collection.Find(ctx,
+		notificationsFilter(userID, unreadOnly),
+		options.Find().
+			SetSort(bson.D{{Key: "created_at", Value: -1}, {Key: "_id", Value: -1}}).
+			SetSkip(int64(skip)).
+			SetLimit(int64(limit)),
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	notifications := []*models.Notification{}
+	for cursor.Next(ctx) {
+		var n models.Notification
+		if err := cursor.Decode(&n); err != nil {
+			continue
+		}
+		notifications = append(notifications, &n)
+	}
+	return notifications, nil
+}
+
+func (nr *NotificationRepo) CountNotifications(ctx context.Context, userID primitive.ObjectID, unreadOnly bool) (int64, error) {
+	return nr.collection.CountDocuments(ctx, notificationsFilter(userID, unreadOnly))
+}
+
+// MarkRead marks user's notification as read, notifications of other users are not found
+func (nr *NotificationRepo) MarkRead(ctx context.Context, id, userID primitive.ObjectID) error {
+	res, err := nr.collection.UpdateOne(ctx,
+		bson.M{"_id": id, "user_id": userID},
+		bson.M{"$set": bson.M{"read": true}},
+	)
+	if err != nil {
+		return err
+	}
+	if res.MatchedCount == 0 {
+		return errs.ErrNotificationNotFound
+	}
+	return nil
+}
+
+// MarkAllRead returns the number of notifications marked as read
+func (nr *NotificationRepo) MarkAllRead(ctx context.Context, userID primitive.ObjectID) (int64, error) {
+	res, err := nr.collection.UpdateMany(ctx,
+		notificationsFilter(userID, true),
+		bson.M{"$set": bson.M{"read": true}},
+	)
+	if err != nil {
+		return 0, err
+	}
+	return res.ModifiedCount, nil
+}
+
+func notificationsFilter(userID primitive.ObjectID, unreadOnly bool) bson.M {
+	filter := bson.M{"user_id": userID}
+	if unreadOnly {
+		filter["read"] = false
+	}
+	return filter
+}
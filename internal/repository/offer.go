@@ -0,0 +1,223 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+	"vk-inter/internal/models"
+	"vk-inter/pkg/db/mongo"
+	"vk-inter/pkg/errs"
+	"vk-inter/pkg/logger"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	mongoDriver "go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"go.uber.org/zap"
+)
+
+type OfferRepo struct {
+	*mongo.MongoDB
+	collection mongoDriver.Collection
+	listings   mongoDriver.Collection
+}
+
+func NewOfferRepo(ctx context.Context, db *mongo.MongoDB) *OfferRepo {
+	log := logger.FromContext(ctx)
+
+	// У покупателя может быть только одно активное предложение по объявлению
+	_, err := db.Collection("offers").Indexes().CreateOne(ctx, mongoDriver.IndexModel{
+		Keys: bson.D{{Key: "listing_id", Value: 1}, {Key: "buyer_id", Value: 1}},
+		Options: options.Index().
+			SetUnique(true).
+			SetPartialFilterExpression(bson.M{"status": models.OfferStatusPending}),
+	})
+	if err != nil {
+		log.Fatal("Failed to create index for offers", zap.Error(err))
+	}
+	indexes := []bson.D{
+		{{Key: "listing_id", Value: 1}, {Key: "created_at", Value: -1}},
+		{{Key: "buyer_id", Value: 1}, {Key: "created_at", Value: -1}},
+		{{Key: "status", Value: 1}, {Key: "expires_at", Value: 1}},
+	}
+	for _, keys := range indexes {
+		if err := db.CreateIndex(ctx, "offers", keys); err != nil {
+			log.Fatal("Failed to create index for offers", zap.Error(err))
+		}
+	}
+
+	return &OfferRepo{
+		MongoDB:    db,
+		collection: *db.Collection("offers"),
+		listings:   *db.Collection("listings"),
+	}
+}
+
+// CreateOffer inserts offer starting a new chain
+func (or *OfferRepo) CreateOffer(ctx context.Context, offer *models.Offer) (*models.Offer, error) {
+	offer.ID = primitive.NewObjectID()
+	offer.RootID = offer.ID
+	if _, err := or.collection.InsertOne(ctx, offer); err != nil {
+		if or.IsDuplicateKeyError(err) {
+			return nil, errs.ErrOfferExists
+		}
+		return nil, err
+	}
+	return offer, nil
+}
+
+func (or *OfferRepo) GetOfferByID(ctx context.Context, id primitive.ObjectID) (*models.Offer, error) {
+	var offer models.Offer
+	err := or.collection.FindOne(ctx, bson.M{"_id": id}).Decode(&offer)
+	if err != nil {
+		if errors.Is(err, mongoDriver.ErrNoDocuments) {
+			return nil, errs.ErrOfferNotFound
+		}
+		return nil, err
+	}
+	return &offer, nil
+}
+
+// GetListingOffers returns offers of the listing, latest first, status is optional
+func (or *OfferRepo) GetListingOffers(ctx context.Context, listingID primitive.ObjectID, status string, skip, limit int) ([]*models.Offer, error) {
+	return or.findOffers(ctx, bson.M{"listing_id": listingID}, status, skip, limit)
+}
+
+// GetBuyerOffers returns offers of all negotiations of the buyer, latest first, status is optional
+func (or *OfferRepo) GetBuyerOffers(ctx context.Context, buyerID primitive.ObjectID, status string, skip, limit int) ([]*models.Offer, error) {
+	return or.findOffers(ctx, bson.M{"buyer_id": buyerID}, status, skip, limit)
+}
+
+func (or *OfferRepo) findOffers(ctx context.Context, filter bson.M, status string, skip, limit int) ([]*models.Offer, error) {
+	if status != "" {
+		filter["status"] = status
+	}
+	cursor, err := or.collection.Find(ctx, filter,
+		options.Find().
+			SetSort(bson.D{{Key: "created_at", Value: -1}, {Key: "_id", Value: -1}}).
+			SetSkip(int64(skip)).
+			SetLimit(int64(limit)),
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	offers := []*models.Offer{}
+	for cursor.Next(ctx) {
+		var o models.Offer
+		if err := cursor.Decode(&o); err != nil {
+			continue
+		}
+		offers = append(offers, &o)
+	}
+	return offers, nil
+}
+
+// CloseOffer moves pending offer which is not expired yet to the final status
+func (or *OfferRepo) CloseOffer(ctx context.Context, id primitive.ObjectID, status string, now time.Time) (*models.Offer, error) {
+	var offer models.Offer
+	err := or.collection.FindOneAndUpdate(ctx,
+		pendingOffer(id, now),
+		bson.M{"$set": bson.M{"status": status, "updated_at": now}},
+		options.FindOneAndUpdate().SetReturnDocument(options.After),
+	).Decode(&offer)
+	if errors.Is(err, mongoDriver.ErrNoDocuments) {
+		return nil, errs.ErrOfferNotPending
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &offer, nil
+}
+
+// CounterOffer marks pending offer as countered and inserts the counter-offer in one transaction
+func (or *OfferRepo) CounterOffer(ctx context.Context, id primitive.ObjectID, counter *models.Offer, now time.Time) (*models.Offer, error) {
+	counter.ID = primitive.NewObjectID()
+	err := or.WithTransaction(ctx, func(ctx context.Context) error {
+		res, err := or.collection.UpdateOne(ctx,
+			pendingOffer(id, now),
+			bson.M{"$set": bson.M{"status": models.OfferStatusCountered, "updated_at": now}},
+		)
+		if err != nil {
+			return err
+		}
+		if res.MatchedCount == 0 {
+			return errs.ErrOfferNotPending
+		}
+		_, err = or.collection.InsertOne(ctx, counter)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return counter, nil
+}
+
+// AcceptOffer accepts pending offer, reserves the listing and rejects other pending offers
+// on it in one transaction. Rejected offers are returned to notify their buyers.
+func (or *OfferRepo) AcceptOffer(ctx context.Context, id primitive.ObjectID, now time.Time) (*models.Offer, []*models.Offer, error) {
+	var accepted models.Offer
+	var rejected []*models.Offer
+	err := or.WithTransaction(ctx, func(ctx context.Context) error {
+		// Транзакция может повторяться, поэтому результат собирается заново
+		rejected = nil
+
+		err := or.collection.FindOneAndUpdate(ctx,
+			pendingOffer(id, now),
+			bson.M{"$set": bson.M{"status": models.OfferStatusAccepted, "updated_at": now}},
+			options.FindOneAndUpdate().SetReturnDocument(options.After),
+		).Decode(&accepted)
+		if errors.Is(err, mongoDriver.ErrNoDocuments) {
+			return errs.ErrOfferNotPending
+		}
+		if err != nil {
+			return err
+		}
+
+		res, err := or.listings.UpdateOne(ctx,
+			bson.M{"_id": accepted.ListingID, "status": models.ListingStatusPublished},
+			bson.M{"$set": bson.M{"status": models.ListingStatusReserved, "reserved_offer_id": accepted.ID, "buyer_id": accepted.BuyerID}},
+		)
+		if err != nil {
+			return err
+		}
+		if res.MatchedCount == 0 {
+			return errs.ErrListingNotAvailable
+		}
+
+		competing := bson.M{"listing_id": accepted.ListingID, "status": models.OfferStatusPending}
+		cursor, err := or.collection.Find(ctx, competing)
+		if err != nil {
+			return err
+		}
+		if err := cursor.All(ctx, &rejected); err != nil {
+			return err
+		}
+		_, err = or.collection.UpdateMany(ctx, competing,
+			bson.M{"$set": bson.M{"status": models.OfferStatusRejected, "updated_at": now}},
+		)
+		return err
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+	return &accepted, rejected, nil
+}
+
+// ExpireOffers marks pending offers expired by now, returns the number of expired offers
+func (or *OfferRepo) ExpireOffers(ctx context.Context, now time.Time) (int64, error) {
+	res, err := or.collection.UpdateMany(ctx,
+		bson.M{"status": models.OfferStatusPending, "expires_at": bson.M{"$lte": now}},
+		bson.M{"$set": bson.M{"status": models.OfferStatusExpired, "updated_at": now}},
+	)
+	if err != nil {
+		return 0, err
+	}
+	return res.ModifiedCount, nil
+}
+
+// pendingOffer matches the offer if it is pending and not expired, even if the worker has not marked it yet
+func pendingOffer(id primitive.ObjectID, now time.Time) bson.M {
+	return bson.M{"_id": id, "status": models.OfferStatusPending, "expires_at": bson.M{"$gt": now}}
+}
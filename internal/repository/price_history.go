@@ -0,0 +1,90 @@
+package repository
+
+import (
+	"context"
+	"vk-inter/internal/models"
+	"vk-inter/pkg/db/mongo"
+	"vk-inter/pkg/logger"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	mongoDriver "go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"go.uber.org/zap"
+)
+
+type PriceHistoryRepo struct {
+	*mongo.MongoDB
+	collection mongoDriver.Collection
+}
+
+func NewPriceHistoryRepo(ctx context.Context, db *mongo.MongoDB) *PriceHistoryRepo {
+	log := logger.FromContext(ctx)
+
+	err := db.CreateIndex(ctx, "price_history", bson.D{{Key: "listing_id", Value: 1}, {Key: "changed_at", Value: -1}})
+	if err != nil {
+		log.Fatal("Failed to create index for price history", zap.Error(err))
+	}
+	// Очередь неразосланных снижений цены
+	_, err = db.Collection("price_history").Indexes().CreateOne(ctx, mongoDriver.IndexModel{
+		Keys:    bson.D{{Key: "_id", Value: 1}, {Key: "alerts_sent", Value: 1}},
+		Options: options.Index().SetPartialFilterExpression(bson.M{"alerts_sent": false}),
+	})
+	if err != nil {
+		log.Fatal("Failed to create index for price history", zap.Error(err))
+	}
+
+	return &PriceHistoryRepo{
+		MongoDB:    db,
+		collection: *db.Collection("price_history"),
+	}
+}
+
+// GetPriceHistory returns price changes of the listing, latest first
+func (pr *PriceHistoryRepo) GetPriceHistory(ctx context.Context, listingID primitive.ObjectID, limit int) ([]*models.PriceChange, error) {
+	cursor, err := pr.collection.Find(ctx,
+		bson.M{"listing_id": listingID},
+		options.Find().SetSort(bson.D{{Key: "changed_at", Value: -1}, {Key: "_id", Value: -1}}).SetLimit(int64(limit)),
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	changes := []*models.PriceChange{}
+	for cursor.Next(ctx) {
+		var c models.PriceChange
+		if err := cursor.Decode(&c); err != nil {
+			continue
+		}
+		changes = append(changes, &c)
+	}
+	return changes, nil
+}
+
+// GetUnsentDrops returns price drops whose alerts are not sent yet, oldest first
+func (pr *PriceHistoryRepo) GetUnsentDrops(ctx context.Context, limit int) ([]*models.PriceChange, error) {
+	cursor, err := pr.collection.Find(ctx,
+		bson.M{"alerts_sent": false},
+		options.Find().SetSort(bson.D{{Key: "_id", Value: 1}}).SetLimit(int64(limit)),
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var changes []*models.PriceChange
+	for cursor.Next(ctx) {
+		var c models.PriceChange
+		if err := cursor.Decode(&c); err != nil {
+			continue
+		}
+		changes = append(changes, &c)
+	}
+	return changes, nil
+}
+
+func (pr *PriceHistoryRepo) SetAlertsSent(ctx context.Context, id primitive.ObjectID) error {
+	_, err := pr.collection.UpdateOne(ctx, bson.M{"_id": id}, bson.M{"$set": bson.M{"alerts_sent": true}})
+	return err
+}
@@ -0,0 +1,113 @@
+package repository
+
+import (
+	"context"
+	"time"
+	"vk-inter/internal/models"
+	"vk-inter/pkg/db/mongo"
+	"vk-inter/pkg/errs"
+	"vk-inter/pkg/logger"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	mongoDriver "go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"go.uber.org/zap"
+)
+
+type PriceWatchRepo struct {
+	*mongo.MongoDB
+	collection mongoDriver.Collection
+}
+
+func NewPriceWatchRepo(ctx context.Context, db *mongo.MongoDB) *PriceWatchRepo {
+	log := logger.FromContext(ctx)
+
+	_, err := db.Collection("price_watches").Indexes().CreateOne(ctx, mongoDriver.IndexModel{
+		Keys:    bson.D{{Key: "listing_id", Value: 1}, {Key: "user_id", Value: 1}},
+		Options: options.Index().SetUnique(true),
+	})
+	if err != nil {
+		log.Fatal("Failed to create index for price watches", zap.Error(err))
+	}
+
+	return &PriceWatchRepo{
+		MongoDB:    db,
+		collection: *db.Collection("price_watches"),
+	}
+}
+
+// SetWatch creates user's watch of the listing or updates its threshold
+func (pr *PriceWatchRepo) SetWatch(ctx context.Context, userID, listingID primitive.ObjectID, threshold float64) (*models.PriceWatch, error) {
+	var watch models.PriceWatch
+	err := pr.collection.FindOneAndUpdate(ctx,
+		bson.M{"user_id": userID, "listing_id": listingID},
+		bson.M{
+			"$set":         bson.M{"threshold_percent": threshold},
+			"$setOnInsert": bson.M{"created_at": time.Now()},
+		},
+		options.FindOneAndUpdate().SetUpsert(true).SetReturnDocument(options.After),
+	).Decode(&watch)
+	if err != nil {
+		return nil, err
+	}
+	return &watch, nil
+}
+
+func (pr *PriceWatchRepo) DeleteWatch(ctx context.Context, userID, listingID primitive.ObjectID) error {
+	res, err := pr.collection.DeleteOne(ctx, bson.M{"user_id": userID, "listing_id": listingID})
+	if err != nil {
+		return err
+	}
+	if res.DeletedCount == 0 {
+		return errs.ErrPriceAlertNotFound
+	}
+	return nil
+}
+
+// GetWatches returns watches of the listing ordered by _id, next batch starts after the last returned id
+func (pr *PriceWatchRepo) GetWatches(ctx context.Context, listingID, after primitive.ObjectID, limit int) ([]*models.PriceWatch, error) {
+	filter := bson.M{"listing_id": listingID}
+	if after != primitive.NilObjectID {
+		filter["_id"] = bson.M{"$gt": after}
+	}
+	cursor, err := pr.collection.Find(ctx, filter,
+		options.Find().SetSort(bson.D{{Key: "_id", Value: 1}}).SetLimit(int64(limit)),
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var watches []*models.PriceWatch
+	for cursor.Next(ctx) {
+		var w models.PriceWatch
+		if err := cursor.Decode(&w); err != nil {
+			continue
+		}
+		watches = append(watches, &w)
+	}
+	return watches, nil
+}
+
+// GetWatchingUsers returns those of userIDs who have their own watch of the listing
+func (pr *PriceWatchRepo) GetWatchingUsers(ctx context.Context, listingID primitive.ObjectID, userIDs []primitive.ObjectID) (map[primitive.ObjectID]bool, error) {
+	cursor, err := pr.collection.Find(ctx,
+		bson.M{"listing_id": listingID, "user_id": bson.M{"$in": userIDs}},
+		options.Find().SetProjection(bson.M{"user_id": 1}),
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	watching := map[primitive.ObjectID]bool{}
+	for cursor.Next(ctx) {
+		var w models.PriceWatch
+		if err := cursor.Decode(&w); err != nil {
+			continue
+		}
+		watching[w.UserID] = true
+	}
+	return watching, nil
+}
@@ -0,0 +1,152 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+	"vk-inter/internal/models"
+	"vk-inter/pkg/db/mongo"
+	"vk-inter/pkg/errs"
+	"vk-inter/pkg/logger"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	mongoDriver "go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"go.uber.org/zap"
+)
+
+type ReviewRepo struct {
+	*mongo.MongoDB
+	collection mongoDriver.Collection
+	users      mongoDriver.Collection
+}
+
+func NewReviewRepo(ctx context.Context, db *mongo.MongoDB) *ReviewRepo {
+	log := logger.FromContext(ctx)
+
+	// Каждая сторона сделки оставляет один отзыв
+	_, err := db.Collection("reviews").Indexes().CreateOne(ctx, mongoDriver.IndexModel{
+		Keys:    bson.D{{Key: "listing_id", Value: 1}, {Key: "author_id", Value: 1}},
+		Options: options.Index().SetUnique(true),
+	})
+	if err != nil {
+		log.Fatal("Failed to create index for reviews", zap.Error(err))
+	}
+	err = db.CreateIndex(ctx, "reviews", bson.D{{Key: "target_id", Value: 1}, {Key: "hidden", Value: 1}, {Key: "created_at", Value: -1}})
+	if err != nil {
+		log.Fatal("Failed to create index for reviews", zap.Error(err))
+	}
+
+	return &ReviewRepo{
+		MongoDB:    db,
+		collection: *db.Collection("reviews"),
+		users:      *db.Collection("users"),
+	}
+}
+
+// CreateReview inserts review and adds its rating to the target's aggregate in one transaction
+func (rr *ReviewRepo) CreateReview(ctx context.Context, review *models.Review) (*models.Review, error) {
+	review.ID = primitive.NewObjectID()
+	review.CreatedAt = time.Now()
+	err := rr.WithTransaction(ctx, func(ctx context.Context) error {
+		if _, err := rr.collection.InsertOne(ctx, review); err != nil {
+			if rr.IsDuplicateKeyError(err) {
+				return errs.ErrReviewExists
+			}
+			return err
+		}
+		return rr.addRating(ctx, review.TargetID, review.Rating, 1)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return review, nil
+}
+
+// GetUserReviews returns visible reviews about the user, latest first, and their total number
+func (rr *ReviewRepo) GetUserReviews(ctx context.Context, userID primitive.ObjectID, skip, limit int) ([]*models.Review, int64, error) {
+	filter := bson.M{"target_id": userID, "hidden": false}
+	total, err := rr.collection.CountDocuments(ctx, filter)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	cursor, err := rr.collection.Find(ctx, filter,
+		options.Find().
+			SetSort(bson.D{{Key: "created_at", Value: -1}, {Key: "_id", Value: -1}}).
+			SetSkip(int64(skip)).
+			SetLimit(int64(limit)),
+	)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer cursor.Close(ctx)
+
+	reviews := []*models.Review{}
+	for cursor.Next(ctx) {
+		var r models.Review
+		if err := cursor.Decode(&r); err != nil {
+			continue
+		}
+		reviews = append(reviews, &r)
+	}
+	return reviews, total, nil
+}
+
+// SetHidden hides or restores the review and updates the target's aggregate in one transaction.
+//
+// Review which already has the requested state is returned unchanged.
+func (rr *ReviewRepo) SetHidden(ctx context.Context, id primitive.ObjectID, hidden bool, reason string) (*models.Review, error) {
+	set := bson.M{"hidden": hidden}
+	update := bson.M{"$set": set}
+	if hidden {
+		set["hidden_reason"] = reason
+	} else {
+		update["$unset"] = bson.M{"hidden_reason": ""}
+	}
+
+	var review models.Review
+	err := rr.WithTransaction(ctx, func(ctx context.Context) error {
+		err := rr.collection.FindOneAndUpdate(ctx,
+			bson.M{"_id": id, "hidden": !hidden},
+			update,
+			options.FindOneAndUpdate().SetReturnDocument(options.After),
+		).Decode(&review)
+		if errors.Is(err, mongoDriver.ErrNoDocuments) {
+			// Отзыв уже в нужном состоянии или не существует
+			return rr.collection.FindOne(ctx, bson.M{"_id": id}).Decode(&review)
+		}
+		if err != nil {
+			return err
+		}
+
+		delta := int64(1)
+		if hidden {
+			delta = -1
+		}
+		return rr.addRating(ctx, review.TargetID, review.Rating, delta)
+	})
+	if errors.Is(err, mongoDriver.ErrNoDocuments) {
+		return nil, errs.ErrReviewNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &review, nil
+}
+
+// addRating updates rating aggregate of the user incrementally
+func (rr *ReviewRepo) addRating(ctx context.Context, userID primitive.ObjectID, rating int, delta int64) error {
+	res, err := rr.users.UpdateOne(ctx,
+		bson.M{"_id": userID},
+		bson.M{"$inc": bson.M{"rating_sum": int64(rating) * delta, "rating_count": delta}},
+	)
+	if err != nil {
+		return err
+	}
+	if res.MatchedCount == 0 {
+		return errs.ErrUserNotFound
+	}
+	return nil
+}
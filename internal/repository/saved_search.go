@@ -0,0 +1,157 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+	"vk-inter/internal/models"
+	"vk-inter/pkg/db/mongo"
+	"vk-inter/pkg/errs"
+	"vk-inter/pkg/logger"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	mongoDriver "go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"go.uber.org/zap"
+)
+
+type SavedSearchRepo struct {
+	*mongo.MongoDB
+	collection mongoDriver.Collection
+}
+
+func NewSavedSearchRepo(ctx context.Context, db *mongo.MongoDB) *SavedSearchRepo {
+	log := logger.FromContext(ctx)
+
+	// Кандидаты для объявления ищутся по категории и ценовой корзине
+	err := db.CreateIndex(ctx, "saved_searches", bson.D{{Key: "category_id", Value: 1}, {Key: "price_buckets", Value: 1}, {Key: "_id", Value: 1}})
+	if err != nil {
+		log.Fatal("Failed to create index for saved searches", zap.Error(err))
+	}
+	err = db.CreateIndex(ctx, "saved_searches", bson.D{{Key: "user_id", Value: 1}, {Key: "created_at", Value: -1}})
+	if err != nil {
+		log.Fatal("Failed to create index for saved searches", zap.Error(err))
+	}
+
+	return &SavedSearchRepo{
+		MongoDB:    db,
+		collection: *db.Collection("saved_searches"),
+	}
+}
+
+func (sr *SavedSearchRepo) CreateSavedSearch(ctx context.Context, search *models.SavedSearch) (*models.SavedSearch, error) {
+	search.CreatedAt = time.Now()
+	res, err := sr.collection.InsertOne(ctx, search)
+	if err != nil {
+		return nil, err
+	}
+	search.ID = res.InsertedID.(primitive.ObjectID)
+	return search, nil
+}
+
+// GetSavedSearches returns user's searches, recently created first
+func (sr *SavedSearchRepo) GetSavedSearches(ctx context.Context, userID primitive.ObjectID) ([]*models.SavedSearch, error) {
+	cursor, err := sr.collection.Find(ctx,
+		bson.M{"user_id": userID},
+		options.Find().SetSort(bson.D{{Key: "created_at", Value: -1}}),
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	searches := []*models.SavedSearch{}
+	for cursor.Next(ctx) {
+		var s models.SavedSearch
+		if err := cursor.Decode(&s); err != nil {
+			continue
+		}
+		searches = append(searches, &s)
+	}
+	return searches, nil
+}
+
+func (sr *SavedSearchRepo) CountSavedSearches(ctx context.Context, userID primitive.ObjectID) (int64, error) {
+	return sr.collection.CountDocuments(ctx, bson.M{"user_id": userID})
+}
+
+// DeleteSavedSearch deletes user's search, searches of other users are not found
+func (sr *SavedSearchRepo) DeleteSavedSearch(ctx context.Context, id, userID primitive.ObjectID) error {
+	res, err := sr.collection.DeleteOne(ctx, bson.M{"_id": id, "user_id": userID})
+	if err != nil {
+		return err
+	}
+	if res.DeletedCount == 0 {
+		return errs.ErrSavedSearchNotFound
+	}
+	return nil
+}
+
+func (sr *SavedSearchRepo) GetSavedSearchByID(ctx context.Context, id primitive.ObjectID) (*models.SavedSearch, error) {
+	var s models.SavedSearch
+	err := sr.collection.FindOne(ctx, bson.M{"_id": id}).Decode(&s)
+	if err != nil {
+		if errors.Is(err, mongoDriver.ErrNoDocuments) {
+			return nil, errs.ErrSavedSearchNotFound
+		}
+		return nil, err
+	}
+	return &s, nil
+}
+
+// FindSearchCandidates returns searches which may match a listing: searches without category
+// or in one of categoryIDs, covering the price bucket. Results are ordered by _id.
+//
+// next is the id of the last read search which the next batch starts after, nil id if there
+// are no more searches. Searches which can't be decoded are skipped, but still move next.
+func (sr *SavedSearchRepo) FindSearchCandidates(ctx context.Context, categoryIDs []primitive.ObjectID, bucket int, after primitive.ObjectID, limit int) ([]*models.SavedSearch, primitive.ObjectID, error) {
+	categories := bson.A{nil}
+	for _, id := range categoryIDs {
+		categories = append(categories, id)
+	}
+	filter := bson.M{
+		"category_id":   bson.M{"$in": categories},
+		"price_buckets": bucket,
+	}
+	if after != primitive.NilObjectID {
+		filter["_id"] = bson.M{"$gt": after}
+	}
+
+	cursor, err := sr.collection.Find(ctx, filter,
+		options.Find().SetSort(bson.D{{Key: "_id", Value: 1}}).SetLimit(int64(limit)),
+	)
+	if err != nil {
+		return nil, primitive.NilObjectID, err
+	}
+	defer cursor.Close(ctx)
+
+	var searches []*models.SavedSearch
+	next, read := primitive.NilObjectID, 0
+	for cursor.Next(ctx) {
+		read++
+		if id, ok := cursor.Current.Lookup("_id").ObjectIDOK(); ok {
+			next = id
+		}
+		var s models.SavedSearch
+		if err := cursor.Decode(&s); err != nil {
+			continue
+		}
+		searches = append(searches, &s)
+	}
+	if err := cursor.Err(); err != nil {
+		return nil, primitive.NilObjectID, err
+	}
+	if read < limit {
+		next = primitive.NilObjectID
+	}
+	return searches, next, nil
+}
+
+func (sr *SavedSearchRepo) IncMatches(ctx context.Context, id primitive.ObjectID, at time.Time) error {
+	_, err := sr.collection.UpdateOne(ctx,
+		bson.M{"_id": id},
+		bson.M{"$inc": bson.M{"match_count": 1}, "$max": bson.M{"last_match_at": at}},
+	)
+	return err
+}
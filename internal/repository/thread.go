@@ -0,0 +1,196 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+	"vk-inter/internal/models"
+	"vk-inter/pkg/db/mongo"
+	"vk-inter/pkg/errs"
+	"vk-inter/pkg/logger"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	mongoDriver "go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"go.uber.org/zap"
+)
+
+type ThreadRepo struct {
+	*mongo.MongoDB
+	collection mongoDriver.Collection
+}
+
+func NewThreadRepo(ctx context.Context, db *mongo.MongoDB) *ThreadRepo {
+	log := logger.FromContext(ctx)
+
+	// Покупатель пишет продавцу по объявлению в одну переписку
+	_, err := db.Collection("threads").Indexes().CreateOne(ctx, mongoDriver.IndexModel{
+		Keys:    bson.D{{Key: "listing_id", Value: 1}, {Key: "buyer_id", Value: 1}},
+		Options: options.Index().SetUnique(true),
+	})
+	if err != nil {
+		log.Fatal("Failed to create index for threads", zap.Error(err))
+	}
+	indexes := []bson.D{
+		{{Key: "participants", Value: 1}, {Key: "last_message_at", Value: -1}, {Key: "_id", Value: -1}},
+		{{Key: "buyer_id", Value: 1}, {Key: "created_at", Value: -1}},
+	}
+	for _, keys := range indexes {
+		if err := db.CreateIndex(ctx, "threads", keys); err != nil {
+			log.Fatal("Failed to create index for threads", zap.Error(err))
+		}
+	}
+
+	return &ThreadRepo{
+		MongoDB:    db,
+		collection: *db.Collection("threads"),
+	}
+}
+
+// CreateThread inserts thread, if the buyer already has a thread about the listing
+// it is returned with created=false
+func (tr *ThreadRepo) CreateThread(ctx context.Context, thread *models.Thread) (*models.Thread, bool, error) {
+	thread.CreatedAt = time.Now()
+	thread.LastMessageAt = thread.CreatedAt
+	thread.Participants = []primitive.ObjectID{thread.BuyerID, thread.SellerID}
+
+	res, err := tr.collection.InsertOne(ctx, thread)
+	if tr.IsDuplicateKeyError(err) {
+		var existing models.Thread
+		err := tr.collection.FindOne(ctx, bson.M{"listing_id": thread.ListingID, "buyer_id": thread.BuyerID}).Decode(&existing)
+		if err != nil {
+			return nil, false, err
+		}
+		return &existing, false, nil
+	}
+	if err != nil {
+		return nil, false, err
+	}
+	thread.ID = res.InsertedID.(primitive.ObjectID)
+	return thread, true, nil
+}
+
+// FindThread returns the thread of the buyer about the listing
+func (tr *ThreadRepo) FindThread(ctx context.Context, listingID, buyerID primitive.ObjectID) (*models.Thread, error) {
+	var thread models.Thread
+	err := tr.collection.FindOne(ctx, bson.M{"listing_id": listingID, "buyer_id": buyerID}).Decode(&thread)
+	if err != nil {
+		if errors.Is(err, mongoDriver.ErrNoDocuments) {
+			return nil, errs.ErrThreadNotFound
+		}
+		return nil, err
+	}
+	return &thread, nil
+}
+
+func (tr *ThreadRepo) GetThreadByID(ctx context.Context, id primitive.ObjectID) (*models.Thread, error) {
+	var thread models.Thread
+	err := tr.collection.FindOne(ctx, bson.M{"_id": id}).Decode(&thread)
+	if err != nil {
+		if errors.Is(err, mongoDriver.ErrNoDocuments) {
+			return nil, errs.ErrThreadNotFound
+		}
+		return nil, err
+	}
+	return &thread, nil
+}
+
+// GetThreads returns user's threads with the latest messages first, starting after the cursor
+func (tr *ThreadRepo) GetThreads(ctx context.Context, userID primitive.ObjectID, after *models.TimeCursor, limit int) ([]*models.Thread, error) {
+	filter := bson.M{"participants": userID}
+	if after != nil {
+		filter["$or"] = bson.A{
+			bson.M{"last_message_at": bson.M{"$lt": after.Time}},
+			bson.M{"last_message_at": after.Time, "_id": bson.M{"$lt": after.ID}},
+		}
+	}
+	cursor, err := tr.collection.Find(ctx, filter,
+		options.Find().
+			SetSort(bson.D{{Key: "last_message_at", Value: -1}, {Key: "_id", Value: -1}}).
+			SetLimit(int64(limit)),
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	threads := []*models.Thread{}
+	for cursor.Next(ctx) {
+		var t models.Thread
+		if err := cursor.Decode(&t); err != nil {
+			continue
+		}
+		threads = append(threads, &t)
+	}
+	return threads, nil
+}
+
+// AddMessage sets the last message of the thread and increments unread counter of the recipient
+func (tr *ThreadRepo) AddMessage(ctx context.Context, id primitive.ObjectID, preview *models.MessagePreview, recipientRole string) (*models.Thread, error) {
+	var thread models.Thread
+	err := tr.collection.FindOneAndUpdate(ctx,
+		bson.M{"_id": id},
+		bson.M{
+			"$set": bson.M{"last_message": preview, "last_message_at": preview.CreatedAt},
+			"$inc": bson.M{recipientRole + "_unread": 1},
+		},
+		options.FindOneAndUpdate().SetReturnDocument(options.After),
+	).Decode(&thread)
+	if err != nil {
+		if errors.Is(err, mongoDriver.ErrNoDocuments) {
+			return nil, errs.ErrThreadNotFound
+		}
+		return nil, err
+	}
+	return &thread, nil
+}
+
+// MarkRead resets unread counter of the participant and sets their read receipt
+func (tr *ThreadRepo) MarkRead(ctx context.Context, id primitive.ObjectID, role string, at time.Time) (*models.Thread, error) {
+	var thread models.Thread
+	err := tr.collection.FindOneAndUpdate(ctx,
+		bson.M{"_id": id},
+		bson.M{"$set": bson.M{role + "_unread": 0, role + "_read_at": at}},
+		options.FindOneAndUpdate().SetReturnDocument(options.After),
+	).Decode(&thread)
+	if err != nil {
+		if errors.Is(err, mongoDriver.ErrNoDocuments) {
+			return nil, errs.ErrThreadNotFound
+		}
+		return nil, err
+	}
+	return &thread, nil
+}
+
+// CountThreadsSince counts threads started by the buyer since the time
+func (tr *ThreadRepo) CountThreadsSince(ctx context.Context, buyerID primitive.ObjectID, since time.Time) (int64, error) {
+	return tr.collection.CountDocuments(ctx, bson.M{"buyer_id": buyerID, "created_at": bson.M{"$gte": since}})
+}
+
+// CountUnread returns the number of unread messages of the user in all threads
+func (tr *ThreadRepo) CountUnread(ctx context.Context, userID primitive.ObjectID) (int64, error) {
+	cursor, err := tr.collection.Aggregate(ctx, mongoDriver.Pipeline{
+		{{Key: "$match", Value: bson.M{"participants": userID}}},
+		{{Key: "$group", Value: bson.M{
+			"_id": nil,
+			"unread": bson.M{"$sum": bson.M{"$cond": bson.A{
+				bson.M{"$eq": bson.A{"$buyer_id", userID}}, "$buyer_unread", "$seller_unread",
+			}}},
+		}}},
+	})
+	if err != nil {
+		return 0, err
+	}
+	defer cursor.Close(ctx)
+
+	var row struct {
+		Unread int64 `bson:"unread"`
+	}
+	if cursor.Next(ctx) {
+		if err := cursor.Decode(&row); err != nil {
+			return 0, err
+		}
+	}
+	return row.Unread, nil
+}
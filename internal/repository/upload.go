@@ -0,0 +1,93 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+	"vk-inter/internal/models"
+	"vk-inter/pkg/db/mongo"
+	"vk-inter/pkg/errs"
+	"vk-inter/pkg/logger"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	mongoDriver "go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"go.uber.org/zap"
+)
+
+type UploadRepo struct {
+	*mongo.MongoDB
+	collection mongoDriver.Collection
+}
+
+func NewUploadRepo(ctx context.Context, db *mongo.MongoDB) *UploadRepo {
+	log := logger.FromContext(ctx)
+
+	for _, field := range []string{"owner_id", "variants_status"} {
+		err := db.CreateUniqueIndex(ctx, "uploads", field, false)
+		if err != nil {
+			log.Fatal("Failed to create index for uploads", zap.Error(err))
+		}
+	}
+
+	return &UploadRepo{
+		MongoDB:    db,
+		collection: *db.Collection("uploads"),
+	}
+}
+
+func (ur *UploadRepo) CreateUpload(ctx context.Context, upload *models.Upload) (*models.Upload, error) {
+	upload.CreatedAt = time.Now()
+
+	_, err := ur.collection.InsertOne(ctx, upload)
+	if err != nil {
+		return nil, err
+	}
+	return upload, nil
+}
+
+func (ur *UploadRepo) GetUpload(ctx context.Context, id primitive.ObjectID) (*models.Upload, error) {
+	var upload models.Upload
+	err := ur.collection.FindOne(ctx, bson.M{"_id": id}).Decode(&upload)
+	if err != nil {
+		if errors.Is(err, mongoDriver.ErrNoDocuments) {
+			return nil, errs.ErrUploadNotFound
+		}
+		return nil, err
+	}
+	return &upload, nil
+}
+
+// GetPendingUploads returns uploads waiting for variants generation, oldest first.
+//
+// Uploads created before variants were introduced have no status and are pending too.
+func (ur *UploadRepo) GetPendingUploads(ctx context.Context, limit int) ([]*models.Upload, error) {
+	cursor, err := ur.collection.Find(ctx,
+		bson.M{"variants_status": bson.M{"$in": bson.A{models.VariantsStatusPending, nil}}},
+		options.Find().SetSort(bson.D{{Key: "_id", Value: 1}}).SetLimit(int64(limit)),
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var uploads []*models.Upload
+	for cursor.Next(ctx) {
+		var u models.Upload
+		if err := cursor.Decode(&u); err != nil {
+			continue
+		}
+		uploads = append(uploads, &u)
+	}
+	return uploads, nil
+}
+
+func (ur *UploadRepo) SetVariants(ctx context.Context, id primitive.ObjectID, variants map[string]models.ImageVariant, phash int64, status string) error {
+	set := bson.M{"variants": variants, "variants_status": status}
+	if phash != 0 {
+		set["phash"] = phash
+	}
+	_, err := ur.collection.UpdateOne(ctx, bson.M{"_id": id}, bson.M{"$set": set})
+	return err
+}
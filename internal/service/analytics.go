@@ -0,0 +1,149 @@
+package service
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"vk-inter/internal/models"
+	"vk-inter/pkg/errs"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+const (
+	analyticsDefaultDays = 30
+	analyticsMaxDays     = 90
+)
+
+type AnalyticsRepo interface {
+	GetSellerListings(ctx context.Context, ownerID primitive.ObjectID) ([]*models.Listing, error)
+	GetDailyViews(ctx context.Context, ownerID primitive.ObjectID, from, to string) ([]models.DailyCount, error)
+	GetDailyFavorites(ctx context.Context, listingIDs []primitive.ObjectID, from, to string) ([]models.DailyCount, error)
+	GetDailyMessages(ctx context.Context, sellerID primitive.ObjectID, from, to string) ([]models.DailyCount, error)
+}
+
+// AnalyticsService reports views, favorites and messages of the seller's listings by day
+type AnalyticsService struct {
+	repo  AnalyticsRepo
+	clock Clock
+}
+
+func NewAnalyticsService(repo AnalyticsRepo, clock Clock) *AnalyticsService {
+	return &AnalyticsService{repo: repo, clock: clock}
+}
+
+// GetSellerAnalytics returns activity of the user's listings over the inclusive range of days in UTC,
+// the last 30 days by default
+func (as *AnalyticsService) GetSellerAnalytics(ctx context.Context, from, to string, user *models.User) (*models.SellerAnalytics, error) {
+	start, end, err := analyticsRange(from, to, as.clock.Now())
+	if err != nil {
+		return nil, err
+	}
+	from, to = start.Format(models.DayLayout), end.Format(models.DayLayout)
+
+	listings, err := as.repo.GetSellerListings(ctx, user.ID)
+	if err != nil {
+		return nil, err
+	}
+	ids := make([]primitive.ObjectID, 0, len(listings))
+	for _, l := range listings {
+		ids = append(ids, l.ID)
+	}
+
+	views, err := as.repo.GetDailyViews(ctx, user.ID, from, to)
+	if err != nil {
+		return nil, err
+	}
+	favorites, err := as.repo.GetDailyFavorites(ctx, ids, from, to)
+	if err != nil {
+		return nil, err
+	}
+	messages, err := as.repo.GetDailyMessages(ctx, user.ID, from, to)
+	if err != nil {
+		return nil, err
+	}
+
+	// Активность по объявлению и дню
+	activity := map[primitive.ObjectID]map[string]*models.AnalyticsDay{}
+	add := func(counts []models.DailyCount, field func(*models.AnalyticsDay) *int64) {
+		for _, c := range counts {
+			days, ok := activity[c.ListingID]
+			if !ok {
+				days = map[string]*models.AnalyticsDay{}
+				activity[c.ListingID] = days
+			}
+			day, ok := days[c.Day]
+			if !ok {
+				day = &models.AnalyticsDay{Date: c.Day}
+				days[c.Day] = day
+			}
+			*field(day) += c.Count
+		}
+	}
+	add(views, func(d *models.AnalyticsDay) *int64 { return &d.Views })
+	add(favorites, func(d *models.AnalyticsDay) *int64 { return &d.Favorites })
+	add(messages, func(d *models.AnalyticsDay) *int64 { return &d.Messages })
+
+	result := &models.SellerAnalytics{From: from, To: to, Listings: make([]*models.ListingAnalytics, 0, len(listings))}
+	result.Days = make([]models.AnalyticsDay, int(end.Sub(start)/(24*time.Hour))+1)
+	totals := make(map[string]*models.AnalyticsDay, len(result.Days))
+	for i := range result.Days {
+		result.Days[i].Date = start.AddDate(0, 0, i).Format(models.DayLayout)
+		totals[result.Days[i].Date] = &result.Days[i]
+	}
+
+	for _, l := range listings {
+		item := &models.ListingAnalytics{
+			ListingID:  l.ID,
+			Title:      l.Title,
+			Status:     l.Status,
+			TotalViews: l.ViewsCount,
+			Days:       []models.AnalyticsDay{},
+		}
+		for _, day := range activity[l.ID] {
+			item.Days = append(item.Days, *day)
+			item.Views += day.Views
+			item.Favorites += day.Favorites
+			item.Messages += day.Messages
+			if total, ok := totals[day.Date]; ok {
+				total.Views += day.Views
+				total.Favorites += day.Favorites
+				total.Messages += day.Messages
+			}
+		}
+		sort.Slice(item.Days, func(i, j int) bool { return item.Days[i].Date < item.Days[j].Date })
+
+		result.Views += item.Views
+		result.Favorites += item.Favorites
+		result.Messages += item.Messages
+		result.Listings = append(result.Listings, item)
+	}
+	// Самые просматриваемые объявления первыми
+	sort.SliceStable(result.Listings, func(i, j int) bool { return result.Listings[i].Views > result.Listings[j].Views })
+	return result, nil
+}
+
+// analyticsRange parses the range of days, missing bounds are taken relative to now
+func analyticsRange(from, to string, now time.Time) (time.Time, time.Time, error) {
+	end := now.UTC().Truncate(24 * time.Hour)
+	if to != "" {
+		t, err := time.Parse(models.DayLayout, to)
+		if err != nil {
+			return time.Time{}, time.Time{}, errs.ErrAnalyticsInvalidRange
+		}
+		end = t
+	}
+	start := end.AddDate(0, 0, 1-analyticsDefaultDays)
+	if from != "" {
+		t, err := time.Parse(models.DayLayout, from)
+		if err != nil {
+			return time.Time{}, time.Time{}, errs.ErrAnalyticsInvalidRange
+		}
+		start = t
+	}
+	if start.After(end) || end.Sub(start) >= analyticsMaxDays*24*time.Hour {
+		return time.Time{}, time.Time{}, errs.ErrAnalyticsInvalidRange
+	}
+	return start, end, nil
+}
@@ -0,0 +1,275 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"vk-inter/internal/models"
+	"vk-inter/pkg/errs"
+)
+
+const (
+	maxAttributesPerCategory = 50
+	maxAttributeStringLength = 256
+)
+
+var attributeNameRe = regexp.MustCompile(`^[a-z][a-z0-9_]{0,31}$`)
+
+// attributeOpSuffixes are suffixes of attr.<name>_<op> query parameters
+var attributeOpSuffixes = []string{
+	models.AttributeOpGte,
+	models.AttributeOpLte,
+	models.AttributeOpGt,
+	models.AttributeOpLt,
+}
+
+// parseAttributeParam splits query parameter name like "year_gte" into attribute name and operator
+func parseAttributeParam(param string) (name, op string) {
+	for _, suffix := range attributeOpSuffixes {
+		if n, ok := strings.CutSuffix(param, "_"+suffix); ok && n != "" {
+			return n, suffix
+		}
+	}
+	return param, models.AttributeOpEq
+}
+
+// ValidateAttributeSchema checks attributes schema defined by admin
+func ValidateAttributeSchema(schema []models.AttributeSchema) error {
+	if len(schema) > maxAttributesPerCategory {
+		return fmt.Errorf("%w: expected up to %d attributes", errs.ErrCategoryInvalidSchema, maxAttributesPerCategory)
+	}
+
+	seen := make(map[string]struct{}, len(schema))
+	for _, a := range schema {
+		if !attributeNameRe.MatchString(a.Name) {
+			return fmt.Errorf("%w: attribute name %q must be 1-32 lowercase latin letters, numbers and '_'", errs.ErrCategoryInvalidSchema, a.Name)
+		}
+		// Иначе attr.<name> в запросе будет неоднозначным
+		if name, op := parseAttributeParam(a.Name); op != models.AttributeOpEq {
+			return fmt.Errorf("%w: attribute name %q must not end with _%s", errs.ErrCategoryInvalidSchema, name, op)
+		}
+		if _, ok := seen[a.Name]; ok {
+			return fmt.Errorf("%w: duplicate attribute %q", errs.ErrCategoryInvalidSchema, a.Name)
+		}
+		seen[a.Name] = struct{}{}
+
+		switch a.Type {
+		case models.AttributeTypeString:
+			if a.Min != nil || a.Max != nil {
+				return fmt.Errorf("%w: attribute %q: min and max are allowed only for numeric attributes", errs.ErrCategoryInvalidSchema, a.Name)
+			}
+		case models.AttributeTypeInteger, models.AttributeTypeNumber:
+			if len(a.Enum) > 0 {
+				return fmt.Errorf("%w: attribute %q: enum is allowed only for string attributes", errs.ErrCategoryInvalidSchema, a.Name)
+			}
+			if a.Min != nil && a.Max != nil && *a.Min > *a.Max {
+				return fmt.Errorf("%w: attribute %q: min must not be greater than max", errs.ErrCategoryInvalidSchema, a.Name)
+			}
+		case models.AttributeTypeBoolean:
+			if len(a.Enum) > 0 || a.Min != nil || a.Max != nil {
+				return fmt.Errorf("%w: attribute %q: boolean attribute has no constraints", errs.ErrCategoryInvalidSchema, a.Name)
+			}
+		default:
+			return fmt.Errorf("%w: attribute %q: unknown type %q", errs.ErrCategoryInvalidSchema, a.Name, a.Type)
+		}
+	}
+	return nil
+}
+
+// ValidateAttributes checks listing attributes against category schema.
+//
+// Returns attributes converted to schema types: integers become int64,
+// numbers float64, so they are stored in Mongo as numbers and can be compared.
+func ValidateAttributes(schema []models.AttributeSchema, attrs map[string]any) (map[string]any, error) {
+	known := make(map[string]models.AttributeSchema, len(schema))
+	for _, a := range schema {
+		known[a.Name] = a
+	}
+
+	for name := range attrs {
+		if _, ok := known[name]; !ok {
+			return nil, fmt.Errorf("%w: unknown attribute %q", errs.ErrListingInvalidAttributes, name)
+		}
+	}
+
+	result := make(map[string]any, len(attrs))
+	for _, a := range schema {
+		raw, ok := attrs[a.Name]
+		if !ok || raw == nil {
+			if a.Required {
+				return nil, fmt.Errorf("%w: attribute %q is required", errs.ErrListingInvalidAttributes, a.Name)
+			}
+			continue
+		}
+
+		value, err := convertAttribute(a, raw)
+		if err != nil {
+			return nil, err
+		}
+		result[a.Name] = value
+	}
+
+	if len(result) == 0 {
+		return nil, nil
+	}
+	return result, nil
+}
+
+func convertAttribute(a models.AttributeSchema, raw any) (any, error) {
+	switch a.Type {
+	case models.AttributeTypeString:
+		s, ok := raw.(string)
+		if !ok {
+			return nil, fmt.Errorf("%w: attribute %q must be a string", errs.ErrListingInvalidAttributes, a.Name)
+		}
+		s = strings.TrimSpace(s)
+		if s == "" || utf8.RuneCountInString(s) > maxAttributeStringLength {
+			return nil, fmt.Errorf("%w: attribute %q must be 1-%d chars", errs.ErrListingInvalidAttributes, a.Name, maxAttributeStringLength)
+		}
+		if len(a.Enum) > 0 && !slices.Contains(a.Enum, s) {
+			return nil, fmt.Errorf("%w: attribute %q must be one of %s", errs.ErrListingInvalidAttributes, a.Name, strings.Join(a.Enum, ", "))
+		}
+		return s, nil
+
+	case models.AttributeTypeInteger, models.AttributeTypeNumber:
+		f, ok := raw.(float64)
+		if !ok || math.IsNaN(f) || math.IsInf(f, 0) {
+			return nil, fmt.Errorf("%w: attribute %q must be a number", errs.ErrListingInvalidAttributes, a.Name)
+		}
+		if a.Type == models.AttributeTypeInteger && f != math.Trunc(f) {
+			return nil, fmt.Errorf("%w: attribute %q must be an integer", errs.ErrListingInvalidAttributes, a.Name)
+		}
+		if (a.Min != nil && f < *a.Min) || (a.Max != nil && f > *a.Max) {
+			return nil, fmt.Errorf("%w: attribute %q is out of range", errs.ErrListingInvalidAttributes, a.Name)
+		}
+		if a.Type == models.AttributeTypeInteger {
+			return int64(f), nil
+		}
+		return f, nil
+
+	case models.AttributeTypeBoolean:
+		b, ok := raw.(bool)
+		if !ok {
+			return nil, fmt.Errorf("%w: attribute %q must be a boolean", errs.ErrListingInvalidAttributes, a.Name)
+		}
+		return b, nil
+	}
+
+	return nil, fmt.Errorf("%w: attribute %q has unknown type", errs.ErrListingInvalidAttributes, a.Name)
+}
+
+// ParseAttributeFilters converts raw filter values to typed values.
+//
+// When schema is nil (no category requested) the type is guessed from the value.
+func ParseAttributeFilters(schema []models.AttributeSchema, filters []models.AttributeFilter) error {
+	var known map[string]models.AttributeSchema
+	if schema != nil {
+		known = make(map[string]models.AttributeSchema, len(schema))
+		for _, a := range schema {
+			known[a.Name] = a
+		}
+	}
+
+	for i := range filters {
+		f := &filters[i]
+		f.Name, f.Op = parseAttributeParam(f.Param)
+		if !attributeNameRe.MatchString(f.Name) || len(f.Values) == 0 {
+			return fmt.Errorf("%w: attr.%s", errs.ErrListingInvalidAttrFilter, f.Name)
+		}
+
+		a, ok := known[f.Name]
+		if known != nil && !ok {
+			return fmt.Errorf("%w: unknown attribute %q", errs.ErrListingInvalidAttrFilter, f.Name)
+		}
+		if f.Op != models.AttributeOpEq && ok && a.Type != models.AttributeTypeInteger && a.Type != models.AttributeTypeNumber {
+			return fmt.Errorf("%w: range filter on non-numeric attribute %q", errs.ErrListingInvalidAttrFilter, f.Name)
+		}
+		if f.Op != models.AttributeOpEq && len(f.Values) != 1 {
+			return fmt.Errorf("%w: range filter on %q expects single value", errs.ErrListingInvalidAttrFilter, f.Name)
+		}
+
+		f.Parsed = make([]any, 0, len(f.Values))
+		for _, v := range f.Values {
+			parsed, err := parseAttributeValue(a, ok, f.Op, v)
+			if err != nil {
+				return fmt.Errorf("%w: attr.%s: %v", errs.ErrListingInvalidAttrFilter, f.Name, err)
+			}
+			f.Parsed = append(f.Parsed, parsed)
+		}
+	}
+	return nil
+}
+
+func parseAttributeValue(a models.AttributeSchema, typed bool, op, v string) (any, error) {
+	if !typed {
+		if f, err := strconv.ParseFloat(v, 64); err == nil {
+			return f, nil
+		}
+		if op != models.AttributeOpEq {
+			return nil, fmt.Errorf("%q is not a number", v)
+		}
+		if v == "true" || v == "false" {
+			return v == "true", nil
+		}
+		return v, nil
+	}
+
+	switch a.Type {
+	case models.AttributeTypeInteger, models.AttributeTypeNumber:
+		f, err := strconv.ParseFloat(v, 64)
+		if err != nil {
+			return nil, fmt.Errorf("%q is not a number", v)
+		}
+		return f, nil
+	case models.AttributeTypeBoolean:
+		b, err := strconv.ParseBool(v)
+		if err != nil {
+			return nil, fmt.Errorf("%q is not a boolean", v)
+		}
+		return b, nil
+	}
+	return v, nil
+}
+
+// categoryAttributes returns attributes schema of the category merged with schemas of its ancestors.
+//
+// Attributes of a subcategory override inherited attributes with the same name.
+func categoryAttributes(ctx context.Context, repo CategoryRepo, category *models.Category) ([]models.AttributeSchema, error) {
+	chain := make([]*models.Category, 0, len(category.Ancestors)+1)
+	if len(category.Ancestors) > 0 {
+		ancestors, err := repo.GetByIDs(ctx, category.Ancestors)
+		if err != nil {
+			return nil, err
+		}
+		byID := make(map[string]*models.Category, len(ancestors))
+		for _, a := range ancestors {
+			byID[a.ID.Hex()] = a
+		}
+		for _, id := range category.Ancestors {
+			if a, ok := byID[id.Hex()]; ok {
+				chain = append(chain, a)
+			}
+		}
+	}
+	chain = append(chain, category)
+
+	schema := []models.AttributeSchema{}
+	index := map[string]int{}
+	for _, c := range chain {
+		for _, a := range c.Attributes {
+			if i, ok := index[a.Name]; ok {
+				schema[i] = a
+				continue
+			}
+			index[a.Name] = len(schema)
+			schema = append(schema, a)
+		}
+	}
+	return schema, nil
+}
@@ -0,0 +1,335 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"vk-inter/internal/models"
+	"vk-inter/pkg/errs"
+	"vk-inter/pkg/logger"
+	"vk-inter/pkg/money"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.uber.org/zap"
+)
+
+const (
+	// Ставка, проигравшая гонку, пересчитывается по новому состоянию аукциона
+	maxBidAttempts     = 3
+	auctionCloseBatch  = 100
+	defaultBidsPerPage = 50
+)
+
+// Clock returns current time, tests replace it to control auction deadlines
+type Clock interface {
+	Now() time.Time
+}
+
+type SystemClock struct{}
+
+func (SystemClock) Now() time.Time {
+	return time.Now()
+}
+
+type AuctionConfig struct {
+	MinDuration time.Duration `env:"AUCTION_MIN_DURATION" env-default:"1h"`
+	MaxDuration time.Duration `env:"AUCTION_MAX_DURATION" env-default:"720h"`
+	// MaxExtension limits anti-sniping extension of the auction end
+	MaxExtension  time.Duration `env:"AUCTION_MAX_EXTENSION" env-default:"1h"`
+	CloseInterval time.Duration `env:"AUCTION_CLOSE_INTERVAL" env-default:"5s"`
+}
+
+type AuctionRepo interface {
+	PlaceBid(ctx context.Context, bid *models.Bid, seenBids int64, endsAt, now time.Time) (*models.Listing, error)
+	InsertBid(ctx context.Context, bid *models.Bid) error
+	GetBids(ctx context.Context, listingID primitive.ObjectID, limit int) ([]*models.Bid, error)
+	GetEndedAuctions(ctx context.Context, now time.Time, limit int) ([]*models.Listing, error)
+	CloseAuction(ctx context.Context, id primitive.ObjectID, seenBids int64, winnerID *primitive.ObjectID, now time.Time) (*models.Listing, error)
+}
+
+type AuctionListingRepo interface {
+	GetListingByID(ctx context.Context, id primitive.ObjectID) (*models.Listing, error)
+}
+
+// AuctionService runs timed auctions.
+//
+// A bid is accepted only if the auction has not changed since the bid was validated,
+// so of two concurrent bids one is recalculated against the other. Ended auctions are
+// closed by Run, the leader wins if the reserve price is met and the listing becomes reserved.
+type AuctionService struct {
+	repo          AuctionRepo
+	listingRepo   AuctionListingRepo
+	notifications NotificationCreator
+	events        EventPublisher
+	clock         Clock
+	cfg           AuctionConfig
+}
+
+func NewAuctionService(repo AuctionRepo, listingRepo AuctionListingRepo, notifications NotificationCreator, events EventPublisher, clock Clock, cfg AuctionConfig) *AuctionService {
+	return &AuctionService{
+		repo:          repo,
+		listingRepo:   listingRepo,
+		notifications: notifications,
+		events:        events,
+		clock:         clock,
+		cfg:           cfg,
+	}
+}
+
+// PrepareAuction validates sale mode of the new listing, the listing price is the start price of the auction
+func (as *AuctionService) PrepareAuction(listing *models.Listing) error {
+	switch listing.SaleMode {
+	case "", models.SaleModeFixed:
+		listing.SaleMode = models.SaleModeFixed
+		listing.Auction = nil
+		return nil
+	case models.SaleModeAuction:
+	default:
+		return errs.ErrListingInvalidSaleMode
+	}
+
+	a := listing.Auction
+	if a == nil {
+		return fmt.Errorf("%w: auction settings are required", errs.ErrListingInvalidAuction)
+	}
+	if !listing.Price.IsPositive() {
+		return fmt.Errorf("%w: start price must be positive", errs.ErrListingInvalidAuction)
+	}
+	increment, ok := auctionAmount(a.MinIncrement, listing.Currency)
+	if !ok {
+		return fmt.Errorf("%w: invalid min_increment", errs.ErrListingInvalidAuction)
+	}
+	var reserve *money.Decimal
+	if a.ReservePrice != nil {
+		r, ok := auctionAmount(*a.ReservePrice, listing.Currency)
+		if !ok || r.LessThan(listing.Price.Decimal) {
+			return fmt.Errorf("%w: reserve_price must not be less than the start price", errs.ErrListingInvalidAuction)
+		}
+		reserve = &r
+	}
+
+	duration := a.EndsAt.Sub(as.clock.Now())
+	if duration < as.cfg.MinDuration || duration > as.cfg.MaxDuration {
+		return fmt.Errorf("%w: auction must last from %s to %s", errs.ErrListingInvalidAuction, as.cfg.MinDuration, as.cfg.MaxDuration)
+	}
+	if a.ExtendSeconds < 0 || time.Duration(a.ExtendSeconds)*time.Second > as.cfg.MaxExtension {
+		return fmt.Errorf("%w: extend_seconds must be from 0 to %d", errs.ErrListingInvalidAuction, int(as.cfg.MaxExtension.Seconds()))
+	}
+
+	listing.Auction = &models.Auction{
+		ReservePrice:  reserve,
+		MinIncrement:  increment,
+		EndsAt:        a.EndsAt.UTC().Truncate(time.Millisecond),
+		ExtendSeconds: a.ExtendSeconds,
+		Status:        models.AuctionStatusOpen,
+	}
+	return nil
+}
+
+// PlaceBid places the bid on the auction listing
+func (as *AuctionService) PlaceBid(ctx context.Context, listingID primitive.ObjectID, amount money.Decimal, user *models.User) (*models.BidResult, error) {
+	for attempt := 1; ; attempt++ {
+		listing, err := as.listingRepo.GetListingByID(ctx, listingID)
+		if err != nil {
+			return nil, err
+		}
+		result, previous, err := as.placeBid(ctx, listing, amount, user)
+		if errors.Is(err, errs.ErrBidConflict) && attempt < maxBidAttempts {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		if previous != nil && *previous != user.ID {
+			as.notify(ctx, *previous, listing,
+				fmt.Sprintf("Your bid on %q was outbid: %s %s", listing.Title, result.Bid.Amount, result.Bid.Currency),
+				"outbid:"+result.Bid.ID.Hex())
+		}
+		return result, nil
+	}
+}
+
+// placeBid validates the bid against the observed auction state and returns the previous leader
+func (as *AuctionService) placeBid(ctx context.Context, listing *models.Listing, amount money.Decimal, user *models.User) (*models.BidResult, *primitive.ObjectID, error) {
+	if !listing.IsPublic() {
+		return nil, nil, errs.ErrListingNotFound
+	}
+	if !listing.IsAuction() || listing.Auction == nil {
+		return nil, nil, errs.ErrListingNotAuction
+	}
+	now := as.clock.Now()
+	a := listing.Auction
+	if a.Status != models.AuctionStatusOpen || !now.Before(a.EndsAt) {
+		return nil, nil, errs.ErrAuctionEnded
+	}
+	if listing.Status != models.ListingStatusPublished {
+		return nil, nil, errs.ErrListingNotAvailable
+	}
+	if listing.OwnerID == user.ID {
+		return nil, nil, errs.ErrBidOwnListing
+	}
+	amount, ok := auctionAmount(amount, listing.Currency)
+	if !ok {
+		return nil, nil, errs.ErrBidInvalidAmount
+	}
+	minimum := minimumBid(listing)
+	if amount.LessThan(minimum.Decimal) {
+		return nil, nil, fmt.Errorf("%w: minimum is %s %s", errs.ErrBidTooLow, minimum, listing.Currency)
+	}
+
+	// Ставка в последние секунды продлевает аукцион, чтобы другие успели ответить
+	endsAt := a.EndsAt
+	if extension := time.Duration(a.ExtendSeconds) * time.Second; endsAt.Sub(now) < extension {
+		endsAt = now.Add(extension).Truncate(time.Millisecond)
+	}
+
+	bid := &models.Bid{
+		ListingID:   listing.ID,
+		BidderID:    user.ID,
+		BidderLogin: user.Login,
+		Amount:      amount,
+		Currency:    listing.Currency,
+		CreatedAt:   now,
+	}
+	updated, err := as.repo.PlaceBid(ctx, bid, a.BidsCount, endsAt, now)
+	if err != nil {
+		return nil, nil, err
+	}
+	// Ставка уже принята аукционом, без записи в истории она остаётся в силе
+	if err := as.repo.InsertBid(ctx, bid); err != nil {
+		logger.FromContext(ctx).Warn("Save bid error", zap.String("listing_id", listing.ID.Hex()), zap.Error(err))
+	}
+	return &models.BidResult{Bid: bid, Auction: updated.Auction}, a.LeaderID, nil
+}
+
+// GetBids returns bids of the auction, the highest first
+func (as *AuctionService) GetBids(ctx context.Context, listingID primitive.ObjectID, limit int) ([]*models.Bid, error) {
+	listing, err := as.listingRepo.GetListingByID(ctx, listingID)
+	if err != nil {
+		return nil, err
+	}
+	if !listing.IsPublic() {
+		return nil, errs.ErrListingNotFound
+	}
+	if !listing.IsAuction() {
+		return nil, errs.ErrListingNotAuction
+	}
+	if limit < 1 || limit > 100 {
+		limit = defaultBidsPerPage
+	}
+	return as.repo.GetBids(ctx, listingID, limit)
+}
+
+// Run closes ended auctions and blocks until ctx is done
+func (as *AuctionService) Run(ctx context.Context) {
+	ticker := time.NewTicker(max(as.cfg.CloseInterval, time.Second))
+	defer ticker.Stop()
+	for {
+		closed, err := as.CloseEnded(ctx)
+		if err != nil && ctx.Err() == nil {
+			logger.FromContext(ctx).Warn("Close auctions error", zap.Error(err))
+		}
+		if closed > 0 {
+			logger.FromContext(ctx).Debug("Auctions closed", zap.Int("count", closed))
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// CloseEnded closes auctions ended by now, returns the number of closed auctions
+func (as *AuctionService) CloseEnded(ctx context.Context) (int, error) {
+	now := as.clock.Now()
+	listings, err := as.repo.GetEndedAuctions(ctx, now, auctionCloseBatch)
+	if err != nil {
+		return 0, err
+	}
+
+	closed := 0
+	for _, listing := range listings {
+		ok, err := as.closeAuction(ctx, listing, now)
+		if err != nil {
+			logger.FromContext(ctx).Warn("Close auction error", zap.String("listing_id", listing.ID.Hex()), zap.Error(err))
+			continue
+		}
+		if ok {
+			closed++
+		}
+	}
+	return closed, nil
+}
+
+func (as *AuctionService) closeAuction(ctx context.Context, listing *models.Listing, now time.Time) (bool, error) {
+	a := listing.Auction
+	reserveMet := a.CurrentBid != nil && (a.ReservePrice == nil || !a.CurrentBid.LessThan(a.ReservePrice.Decimal))
+	var winnerID *primitive.ObjectID
+	if reserveMet {
+		winnerID = a.LeaderID
+	}
+
+	closed, err := as.repo.CloseAuction(ctx, listing.ID, a.BidsCount, winnerID, now)
+	if errors.Is(err, errs.ErrBidConflict) {
+		// Ставка в последний момент продлила аукцион, он закроется позже
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+
+	switch {
+	case winnerID != nil:
+		price := fmt.Sprintf("%s %s", a.CurrentBid, closed.Currency)
+		as.notify(ctx, *winnerID, closed, fmt.Sprintf("You won the auction %q for %s", closed.Title, price), "won")
+		as.notify(ctx, closed.OwnerID, closed, fmt.Sprintf("Auction %q is won by %s for %s", closed.Title, a.LeaderLogin, price), "ended")
+	case a.LeaderID != nil:
+		as.notify(ctx, *a.LeaderID, closed, fmt.Sprintf("Auction %q ended, your bid did not meet the reserve price", closed.Title), "lost")
+		as.notify(ctx, closed.OwnerID, closed, fmt.Sprintf("Auction %q ended, the reserve price was not met", closed.Title), "ended")
+	default:
+		as.notify(ctx, closed.OwnerID, closed, fmt.Sprintf("Auction %q ended without bids", closed.Title), "ended")
+	}
+	if closed.Status == models.ListingStatusReserved {
+		as.events.Publish(ctx, closed.OwnerID, models.EventTypeListingStatus, &models.ListingStatusEvent{
+			ListingID: closed.ID,
+			Status:    closed.Status,
+		})
+	}
+	return true, nil
+}
+
+func (as *AuctionService) notify(ctx context.Context, userID primitive.ObjectID, listing *models.Listing, title, event string) {
+	_, err := as.notifications.CreateNotification(ctx, &models.Notification{
+		UserID:    userID,
+		Type:      models.NotificationTypeAuction,
+		Title:     title,
+		ListingID: &listing.ID,
+		DedupKey:  "auction:" + listing.ID.Hex() + ":" + event + ":" + userID.Hex(),
+	})
+	if err != nil {
+		logger.FromContext(ctx).Warn("Create auction notification error", zap.String("listing_id", listing.ID.Hex()), zap.Error(err))
+	}
+}
+
+// minimumBid is the start price for the first bid, then the current bid plus the increment
+func minimumBid(listing *models.Listing) money.Decimal {
+	a := listing.Auction
+	if a.CurrentBid == nil {
+		return listing.Price
+	}
+	return money.New(a.CurrentBid.Add(a.MinIncrement.Decimal))
+}
+
+// auctionAmount validates amount in the listing currency
+func auctionAmount(amount money.Decimal, currency string) (money.Decimal, bool) {
+	units := money.MinorUnits(currency)
+	if !amount.IsPositive() || amount.GreaterThan(maxPrice.Decimal) || amount.Scale() > units {
+		return money.Zero, false
+	}
+	return money.New(amount.Round(units)), true
+}
@@ -0,0 +1,410 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"vk-inter/internal/models"
+	"vk-inter/pkg/errs"
+	"vk-inter/pkg/logger"
+	"vk-inter/pkg/money"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type fakeClock struct {
+	mu  sync.Mutex
+	now time.Time
+}
+
+func (c *fakeClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+func (c *fakeClock) Advance(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.now = c.now.Add(d)
+}
+
+// fakeAuctionRepo keeps listings in memory and applies the same conditions as the Mongo filters
+type fakeAuctionRepo struct {
+	mu       sync.Mutex
+	listings map[primitive.ObjectID]*models.Listing
+	bids     []*models.Bid
+	// accepted counts accepted bids by bids_count they were validated against
+	accepted map[int64]int
+}
+
+func newFakeAuctionRepo(listings ...*models.Listing) *fakeAuctionRepo {
+	r := &fakeAuctionRepo{listings: map[primitive.ObjectID]*models.Listing{}, accepted: map[int64]int{}}
+	for _, l := range listings {
+		r.listings[l.ID] = l
+	}
+	return r
+}
+
+func copyListing(l *models.Listing) *models.Listing {
+	c := *l
+	if l.Auction != nil {
+		a := *l.Auction
+		c.Auction = &a
+	}
+	return &c
+}
+
+func (r *fakeAuctionRepo) GetListingByID(_ context.Context, id primitive.ObjectID) (*models.Listing, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	l, ok := r.listings[id]
+	if !ok {
+		return nil, errs.ErrListingNotFound
+	}
+	return copyListing(l), nil
+}
+
+func (r *fakeAuctionRepo) PlaceBid(_ context.Context, bid *models.Bid, seenBids int64, endsAt, now time.Time) (*models.Listing, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	l, ok := r.listings[bid.ListingID]
+	if !ok || l.Status != models.ListingStatusPublished || l.Auction.Status != models.AuctionStatusOpen ||
+		l.Auction.BidsCount != seenBids || !l.Auction.EndsAt.After(now) {
+		return nil, errs.ErrBidConflict
+	}
+	bid.ID = primitive.NewObjectID()
+	amount := bid.Amount
+	leader := bid.BidderID
+	l.Auction.CurrentBid = &amount
+	l.Auction.LeaderID = &leader
+	l.Auction.LeaderLogin = bid.BidderLogin
+	l.Auction.EndsAt = endsAt
+	l.Auction.BidsCount++
+	r.accepted[seenBids]++
+	return copyListing(l), nil
+}
+
+func (r *fakeAuctionRepo) InsertBid(_ context.Context, bid *models.Bid) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.bids = append(r.bids, bid)
+	return nil
+}
+
+func (r *fakeAuctionRepo) GetBids(_ context.Context, listingID primitive.ObjectID, limit int) ([]*models.Bid, error) {
+	return nil, nil
+}
+
+func (r *fakeAuctionRepo) GetEndedAuctions(_ context.Context, now time.Time, limit int) ([]*models.Listing, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var ended []*models.Listing
+	for _, l := range r.listings {
+		if l.Auction != nil && l.Auction.Status == models.AuctionStatusOpen && !l.Auction.EndsAt.After(now) {
+			ended = append(ended, copyListing(l))
+		}
+	}
+	return ended, nil
+}
+
+func (r *fakeAuctionRepo) CloseAuction(_ context.Context, id primitive.ObjectID, seenBids int64, winnerID *primitive.ObjectID, now time.Time) (*models.Listing, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	l, ok := r.listings[id]
+	if !ok || l.Auction.Status != models.AuctionStatusOpen || l.Auction.BidsCount != seenBids || l.Auction.EndsAt.After(now) {
+		return nil, errs.ErrBidConflict
+	}
+	l.Auction.Status = models.AuctionStatusClosed
+	l.Auction.ClosedAt = &now
+	if winnerID != nil {
+		l.Auction.WinnerID = winnerID
+		if l.Status == models.ListingStatusPublished {
+			l.Status = models.ListingStatusReserved
+			l.BuyerID = winnerID
+		}
+	}
+	return copyListing(l), nil
+}
+
+type fakeNotifications struct {
+	mu    sync.Mutex
+	users map[primitive.ObjectID][]string
+}
+
+func (n *fakeNotifications) CreateNotification(_ context.Context, notification *models.Notification) (bool, error) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	if n.users == nil {
+		n.users = map[primitive.ObjectID][]string{}
+	}
+	n.users[notification.UserID] = append(n.users[notification.UserID], notification.Title)
+	return true, nil
+}
+
+type fakeEvents struct{}
+
+func (fakeEvents) Publish(context.Context, primitive.ObjectID, string, any) {}
+
+var auctionTestConfig = AuctionConfig{
+	MinDuration:  time.Hour,
+	MaxDuration:  720 * time.Hour,
+	MaxExtension: time.Hour,
+}
+
+func testContext() context.Context {
+	return context.WithValue(context.Background(), logger.LoggerKey, logger.New(false))
+}
+
+func mustDecimal(t *testing.T, s string) money.Decimal {
+	t.Helper()
+	d, err := money.NewFromString(s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return d
+}
+
+func newAuctionListing(clock *fakeClock, endsIn time.Duration, extendSeconds int) *models.Listing {
+	return &models.Listing{
+		ID:       primitive.NewObjectID(),
+		OwnerID:  primitive.NewObjectID(),
+		Title:    "Лот",
+		Price:    money.NewFromInt(1000),
+		Currency: "RUB",
+		Status:   models.ListingStatusPublished,
+		SaleMode: models.SaleModeAuction,
+		Auction: &models.Auction{
+			MinIncrement:  money.NewFromInt(100),
+			EndsAt:        clock.Now().Add(endsIn),
+			ExtendSeconds: extendSeconds,
+			Status:        models.AuctionStatusOpen,
+		},
+	}
+}
+
+func newAuctionTestService(clock *fakeClock, listings ...*models.Listing) (*AuctionService, *fakeAuctionRepo, *fakeNotifications) {
+	repo := newFakeAuctionRepo(listings...)
+	notifications := &fakeNotifications{}
+	return NewAuctionService(repo, repo, notifications, fakeEvents{}, clock, auctionTestConfig), repo, notifications
+}
+
+func bidder(login string) *models.User {
+	return &models.User{ID: primitive.NewObjectID(), Login: login}
+}
+
+func TestPrepareAuctionEndTime(t *testing.T) {
+	clock := &fakeClock{now: time.Date(2026, 5, 1, 12, 0, 0, 0, time.UTC)}
+	as, _, _ := newAuctionTestService(clock)
+
+	tests := []struct {
+		name   string
+		endsIn time.Duration
+		extend int
+		ok     bool
+	}{
+		{name: "min duration", endsIn: time.Hour, ok: true},
+		{name: "max duration", endsIn: 720 * time.Hour, ok: true},
+		{name: "too short", endsIn: 59 * time.Minute},
+		{name: "in the past", endsIn: -time.Minute},
+		{name: "too long", endsIn: 721 * time.Hour},
+		{name: "max extension", endsIn: 2 * time.Hour, extend: 3600, ok: true},
+		{name: "too long extension", endsIn: 2 * time.Hour, extend: 3601},
+		{name: "negative extension", endsIn: 2 * time.Hour, extend: -1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			listing := &models.Listing{
+				Price:    money.NewFromInt(1000),
+				Currency: "RUB",
+				SaleMode: models.SaleModeAuction,
+				Auction: &models.Auction{
+					MinIncrement:  money.NewFromInt(100),
+					EndsAt:        clock.Now().Add(tt.endsIn),
+					ExtendSeconds: tt.extend,
+				},
+			}
+			err := as.PrepareAuction(listing)
+			if !tt.ok {
+				if !errors.Is(err, errs.ErrListingInvalidAuction) {
+					t.Fatalf("PrepareAuction() = %v, want ErrListingInvalidAuction", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("PrepareAuction() = %v", err)
+			}
+			if want := clock.Now().Add(tt.endsIn); !listing.Auction.EndsAt.Equal(want) {
+				t.Fatalf("EndsAt = %v, want %v", listing.Auction.EndsAt, want)
+			}
+			if listing.Auction.Status != models.AuctionStatusOpen {
+				t.Fatalf("Status = %q, want open", listing.Auction.Status)
+			}
+		})
+	}
+}
+
+func TestPlaceBidAfterEnd(t *testing.T) {
+	clock := &fakeClock{now: time.Date(2026, 5, 1, 12, 0, 0, 0, time.UTC)}
+	listing := newAuctionListing(clock, time.Hour, 0)
+	as, _, _ := newAuctionTestService(clock, listing)
+	ctx := testContext()
+
+	if _, err := as.PlaceBid(ctx, listing.ID, mustDecimal(t, "1000"), bidder("first")); err != nil {
+		t.Fatalf("PlaceBid() before end = %v", err)
+	}
+	clock.Advance(time.Hour)
+	_, err := as.PlaceBid(ctx, listing.ID, mustDecimal(t, "2000"), bidder("late"))
+	if !errors.Is(err, errs.ErrAuctionEnded) {
+		t.Fatalf("PlaceBid() at end = %v, want ErrAuctionEnded", err)
+	}
+}
+
+func TestPlaceBidAntiSniping(t *testing.T) {
+	tests := []struct {
+		name     string
+		endsIn   time.Duration
+		extend   int
+		extended bool
+	}{
+		{name: "early bid", endsIn: 10 * time.Minute, extend: 120},
+		{name: "bid at the extension bound", endsIn: 2 * time.Minute, extend: 120},
+		{name: "last seconds bid", endsIn: 30 * time.Second, extend: 120, extended: true},
+		{name: "extension disabled", endsIn: 30 * time.Second},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			clock := &fakeClock{now: time.Date(2026, 5, 1, 12, 0, 0, 0, time.UTC)}
+			listing := newAuctionListing(clock, tt.endsIn, tt.extend)
+			as, _, _ := newAuctionTestService(clock, listing)
+
+			result, err := as.PlaceBid(testContext(), listing.ID, mustDecimal(t, "1000"), bidder("sniper"))
+			if err != nil {
+				t.Fatalf("PlaceBid() = %v", err)
+			}
+			want := listing.Auction.EndsAt
+			if tt.extended {
+				want = clock.Now().Add(time.Duration(tt.extend) * time.Second)
+			}
+			if !result.Auction.EndsAt.Equal(want) {
+				t.Fatalf("EndsAt = %v, want %v", result.Auction.EndsAt, want)
+			}
+		})
+	}
+}
+
+func TestCloseEnded(t *testing.T) {
+	clock := &fakeClock{now: time.Date(2026, 5, 1, 12, 0, 0, 0, time.UTC)}
+	reserve := money.NewFromInt(1500)
+	sold := newAuctionListing(clock, time.Hour, 120)
+	sold.Auction.ReservePrice = &reserve
+	unsold := newAuctionListing(clock, time.Hour, 0)
+	unsold.Auction.ReservePrice = &reserve
+	empty := newAuctionListing(clock, time.Hour, 0)
+	as, repo, notifications := newAuctionTestService(clock, sold, unsold, empty)
+	ctx := testContext()
+
+	winner, loser := bidder("winner"), bidder("loser")
+	if _, err := as.PlaceBid(ctx, unsold.ID, mustDecimal(t, "1200"), loser); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := as.PlaceBid(ctx, sold.ID, mustDecimal(t, "1200"), loser); err != nil {
+		t.Fatal(err)
+	}
+	// Ставка за минуту до конца продлевает аукцион на две минуты
+	clock.Advance(59 * time.Minute)
+	if _, err := as.PlaceBid(ctx, sold.ID, mustDecimal(t, "1500"), winner); err != nil {
+		t.Fatal(err)
+	}
+
+	clock.Advance(time.Minute)
+	closed, err := as.CloseEnded(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if closed != 2 {
+		t.Fatalf("closed = %d at the original end, want 2", closed)
+	}
+	if got, _ := repo.GetListingByID(ctx, sold.ID); got.Auction.Status != models.AuctionStatusOpen {
+		t.Fatalf("extended auction is %q, want open", got.Auction.Status)
+	}
+
+	clock.Advance(time.Minute)
+	if closed, err = as.CloseEnded(ctx); err != nil || closed != 1 {
+		t.Fatalf("CloseEnded() after extension = %d, %v, want 1", closed, err)
+	}
+	got, _ := repo.GetListingByID(ctx, sold.ID)
+	if got.Auction.WinnerID == nil || *got.Auction.WinnerID != winner.ID {
+		t.Fatalf("winner = %v, want %s", got.Auction.WinnerID, winner.ID.Hex())
+	}
+	if got.Status != models.ListingStatusReserved {
+		t.Fatalf("status = %q, want reserved", got.Status)
+	}
+
+	got, _ = repo.GetListingByID(ctx, unsold.ID)
+	if got.Auction.Status != models.AuctionStatusClosed || got.Auction.WinnerID != nil || got.Status != models.ListingStatusPublished {
+		t.Fatalf("auction below reserve: status %q, winner %v, listing %q", got.Auction.Status, got.Auction.WinnerID, got.Status)
+	}
+	got, _ = repo.GetListingByID(ctx, empty.ID)
+	if got.Auction.Status != models.AuctionStatusClosed || got.Auction.WinnerID != nil {
+		t.Fatalf("auction without bids: status %q, winner %v", got.Auction.Status, got.Auction.WinnerID)
+	}
+
+	if len(notifications.users[winner.ID]) != 1 {
+		t.Fatalf("winner notifications = %v, want one", notifications.users[winner.ID])
+	}
+	// Проигравший получил уведомление о перебитой ставке и о непройденном резерве
+	if len(notifications.users[loser.ID]) != 2 {
+		t.Fatalf("loser notifications = %v, want two", notifications.users[loser.ID])
+	}
+
+	if closed, err = as.CloseEnded(ctx); err != nil || closed != 0 {
+		t.Fatalf("CloseEnded() again = %d, %v, want 0", closed, err)
+	}
+}
+
+func TestPlaceBidConcurrent(t *testing.T) {
+	clock := &fakeClock{now: time.Date(2026, 5, 1, 12, 0, 0, 0, time.UTC)}
+	listing := newAuctionListing(clock, time.Hour, 0)
+	as, repo, _ := newAuctionTestService(clock, listing)
+	ctx := testContext()
+
+	const bidders = 20
+	var wg sync.WaitGroup
+	results := make(chan error, bidders)
+	for range bidders {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := as.PlaceBid(ctx, listing.ID, mustDecimal(t, "1000"), bidder("bidder"))
+			results <- err
+		}()
+	}
+	wg.Wait()
+	close(results)
+
+	won := 0
+	for err := range results {
+		switch {
+		case err == nil:
+			won++
+		case !errors.Is(err, errs.ErrBidTooLow) && !errors.Is(err, errs.ErrBidConflict):
+			t.Fatalf("PlaceBid() = %v, want ErrBidTooLow or ErrBidConflict", err)
+		}
+	}
+	if won != 1 {
+		t.Fatalf("%d bids of the same amount won, want 1", won)
+	}
+	for count, n := range repo.accepted {
+		if n != 1 {
+			t.Fatalf("%d bids accepted at bids_count %d, want 1", n, count)
+		}
+	}
+	got, _ := repo.GetListingByID(ctx, listing.ID)
+	if got.Auction.BidsCount != 1 || len(repo.bids) != 1 {
+		t.Fatalf("bids_count = %d, history = %d, want 1", got.Auction.BidsCount, len(repo.bids))
+	}
+}
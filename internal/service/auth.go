@@ -52,6 +52,9 @@ func (as *AuthService) LogIn(ctx context.Context, login, password string) (strin
 		}
 		return "", 0, err
 	}
+	if _, err := as.GetUserById(ctx, id); err != nil {
+		return "", 0, err
+	}
 	duration := time.Hour * 48
 	token, err := jwt.NewAccessToken(id, as.secret, duration)
 	if err != nil {
@@ -69,5 +72,9 @@ func (as *AuthService) GetUserById(ctx context.Context, id string) (*models.User
 	if err != nil {
 		return nil, err
 	}
+	// Токены забаненных пользователей перестают действовать сразу
+	if user.BannedAt != nil {
+		return nil, errs.ErrUserBanned
+	}
 	return user, nil
 }
@@ -0,0 +1,183 @@
+package service
+
+import (
+	"context"
+	"strings"
+
+	"vk-inter/internal/models"
+	"vk-inter/pkg/errs"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type CategoryRepo interface {
+	CreateCategory(ctx context.Context, category *models.Category) (*models.Category, error)
+	UpdateCategory(ctx context.Context, category *models.Category) (*models.Category, error)
+	DeleteCategory(ctx context.Context, id primitive.ObjectID) error
+	GetByID(ctx context.Context, id primitive.ObjectID) (*models.Category, error)
+	GetByIDs(ctx context.Context, ids []primitive.ObjectID) ([]*models.Category, error)
+	GetAll(ctx context.Context) ([]*models.Category, error)
+	GetDescendants(ctx context.Context, id primitive.ObjectID) ([]*models.Category, error)
+	HasChildren(ctx context.Context, id primitive.ObjectID) (bool, error)
+}
+
+type CategoryListingRepo interface {
+	CountByCategory(ctx context.Context) (map[primitive.ObjectID]int64, error)
+	CountInCategory(ctx context.Context, categoryID primitive.ObjectID) (int64, error)
+}
+
+type CategoryService struct {
+	repo        CategoryRepo
+	listingRepo CategoryListingRepo
+}
+
+func NewCategoryService(repo CategoryRepo, listingRepo CategoryListingRepo) *CategoryService {
+	return &CategoryService{
+		repo:        repo,
+		listingRepo: listingRepo,
+	}
+}
+
+func (cs *CategoryService) CreateCategory(ctx context.Context, name, slug string, parentID *primitive.ObjectID, attributes []models.AttributeSchema, user *models.User) (*models.Category, error) {
+	if !user.IsAdmin() {
+		return nil, errs.ErrForbidden
+	}
+	if err := ValidateAttributeSchema(attributes); err != nil {
+		return nil, err
+	}
+
+	category := models.Category{
+		Name:       strings.TrimSpace(name),
+		Slug:       strings.ToLower(strings.TrimSpace(slug)),
+		Attributes: attributes,
+	}
+	if parentID != nil {
+		parent, err := cs.repo.GetByID(ctx, *parentID)
+		if err != nil {
+			return nil, err
+		}
+		category.ParentID = &parent.ID
+		category.Ancestors = append(append([]primitive.ObjectID{}, parent.Ancestors...), parent.ID)
+	}
+
+	return cs.repo.CreateCategory(ctx, &category)
+}
+
+func (cs *CategoryService) UpdateCategory(ctx context.Context, id primitive.ObjectID, name, slug string, parentID *primitive.ObjectID, attributes []models.AttributeSchema, user *models.User) (*models.Category, error) {
+	if !user.IsAdmin() {
+		return nil, errs.ErrForbidden
+	}
+	if err := ValidateAttributeSchema(attributes); err != nil {
+		return nil, err
+	}
+
+	category, err := cs.repo.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	category.Name = strings.TrimSpace(name)
+	category.Slug = strings.ToLower(strings.TrimSpace(slug))
+	category.Attributes = attributes
+	category.ParentID = nil
+	category.Ancestors = []primitive.ObjectID{}
+
+	if parentID != nil {
+		if *parentID == id {
+			return nil, errs.ErrCategoryCycle
+		}
+		parent, err := cs.repo.GetByID(ctx, *parentID)
+		if err != nil {
+			return nil, err
+		}
+		// Нельзя переносить категорию в её же поддерево
+		for _, a := range parent.Ancestors {
+			if a == id {
+				return nil, errs.ErrCategoryCycle
+			}
+		}
+		category.ParentID = &parent.ID
+		category.Ancestors = append(append([]primitive.ObjectID{}, parent.Ancestors...), parent.ID)
+	}
+
+	return cs.repo.UpdateCategory(ctx, category)
+}
+
+func (cs *CategoryService) DeleteCategory(ctx context.Context, id primitive.ObjectID, user *models.User) error {
+	if !user.IsAdmin() {
+		return errs.ErrForbidden
+	}
+
+	hasChildren, err := cs.repo.HasChildren(ctx, id)
+	if err != nil {
+		return err
+	}
+	if hasChildren {
+		return errs.ErrCategoryNotEmpty
+	}
+
+	count, err := cs.listingRepo.CountInCategory(ctx, id)
+	if err != nil {
+		return err
+	}
+	if count > 0 {
+		return errs.ErrCategoryNotEmpty
+	}
+
+	return cs.repo.DeleteCategory(ctx, id)
+}
+
+// GetAttributes returns attributes schema of the category including inherited attributes
+func (cs *CategoryService) GetAttributes(ctx context.Context, id primitive.ObjectID) ([]models.AttributeSchema, error) {
+	category, err := cs.repo.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	return categoryAttributes(ctx, cs.repo, category)
+}
+
+// GetTree returns root categories with nested children and listing counters
+func (cs *CategoryService) GetTree(ctx context.Context) ([]*models.CategoryNode, error) {
+	categories, err := cs.repo.GetAll(ctx)
+	if err != nil {
+		return nil, err
+	}
+	counts, err := cs.listingRepo.CountByCategory(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	nodes := make(map[primitive.ObjectID]*models.CategoryNode, len(categories))
+	for _, c := range categories {
+		nodes[c.ID] = &models.CategoryNode{
+			Category:     *c,
+			ListingCount: counts[c.ID],
+			Children:     []*models.CategoryNode{},
+		}
+	}
+
+	roots := []*models.CategoryNode{}
+	for _, c := range categories {
+		node := nodes[c.ID]
+		if c.ParentID != nil {
+			if parent, ok := nodes[*c.ParentID]; ok {
+				parent.Children = append(parent.Children, node)
+				continue
+			}
+		}
+		roots = append(roots, node)
+	}
+
+	for _, root := range roots {
+		sumTotals(root)
+	}
+
+	return roots, nil
+}
+
+func sumTotals(node *models.CategoryNode) int64 {
+	node.TotalCount = node.ListingCount
+	for _, child := range node.Children {
+		node.TotalCount += sumTotals(child)
+	}
+	return node.TotalCount
+}
@@ -0,0 +1,111 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"io/fs"
+	"os"
+	"slices"
+	"sync/atomic"
+	"time"
+
+	"vk-inter/internal/models"
+	"vk-inter/pkg/logger"
+	"vk-inter/pkg/textfilter"
+
+	"go.uber.org/zap"
+)
+
+type ContentFilterConfig struct {
+	// RulesFile is JSON file with rules, see package textfilter
+	RulesFile string `env:"CONTENT_RULES_FILE" env-default:"./content_rules.json"`
+	// ReloadInterval is how often the file is checked for changes
+	ReloadInterval time.Duration `env:"CONTENT_RULES_RELOAD_INTERVAL" env-default:"10s"`
+}
+
+// ContentVerdict is the result of checking listing text.
+//
+// Rules are names of matched rules, Flagged listings are published and sent to moderation.
+type ContentVerdict struct {
+	Reject  bool
+	Flagged bool
+	Rules   []string
+}
+
+// ContentFilter checks title and description of listings with rules from the file.
+//
+// The file is reloaded on change, invalid rules are ignored until fixed.
+type ContentFilter struct {
+	cfg     ContentFilterConfig
+	filter  atomic.Pointer[textfilter.Filter]
+	modTime time.Time
+}
+
+func NewContentFilter(ctx context.Context, cfg ContentFilterConfig) *ContentFilter {
+	cf := &ContentFilter{cfg: cfg}
+	cf.filter.Store(&textfilter.Filter{})
+	if err := cf.reload(ctx); err != nil {
+		logger.FromContext(ctx).Warn("Content rules are not loaded, listings are not filtered", zap.String("file", cfg.RulesFile), zap.Error(err))
+	}
+	return cf
+}
+
+// Check applies rules to title and description and masks them in place
+func (cf *ContentFilter) Check(listing *models.Listing) *ContentVerdict {
+	filter := cf.filter.Load()
+	verdict := &ContentVerdict{}
+	for _, text := range []*string{&listing.Title, &listing.Description} {
+		res := filter.Apply(*text)
+		*text = res.Text
+		verdict.Reject = verdict.Reject || res.Reject
+		verdict.Flagged = verdict.Flagged || res.Flagged
+		for _, rule := range res.Rules {
+			if !slices.Contains(verdict.Rules, rule) {
+				verdict.Rules = append(verdict.Rules, rule)
+			}
+		}
+	}
+	return verdict
+}
+
+// Run reloads rules when the file changes
+func (cf *ContentFilter) Run(ctx context.Context) {
+	ticker := time.NewTicker(max(cf.cfg.ReloadInterval, time.Second))
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		// Об отсутствии файла предупреждает конструктор
+		if err := cf.reload(ctx); err != nil && !errors.Is(err, fs.ErrNotExist) {
+			logger.FromContext(ctx).Warn("Reload content rules error", zap.String("file", cf.cfg.RulesFile), zap.Error(err))
+		}
+	}
+}
+
+func (cf *ContentFilter) reload(ctx context.Context) error {
+	info, err := os.Stat(cf.cfg.RulesFile)
+	if err != nil {
+		return err
+	}
+	if info.ModTime().Equal(cf.modTime) {
+		return nil
+	}
+	// Ошибочный файл не перечитывается, пока его не изменят
+	cf.modTime = info.ModTime()
+
+	data, err := os.ReadFile(cf.cfg.RulesFile)
+	if err != nil {
+		return err
+	}
+	filter, err := textfilter.Parse(data)
+	if err != nil {
+		return err
+	}
+	cf.filter.Store(filter)
+	logger.FromContext(ctx).Info("Content rules loaded", zap.Int("rules", filter.Len()))
+	return nil
+}
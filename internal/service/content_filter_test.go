@@ -0,0 +1,98 @@
+package service
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+	"time"
+
+	"vk-inter/internal/models"
+)
+
+func writeRules(t *testing.T, path, data string, modTime time.Time) {
+	t.Helper()
+	if err := os.WriteFile(path, []byte(data), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	// Время изменения задаётся явно, иначе быстрые перезаписи не отличить
+	if err := os.Chtimes(path, modTime, modTime); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestContentFilterReload(t *testing.T) {
+	ctx := testContext()
+	path := filepath.Join(t.TempDir(), "rules.json")
+	modTime := time.Now().Add(-time.Hour)
+	writeRules(t, path, `{"rules": [{"name": "drugs", "type": "stop_words", "action": "reject", "words": ["наркотик"]}]}`, modTime)
+
+	cf := NewContentFilter(ctx, ContentFilterConfig{RulesFile: path})
+
+	tests := []struct {
+		name string
+		// file is new content of the rules file, empty keeps the file
+		file    string
+		wantErr bool
+		title   string
+		reject  bool
+		flagged bool
+		rules   []string
+	}{
+		{name: "initial rules", title: "Продам наркотики", reject: true, rules: []string{"drugs"}},
+		{name: "not listed word", title: "Продам оружие"},
+		{
+			name:  "changed file",
+			file:  `{"rules": [{"name": "weapons", "type": "stop_words", "action": "flag", "words": ["оружие"]}]}`,
+			title: "Продам оружие", flagged: true, rules: []string{"weapons"},
+		},
+		{name: "old rules are replaced", title: "Продам наркотики"},
+		{
+			name:    "invalid file keeps rules",
+			file:    `{"rules": [{"type": "weapons", "action": "flag"}]}`,
+			wantErr: true,
+			title:   "Продам оружие", flagged: true, rules: []string{"weapons"},
+		},
+	}
+	for _, tt := range tests {
+		if tt.file != "" {
+			modTime = modTime.Add(time.Minute)
+			writeRules(t, path, tt.file, modTime)
+			if err := cf.reload(ctx); (err != nil) != tt.wantErr {
+				t.Fatalf("%s: reload() = %v, want error %v", tt.name, err, tt.wantErr)
+			}
+		}
+		v := cf.Check(&models.Listing{Title: tt.title})
+		if v.Reject != tt.reject || v.Flagged != tt.flagged || !slices.Equal(v.Rules, tt.rules) {
+			t.Errorf("%s: Check(%q) = %+v, want reject %v, flagged %v, rules %v", tt.name, tt.title, v, tt.reject, tt.flagged, tt.rules)
+		}
+	}
+
+	// Неизменённый файл не перечитывается
+	if err := cf.reload(ctx); err != nil {
+		t.Fatalf("reload() of unchanged invalid file = %v, want nil", err)
+	}
+}
+
+func TestContentFilterMasksListing(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "rules.json")
+	writeRules(t, path, `{"rules": [{"type": "phone", "action": "mask"}, {"type": "url", "action": "flag"}]}`, time.Now())
+
+	cf := NewContentFilter(testContext(), ContentFilterConfig{RulesFile: path})
+	listing := &models.Listing{Title: "Велосипед", Description: "Звоните 89123456789, фото на avito.ru"}
+	v := cf.Check(listing)
+	if v.Reject || !v.Flagged || !slices.Equal(v.Rules, []string{"phone", "url"}) {
+		t.Fatalf("Check() = %+v, want flagged by phone and url", v)
+	}
+	if want := "Звоните ***********, фото на avito.ru"; listing.Description != want {
+		t.Fatalf("Description = %q, want %q", listing.Description, want)
+	}
+}
+
+func TestContentFilterMissingFile(t *testing.T) {
+	cf := NewContentFilter(testContext(), ContentFilterConfig{RulesFile: filepath.Join(t.TempDir(), "missing.json")})
+	v := cf.Check(&models.Listing{Title: "Продам наркотики"})
+	if v.Reject || v.Flagged || len(v.Rules) != 0 {
+		t.Fatalf("Check() without rules = %+v, want nothing matched", v)
+	}
+}
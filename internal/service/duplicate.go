@@ -0,0 +1,199 @@
+package service
+
+import (
+	"context"
+	"strconv"
+	"time"
+
+	"vk-inter/internal/models"
+	"vk-inter/pkg/errs"
+	"vk-inter/pkg/fingerprint"
+	"vk-inter/pkg/imaging"
+	"vk-inter/pkg/logger"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.uber.org/zap"
+)
+
+const (
+	DuplicateActionReject = "reject"
+	DuplicateActionFlag   = "flag"
+
+	textBands           = 16
+	imageBands          = 4
+	maxDuplicateMatches = 200
+)
+
+type DuplicateConfig struct {
+	// Window limits search of duplicates to listings created recently
+	Window time.Duration `env:"DUPLICATE_WINDOW" env-default:"720h"`
+	// TextThreshold is minimal estimated similarity of title and description, 0..1
+	TextThreshold float64 `env:"DUPLICATE_TEXT_THRESHOLD" env-default:"0.8"`
+	// ImageDistance is maximal number of differing bits of image hashes.
+	// Images within distance 3 are always found, larger distances are found on best effort
+	ImageDistance int `env:"DUPLICATE_IMAGE_DISTANCE" env-default:"5"`
+	// SameOwnerAction is reject or flag
+	SameOwnerAction string `env:"DUPLICATE_SAME_OWNER_ACTION" env-default:"reject"`
+}
+
+type DuplicateListingRepo interface {
+	FindDuplicateCandidates(ctx context.Context, exclude primitive.ObjectID, bands []string, since time.Time, limit int) ([]*models.Listing, error)
+}
+
+type DuplicateReportRepo interface {
+	SaveDuplicateReport(ctx context.Context, report *models.DuplicateReport) error
+	GetDuplicateReports(ctx context.Context, limit int) ([]*models.DuplicateReport, error)
+}
+
+// DuplicateMatch is a listing similar to the checked one
+type DuplicateMatch struct {
+	Listing        *models.Listing
+	TextSimilarity float64
+	// ImageDistance is -1 if no images are similar
+	ImageDistance int
+}
+
+// DuplicateVerdict is the result of duplicates check.
+//
+// DuplicateOf is the earliest similar listing of the same owner, Reject tells
+// whether the new listing must be rejected because of it. Others are similar
+// listings of other owners reported to moderation.
+type DuplicateVerdict struct {
+	DuplicateOf *models.Listing
+	Reject      bool
+	Others      []DuplicateMatch
+}
+
+// DuplicateDetector finds reposts of the same item by text and image fingerprints
+type DuplicateDetector struct {
+	listingRepo DuplicateListingRepo
+	reportRepo  DuplicateReportRepo
+	cfg         DuplicateConfig
+}
+
+func NewDuplicateDetector(listingRepo DuplicateListingRepo, reportRepo DuplicateReportRepo, cfg DuplicateConfig) *DuplicateDetector {
+	cfg.ImageDistance = min(max(cfg.ImageDistance, 0), 64)
+	if cfg.SameOwnerAction != DuplicateActionFlag {
+		cfg.SameOwnerAction = DuplicateActionReject
+	}
+	return &DuplicateDetector{
+		listingRepo: listingRepo,
+		reportRepo:  reportRepo,
+		cfg:         cfg,
+	}
+}
+
+// Check sets listing fingerprint and finds its duplicates
+func (d *DuplicateDetector) Check(ctx context.Context, listing *models.Listing) (*DuplicateVerdict, error) {
+	listing.Fingerprint = listingFingerprint(listing)
+	verdict := &DuplicateVerdict{}
+	if len(listing.Fingerprint.Bands) == 0 {
+		return verdict, nil
+	}
+
+	candidates, err := d.listingRepo.FindDuplicateCandidates(ctx, listing.ID, listing.Fingerprint.Bands,
+		time.Now().Add(-d.cfg.Window), maxDuplicateMatches)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, c := range candidates {
+		match, ok := d.match(listing, c)
+		if !ok {
+			continue
+		}
+		// Повтор считается повтором самого раннего объявления владельца
+		if c.OwnerID == listing.OwnerID {
+			if verdict.DuplicateOf == nil || c.CreatedAt.Before(verdict.DuplicateOf.CreatedAt) {
+				verdict.DuplicateOf = c
+			}
+			verdict.Reject = d.cfg.SameOwnerAction == DuplicateActionReject
+			continue
+		}
+		verdict.Others = append(verdict.Others, match)
+	}
+	return verdict, nil
+}
+
+// Report sends probable duplicates of other owners to moderation
+func (d *DuplicateDetector) Report(ctx context.Context, listing *models.Listing, matches []DuplicateMatch) {
+	for _, m := range matches {
+		report := &models.DuplicateReport{
+			ListingID:        listing.ID,
+			OwnerID:          listing.OwnerID,
+			DuplicateOfID:    m.Listing.ID,
+			DuplicateOwnerID: m.Listing.OwnerID,
+			TextSimilarity:   m.TextSimilarity,
+		}
+		if m.ImageDistance >= 0 {
+			distance := m.ImageDistance
+			report.ImageDistance = &distance
+		}
+		if err := d.reportRepo.SaveDuplicateReport(ctx, report); err != nil {
+			logger.FromContext(ctx).Warn("Save duplicate report error", zap.String("listing_id", listing.ID.Hex()), zap.Error(err))
+		}
+	}
+}
+
+// GetReports returns latest probable duplicates for moderators
+func (d *DuplicateDetector) GetReports(ctx context.Context, limit int, user *models.User) ([]*models.DuplicateReport, error) {
+	if !user.IsModerator() {
+		return nil, errs.ErrForbidden
+	}
+	if limit < 1 || limit > 100 {
+		limit = 50
+	}
+	return d.reportRepo.GetDuplicateReports(ctx, limit)
+}
+
+func (d *DuplicateDetector) match(listing, candidate *models.Listing) (DuplicateMatch, bool) {
+	m := DuplicateMatch{Listing: candidate, ImageDistance: -1}
+	if candidate.Fingerprint != nil {
+		m.TextSimilarity = fingerprint.Similarity(listing.Fingerprint.Text, candidate.Fingerprint.Text)
+	}
+	for _, a := range listing.Images {
+		for _, b := range candidate.Images {
+			if a.PHash == 0 || b.PHash == 0 {
+				continue
+			}
+			dist := imaging.HashDistance(uint64(a.PHash), uint64(b.PHash))
+			if dist <= d.cfg.ImageDistance && (m.ImageDistance < 0 || dist < m.ImageDistance) {
+				m.ImageDistance = dist
+			}
+		}
+	}
+	return m, m.TextSimilarity >= d.cfg.TextThreshold || m.ImageDistance >= 0
+}
+
+// listingFingerprint computes text signature and lookup bands of text and image hashes
+func listingFingerprint(listing *models.Listing) *models.Fingerprint {
+	fp := &models.Fingerprint{Text: fingerprint.MinHash(listing.Title + "\n" + listing.Description)}
+	fp.Bands = fingerprint.Bands(fp.Text, textBands)
+	for _, img := range listing.Images {
+		fp.Bands = append(fp.Bands, imageHashBands(img.PHash)...)
+	}
+	return fp
+}
+
+// imageHashBands returns lookup bands of image perceptual hash, none for unknown hash
+func imageHashBands(phash int64) []string {
+	if phash == 0 {
+		return nil
+	}
+	bands := make([]string, 0, imageBands)
+	for band := range imageBands {
+		// Похожие хэши с расстоянием до 3 совпадают хотя бы в одной 16-битной полосе
+		b := uint64(phash) >> (16 * band) & 0xffff
+		bands = append(bands, "i"+strconv.Itoa(band)+":"+strconv.FormatUint(b, 16))
+	}
+	return bands
+}
+
+// imagePHash returns perceptual hash of encoded image, 0 if it can't be decoded
+func imagePHash(data []byte, maxPixels int) int64 {
+	img, err := imaging.Decode(data, maxPixels)
+	if err != nil {
+		return 0
+	}
+	return int64(imaging.DHash(img))
+}
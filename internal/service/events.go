@@ -0,0 +1,179 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sync"
+	"time"
+
+	"vk-inter/internal/models"
+	"vk-inter/pkg/errs"
+	"vk-inter/pkg/logger"
+	"vk-inter/pkg/pubsub"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.uber.org/zap"
+)
+
+const eventsTopic = "events"
+
+type RealtimeConfig struct {
+	// Buffer is the number of undelivered events after which a slow connection is closed
+	Buffer          int           `env:"REALTIME_BUFFER" env-default:"64"`
+	Heartbeat       time.Duration `env:"REALTIME_HEARTBEAT" env-default:"30s"`
+	MaxConnsPerUser int           `env:"REALTIME_MAX_CONNS_PER_USER" env-default:"5"`
+}
+
+// EventPublisher sends events to user's realtime connections
+type EventPublisher interface {
+	Publish(ctx context.Context, userID primitive.ObjectID, eventType string, data any)
+}
+
+// envelope is an event addressed to the user, as it is sent through pubsub
+type envelope struct {
+	UserID primitive.ObjectID `json:"user_id"`
+	Event  *models.Event      `json:"event"`
+}
+
+type eventStream struct {
+	ch     chan *models.Event
+	closed bool
+}
+
+// EventHub fans out events to realtime connections of users.
+//
+// Events go through pubsub, so a user connected to another instance gets them
+// too. Delivery is best effort: a connection which does not read events fast
+// enough is closed, the client should reconnect and reload state over REST.
+type EventHub struct {
+	pubsub pubsub.PubSub
+	cfg    RealtimeConfig
+
+	mu      sync.Mutex
+	streams map[primitive.ObjectID]map[*eventStream]struct{}
+}
+
+func NewEventHub(ps pubsub.PubSub, cfg RealtimeConfig) *EventHub {
+	return &EventHub{
+		pubsub:  ps,
+		cfg:     cfg,
+		streams: make(map[primitive.ObjectID]map[*eventStream]struct{}),
+	}
+}
+
+// Publish sends event to the user, errors are only logged
+func (h *EventHub) Publish(ctx context.Context, userID primitive.ObjectID, eventType string, data any) {
+	log := logger.FromContext(ctx)
+	raw, err := json.Marshal(data)
+	if err != nil {
+		log.Warn("Marshal event error", zap.String("type", eventType), zap.Error(err))
+		return
+	}
+	payload, err := json.Marshal(envelope{
+		UserID: userID,
+		Event:  &models.Event{Type: eventType, Data: raw, CreatedAt: time.Now()},
+	})
+	if err != nil {
+		log.Warn("Marshal event error", zap.String("type", eventType), zap.Error(err))
+		return
+	}
+	if err := h.pubsub.Publish(ctx, eventsTopic, payload); err != nil {
+		log.Warn("Publish event error", zap.String("type", eventType), zap.Error(err))
+	}
+}
+
+// Subscribe registers a connection of the user.
+//
+// Events channel is closed when the connection falls behind or the hub stops,
+// cancel must be called when the connection is closed.
+func (h *EventHub) Subscribe(ctx context.Context, userID primitive.ObjectID) (<-chan *models.Event, func(), error) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if len(h.streams[userID]) >= h.cfg.MaxConnsPerUser {
+		return nil, nil, fmt.Errorf("%w: up to %d per user", errs.ErrRealtimeTooManyConns, h.cfg.MaxConnsPerUser)
+	}
+	if h.streams[userID] == nil {
+		h.streams[userID] = make(map[*eventStream]struct{})
+	}
+	s := &eventStream{ch: make(chan *models.Event, max(h.cfg.Buffer, 1))}
+	h.streams[userID][s] = struct{}{}
+
+	var once sync.Once
+	cancel := func() {
+		once.Do(func() {
+			h.mu.Lock()
+			defer h.mu.Unlock()
+			h.remove(userID, s)
+		})
+	}
+	return s.ch, cancel, nil
+}
+
+// Heartbeat is the interval of keepalive messages of connections
+func (h *EventHub) Heartbeat() time.Duration {
+	return max(h.cfg.Heartbeat, time.Second)
+}
+
+// Run delivers events from pubsub to connections of this instance and blocks until ctx is done
+func (h *EventHub) Run(ctx context.Context) {
+	log := logger.FromContext(ctx)
+	defer h.closeAll()
+
+	for ctx.Err() == nil {
+		messages, err := h.pubsub.Subscribe(ctx, eventsTopic)
+		if err != nil {
+			log.Warn("Subscribe to events error", zap.Error(err))
+			select {
+			case <-ctx.Done():
+			case <-time.After(time.Second):
+			}
+			continue
+		}
+		for payload := range messages {
+			var e envelope
+			if err := json.Unmarshal(payload, &e); err != nil || e.Event == nil {
+				log.Warn("Invalid event", zap.Error(err))
+				continue
+			}
+			h.dispatch(ctx, &e)
+		}
+	}
+}
+
+func (h *EventHub) dispatch(ctx context.Context, e *envelope) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	for s := range h.streams[e.UserID] {
+		select {
+		case s.ch <- e.Event:
+		default:
+			// Медленное соединение не должно задерживать остальных, клиент переподключится
+			logger.FromContext(ctx).Debug("Realtime connection is too slow", zap.String("user_id", e.UserID.Hex()))
+			h.remove(e.UserID, s)
+		}
+	}
+}
+
+// remove unregisters the stream, h.mu must be held
+func (h *EventHub) remove(userID primitive.ObjectID, s *eventStream) {
+	if s.closed {
+		return
+	}
+	s.closed = true
+	close(s.ch)
+	delete(h.streams[userID], s)
+	if len(h.streams[userID]) == 0 {
+		delete(h.streams, userID)
+	}
+}
+
+func (h *EventHub) closeAll() {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	for userID, streams := range h.streams {
+		for s := range streams {
+			h.remove(userID, s)
+		}
+	}
+}
@@ -0,0 +1,126 @@
+package service
+
+import (
+	"context"
+	"errors"
+
+	"vk-inter/internal/models"
+	"vk-inter/pkg/errs"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type FavoriteRepo interface {
+	AddFavorite(ctx context.Context, userID, listingID primitive.ObjectID) (bool, int64, error)
+	RemoveFavorite(ctx context.Context, userID, listingID primitive.ObjectID) (bool, int64, error)
+	GetFavoriteListingIDs(ctx context.Context, userID primitive.ObjectID, skip, limit int) ([]primitive.ObjectID, error)
+	CountFavorites(ctx context.Context, userID primitive.ObjectID) (int64, error)
+}
+
+type FavoriteListingRepo interface {
+	GetListingByID(ctx context.Context, id primitive.ObjectID) (*models.Listing, error)
+	GetListingsByIDs(ctx context.Context, ids []primitive.ObjectID) ([]*models.Listing, error)
+}
+
+type FavoriteService struct {
+	repo        FavoriteRepo
+	listingRepo FavoriteListingRepo
+}
+
+func NewFavoriteService(repo FavoriteRepo, listingRepo FavoriteListingRepo) *FavoriteService {
+	return &FavoriteService{
+		repo:        repo,
+		listingRepo: listingRepo,
+	}
+}
+
+// AddFavorite bookmarks listing, adding it again changes nothing
+func (fs *FavoriteService) AddFavorite(ctx context.Context, listingID primitive.ObjectID, user *models.User) (*models.FavoriteState, error) {
+	listing, err := fs.listingRepo.GetListingByID(ctx, listingID)
+	if err != nil {
+		return nil, err
+	}
+	if !listing.IsPublic() && listing.OwnerID != user.ID && !user.IsAdmin() {
+		return nil, errs.ErrListingNotFound
+	}
+
+	state := &models.FavoriteState{ListingID: listingID, IsFavorite: true, FavoritesCount: listing.FavoritesCount}
+	added, count, err := fs.repo.AddFavorite(ctx, user.ID, listingID)
+	if err != nil {
+		return nil, err
+	}
+	if added {
+		state.FavoritesCount = count
+	}
+	return state, nil
+}
+
+// RemoveFavorite removes listing from favorites, also works for deleted listings
+func (fs *FavoriteService) RemoveFavorite(ctx context.Context, listingID primitive.ObjectID, user *models.User) (*models.FavoriteState, error) {
+	state := &models.FavoriteState{ListingID: listingID}
+	removed, count, err := fs.repo.RemoveFavorite(ctx, user.ID, listingID)
+	if err != nil {
+		return nil, err
+	}
+	if removed {
+		state.FavoritesCount = count
+		return state, nil
+	}
+
+	listing, err := fs.listingRepo.GetListingByID(ctx, listingID)
+	if errors.Is(err, errs.ErrListingNotFound) {
+		return state, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	state.FavoritesCount = listing.FavoritesCount
+	return state, nil
+}
+
+// GetFavorites returns a page of user's favorite listings, recently added first.
+//
+// Listings which are no longer public are hidden unless the user owns them or is admin.
+func (fs *FavoriteService) GetFavorites(ctx context.Context, page, limit int, user *models.User) (*models.ListingPage, error) {
+	if page < 1 {
+		page = 1
+	}
+	if limit < 1 || limit > 100 {
+		limit = 10
+	}
+
+	ids, err := fs.repo.GetFavoriteListingIDs(ctx, user.ID, (page-1)*limit, limit+1)
+	if err != nil {
+		return nil, err
+	}
+	hasNext := len(ids) > limit
+	if hasNext {
+		ids = ids[:limit]
+	}
+	total, err := fs.repo.CountFavorites(ctx, user.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	listings, err := fs.listingRepo.GetListingsByIDs(ctx, ids)
+	if err != nil {
+		return nil, err
+	}
+	visible := make([]*models.Listing, 0, len(listings))
+	for _, l := range listings {
+		isFavorite, isMine := true, l.OwnerID == user.ID
+		if !l.IsPublic() && !isMine && !user.IsAdmin() {
+			continue
+		}
+		l.IsFavorite, l.IsMyListing = &isFavorite, &isMine
+		visible = append(visible, l)
+	}
+
+	return &models.ListingPage{
+		Items:   visible,
+		Page:    page,
+		Limit:   limit,
+		Total:   &total,
+		HasNext: hasNext,
+	}, nil
+}
@@ -0,0 +1,92 @@
+package service
+
+import (
+	"context"
+	"slices"
+	"testing"
+
+	"vk-inter/internal/models"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type fakeFavoriteRepo struct {
+	ids []primitive.ObjectID
+}
+
+func (r *fakeFavoriteRepo) AddFavorite(context.Context, primitive.ObjectID, primitive.ObjectID) (bool, int64, error) {
+	return false, 0, nil
+}
+
+func (r *fakeFavoriteRepo) RemoveFavorite(context.Context, primitive.ObjectID, primitive.ObjectID) (bool, int64, error) {
+	return false, 0, nil
+}
+
+func (r *fakeFavoriteRepo) GetFavoriteListingIDs(_ context.Context, _ primitive.ObjectID, skip, limit int) ([]primitive.ObjectID, error) {
+	ids := r.ids[min(skip, len(r.ids)):]
+	return ids[:min(limit, len(ids))], nil
+}
+
+func (r *fakeFavoriteRepo) CountFavorites(context.Context, primitive.ObjectID) (int64, error) {
+	return int64(len(r.ids)), nil
+}
+
+type fakeFavoriteListingRepo struct {
+	listings map[primitive.ObjectID]models.Listing
+}
+
+func (r *fakeFavoriteListingRepo) GetListingByID(_ context.Context, id primitive.ObjectID) (*models.Listing, error) {
+	l := r.listings[id]
+	return &l, nil
+}
+
+func (r *fakeFavoriteListingRepo) GetListingsByIDs(_ context.Context, ids []primitive.ObjectID) ([]*models.Listing, error) {
+	listings := make([]*models.Listing, 0, len(ids))
+	for _, id := range ids {
+		// Копия, как при чтении из базы
+		l := r.listings[id]
+		listings = append(listings, &l)
+	}
+	return listings, nil
+}
+
+func TestGetFavoritesHidesNonPublicListings(t *testing.T) {
+	user := &models.User{ID: primitive.NewObjectID()}
+	other := primitive.NewObjectID()
+	listings := map[primitive.ObjectID]models.Listing{}
+	var ids []primitive.ObjectID
+	add := func(owner primitive.ObjectID, status string) primitive.ObjectID {
+		id := primitive.NewObjectID()
+		listings[id] = models.Listing{ID: id, OwnerID: owner, Status: status}
+		ids = append(ids, id)
+		return id
+	}
+	published := add(other, models.ListingStatusPublished)
+	sold := add(other, models.ListingStatusSold)
+	add(other, models.ListingStatusHidden)
+	add(other, models.ListingStatusPendingMedia)
+	own := add(user.ID, models.ListingStatusRejected)
+
+	fs := NewFavoriteService(&fakeFavoriteRepo{ids: ids}, &fakeFavoriteListingRepo{listings: listings})
+	tests := []struct {
+		name string
+		user *models.User
+		want []primitive.ObjectID
+	}{
+		{name: "user", user: user, want: []primitive.ObjectID{published, sold, own}},
+		{name: "admin", user: &models.User{ID: user.ID, Role: models.RoleAdmin}, want: ids},
+	}
+	for _, tt := range tests {
+		page, err := fs.GetFavorites(context.Background(), 1, 10, tt.user)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var got []primitive.ObjectID
+		for _, l := range page.Items {
+			got = append(got, l.ID)
+		}
+		if !slices.Equal(got, tt.want) {
+			t.Fatalf("%s: GetFavorites() = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,84 @@
+package service
+
+import (
+	"fmt"
+	"math"
+	"strings"
+	"unicode/utf8"
+
+	"vk-inter/internal/models"
+	"vk-inter/pkg/errs"
+)
+
+const (
+	maxAddressLength = 200
+	// Половина длины экватора: дальше любая точка Земли уже попадает в радиус
+	maxRadiusKm = 20_040
+)
+
+func validCoordinates(lon, lat float64) bool {
+	if math.IsNaN(lon) || math.IsNaN(lat) {
+		return false
+	}
+	return lon >= -180 && lon <= 180 && lat >= -90 && lat <= 90
+}
+
+// normalizeLocation checks listing location and address
+func normalizeLocation(listing *models.Listing) error {
+	listing.Address = strings.TrimSpace(listing.Address)
+	if utf8.RuneCountInString(listing.Address) > maxAddressLength {
+		return errs.ErrListingInvalidAddress
+	}
+
+	p := listing.Location
+	if p == nil {
+		return nil
+	}
+	if p.Type == "" {
+		p.Type = models.GeoTypePoint
+	}
+	if p.Type != models.GeoTypePoint || len(p.Coordinates) != 2 || !validCoordinates(p.Lon(), p.Lat()) {
+		return errs.ErrListingInvalidLocation
+	}
+	return nil
+}
+
+// validateGeoFilter checks near, radius_km, bbox and sort_by=distance combination
+func validateGeoFilter(filter *models.ListingFilter) error {
+	if filter.Near != nil && !validCoordinates(filter.Near.Lon(), filter.Near.Lat()) {
+		return fmt.Errorf("%w: near must be latitude -90..90 and longitude -180..180", errs.ErrListingInvalidGeoFilter)
+	}
+	if filter.RadiusKm != 0 {
+		if filter.Near == nil {
+			return fmt.Errorf("%w: radius_km requires near", errs.ErrListingInvalidGeoFilter)
+		}
+		if filter.RadiusKm < 0 || filter.RadiusKm > maxRadiusKm || math.IsNaN(filter.RadiusKm) {
+			return fmt.Errorf("%w: radius_km must be between 0 and %d", errs.ErrListingInvalidGeoFilter, maxRadiusKm)
+		}
+	}
+	if filter.BBox != nil {
+		b := filter.BBox
+		if len(b) != 4 || !validCoordinates(b[0], b[1]) || !validCoordinates(b[2], b[3]) || b[0] >= b[2] || b[1] >= b[3] {
+			return fmt.Errorf("%w: bbox must be min_lon,min_lat,max_lon,max_lat", errs.ErrListingInvalidGeoFilter)
+		}
+		// Mongo строит полигон по меньшей из двух областей, поэтому шире полушария нельзя
+		if b[2]-b[0] >= 180 {
+			return fmt.Errorf("%w: bbox must be narrower than 180 degrees of longitude", errs.ErrListingInvalidGeoFilter)
+		}
+	}
+	if filter.SortBy == "distance" && filter.Near == nil {
+		return fmt.Errorf("%w: sort_by=distance requires near", errs.ErrListingInvalidGeoFilter)
+	}
+	return nil
+}
+
+const earthRadiusKm = 6378.1
+
+// distanceKm is the great-circle distance between points, the same Mongo uses for $centerSphere
+func distanceKm(a, b *models.GeoPoint) float64 {
+	lat1, lat2 := a.Lat()*math.Pi/180, b.Lat()*math.Pi/180
+	dLat := lat2 - lat1
+	dLon := (b.Lon() - a.Lon()) * math.Pi / 180
+	h := math.Sin(dLat/2)*math.Sin(dLat/2) + math.Cos(lat1)*math.Cos(lat2)*math.Sin(dLon/2)*math.Sin(dLon/2)
+	return 2 * earthRadiusKm * math.Asin(math.Min(1, math.Sqrt(h)))
+}
@@ -0,0 +1,200 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"vk-inter/internal/models"
+	"vk-inter/pkg/blob"
+	"vk-inter/pkg/errs"
+	"vk-inter/pkg/logger"
+	"vk-inter/pkg/utils"
+
+	"go.uber.org/zap"
+)
+
+const imageGCBatch = 100
+
+type ImageCacheConfig struct {
+	MaxSize int64 `env:"IMAGE_CACHE_MAX_SIZE" env-default:"10485760"`
+	// Images not referenced by listings are deleted after GCGrace, checks run every GCInterval
+	GCInterval time.Duration `env:"IMAGE_CACHE_GC_INTERVAL" env-default:"1h"`
+	GCGrace    time.Duration `env:"IMAGE_CACHE_GC_GRACE" env-default:"24h"`
+}
+
+type ImageRepo interface {
+	SaveImage(ctx context.Context, image *models.CachedImage) (*models.CachedImage, error)
+	GetImage(ctx context.Context, hash string) (*models.CachedImage, error)
+	GetUncheckedImages(ctx context.Context, before time.Time, limit int) ([]*models.CachedImage, error)
+	TouchImage(ctx context.Context, hash string) error
+	DeleteImage(ctx context.Context, hash string, checkedBefore time.Time) (bool, error)
+}
+
+type ImageListingRepo interface {
+	IsImageReferenced(ctx context.Context, hash string) (bool, error)
+}
+
+type ImageFetcher interface {
+	FetchImage(imageURL string, maxSize int64) ([]byte, *utils.ImageInfo, error)
+}
+
+// ImageCache keeps copies of remote listing images, so they are served by us
+// and don't break when the source disappears.
+//
+// Images are stored by SHA-256 of their content without metadata.
+type ImageCache struct {
+	repo        ImageRepo
+	listingRepo ImageListingRepo
+	store       BlobStore
+	fetcher     ImageFetcher
+	cfg         ImageCacheConfig
+	publicURL   string
+	maxPixels   int
+}
+
+func NewImageCache(repo ImageRepo, listingRepo ImageListingRepo, store BlobStore, fetcher ImageFetcher, cfg ImageCacheConfig, uploadCfg UploadConfig) *ImageCache {
+	return &ImageCache{
+		repo:        repo,
+		listingRepo: listingRepo,
+		store:       store,
+		fetcher:     fetcher,
+		cfg:         cfg,
+		publicURL:   strings.TrimRight(uploadCfg.PublicURL, "/"),
+		maxPixels:   uploadCfg.MaxPixels,
+	}
+}
+
+// CacheImage downloads the image and stores it, returns the stored image and its public URL
+func (ic *ImageCache) CacheImage(ctx context.Context, imageURL string) (*models.CachedImage, string, error) {
+	data, info, err := ic.fetcher.FetchImage(imageURL, ic.cfg.MaxSize)
+	if err != nil {
+		return nil, "", err
+	}
+	// Отдаём только форматы, которые принимаем в загрузках
+	if _, ok := uploadExtensions[info.Mime]; !ok {
+		return nil, "", fmt.Errorf("%w: %s", errs.ErrUploadInvalidType, info.Mime)
+	}
+	if data, err = stripImageMetadata(data, info.Mime, ic.maxPixels); err != nil {
+		return nil, "", fmt.Errorf("%w: %v", errs.ErrUploadInvalidType, err)
+	}
+
+	sum := sha256.Sum256(data)
+	hash := hex.EncodeToString(sum[:])
+
+	image, err := ic.repo.GetImage(ctx, hash)
+	if errors.Is(err, errs.ErrImageNotFound) {
+		image = &models.CachedImage{
+			Hash:      hash,
+			Key:       "images/" + hash[:2] + "/" + hash,
+			Mime:      info.Mime,
+			Size:      int64(len(data)),
+			Width:     info.Width,
+			Height:    info.Height,
+			PHash:     imagePHash(data, ic.maxPixels),
+			SourceURL: imageURL,
+		}
+		if err := ic.store.Put(ctx, image.Key, bytes.NewReader(data), image.Size, image.Mime); err != nil {
+			return nil, "", err
+		}
+	} else if err != nil {
+		return nil, "", err
+	}
+
+	// Для уже сохранённого изображения только обновляется время проверки
+	image, err = ic.repo.SaveImage(ctx, image)
+	if err != nil {
+		return nil, "", err
+	}
+	return image, ic.publicURL + "/images/" + hash, nil
+}
+
+// Open returns cached image content, caller must close it
+func (ic *ImageCache) Open(ctx context.Context, hash string) (*models.CachedImage, io.ReadCloser, error) {
+	image, err := ic.repo.GetImage(ctx, hash)
+	if err != nil {
+		return nil, nil, err
+	}
+	content, err := ic.store.Get(ctx, image.Key)
+	if errors.Is(err, blob.ErrNotFound) {
+		return nil, nil, errs.ErrImageNotFound
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+	return image, content, nil
+}
+
+// Run periodically deletes images not referenced by listings, blocks until ctx is done
+func (ic *ImageCache) Run(ctx context.Context) {
+	ticker := time.NewTicker(max(ic.cfg.GCInterval, time.Second))
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			for ic.collectGarbage(ctx) && ctx.Err() == nil {
+			}
+		}
+	}
+}
+
+// collectGarbage checks one batch of images, returns true if there may be more images to check.
+//
+// Images which failed to be checked stay in the next batch, so the batch without
+// processed images ends the run until the next tick instead of being fetched again.
+func (ic *ImageCache) collectGarbage(ctx context.Context) bool {
+	log := logger.FromContext(ctx)
+	cutoff := time.Now().Add(-ic.cfg.GCGrace)
+
+	images, err := ic.repo.GetUncheckedImages(ctx, cutoff, imageGCBatch)
+	if err != nil {
+		log.Warn("Get cached images error", zap.Error(err))
+		return false
+	}
+
+	deleted, processed := 0, 0
+	for _, image := range images {
+		referenced, err := ic.listingRepo.IsImageReferenced(ctx, image.Hash)
+		if err != nil {
+			log.Warn("Check cached image references error", zap.String("hash", image.Hash), zap.Error(err))
+			continue
+		}
+		if referenced {
+			if err := ic.repo.TouchImage(ctx, image.Hash); err != nil {
+				log.Warn("Touch cached image error", zap.String("hash", image.Hash), zap.Error(err))
+				continue
+			}
+			processed++
+			continue
+		}
+
+		// Запись удаляется первой: без неё изображение уже не отдаётся
+		ok, err := ic.repo.DeleteImage(ctx, image.Hash, cutoff)
+		if err != nil {
+			log.Warn("Delete cached image record error", zap.String("hash", image.Hash), zap.Error(err))
+			continue
+		}
+		// Не удалённую запись за это время обновили, она больше не в выборке
+		processed++
+		if !ok {
+			continue
+		}
+		if err := ic.store.Delete(ctx, image.Key); err != nil {
+			log.Warn("Delete cached image error", zap.String("hash", image.Hash), zap.Error(err))
+			continue
+		}
+		deleted++
+	}
+	if deleted > 0 {
+		log.Info("Unreferenced images deleted", zap.Int("count", deleted))
+	}
+	return processed > 0 && len(images) == imageGCBatch
+}
@@ -0,0 +1,71 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"testing"
+	"time"
+
+	"vk-inter/internal/models"
+)
+
+// fakeImageRepo returns unchecked images until they are touched or deleted
+type fakeImageRepo struct {
+	ImageRepo
+	images map[string]*models.CachedImage
+	calls  int
+}
+
+func (r *fakeImageRepo) GetUncheckedImages(_ context.Context, _ time.Time, limit int) ([]*models.CachedImage, error) {
+	r.calls++
+	var images []*models.CachedImage
+	for _, image := range r.images {
+		if len(images) == limit {
+			break
+		}
+		images = append(images, image)
+	}
+	return images, nil
+}
+
+func (r *fakeImageRepo) TouchImage(_ context.Context, hash string) error {
+	delete(r.images, hash)
+	return nil
+}
+
+type fakeImageListingRepo struct {
+	err error
+}
+
+func (r *fakeImageListingRepo) IsImageReferenced(context.Context, string) (bool, error) {
+	return true, r.err
+}
+
+func TestImageCacheGarbageCollection(t *testing.T) {
+	tests := []struct {
+		name      string
+		err       error
+		wantCalls int
+		wantLeft  int
+	}{
+		{name: "all referenced", wantCalls: 2, wantLeft: 0},
+		// Непроверенные изображения не должны запрашиваться снова без паузы
+		{name: "references check fails", err: errors.New("no primary"), wantCalls: 1, wantLeft: imageGCBatch + 50},
+	}
+	for _, tt := range tests {
+		repo := &fakeImageRepo{images: map[string]*models.CachedImage{}}
+		for i := range imageGCBatch + 50 {
+			hash := strconv.Itoa(i)
+			repo.images[hash] = &models.CachedImage{Hash: hash}
+		}
+		ic := NewImageCache(repo, &fakeImageListingRepo{err: tt.err}, nil, nil, ImageCacheConfig{}, UploadConfig{})
+
+		ctx := testContext()
+		for ic.collectGarbage(ctx) && repo.calls < 10 {
+		}
+		if repo.calls != tt.wantCalls || len(repo.images) != tt.wantLeft {
+			t.Errorf("%s: %d batches fetched, %d images left, want %d and %d", tt.name, repo.calls, len(repo.images), tt.wantCalls, tt.wantLeft)
+		}
+	}
+}
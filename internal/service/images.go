@@ -0,0 +1,167 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"unicode/utf8"
+
+	"vk-inter/internal/models"
+	"vk-inter/pkg/errs"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+const maxImageAltLength = 200
+
+// normalizeImages validates listing images and fills uploaded images info.
+//
+// Images referencing uploads must be uploaded by user and get URL of the upload,
+// external URLs are checked later by MediaValidator. The first image is the cover, ImageURL is set to its URL.
+func (ls *ListingService) normalizeImages(ctx context.Context, listing *models.Listing, user *models.User) error {
+	images := listing.Images
+	if len(images) == 0 || len(images) > ls.cfg.MaxImages {
+		return fmt.Errorf("%w: expected 1-%d images", errs.ErrListingInvalidImages, ls.cfg.MaxImages)
+	}
+
+	seen := make(map[string]struct{}, len(images))
+	for i := range images {
+		if images[i].UploadID != nil {
+			upload, err := ls.uploadRepo.GetUpload(ctx, *images[i].UploadID)
+			if errors.Is(err, errs.ErrUploadNotFound) || (err == nil && upload.OwnerID != user.ID) {
+				return fmt.Errorf("%w: unknown upload %s", errs.ErrListingInvalidImages, images[i].UploadID.Hex())
+			}
+			if err != nil {
+				return err
+			}
+			images[i].URL = upload.URL
+			images[i].Mime = upload.Mime
+			images[i].Width = upload.Width
+			images[i].Height = upload.Height
+			images[i].PHash = upload.PHash
+			images[i].Variants = upload.Variants
+		}
+		images[i].URL = strings.TrimSpace(images[i].URL)
+		images[i].Alt = strings.TrimSpace(images[i].Alt)
+		if utf8.RuneCountInString(images[i].Alt) > maxImageAltLength {
+			return fmt.Errorf("%w: alt text of %s is too long", errs.ErrListingInvalidImages, images[i].URL)
+		}
+		if _, ok := seen[images[i].URL]; ok {
+			return fmt.Errorf("%w: duplicate image %s", errs.ErrListingInvalidImages, images[i].URL)
+		}
+		seen[images[i].URL] = struct{}{}
+	}
+
+	listing.ImageURL = images[0].URL
+	return nil
+}
+
+// hasExternalImages reports whether listing has images which are not uploads
+func hasExternalImages(images []models.ListingImage) bool {
+	for _, img := range images {
+		if img.UploadID == nil {
+			return true
+		}
+	}
+	return false
+}
+
+// cacheExternalImages copies external images to our storage and returns them
+// with detected mime type, dimensions and cached copy.
+//
+// Errors are returned by image index, nil for valid images.
+func cacheExternalImages(ctx context.Context, cache RemoteImageCache, images []models.ListingImage) ([]models.ListingImage, []error) {
+	var external []models.ListingImage
+	for _, img := range images {
+		if img.UploadID == nil {
+			external = append(external, img)
+		}
+	}
+
+	// Каждый URL проверяется запросом, поэтому параллельно
+	results := make([]error, len(external))
+	var wg sync.WaitGroup
+	for i := range external {
+		wg.Add(1)
+		go func(img *models.ListingImage, res *error) {
+			defer wg.Done()
+			cached, cachedURL, err := cache.CacheImage(ctx, img.URL)
+			if err != nil {
+				*res = err
+				return
+			}
+			img.Mime = cached.Mime
+			img.Width = cached.Width
+			img.Height = cached.Height
+			img.Hash = cached.Hash
+			img.CachedURL = cachedURL
+			img.PHash = cached.PHash
+		}(&external[i], &results[i])
+	}
+	wg.Wait()
+	return external, results
+}
+
+// editableListing returns listing if user is its owner or admin
+func (ls *ListingService) editableListing(ctx context.Context, id primitive.ObjectID, user *models.User) (*models.Listing, error) {
+	listing, err := ls.repo.GetListingByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if listing.OwnerID != user.ID && !user.IsAdmin() {
+		return nil, errs.ErrForbidden
+	}
+	return listing, nil
+}
+
+// ReorderImages sets new order of listing images, urls must contain every image URL exactly once
+func (ls *ListingService) ReorderImages(ctx context.Context, id primitive.ObjectID, urls []string, user *models.User) (*models.Listing, error) {
+	listing, err := ls.editableListing(ctx, id, user)
+	if err != nil {
+		return nil, err
+	}
+
+	byURL := make(map[string]models.ListingImage, len(listing.Images))
+	for _, img := range listing.Images {
+		byURL[img.URL] = img
+	}
+	if len(urls) != len(listing.Images) {
+		return nil, fmt.Errorf("%w: order must contain all %d images", errs.ErrListingInvalidImages, len(listing.Images))
+	}
+
+	images := make([]models.ListingImage, 0, len(urls))
+	for _, u := range urls {
+		img, ok := byURL[u]
+		if !ok {
+			return nil, fmt.Errorf("%w: unknown or duplicate image %s", errs.ErrListingInvalidImages, u)
+		}
+		delete(byURL, u)
+		images = append(images, img)
+	}
+
+	return ls.repo.UpdateImages(ctx, id, images)
+}
+
+// SetCoverImage makes the image the cover by moving it to the first position
+func (ls *ListingService) SetCoverImage(ctx context.Context, id primitive.ObjectID, url string, user *models.User) (*models.Listing, error) {
+	listing, err := ls.editableListing(ctx, id, user)
+	if err != nil {
+		return nil, err
+	}
+
+	images := make([]models.ListingImage, 0, len(listing.Images))
+	for _, img := range listing.Images {
+		if img.URL == url {
+			images = append([]models.ListingImage{img}, images...)
+		} else {
+			images = append(images, img)
+		}
+	}
+	if len(images) == 0 || images[0].URL != url {
+		return nil, fmt.Errorf("%w: unknown image %s", errs.ErrListingInvalidImages, url)
+	}
+
+	return ls.repo.UpdateImages(ctx, id, images)
+}
@@ -2,79 +2,507 @@ package service
 
 import (
 	"context"
-	"math"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
 
 	"vk-inter/internal/models"
+	"vk-inter/pkg/cursor"
 	"vk-inter/pkg/errs"
-	"vk-inter/pkg/utils"
+	"vk-inter/pkg/logger"
+	"vk-inter/pkg/money"
 
 	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.uber.org/zap"
 )
 
 type ListingRepo interface {
 	CreateListing(ctx context.Context, listing *models.Listing) (*models.Listing, error)
-	GetListings(ctx context.Context, page, limit int, sortBy, order string, minPrice, maxPrice float64, currentUserID primitive.ObjectID) ([]*models.Listing, error)
+	GetListings(ctx context.Context, filter *models.ListingFilter) ([]*models.Listing, error)
+	CountListings(ctx context.Context, filter *models.ListingFilter) (int64, bool, error)
+	SetMissingCurrency(ctx context.Context, currency string) error
+	RecalculateBasePrices(ctx context.Context, rates map[string]money.Decimal) error
+	GetListingByID(ctx context.Context, id primitive.ObjectID) (*models.Listing, error)
+	UpdateImages(ctx context.Context, id primitive.ObjectID, images []models.ListingImage) (*models.Listing, error)
+	MarkFavorites(ctx context.Context, listings []*models.Listing, userID primitive.ObjectID) error
+	UpdatePrice(ctx context.Context, old *models.Listing, change *models.PriceChange, droppedAt *time.Time) error
+	MarkSold(ctx context.Context, id primitive.ObjectID, at time.Time) (*models.Listing, error)
+}
+
+type MediaQueue interface {
+	Enqueue(ctx context.Context, listingID primitive.ObjectID) error
+}
+
+type DuplicateChecker interface {
+	Check(ctx context.Context, listing *models.Listing) (*DuplicateVerdict, error)
+	Report(ctx context.Context, listing *models.Listing, matches []DuplicateMatch)
+}
+
+type PriceRecorder interface {
+	PriceDropped()
+}
+
+type AuctionPlanner interface {
+	PrepareAuction(listing *models.Listing) error
+}
+
+type ContentChecker interface {
+	Check(listing *models.Listing) *ContentVerdict
+}
+
+type ContentFlagger interface {
+	FlagListing(ctx context.Context, listing *models.Listing, rules []string) error
+}
+
+type ViewRecorder interface {
+	RecordView(listing *models.Listing, viewer string)
+}
+
+type ListingConfig struct {
+	MaxImages int `env:"LISTING_MAX_IMAGES" env-default:"10"`
 }
 
 type ListingService struct {
-	repo ListingRepo
+	repo         ListingRepo
+	categoryRepo CategoryRepo
+	uploadRepo   UploadRepo
+	media        MediaQueue
+	duplicates   DuplicateChecker
+	prices       PriceRecorder
+	auctions     AuctionPlanner
+	content      ContentChecker
+	moderation   ContentFlagger
+	views        ViewRecorder
+	rates        RateProvider
+	cfg          ListingConfig
+	secret       string
 }
 
-func NewListingService(repo ListingRepo) *ListingService {
+func NewListingService(repo ListingRepo, categoryRepo CategoryRepo, uploadRepo UploadRepo, media MediaQueue, duplicates DuplicateChecker, prices PriceRecorder, auctions AuctionPlanner, content ContentChecker, moderation ContentFlagger, views ViewRecorder, rates RateProvider, cfg ListingConfig, secret string) *ListingService {
 	return &ListingService{
-		repo: repo,
+		repo:         repo,
+		categoryRepo: categoryRepo,
+		uploadRepo:   uploadRepo,
+		media:        media,
+		duplicates:   duplicates,
+		prices:       prices,
+		auctions:     auctions,
+		content:      content,
+		moderation:   moderation,
+		views:        views,
+		rates:        rates,
+		cfg:          cfg,
+		secret:       secret,
+	}
+}
+
+func (ls *ListingService) CreateListing(ctx context.Context, listing *models.Listing, user *models.User) (*models.Listing, error) {
+	if listing.CategoryID == primitive.NilObjectID {
+		return nil, errs.ErrListingInvalidCategory
+	}
+	category, err := ls.categoryRepo.GetByID(ctx, listing.CategoryID)
+	if err != nil {
+		return nil, err
+	}
+	schema, err := categoryAttributes(ctx, ls.categoryRepo, category)
+	if err != nil {
+		return nil, err
+	}
+	listing.Attributes, err = ValidateAttributes(schema, listing.Attributes)
+	if err != nil {
+		return nil, err
+	}
+	if err := ls.setPrice(listing); err != nil {
+		return nil, err
+	}
+	if err := ls.auctions.PrepareAuction(listing); err != nil {
+		return nil, err
+	}
+
+	if err := ls.normalizeImages(ctx, listing, user); err != nil {
+		return nil, err
+	}
+
+	if err := normalizeLocation(listing); err != nil {
+		return nil, err
+	}
+
+	// Проверка до поиска дубликатов, чтобы отпечаток строился по замаскированному тексту
+	content := ls.content.Check(listing)
+	if content.Reject {
+		return nil, fmt.Errorf("%w: %s", errs.ErrListingContentRejected, strings.Join(content.Rules, ", "))
+	}
+
+	listing.Tags = NormalizeTags(listing.Tags)
+	listing.OwnerID = user.ID
+	listing.OwnerLogin = user.Login
+	listing.Status = models.ListingStatusPublished
+	listing.RejectReason = ""
+	listing.MediaCheck = nil
+	listing.DuplicateOf = nil
+	listing.IsMyListing = nil
+
+	// Хэши внешних изображений появятся после их загрузки, тогда проверка повторится
+	verdict, err := ls.duplicates.Check(ctx, listing)
+	if err != nil {
+		return nil, err
+	}
+	if verdict.Reject {
+		return nil, fmt.Errorf("%w %s", errs.ErrListingDuplicate, verdict.DuplicateOf.ID.Hex())
+	}
+	if verdict.DuplicateOf != nil {
+		listing.DuplicateOf = &verdict.DuplicateOf.ID
+	}
+
+	// Внешние изображения проверяются в фоне, до проверки объявление не публикуется
+	pending := hasExternalImages(listing.Images)
+	if pending {
+		listing.Status = models.ListingStatusPendingMedia
+		listing.MediaCheck = &models.MediaCheck{Status: models.MediaJobQueued, UpdatedAt: time.Now()}
+	}
+
+	created, err := ls.repo.CreateListing(ctx, listing)
+	if err != nil {
+		return nil, err
+	}
+	ls.duplicates.Report(ctx, created, verdict.Others)
+	if content.Flagged {
+		if err := ls.moderation.FlagListing(ctx, created, content.Rules); err != nil {
+			logger.FromContext(ctx).Warn("Flag listing error", zap.String("listing_id", created.ID.Hex()), zap.Error(err))
+		}
+	}
+	if pending {
+		// Если задача не сохранилась, её создаст воркер при следующем запуске
+		if err := ls.media.Enqueue(ctx, created.ID); err != nil {
+			logger.FromContext(ctx).Warn("Enqueue media job error", zap.String("listing_id", created.ID.Hex()), zap.Error(err))
+		}
+	}
+	return created, nil
+}
+
+// MarkSold completes the deal with the buyer of the reserved listing, then both sides can review each other
+func (ls *ListingService) MarkSold(ctx context.Context, id primitive.ObjectID, user *models.User) (*models.Listing, error) {
+	listing, err := ls.editableListing(ctx, id, user)
+	if err != nil {
+		return nil, err
+	}
+	if listing.Status != models.ListingStatusReserved || listing.BuyerID == nil {
+		return nil, errs.ErrListingNotReserved
 	}
+	sold, err := ls.repo.MarkSold(ctx, id, time.Now())
+	if err != nil {
+		return nil, err
+	}
+	isOwner := sold.OwnerID == user.ID
+	sold.IsMyListing = &isOwner
+	return sold, nil
 }
 
-func (ls *ListingService) CreateListing(ctx context.Context, title, description, imageURL string, price float64, user *models.User) (*models.Listing, error) {
-	err := utils.ValidateImageURL(imageURL)
+// GetListing returns listing by id, unpublished listings are visible only to the owner and admins.
+//
+// Views of public listings by anyone except the owner are counted, anonymous viewers
+// are identified by client, e.g. network address.
+func (ls *ListingService) GetListing(ctx context.Context, id primitive.ObjectID, user *models.User, client string) (*models.Listing, error) {
+	listing, err := ls.repo.GetListingByID(ctx, id)
 	if err != nil {
 		return nil, err
 	}
-	listing := models.Listing{
-		Title:       title,
-		Description: description,
-		ImageURL:    imageURL,
-		Price:       price,
-		OwnerID:     user.ID,
-		OwnerLogin:  user.Login,
+	isOwner := user != nil && listing.OwnerID == user.ID
+	if !listing.IsPublic() && !isOwner && !user.IsAdmin() {
+		return nil, errs.ErrListingNotFound
+	}
+	if listing.IsPublic() && !isOwner {
+		if user != nil {
+			ls.views.RecordView(listing, "user:"+user.ID.Hex())
+		} else if client != "" {
+			ls.views.RecordView(listing, "client:"+client)
+		}
 	}
-	return ls.repo.CreateListing(ctx, &listing)
+	if user != nil {
+		listing.IsMyListing = &isOwner
+		if err := ls.repo.MarkFavorites(ctx, []*models.Listing{listing}, user.ID); err != nil {
+			return nil, err
+		}
+	}
+	return listing, nil
 }
 
-func (ls *ListingService) GetListings(
-	ctx context.Context,
-	page, limit int,
-	sortBy, order string,
-	minPrice, maxPrice float64,
-	currentUserID primitive.ObjectID,
-) ([]*models.Listing, error) {
+// GetListings returns a page of listings.
+//
+// If cursorToken is not empty, keyset pagination is used and sort settings are taken from the cursor,
+// otherwise filter.Page is used. Next and previous cursors are returned in both modes.
+func (ls *ListingService) GetListings(ctx context.Context, filter *models.ListingFilter, cursorToken string) (*models.ListingPage, error) {
 	// Ограничения по лимиту и странице
-	if page < 1 {
-		page = 1
+	if filter.Page < 1 {
+		filter.Page = 1
+	}
+	if filter.Limit < 1 || filter.Limit > 100 {
+		filter.Limit = 10
+	}
+
+	filter.Cursor = nil
+	if cursorToken != "" {
+		c, err := ls.decodeCursor(cursorToken)
+		if err != nil {
+			return nil, err
+		}
+		filter.Cursor = c
+		filter.SortBy = c.SortBy
+		filter.Order = c.Order
 	}
-	if limit < 1 || limit > 100 {
-		limit = 10
+
+	displayCurrency, err := ls.NormalizeFilter(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+
+	listings, err := ls.repo.GetListings(ctx, filter)
+	if err != nil {
+		return nil, err
 	}
 
+	// Репозиторий возвращает на одно объявление больше, чтобы понять, есть ли ещё страница
+	hasMore := len(listings) > filter.Limit
+	if hasMore {
+		listings = listings[:filter.Limit]
+	}
+	backward := filter.Cursor != nil && filter.Cursor.Backward
+	if backward {
+		slices.Reverse(listings)
+	}
+	if displayCurrency != "" {
+		if err := ls.setDisplayPrices(listings, displayCurrency); err != nil {
+			return nil, err
+		}
+	}
+
+	if listings == nil {
+		listings = []*models.Listing{}
+	}
+	hasNext := hasMore
+	hasPrev := filter.Cursor != nil || filter.Page > 1
+	if backward {
+		hasNext, hasPrev = true, hasMore
+	}
+
+	page := &models.ListingPage{
+		Items:   listings,
+		Limit:   filter.Limit,
+		HasNext: hasNext && len(listings) > 0,
+	}
+	if filter.Cursor == nil {
+		page.Page = filter.Page
+	}
+	if filter.CountTotal {
+		total, estimated, err := ls.repo.CountListings(ctx, filter)
+		if err != nil {
+			return nil, err
+		}
+		page.Total = &total
+		page.TotalEstimated = estimated
+	}
+	if len(listings) == 0 {
+		return page, nil
+	}
+
+	if hasNext {
+		page.NextCursor, err = ls.encodeCursor(filter, listings[len(listings)-1], false)
+		if err != nil {
+			return nil, err
+		}
+	}
+	if hasPrev {
+		page.PrevCursor, err = ls.encodeCursor(filter, listings[0], true)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return page, nil
+}
+
+// NormalizeFilter validates filter and prepares it for repository: converts price bounds
+// to the base currency, expands category with its descendants and parses attribute filters.
+//
+// Returns the currency prices should be displayed in, empty for original currencies.
+func (ls *ListingService) NormalizeFilter(ctx context.Context, filter *models.ListingFilter) (string, error) {
 	// Проверка допустимых значений сортировки
-	if sortBy != "created_at" && sortBy != "price" {
-		sortBy = "created_at"
+	if filter.SortBy != "created_at" && filter.SortBy != "price" && filter.SortBy != "distance" {
+		filter.SortBy = "created_at"
+	}
+	// Ближайшие объявления по умолчанию первыми
+	if filter.Order != "asc" && filter.Order != "desc" {
+		filter.Order = "desc"
+		if filter.SortBy == "distance" {
+			filter.Order = "asc"
+		}
+	}
+	if err := validateGeoFilter(filter); err != nil {
+		return "", err
+	}
+
+	// Границы цены задаются в валюте отображения, а фильтруются по цене в базовой валюте
+	displayCurrency := filter.Currency
+	if displayCurrency != "" {
+		var err error
+		if displayCurrency, err = ls.normalizeCurrency(displayCurrency); err != nil {
+			return "", err
+		}
 	}
-	if order != "asc" && order != "desc" {
-		order = "desc"
+	boundsCurrency := displayCurrency
+	if boundsCurrency == "" {
+		boundsCurrency = ls.rates.Base()
 	}
 
 	// Безопасная фильтрация цены
-	if minPrice < 0 {
-		minPrice = 0
+	if filter.MinPrice != nil && !filter.MinPrice.IsPositive() {
+		filter.MinPrice = nil
+	}
+	if filter.MaxPrice != nil && !filter.MaxPrice.IsPositive() {
+		filter.MaxPrice = nil
+	}
+	for _, bound := range []*money.Decimal{filter.MinPrice, filter.MaxPrice} {
+		if bound != nil && (bound.GreaterThan(maxPrice.Decimal) || bound.Scale() > money.MinorUnits(boundsCurrency)) {
+			return "", errs.ErrListingInvalidPrice
+		}
+	}
+	if filter.MinPrice != nil && filter.MaxPrice != nil && filter.MaxPrice.LessThan(filter.MinPrice.Decimal) {
+		return "", errs.ErrPriceSorting
+	}
+	var err error
+	if filter.MinPrice, err = ls.toBase(filter.MinPrice, boundsCurrency); err != nil {
+		return "", err
+	}
+	if filter.MaxPrice, err = ls.toBase(filter.MaxPrice, boundsCurrency); err != nil {
+		return "", err
+	}
+
+	// Категория включает в себя все подкатегории
+	filter.CategoryIDs = nil
+	var schema []models.AttributeSchema
+	if filter.CategoryID != primitive.NilObjectID {
+		category, err := ls.categoryRepo.GetByID(ctx, filter.CategoryID)
+		if err != nil {
+			return "", err
+		}
+		schema, err = categoryAttributes(ctx, ls.categoryRepo, category)
+		if err != nil {
+			return "", err
+		}
+
+		descendants, err := ls.categoryRepo.GetDescendants(ctx, filter.CategoryID)
+		if err != nil {
+			return "", err
+		}
+		filter.CategoryIDs = append(filter.CategoryIDs, filter.CategoryID)
+		for _, d := range descendants {
+			filter.CategoryIDs = append(filter.CategoryIDs, d.ID)
+			// Фильтровать можно и по атрибутам подкатегорий
+			for _, a := range d.Attributes {
+				if !slices.ContainsFunc(schema, func(s models.AttributeSchema) bool { return s.Name == a.Name }) {
+					schema = append(schema, a)
+				}
+			}
+		}
+	}
+
+	// Без категории тип атрибута угадывается по значению
+	if err := ParseAttributeFilters(schema, filter.Attributes); err != nil {
+		return "", err
 	}
-	if maxPrice <= 0 || maxPrice > 1_000_000_000 {
-		maxPrice = math.MaxFloat64
+
+	filter.Tags = NormalizeTags(filter.Tags)
+	if filter.TagsMode != models.TagsModeAll {
+		filter.TagsMode = models.TagsModeAny
+	}
+
+	return displayCurrency, nil
+}
+
+func (ls *ListingService) encodeCursor(filter *models.ListingFilter, boundary *models.Listing, backward bool) (string, error) {
+	c := models.ListingCursor{
+		SortBy:   filter.SortBy,
+		Order:    filter.Order,
+		ID:       boundary.ID,
+		Backward: backward,
 	}
-	if maxPrice < minPrice {
-		return []*models.Listing{}, errs.ErrPriceSorting
+	switch filter.SortBy {
+	case "price":
+		c.Value = boundary.PriceBase.String()
+	case "distance":
+		// Сортировка по расстоянию допускается только с near, $geoNear всегда задаёт distance
+		if boundary.Distance == nil {
+			return "", fmt.Errorf("listing %s has no distance for cursor", boundary.ID.Hex())
+		}
+		c.Value = *boundary.Distance
+	default:
+		c.Value = boundary.CreatedAt.UTC().Format(time.RFC3339Nano)
 	}
+	return cursor.Encode(c, ls.secret)
+}
 
-	return ls.repo.GetListings(ctx, page, limit, sortBy, order, minPrice, maxPrice, currentUserID)
+func (ls *ListingService) decodeCursor(token string) (*models.ListingCursor, error) {
+	var c models.ListingCursor
+	if err := cursor.Decode(token, ls.secret, &c); err != nil {
+		return nil, errs.ErrInvalidCursor
+	}
+	if c.Order != "asc" && c.Order != "desc" {
+		return nil, errs.ErrInvalidCursor
+	}
+
+	// После JSON значение ключа сортировки нужно вернуть к типу поля
+	switch c.SortBy {
+	case "price":
+		s, ok := c.Value.(string)
+		if !ok {
+			return nil, errs.ErrInvalidCursor
+		}
+		v, err := money.NewFromString(s)
+		if err != nil {
+			return nil, errs.ErrInvalidCursor
+		}
+		if c.Value, err = v.Decimal128(); err != nil {
+			return nil, errs.ErrInvalidCursor
+		}
+	case "distance":
+		if _, ok := c.Value.(float64); !ok {
+			return nil, errs.ErrInvalidCursor
+		}
+	case "created_at":
+		s, ok := c.Value.(string)
+		if !ok {
+			return nil, errs.ErrInvalidCursor
+		}
+		t, err := time.Parse(time.RFC3339Nano, s)
+		if err != nil {
+			return nil, errs.ErrInvalidCursor
+		}
+		c.Value = t
+	default:
+		return nil, errs.ErrInvalidCursor
+	}
+	return &c, nil
+}
+
+// NormalizeTags lowercases and trims tags, dropping empty ones and duplicates
+func NormalizeTags(tags []string) []string {
+	if len(tags) == 0 {
+		return nil
+	}
+
+	seen := make(map[string]struct{}, len(tags))
+	normalized := make([]string, 0, len(tags))
+	for _, t := range tags {
+		t = strings.ToLower(strings.TrimSpace(t))
+		if t == "" {
+			continue
+		}
+		if _, ok := seen[t]; ok {
+			continue
+		}
+		seen[t] = struct{}{}
+		normalized = append(normalized, t)
+	}
+	return normalized
 }
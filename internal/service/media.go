@@ -0,0 +1,263 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"vk-inter/internal/models"
+	"vk-inter/pkg/errs"
+	"vk-inter/pkg/logger"
+	"vk-inter/pkg/utils"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.uber.org/zap"
+)
+
+const (
+	mediaJobLease    = time.Minute
+	mediaPollPeriod  = 2 * time.Second
+	mediaRetryPeriod = 30 * time.Second
+	// Ошибки чтения объявления повторяются без ограничения попыток, но не чаще
+	mediaMaxRetryDelay = 10 * time.Minute
+)
+
+type MediaConfig struct {
+	Workers     int `env:"MEDIA_WORKERS" env-default:"2"`
+	MaxAttempts int `env:"MEDIA_MAX_ATTEMPTS" env-default:"3"`
+}
+
+type MediaJobRepo interface {
+	EnqueueJob(ctx context.Context, listingID primitive.ObjectID) error
+	ClaimJob(ctx context.Context, lease time.Duration) (*models.MediaJob, error)
+	FinishJob(ctx context.Context, id primitive.ObjectID, status, errMsg string) error
+	RetryJob(ctx context.Context, id primitive.ObjectID, runAfter time.Time, errMsg string) error
+}
+
+type RemoteImageCache interface {
+	CacheImage(ctx context.Context, imageURL string) (*models.CachedImage, string, error)
+}
+
+type MediaListingRepo interface {
+	GetListingByID(ctx context.Context, id primitive.ObjectID) (*models.Listing, error)
+	GetPendingMediaIDs(ctx context.Context) ([]primitive.ObjectID, error)
+	SetMediaCheck(ctx context.Context, id primitive.ObjectID, check *models.MediaCheck) error
+	SetMediaResult(ctx context.Context, id primitive.ObjectID, result *models.MediaResult) error
+}
+
+// MediaValidator checks external images of new listings and looks for duplicates
+// by their hashes in background workers.
+//
+// Jobs are stored in Mongo and claimed with a lease, so jobs of a crashed
+// or restarted instance are picked up again. Listing stays pending_media
+// until its images are checked, then it is published or rejected.
+type MediaValidator struct {
+	jobRepo     MediaJobRepo
+	listingRepo MediaListingRepo
+	images      RemoteImageCache
+	duplicates  DuplicateChecker
+	events      EventPublisher
+	cfg         MediaConfig
+
+	notify chan struct{}
+}
+
+func NewMediaValidator(jobRepo MediaJobRepo, listingRepo MediaListingRepo, images RemoteImageCache, duplicates DuplicateChecker, events EventPublisher, cfg MediaConfig) *MediaValidator {
+	return &MediaValidator{
+		jobRepo:     jobRepo,
+		listingRepo: listingRepo,
+		images:      images,
+		duplicates:  duplicates,
+		events:      events,
+		cfg:         cfg,
+		notify:      make(chan struct{}, 1),
+	}
+}
+
+// Enqueue stores validation job of the listing and wakes up a worker
+func (mv *MediaValidator) Enqueue(ctx context.Context, listingID primitive.ObjectID) error {
+	if err := mv.jobRepo.EnqueueJob(ctx, listingID); err != nil {
+		return err
+	}
+	select {
+	case mv.notify <- struct{}{}:
+	default:
+	}
+	return nil
+}
+
+// Run starts workers and blocks until ctx is done
+func (mv *MediaValidator) Run(ctx context.Context) {
+	mv.recover(ctx)
+
+	done := make(chan struct{})
+	workers := max(mv.cfg.Workers, 1)
+	for range workers {
+		go func() {
+			defer func() { done <- struct{}{} }()
+			mv.work(ctx)
+		}()
+	}
+	for range workers {
+		<-done
+	}
+}
+
+// recover enqueues pending listings which lost their jobs, e.g. created before the queue existed
+func (mv *MediaValidator) recover(ctx context.Context) {
+	ids, err := mv.listingRepo.GetPendingMediaIDs(ctx)
+	if err != nil {
+		logger.FromContext(ctx).Warn("Get pending media listings error", zap.Error(err))
+		return
+	}
+	for _, id := range ids {
+		if err := mv.jobRepo.EnqueueJob(ctx, id); err != nil {
+			logger.FromContext(ctx).Warn("Enqueue media job error", zap.String("listing_id", id.Hex()), zap.Error(err))
+		}
+	}
+}
+
+func (mv *MediaValidator) work(ctx context.Context) {
+	ticker := time.NewTicker(mediaPollPeriod)
+	defer ticker.Stop()
+	for {
+		// Забираем задачи, пока очередь не опустеет
+		for ctx.Err() == nil {
+			job, err := mv.jobRepo.ClaimJob(ctx, mediaJobLease)
+			if err != nil {
+				if ctx.Err() == nil {
+					logger.FromContext(ctx).Warn("Claim media job error", zap.Error(err))
+				}
+				break
+			}
+			if job == nil {
+				break
+			}
+			mv.process(ctx, job)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-mv.notify:
+		case <-ticker.C:
+		}
+	}
+}
+
+func (mv *MediaValidator) process(ctx context.Context, job *models.MediaJob) {
+	log := logger.FromContext(ctx)
+	idField := zap.String("listing_id", job.ID.Hex())
+
+	listing, err := mv.listingRepo.GetListingByID(ctx, job.ID)
+	if err != nil && !errors.Is(err, errs.ErrListingNotFound) {
+		if ctx.Err() != nil {
+			return
+		}
+		// Объявление не отклоняется из-за недоступной базы, задача повторяется позже
+		log.Warn("Get media listing error", idField, zap.Error(err))
+		runAfter := time.Now().Add(min(time.Duration(job.Attempts)*mediaRetryPeriod, mediaMaxRetryDelay))
+		if err := mv.jobRepo.RetryJob(ctx, job.ID, runAfter, err.Error()); err != nil {
+			log.Warn("Retry media job error", idField, zap.Error(err))
+		}
+		return
+	}
+	if err != nil || listing.Status != models.ListingStatusPendingMedia {
+		// Объявление удалено или уже проверено
+		if err := mv.jobRepo.FinishJob(ctx, job.ID, models.MediaJobDone, ""); err != nil {
+			log.Warn("Finish media job error", idField, zap.Error(err))
+		}
+		return
+	}
+
+	check := &models.MediaCheck{Status: models.MediaJobRunning, Attempts: job.Attempts, UpdatedAt: time.Now()}
+	if err := mv.listingRepo.SetMediaCheck(ctx, job.ID, check); err != nil {
+		log.Warn("Set media check error", idField, zap.Error(err))
+	}
+
+	images, results := cacheExternalImages(ctx, mv.images, listing.Images)
+	var failed error
+	for i, err := range results {
+		if err != nil {
+			failed = fmt.Errorf("image %s: %w", images[i].URL, err)
+			break
+		}
+	}
+
+	// С хэшами загруженных изображений повторяем поиск дубликатов
+	var verdict *DuplicateVerdict
+	if failed == nil {
+		byURL := make(map[string]models.ListingImage, len(images))
+		for _, img := range images {
+			byURL[img.URL] = img
+		}
+		for i := range listing.Images {
+			if img, ok := byURL[listing.Images[i].URL]; ok {
+				listing.Images[i] = img
+			}
+		}
+		if verdict, err = mv.duplicates.Check(ctx, listing); err != nil {
+			failed = fmt.Errorf("duplicates check: %w", err)
+		}
+	}
+	if ctx.Err() != nil {
+		// Задачу заберёт другой воркер после истечения аренды
+		return
+	}
+
+	check = &models.MediaCheck{Status: models.MediaJobDone, Attempts: job.Attempts, UpdatedAt: time.Now()}
+	result := &models.MediaResult{Status: models.ListingStatusPublished, Images: images, Check: check}
+	if failed != nil {
+		check.Error = failed.Error()
+		if retryable(failed) && job.Attempts < mv.cfg.MaxAttempts {
+			check.Status = models.MediaJobQueued
+			runAfter := time.Now().Add(time.Duration(job.Attempts) * mediaRetryPeriod)
+			if err := mv.jobRepo.RetryJob(ctx, job.ID, runAfter, check.Error); err != nil {
+				log.Warn("Retry media job error", idField, zap.Error(err))
+			}
+			if err := mv.listingRepo.SetMediaCheck(ctx, job.ID, check); err != nil {
+				log.Warn("Set media check error", idField, zap.Error(err))
+			}
+			return
+		}
+		check.Status = models.MediaJobFailed
+		result.Status, result.RejectReason = models.ListingStatusRejected, check.Error
+		result.Images = nil
+	} else {
+		result.Fingerprint = listing.Fingerprint
+		if verdict.DuplicateOf != nil {
+			result.DuplicateOf = &verdict.DuplicateOf.ID
+		}
+		if verdict.Reject {
+			result.Status = models.ListingStatusRejected
+			result.RejectReason = errs.ErrListingDuplicate.Error() + " " + verdict.DuplicateOf.ID.Hex()
+		}
+	}
+
+	if err := mv.listingRepo.SetMediaResult(ctx, job.ID, result); err != nil {
+		log.Warn("Set media result error", idField, zap.Error(err))
+		return
+	}
+	if err := mv.jobRepo.FinishJob(ctx, job.ID, check.Status, check.Error); err != nil {
+		log.Warn("Finish media job error", idField, zap.Error(err))
+	}
+	if verdict != nil && !verdict.Reject {
+		mv.duplicates.Report(ctx, listing, verdict.Others)
+	}
+	mv.events.Publish(ctx, listing.OwnerID, models.EventTypeListingStatus, &models.ListingStatusEvent{
+		ListingID:    listing.ID,
+		Status:       result.Status,
+		RejectReason: result.RejectReason,
+	})
+	log.Debug("Listing images checked", idField, zap.String("status", result.Status))
+}
+
+// retryable reports whether image check may succeed later, e.g. the host or storage was unavailable
+func retryable(err error) bool {
+	var imageErr utils.ImageError
+	if errors.As(err, &imageErr) {
+		return imageErr.Code == utils.ErrInvalidImageURL
+	}
+	return !errors.Is(err, errs.ErrUploadInvalidType)
+}
@@ -0,0 +1,91 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"vk-inter/internal/models"
+	"vk-inter/pkg/errs"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type fakeMediaJobRepo struct {
+	finished []string
+	retried  []time.Time
+}
+
+func (r *fakeMediaJobRepo) EnqueueJob(context.Context, primitive.ObjectID) error {
+	return nil
+}
+
+func (r *fakeMediaJobRepo) ClaimJob(context.Context, time.Duration) (*models.MediaJob, error) {
+	return nil, nil
+}
+
+func (r *fakeMediaJobRepo) FinishJob(_ context.Context, _ primitive.ObjectID, status, _ string) error {
+	r.finished = append(r.finished, status)
+	return nil
+}
+
+func (r *fakeMediaJobRepo) RetryJob(_ context.Context, _ primitive.ObjectID, runAfter time.Time, _ string) error {
+	r.retried = append(r.retried, runAfter)
+	return nil
+}
+
+type fakeMediaListingRepo struct {
+	listing *models.Listing
+	err     error
+}
+
+func (r *fakeMediaListingRepo) GetListingByID(context.Context, primitive.ObjectID) (*models.Listing, error) {
+	return r.listing, r.err
+}
+
+func (r *fakeMediaListingRepo) GetPendingMediaIDs(context.Context) ([]primitive.ObjectID, error) {
+	return nil, nil
+}
+
+func (r *fakeMediaListingRepo) SetMediaCheck(context.Context, primitive.ObjectID, *models.MediaCheck) error {
+	return nil
+}
+
+func (r *fakeMediaListingRepo) SetMediaResult(context.Context, primitive.ObjectID, *models.MediaResult) error {
+	return nil
+}
+
+func TestMediaValidatorListingErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		listing *models.Listing
+		err     error
+		finish  bool
+	}{
+		{name: "deleted listing", err: errs.ErrListingNotFound, finish: true},
+		{name: "checked listing", listing: &models.Listing{Status: models.ListingStatusPublished}, finish: true},
+		{name: "database error", err: errors.New("no primary")},
+	}
+	for _, tt := range tests {
+		jobs := &fakeMediaJobRepo{}
+		listings := &fakeMediaListingRepo{listing: tt.listing, err: tt.err}
+		mv := NewMediaValidator(jobs, listings, nil, nil, nil, MediaConfig{MaxAttempts: 3})
+
+		// Попытки не ограничены MaxAttempts, пока объявление не удаётся прочитать
+		mv.process(testContext(), &models.MediaJob{ID: primitive.NewObjectID(), Attempts: 5})
+		if tt.finish {
+			if len(jobs.finished) != 1 || jobs.finished[0] != models.MediaJobDone || len(jobs.retried) != 0 {
+				t.Errorf("%s: finished %v, retried %v, want job done", tt.name, jobs.finished, jobs.retried)
+			}
+			continue
+		}
+		if len(jobs.finished) != 0 || len(jobs.retried) != 1 {
+			t.Errorf("%s: finished %v, retried %v, want job retried", tt.name, jobs.finished, jobs.retried)
+			continue
+		}
+		if delay := time.Until(jobs.retried[0]); delay < time.Minute {
+			t.Errorf("%s: retried in %v, want backoff", tt.name, delay)
+		}
+	}
+}
@@ -0,0 +1,324 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"vk-inter/internal/models"
+	"vk-inter/pkg/cursor"
+	"vk-inter/pkg/errs"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+const maxMessageLength = 2000
+
+type MessagingConfig struct {
+	// A buyer may start up to ThreadRateLimit threads per ThreadRateWindow
+	ThreadRateLimit  int           `env:"THREAD_RATE_LIMIT" env-default:"10"`
+	ThreadRateWindow time.Duration `env:"THREAD_RATE_WINDOW" env-default:"1h"`
+}
+
+type ThreadRepo interface {
+	CreateThread(ctx context.Context, thread *models.Thread) (*models.Thread, bool, error)
+	FindThread(ctx context.Context, listingID, buyerID primitive.ObjectID) (*models.Thread, error)
+	GetThreadByID(ctx context.Context, id primitive.ObjectID) (*models.Thread, error)
+	GetThreads(ctx context.Context, userID primitive.ObjectID, after *models.TimeCursor, limit int) ([]*models.Thread, error)
+	AddMessage(ctx context.Context, id primitive.ObjectID, preview *models.MessagePreview, recipientRole string) (*models.Thread, error)
+	MarkRead(ctx context.Context, id primitive.ObjectID, role string, at time.Time) (*models.Thread, error)
+	CountThreadsSince(ctx context.Context, buyerID primitive.ObjectID, since time.Time) (int64, error)
+	CountUnread(ctx context.Context, userID primitive.ObjectID) (int64, error)
+}
+
+type MessageRepo interface {
+	CreateMessage(ctx context.Context, message *models.Message) (*models.Message, error)
+	GetMessages(ctx context.Context, threadID primitive.ObjectID, after *models.TimeCursor, limit int) ([]*models.Message, error)
+}
+
+type ThreadListingRepo interface {
+	GetListingByID(ctx context.Context, id primitive.ObjectID) (*models.Listing, error)
+}
+
+// MessagingService keeps conversations of buyers with sellers.
+//
+// Threads are visible only to their participants, strangers get ErrThreadNotFound.
+type MessagingService struct {
+	threadRepo  ThreadRepo
+	messageRepo MessageRepo
+	listingRepo ThreadListingRepo
+	events      EventPublisher
+	cfg         MessagingConfig
+	secret      string
+}
+
+func NewMessagingService(threadRepo ThreadRepo, messageRepo MessageRepo, listingRepo ThreadListingRepo, events EventPublisher, cfg MessagingConfig, secret string) *MessagingService {
+	return &MessagingService{
+		threadRepo:  threadRepo,
+		messageRepo: messageRepo,
+		listingRepo: listingRepo,
+		events:      events,
+		cfg:         cfg,
+		secret:      secret,
+	}
+}
+
+// StartThread sends the first message to the seller of the listing.
+//
+// If the user already has a thread about the listing, the message is added to it.
+func (ms *MessagingService) StartThread(ctx context.Context, listingID primitive.ObjectID, text string, user *models.User) (*models.ThreadMessage, error) {
+	text, err := normalizeMessage(text)
+	if err != nil {
+		return nil, err
+	}
+	listing, err := ms.listingRepo.GetListingByID(ctx, listingID)
+	if err != nil {
+		return nil, err
+	}
+	if !listing.IsPublic() {
+		return nil, errs.ErrListingNotFound
+	}
+	if listing.OwnerID == user.ID {
+		return nil, errs.ErrThreadOwnListing
+	}
+
+	thread, err := ms.threadRepo.FindThread(ctx, listing.ID, user.ID)
+	if err == nil {
+		return ms.post(ctx, thread, text, user)
+	}
+	if !errors.Is(err, errs.ErrThreadNotFound) {
+		return nil, err
+	}
+
+	// Лимит проверяется до вставки, поэтому параллельные запросы могут превысить его на единицы
+	recent, err := ms.threadRepo.CountThreadsSince(ctx, user.ID, time.Now().Add(-ms.cfg.ThreadRateWindow))
+	if err != nil {
+		return nil, err
+	}
+	if recent >= int64(ms.cfg.ThreadRateLimit) {
+		return nil, fmt.Errorf("%w: up to %d threads per %s", errs.ErrThreadRateLimited, ms.cfg.ThreadRateLimit, ms.cfg.ThreadRateWindow)
+	}
+	// Параллельный запрос мог создать переписку, тогда возвращается она
+	thread, _, err = ms.threadRepo.CreateThread(ctx, &models.Thread{
+		ListingID:    listing.ID,
+		ListingTitle: listing.Title,
+		BuyerID:      user.ID,
+		BuyerLogin:   user.Login,
+		SellerID:     listing.OwnerID,
+		SellerLogin:  listing.OwnerLogin,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return ms.post(ctx, thread, text, user)
+}
+
+// PostMessage adds message to the thread
+func (ms *MessagingService) PostMessage(ctx context.Context, threadID primitive.ObjectID, text string, user *models.User) (*models.ThreadMessage, error) {
+	text, err := normalizeMessage(text)
+	if err != nil {
+		return nil, err
+	}
+	thread, err := ms.participantThread(ctx, threadID, user)
+	if err != nil {
+		return nil, err
+	}
+	return ms.post(ctx, thread, text, user)
+}
+
+func (ms *MessagingService) post(ctx context.Context, thread *models.Thread, text string, user *models.User) (*models.ThreadMessage, error) {
+	message, err := ms.messageRepo.CreateMessage(ctx, &models.Message{
+		ThreadID: thread.ID,
+		SenderID: user.ID,
+		Text:     text,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	role := thread.RoleOf(user.ID)
+	recipient := models.ThreadRoleSeller
+	if role == models.ThreadRoleSeller {
+		recipient = models.ThreadRoleBuyer
+	}
+	thread, err = ms.threadRepo.AddMessage(ctx, thread.ID, &models.MessagePreview{
+		Text:      previewText(text),
+		SenderID:  user.ID,
+		CreatedAt: message.CreatedAt,
+	}, recipient)
+	if err != nil {
+		return nil, err
+	}
+
+	read := false
+	message.Read = &read
+	// Сообщение получают и собеседник, и другие устройства отправителя
+	for _, userID := range []primitive.ObjectID{thread.BuyerID, thread.SellerID} {
+		viewed := *thread
+		setViewer(&viewed, userID)
+		event := &models.ThreadMessage{Thread: &viewed, Message: message}
+		if userID != user.ID {
+			// Отметка о прочтении показывается только отправителю
+			incoming := *message
+			incoming.Read = nil
+			event.Message = &incoming
+		}
+		ms.events.Publish(ctx, userID, models.EventTypeMessage, event)
+	}
+
+	setViewer(thread, user.ID)
+	return &models.ThreadMessage{Thread: thread, Message: message}, nil
+}
+
+// GetThread returns thread visible to the participant
+func (ms *MessagingService) GetThread(ctx context.Context, id primitive.ObjectID, user *models.User) (*models.Thread, error) {
+	thread, err := ms.participantThread(ctx, id, user)
+	if err != nil {
+		return nil, err
+	}
+	setViewer(thread, user.ID)
+	return thread, nil
+}
+
+// GetThreads returns user's threads, the latest conversations first
+func (ms *MessagingService) GetThreads(ctx context.Context, cursorToken string, limit int, user *models.User) (*models.ThreadPage, error) {
+	limit = pageLimit(limit)
+	after, err := ms.decodeCursor(cursorToken)
+	if err != nil {
+		return nil, err
+	}
+
+	threads, err := ms.threadRepo.GetThreads(ctx, user.ID, after, limit+1)
+	if err != nil {
+		return nil, err
+	}
+	page := &models.ThreadPage{Items: threads}
+	if len(threads) > limit {
+		page.Items = threads[:limit]
+		last := page.Items[limit-1]
+		if page.NextCursor, err = cursor.Encode(models.TimeCursor{Time: last.LastMessageAt, ID: last.ID}, ms.secret); err != nil {
+			return nil, err
+		}
+	}
+	for _, t := range page.Items {
+		setViewer(t, user.ID)
+	}
+
+	if page.Unread, err = ms.threadRepo.CountUnread(ctx, user.ID); err != nil {
+		return nil, err
+	}
+	return page, nil
+}
+
+// GetMessages returns messages of the thread, newest first
+func (ms *MessagingService) GetMessages(ctx context.Context, threadID primitive.ObjectID, cursorToken string, limit int, user *models.User) (*models.MessagePage, error) {
+	limit = pageLimit(limit)
+	thread, err := ms.participantThread(ctx, threadID, user)
+	if err != nil {
+		return nil, err
+	}
+	after, err := ms.decodeCursor(cursorToken)
+	if err != nil {
+		return nil, err
+	}
+
+	messages, err := ms.messageRepo.GetMessages(ctx, threadID, after, limit+1)
+	if err != nil {
+		return nil, err
+	}
+	page := &models.MessagePage{Items: messages}
+	if len(messages) > limit {
+		page.Items = messages[:limit]
+		last := page.Items[limit-1]
+		if page.NextCursor, err = cursor.Encode(models.TimeCursor{Time: last.CreatedAt, ID: last.ID}, ms.secret); err != nil {
+			return nil, err
+		}
+	}
+
+	// Прочитано собеседником всё, что создано до его отметки о прочтении
+	otherReadAt := thread.SellerReadAt
+	if thread.RoleOf(user.ID) == models.ThreadRoleSeller {
+		otherReadAt = thread.BuyerReadAt
+	}
+	for _, m := range page.Items {
+		if m.SenderID == user.ID {
+			read := otherReadAt != nil && !m.CreatedAt.After(*otherReadAt)
+			m.Read = &read
+		}
+	}
+	return page, nil
+}
+
+// MarkRead marks all messages of the thread as read by the user
+func (ms *MessagingService) MarkRead(ctx context.Context, threadID primitive.ObjectID, user *models.User) (*models.Thread, error) {
+	thread, err := ms.participantThread(ctx, threadID, user)
+	if err != nil {
+		return nil, err
+	}
+	thread, err = ms.threadRepo.MarkRead(ctx, thread.ID, thread.RoleOf(user.ID), time.Now())
+	if err != nil {
+		return nil, err
+	}
+	setViewer(thread, user.ID)
+	return thread, nil
+}
+
+func (ms *MessagingService) participantThread(ctx context.Context, id primitive.ObjectID, user *models.User) (*models.Thread, error) {
+	thread, err := ms.threadRepo.GetThreadByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	// Чужие переписки не отличаются от несуществующих
+	if thread.RoleOf(user.ID) == "" {
+		return nil, errs.ErrThreadNotFound
+	}
+	return thread, nil
+}
+
+func (ms *MessagingService) decodeCursor(token string) (*models.TimeCursor, error) {
+	if token == "" {
+		return nil, nil
+	}
+	var c models.TimeCursor
+	if err := cursor.Decode(token, ms.secret, &c); err != nil {
+		return nil, errs.ErrInvalidCursor
+	}
+	return &c, nil
+}
+
+// setViewer sets role and unread counter of the user
+func setViewer(thread *models.Thread, userID primitive.ObjectID) {
+	thread.Role = thread.RoleOf(userID)
+	thread.Unread = thread.BuyerUnread
+	if thread.Role == models.ThreadRoleSeller {
+		thread.Unread = thread.SellerUnread
+	}
+}
+
+func normalizeMessage(text string) (string, error) {
+	text = strings.TrimSpace(text)
+	if text == "" || utf8.RuneCountInString(text) > maxMessageLength {
+		return "", errs.ErrMessageInvalidText
+	}
+	return text, nil
+}
+
+// previewText cuts message for the threads list
+func previewText(text string) string {
+	const maxPreview = 100
+	if utf8.RuneCountInString(text) <= maxPreview {
+		return text
+	}
+	return string([]rune(text)[:maxPreview]) + "…"
+}
+
+func pageLimit(limit int) int {
+	if limit < 1 || limit > 100 {
+		return 20
+	}
+	return limit
+}
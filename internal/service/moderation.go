@@ -0,0 +1,212 @@
+package service
+
+import (
+	"context"
+	"slices"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"vk-inter/internal/models"
+	"vk-inter/pkg/errs"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+const maxModerationComment = 1000
+
+var (
+	reportReasons = []string{
+		models.ReportReasonScam,
+		models.ReportReasonProhibited,
+		models.ReportReasonSpam,
+		models.ReportReasonOffensive,
+		models.ReportReasonWrongCategory,
+		models.ReportReasonOther,
+	}
+	caseStatuses = []string{models.CaseStatusOpen, models.CaseStatusClaimed, models.CaseStatusResolved}
+	caseActions  = []string{models.CaseActionDismiss, models.CaseActionHide, models.CaseActionRemove, models.CaseActionBanOwner}
+)
+
+type ModerationConfig struct {
+	// Listing is hidden after AutoHideReports distinct reports pending review
+	AutoHideReports int64 `env:"MODERATION_AUTO_HIDE_REPORTS" env-default:"3"`
+	// ClaimTTL is the time after which a claimed case can be taken over by another moderator
+	ClaimTTL time.Duration `env:"MODERATION_CLAIM_TTL" env-default:"30m"`
+}
+
+type ModerationRepo interface {
+	CreateReport(ctx context.Context, report *models.Report, listing *models.Listing, autoHideAt int64) (*models.ModerationCase, error)
+	FlagListing(ctx context.Context, listing *models.Listing, rules []string, now time.Time) (*models.ModerationCase, error)
+	GetCases(ctx context.Context, status string, skip, limit int) ([]*models.ModerationCase, int64, error)
+	GetCase(ctx context.Context, id primitive.ObjectID) (*models.ModerationCase, error)
+	GetCaseReports(ctx context.Context, caseID primitive.ObjectID) ([]*models.Report, error)
+	GetAudit(ctx context.Context, caseID primitive.ObjectID) ([]*models.AuditEntry, error)
+	ClaimCase(ctx context.Context, id primitive.ObjectID, moderator *models.User, now, staleBefore time.Time) (*models.ModerationCase, error)
+	ResolveCase(ctx context.Context, id primitive.ObjectID, moderator *models.User, action, comment string, now time.Time) (*models.ModerationCase, error)
+}
+
+type ModerationListingRepo interface {
+	GetListingByID(ctx context.Context, id primitive.ObjectID) (*models.Listing, error)
+}
+
+// ModerationService collects abuse reports into moderation cases and lets moderators resolve them.
+//
+// A case must be claimed before it is resolved, every step is written to the audit trail.
+type ModerationService struct {
+	repo        ModerationRepo
+	listingRepo ModerationListingRepo
+	events      EventPublisher
+	cfg         ModerationConfig
+}
+
+func NewModerationService(repo ModerationRepo, listingRepo ModerationListingRepo, events EventPublisher, cfg ModerationConfig) *ModerationService {
+	return &ModerationService{
+		repo:        repo,
+		listingRepo: listingRepo,
+		events:      events,
+		cfg:         cfg,
+	}
+}
+
+// ReportListing files the user's report about the listing
+func (ms *ModerationService) ReportListing(ctx context.Context, listingID primitive.ObjectID, reason, comment string, user *models.User) (*models.Report, error) {
+	if !slices.Contains(reportReasons, reason) {
+		return nil, errs.ErrReportInvalidReason
+	}
+	comment, ok := normalizeModerationComment(comment)
+	if !ok {
+		return nil, errs.ErrReportInvalidComment
+	}
+	listing, err := ms.listingRepo.GetListingByID(ctx, listingID)
+	if err != nil {
+		return nil, err
+	}
+	if !listing.IsPublic() {
+		return nil, errs.ErrListingNotFound
+	}
+	if listing.OwnerID == user.ID {
+		return nil, errs.ErrReportOwnListing
+	}
+
+	report := &models.Report{
+		ListingID:     listing.ID,
+		ReporterID:    user.ID,
+		ReporterLogin: user.Login,
+		Reason:        reason,
+		Comment:       comment,
+		CreatedAt:     time.Now(),
+	}
+	c, err := ms.repo.CreateReport(ctx, report, listing, ms.cfg.AutoHideReports)
+	if err != nil {
+		return nil, err
+	}
+	if c.AutoHidden && listing.Status == models.ListingStatusPublished {
+		ms.publishStatus(ctx, c, models.ListingStatusHidden)
+	}
+	return report, nil
+}
+
+// FlagListing sends the listing matched by content filter rules to the moderation queue
+func (ms *ModerationService) FlagListing(ctx context.Context, listing *models.Listing, rules []string) error {
+	_, err := ms.repo.FlagListing(ctx, listing, rules, time.Now())
+	return err
+}
+
+// GetCases returns moderation queue in the status, the most reported cases first
+func (ms *ModerationService) GetCases(ctx context.Context, status string, page, limit int, user *models.User) (*models.CasePage, error) {
+	if !user.IsModerator() {
+		return nil, errs.ErrForbidden
+	}
+	if status == "" {
+		status = models.CaseStatusOpen
+	}
+	if !slices.Contains(caseStatuses, status) {
+		return nil, errs.ErrCaseInvalidStatus
+	}
+	if page < 1 {
+		page = 1
+	}
+	limit = pageLimit(limit)
+
+	cases, total, err := ms.repo.GetCases(ctx, status, (page-1)*limit, limit)
+	if err != nil {
+		return nil, err
+	}
+	return &models.CasePage{
+		Items:   cases,
+		Page:    page,
+		Limit:   limit,
+		Total:   total,
+		HasNext: int64(page*limit) < total,
+	}, nil
+}
+
+// GetCase returns the case with its reports and audit trail
+func (ms *ModerationService) GetCase(ctx context.Context, id primitive.ObjectID, user *models.User) (*models.CaseDetails, error) {
+	if !user.IsModerator() {
+		return nil, errs.ErrForbidden
+	}
+	c, err := ms.repo.GetCase(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	reports, err := ms.repo.GetCaseReports(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	audit, err := ms.repo.GetAudit(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	return &models.CaseDetails{Case: c, Reports: reports, Audit: audit}, nil
+}
+
+// ClaimCase assigns the case to the moderator, claims older than ClaimTTL can be taken over
+func (ms *ModerationService) ClaimCase(ctx context.Context, id primitive.ObjectID, user *models.User) (*models.ModerationCase, error) {
+	if !user.IsModerator() {
+		return nil, errs.ErrForbidden
+	}
+	now := time.Now()
+	return ms.repo.ClaimCase(ctx, id, user, now, now.Add(-ms.cfg.ClaimTTL))
+}
+
+// ResolveCase resolves the case claimed by the moderator
+func (ms *ModerationService) ResolveCase(ctx context.Context, id primitive.ObjectID, action, comment string, user *models.User) (*models.ModerationCase, error) {
+	if !user.IsModerator() {
+		return nil, errs.ErrForbidden
+	}
+	if !slices.Contains(caseActions, action) {
+		return nil, errs.ErrCaseInvalidAction
+	}
+	comment, ok := normalizeModerationComment(comment)
+	if !ok {
+		return nil, errs.ErrCaseInvalidComment
+	}
+
+	c, err := ms.repo.ResolveCase(ctx, id, user, action, comment, time.Now())
+	if err != nil {
+		return nil, err
+	}
+	switch {
+	case action == models.CaseActionHide:
+		ms.publishStatus(ctx, c, models.ListingStatusHidden)
+	case action == models.CaseActionRemove || action == models.CaseActionBanOwner:
+		ms.publishStatus(ctx, c, models.ListingStatusRemoved)
+	case c.AutoHidden:
+		ms.publishStatus(ctx, c, c.RestoreStatus)
+	}
+	return c, nil
+}
+
+func (ms *ModerationService) publishStatus(ctx context.Context, c *models.ModerationCase, status string) {
+	ms.events.Publish(ctx, c.OwnerID, models.EventTypeListingStatus, &models.ListingStatusEvent{
+		ListingID: c.ListingID,
+		Status:    status,
+	})
+}
+
+func normalizeModerationComment(comment string) (string, bool) {
+	comment = strings.TrimSpace(comment)
+	return comment, utf8.RuneCountInString(comment) <= maxModerationComment
+}
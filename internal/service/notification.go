@@ -0,0 +1,84 @@
+package service
+
+import (
+	"context"
+
+	"vk-inter/internal/models"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type NotificationRepo interface {
+	CreateNotification(ctx context.Context, n *models.Notification) (bool, error)
+	GetNotifications(ctx context.Context, userID primitive.ObjectID, unreadOnly bool, skip, limit int) ([]*models.Notification, error)
+	CountNotifications(ctx context.Context, userID primitive.ObjectID, unreadOnly bool) (int64, error)
+	MarkRead(ctx context.Context, id, userID primitive.ObjectID) error
+	MarkAllRead(ctx context.Context, userID primitive.ObjectID) (int64, error)
+}
+
+type NotificationService struct {
+	repo   NotificationRepo
+	events EventPublisher
+}
+
+func NewNotificationService(repo NotificationRepo, events EventPublisher) *NotificationService {
+	return &NotificationService{repo: repo, events: events}
+}
+
+// CreateNotification stores notification and pushes it to user's connections,
+// returns false if the notification with the same dedup key already exists
+func (ns *NotificationService) CreateNotification(ctx context.Context, n *models.Notification) (bool, error) {
+	created, err := ns.repo.CreateNotification(ctx, n)
+	if err != nil || !created {
+		return created, err
+	}
+	ns.events.Publish(ctx, n.UserID, models.EventTypeNotification, n)
+	return true, nil
+}
+
+// GetNotifications returns a page of user's notifications, latest first
+func (ns *NotificationService) GetNotifications(ctx context.Context, page, limit int, unreadOnly bool, user *models.User) (*models.NotificationPage, error) {
+	if page < 1 {
+		page = 1
+	}
+	if limit < 1 || limit > 100 {
+		limit = 20
+	}
+
+	items, err := ns.repo.GetNotifications(ctx, user.ID, unreadOnly, (page-1)*limit, limit+1)
+	if err != nil {
+		return nil, err
+	}
+	hasNext := len(items) > limit
+	if hasNext {
+		items = items[:limit]
+	}
+	total, err := ns.repo.CountNotifications(ctx, user.ID, unreadOnly)
+	if err != nil {
+		return nil, err
+	}
+	unread := total
+	if !unreadOnly {
+		if unread, err = ns.repo.CountNotifications(ctx, user.ID, true); err != nil {
+			return nil, err
+		}
+	}
+
+	return &models.NotificationPage{
+		Items:   items,
+		Page:    page,
+		Limit:   limit,
+		Total:   total,
+		Unread:  unread,
+		HasNext: hasNext,
+	}, nil
+}
+
+func (ns *NotificationService) MarkRead(ctx context.Context, id primitive.ObjectID, user *models.User) error {
+	return ns.repo.MarkRead(ctx, id, user.ID)
+}
+
+// MarkAllRead returns the number of notifications marked as read
+func (ns *NotificationService) MarkAllRead(ctx context.Context, user *models.User) (int64, error) {
+	return ns.repo.MarkAllRead(ctx, user.ID)
+}
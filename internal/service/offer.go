@@ -0,0 +1,356 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"vk-inter/internal/models"
+	"vk-inter/pkg/errs"
+	"vk-inter/pkg/logger"
+	"vk-inter/pkg/money"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.uber.org/zap"
+)
+
+const maxOfferMessage = 500
+
+var offerStatuses = []string{
+	models.OfferStatusPending,
+	models.OfferStatusAccepted,
+	models.OfferStatusRejected,
+	models.OfferStatusCountered,
+	models.OfferStatusWithdrawn,
+	models.OfferStatusExpired,
+}
+
+type OfferConfig struct {
+	// TTL is the time to respond to an offer, then it expires
+	TTL            time.Duration `env:"OFFER_TTL" env-default:"48h"`
+	ExpireInterval time.Duration `env:"OFFER_EXPIRE_INTERVAL" env-default:"1m"`
+}
+
+type OfferRepo interface {
+	CreateOffer(ctx context.Context, offer *models.Offer) (*models.Offer, error)
+	GetOfferByID(ctx context.Context, id primitive.ObjectID) (*models.Offer, error)
+	GetListingOffers(ctx context.Context, listingID primitive.ObjectID, status string, skip, limit int) ([]*models.Offer, error)
+	GetBuyerOffers(ctx context.Context, buyerID primitive.ObjectID, status string, skip, limit int) ([]*models.Offer, error)
+	CloseOffer(ctx context.Context, id primitive.ObjectID, status string, now time.Time) (*models.Offer, error)
+	CounterOffer(ctx context.Context, id primitive.ObjectID, counter *models.Offer, now time.Time) (*models.Offer, error)
+	AcceptOffer(ctx context.Context, id primitive.ObjectID, now time.Time) (*models.Offer, []*models.Offer, error)
+	ExpireOffers(ctx context.Context, now time.Time) (int64, error)
+}
+
+type OfferListingRepo interface {
+	GetListingByID(ctx context.Context, id primitive.ObjectID) (*models.Listing, error)
+}
+
+// OfferService runs negotiations of buyers with sellers.
+//
+// Only the party who did not propose an offer can accept, reject or counter it,
+// the proposer can withdraw it. Accepting an offer reserves the listing.
+type OfferService struct {
+	repo          OfferRepo
+	listingRepo   OfferListingRepo
+	notifications NotificationCreator
+	events        EventPublisher
+	cfg           OfferConfig
+}
+
+func NewOfferService(repo OfferRepo, listingRepo OfferListingRepo, notifications NotificationCreator, events EventPublisher, cfg OfferConfig) *OfferService {
+	return &OfferService{
+		repo:          repo,
+		listingRepo:   listingRepo,
+		notifications: notifications,
+		events:        events,
+		cfg:           cfg,
+	}
+}
+
+// MakeOffer starts a negotiation about the listing price
+func (of *OfferService) MakeOffer(ctx context.Context, listingID primitive.ObjectID, amount money.Decimal, message string, user *models.User) (*models.Offer, error) {
+	message, err := normalizeOfferMessage(message)
+	if err != nil {
+		return nil, err
+	}
+	listing, err := of.listingRepo.GetListingByID(ctx, listingID)
+	if err != nil {
+		return nil, err
+	}
+	if !listing.IsPublic() {
+		return nil, errs.ErrListingNotFound
+	}
+	if listing.IsAuction() {
+		return nil, errs.ErrListingIsAuction
+	}
+	if listing.Status != models.ListingStatusPublished {
+		return nil, errs.ErrListingNotAvailable
+	}
+	if listing.OwnerID == user.ID {
+		return nil, errs.ErrOfferOwnListing
+	}
+	amount, err = offerAmount(amount, listing.Currency)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	offer, err := of.repo.CreateOffer(ctx, &models.Offer{
+		ListingID:    listing.ID,
+		ListingTitle: listing.Title,
+		BuyerID:      user.ID,
+		BuyerLogin:   user.Login,
+		SellerID:     listing.OwnerID,
+		ProposedBy:   models.OfferByBuyer,
+		Amount:       amount,
+		Currency:     listing.Currency,
+		Message:      message,
+		Status:       models.OfferStatusPending,
+		ExpiresAt:    now.Add(of.cfg.TTL),
+		CreatedAt:    now,
+		UpdatedAt:    now,
+	})
+	if err != nil {
+		return nil, err
+	}
+	of.notify(ctx, offer, offer.SellerID, fmt.Sprintf("%s offers %s %s for %q", offer.BuyerLogin, offer.Amount, offer.Currency, offer.ListingTitle))
+	return offer, nil
+}
+
+// CounterOffer answers the offer with another amount
+func (of *OfferService) CounterOffer(ctx context.Context, id primitive.ObjectID, amount money.Decimal, message string, user *models.User) (*models.Offer, error) {
+	message, err := normalizeOfferMessage(message)
+	if err != nil {
+		return nil, err
+	}
+	offer, err := of.respondable(ctx, id, user)
+	if err != nil {
+		return nil, err
+	}
+	amount, err = offerAmount(amount, offer.Currency)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	counter := *offer
+	counter.ParentID = &offer.ID
+	counter.ProposedBy = offer.RoleOf(user.ID)
+	counter.Amount = amount
+	counter.Message = message
+	counter.Status = models.OfferStatusPending
+	counter.ExpiresAt = now.Add(of.cfg.TTL)
+	counter.CreatedAt = now
+	counter.UpdatedAt = now
+
+	created, err := of.repo.CounterOffer(ctx, offer.ID, &counter, now)
+	if err != nil {
+		return nil, err
+	}
+	of.notify(ctx, created, otherParty(created, user.ID), fmt.Sprintf("Counter-offer %s %s for %q", created.Amount, created.Currency, created.ListingTitle))
+	return created, nil
+}
+
+// AcceptOffer accepts the offer, reserves the listing and rejects competing offers
+func (of *OfferService) AcceptOffer(ctx context.Context, id primitive.ObjectID, user *models.User) (*models.Offer, error) {
+	offer, err := of.respondable(ctx, id, user)
+	if err != nil {
+		return nil, err
+	}
+
+	accepted, rejected, err := of.repo.AcceptOffer(ctx, offer.ID, time.Now())
+	if err != nil {
+		return nil, err
+	}
+
+	of.notify(ctx, accepted, otherParty(accepted, user.ID), fmt.Sprintf("Offer %s %s for %q is accepted", accepted.Amount, accepted.Currency, accepted.ListingTitle))
+	for _, o := range rejected {
+		of.notify(ctx, o, o.BuyerID, fmt.Sprintf("%q is reserved for another buyer", o.ListingTitle))
+	}
+	of.events.Publish(ctx, accepted.SellerID, models.EventTypeListingStatus, &models.ListingStatusEvent{
+		ListingID: accepted.ListingID,
+		Status:    models.ListingStatusReserved,
+	})
+	return accepted, nil
+}
+
+// RejectOffer declines the offer, the negotiation ends
+func (of *OfferService) RejectOffer(ctx context.Context, id primitive.ObjectID, user *models.User) (*models.Offer, error) {
+	offer, err := of.respondable(ctx, id, user)
+	if err != nil {
+		return nil, err
+	}
+	rejected, err := of.repo.CloseOffer(ctx, offer.ID, models.OfferStatusRejected, time.Now())
+	if err != nil {
+		return nil, err
+	}
+	of.notify(ctx, rejected, otherParty(rejected, user.ID), fmt.Sprintf("Offer %s %s for %q is rejected", rejected.Amount, rejected.Currency, rejected.ListingTitle))
+	return rejected, nil
+}
+
+// WithdrawOffer cancels the offer by its proposer
+func (of *OfferService) WithdrawOffer(ctx context.Context, id primitive.ObjectID, user *models.User) (*models.Offer, error) {
+	offer, err := of.participantOffer(ctx, id, user)
+	if err != nil {
+		return nil, err
+	}
+	if offer.RoleOf(user.ID) != offer.ProposedBy {
+		return nil, errs.ErrForbidden
+	}
+	withdrawn, err := of.repo.CloseOffer(ctx, offer.ID, models.OfferStatusWithdrawn, time.Now())
+	if err != nil {
+		return nil, err
+	}
+	of.notify(ctx, withdrawn, otherParty(withdrawn, user.ID), fmt.Sprintf("Offer for %q is withdrawn", withdrawn.ListingTitle))
+	return withdrawn, nil
+}
+
+// GetOffer returns offer visible to the participants and admins
+func (of *OfferService) GetOffer(ctx context.Context, id primitive.ObjectID, user *models.User) (*models.Offer, error) {
+	return of.participantOffer(ctx, id, user)
+}
+
+// GetListingOffers returns offers on the listing to its owner, latest first
+func (of *OfferService) GetListingOffers(ctx context.Context, listingID primitive.ObjectID, status string, page, limit int, user *models.User) (*models.OfferPage, error) {
+	if err := validOfferStatus(status); err != nil {
+		return nil, err
+	}
+	listing, err := of.listingRepo.GetListingByID(ctx, listingID)
+	if err != nil {
+		return nil, err
+	}
+	if listing.OwnerID != user.ID && !user.IsAdmin() {
+		return nil, errs.ErrForbidden
+	}
+
+	page, limit = offerPageBounds(page, limit)
+	offers, err := of.repo.GetListingOffers(ctx, listingID, status, (page-1)*limit, limit+1)
+	if err != nil {
+		return nil, err
+	}
+	return offerPage(offers, page, limit), nil
+}
+
+// GetMyOffers returns offers of the user's negotiations as a buyer, latest first
+func (of *OfferService) GetMyOffers(ctx context.Context, status string, page, limit int, user *models.User) (*models.OfferPage, error) {
+	if err := validOfferStatus(status); err != nil {
+		return nil, err
+	}
+	page, limit = offerPageBounds(page, limit)
+	offers, err := of.repo.GetBuyerOffers(ctx, user.ID, status, (page-1)*limit, limit+1)
+	if err != nil {
+		return nil, err
+	}
+	return offerPage(offers, page, limit), nil
+}
+
+// Run expires offers which were not answered in time and blocks until ctx is done
+func (of *OfferService) Run(ctx context.Context) {
+	ticker := time.NewTicker(max(of.cfg.ExpireInterval, time.Second))
+	defer ticker.Stop()
+	for {
+		expired, err := of.repo.ExpireOffers(ctx, time.Now())
+		if err != nil && ctx.Err() == nil {
+			logger.FromContext(ctx).Warn("Expire offers error", zap.Error(err))
+		}
+		if expired > 0 {
+			logger.FromContext(ctx).Debug("Offers expired", zap.Int64("count", expired))
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (of *OfferService) participantOffer(ctx context.Context, id primitive.ObjectID, user *models.User) (*models.Offer, error) {
+	offer, err := of.repo.GetOfferByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if offer.RoleOf(user.ID) == "" && !user.IsAdmin() {
+		return nil, errs.ErrOfferNotFound
+	}
+	return offer, nil
+}
+
+// respondable returns pending offer which the user can accept, reject or counter
+func (of *OfferService) respondable(ctx context.Context, id primitive.ObjectID, user *models.User) (*models.Offer, error) {
+	offer, err := of.participantOffer(ctx, id, user)
+	if err != nil {
+		return nil, err
+	}
+	role := offer.RoleOf(user.ID)
+	if role == "" || role == offer.ProposedBy {
+		return nil, errs.ErrOfferNotAllowed
+	}
+	if offer.Status != models.OfferStatusPending {
+		return nil, errs.ErrOfferNotPending
+	}
+	return offer, nil
+}
+
+func (of *OfferService) notify(ctx context.Context, offer *models.Offer, userID primitive.ObjectID, title string) {
+	_, err := of.notifications.CreateNotification(ctx, &models.Notification{
+		UserID:    userID,
+		Type:      models.NotificationTypeOffer,
+		Title:     title,
+		ListingID: &offer.ListingID,
+		OfferID:   &offer.ID,
+		DedupKey:  "offer:" + offer.ID.Hex() + ":" + offer.Status + ":" + userID.Hex(),
+	})
+	if err != nil {
+		logger.FromContext(ctx).Warn("Create offer notification error", zap.String("offer_id", offer.ID.Hex()), zap.Error(err))
+	}
+}
+
+func otherParty(offer *models.Offer, userID primitive.ObjectID) primitive.ObjectID {
+	if userID == offer.BuyerID {
+		return offer.SellerID
+	}
+	return offer.BuyerID
+}
+
+// offerAmount validates amount in the listing currency
+func offerAmount(amount money.Decimal, currency string) (money.Decimal, error) {
+	if !amount.IsPositive() || amount.GreaterThan(maxPrice.Decimal) || amount.Scale() > money.MinorUnits(currency) {
+		return money.Zero, errs.ErrOfferInvalidAmount
+	}
+	return money.New(amount.Round(money.MinorUnits(currency))), nil
+}
+
+func normalizeOfferMessage(message string) (string, error) {
+	message = strings.TrimSpace(message)
+	if utf8.RuneCountInString(message) > maxOfferMessage {
+		return "", errs.ErrOfferInvalidMessage
+	}
+	return message, nil
+}
+
+func validOfferStatus(status string) error {
+	if status != "" && !slices.Contains(offerStatuses, status) {
+		return errs.ErrOfferInvalidStatus
+	}
+	return nil
+}
+
+func offerPageBounds(page, limit int) (int, int) {
+	if page < 1 {
+		page = 1
+	}
+	return page, pageLimit(limit)
+}
+
+func offerPage(offers []*models.Offer, page, limit int) *models.OfferPage {
+	hasNext := len(offers) > limit
+	if hasNext {
+		offers = offers[:limit]
+	}
+	return &models.OfferPage{Items: offers, Page: page, Limit: limit, HasNext: hasNext}
+}
@@ -0,0 +1,188 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"vk-inter/internal/models"
+	"vk-inter/pkg/errs"
+	"vk-inter/pkg/money"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+var maxPrice = money.NewFromInt(1_000_000_000)
+
+// maxPriceAttempts limits retries of price update changed concurrently
+const maxPriceAttempts = 3
+
+// RateProvider converts prices between currencies
+type RateProvider interface {
+	Base() string
+	Currencies() []string
+	Rate(from, to string) (money.Decimal, error)
+}
+
+// SyncExchangeRates recalculates prices in the base currency of all listings.
+//
+// Must be called on startup, because rates could change since listings were saved.
+func (ls *ListingService) SyncExchangeRates(ctx context.Context) error {
+	if err := ls.repo.SetMissingCurrency(ctx, ls.rates.Base()); err != nil {
+		return err
+	}
+
+	rates := map[string]money.Decimal{}
+	for _, currency := range ls.rates.Currencies() {
+		rate, err := ls.rates.Rate(currency, ls.rates.Base())
+		if err != nil {
+			return err
+		}
+		rates[currency] = rate
+	}
+	return ls.repo.RecalculateBasePrices(ctx, rates)
+}
+
+// normalizeCurrency returns supported currency code, empty code means the base currency
+func (ls *ListingService) normalizeCurrency(code string) (string, error) {
+	if code == "" {
+		return ls.rates.Base(), nil
+	}
+	code, ok := money.NormalizeCurrency(code)
+	if !ok {
+		return "", errs.ErrListingInvalidCurrency
+	}
+	if _, err := ls.rates.Rate(code, ls.rates.Base()); err != nil {
+		return "", errs.ErrListingInvalidCurrency
+	}
+	return code, nil
+}
+
+// setPrice validates price in listing currency and calculates price in the base currency
+func (ls *ListingService) setPrice(listing *models.Listing) error {
+	currency, err := ls.normalizeCurrency(listing.Currency)
+	if err != nil {
+		return err
+	}
+	listing.Currency = currency
+
+	units := money.MinorUnits(currency)
+	price := listing.Price
+	if price.IsNegative() || price.GreaterThan(maxPrice.Decimal) || price.Scale() > units {
+		return errs.ErrListingInvalidPrice
+	}
+	// 10.5 USD хранится как 10.50
+	listing.Price = money.New(price.Round(units))
+
+	rate, err := ls.rates.Rate(currency, ls.rates.Base())
+	if err != nil {
+		return errs.ErrListingInvalidCurrency
+	}
+	listing.PriceBase = money.New(listing.Price.Mul(rate.Decimal))
+	return nil
+}
+
+// UpdatePrice changes price of the listing and records the change in its price history
+func (ls *ListingService) UpdatePrice(ctx context.Context, id primitive.ObjectID, price money.Decimal, currency string, user *models.User) (*models.Listing, error) {
+	for attempt := 1; ; attempt++ {
+		listing, err := ls.repo.GetListingByID(ctx, id)
+		if err != nil {
+			return nil, err
+		}
+		if listing.OwnerID != user.ID && !user.IsAdmin() {
+			return nil, errs.ErrForbidden
+		}
+		// Цена аукциона определяется ставками
+		if listing.IsAuction() {
+			return nil, errs.ErrListingIsAuction
+		}
+
+		updated, err := ls.updatePrice(ctx, listing, price, currency)
+		if errors.Is(err, errs.ErrListingPriceConflict) && attempt < maxPriceAttempts {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		isOwner := listing.OwnerID == user.ID
+		updated.IsMyListing = &isOwner
+		return updated, nil
+	}
+}
+
+// updatePrice sets the new price if the listing still has the price of listing.
+//
+// Drop is computed from the same state the repository checks, so concurrent changes
+// can't record it against a price which was already replaced.
+func (ls *ListingService) updatePrice(ctx context.Context, listing *models.Listing, price money.Decimal, currency string) (*models.Listing, error) {
+	updated := *listing
+	updated.Price, updated.Currency = price, currency
+	if err := ls.setPrice(&updated); err != nil {
+		return nil, err
+	}
+	if updated.Price.Equal(listing.Price.Decimal) && updated.Currency == listing.Currency {
+		return listing, nil
+	}
+
+	// Снижение считается по цене в базовой валюте, чтобы смена валюты не выглядела скидкой
+	now := time.Now()
+	droppedAt := listing.PriceDroppedAt
+	dropPercent := 0.0
+	if updated.PriceBase.LessThan(listing.PriceBase.Decimal) {
+		droppedAt = &now
+		if listing.PriceBase.IsPositive() {
+			dropPercent = listing.PriceBase.Sub(updated.PriceBase.Decimal).Div(listing.PriceBase.Decimal).InexactFloat64() * 100
+		}
+	} else if updated.PriceBase.GreaterThan(listing.PriceBase.Decimal) {
+		droppedAt = nil
+	}
+
+	change := &models.PriceChange{
+		ListingID:    listing.ID,
+		OldPrice:     listing.Price,
+		OldCurrency:  listing.Currency,
+		OldPriceBase: listing.PriceBase,
+		Price:        updated.Price,
+		Currency:     updated.Currency,
+		PriceBase:    updated.PriceBase,
+		DropPercent:  dropPercent,
+		ChangedAt:    now,
+		AlertsSent:   dropPercent <= 0,
+	}
+	if err := ls.repo.UpdatePrice(ctx, listing, change, droppedAt); err != nil {
+		return nil, err
+	}
+	if !change.AlertsSent {
+		ls.prices.PriceDropped()
+	}
+
+	updated.PriceDroppedAt = droppedAt
+	return &updated, nil
+}
+
+// toBase converts price bound from display currency to the base currency
+func (ls *ListingService) toBase(price *money.Decimal, currency string) (*money.Decimal, error) {
+	if price == nil {
+		return nil, nil
+	}
+	rate, err := ls.rates.Rate(currency, ls.rates.Base())
+	if err != nil {
+		return nil, errs.ErrListingInvalidCurrency
+	}
+	converted := money.New(price.Mul(rate.Decimal))
+	return &converted, nil
+}
+
+// setDisplayPrices converts prices of listings to the currency requested by client
+func (ls *ListingService) setDisplayPrices(listings []*models.Listing, currency string) error {
+	for _, l := range listings {
+		rate, err := ls.rates.Rate(l.Currency, currency)
+		if err != nil {
+			return err
+		}
+		display := money.New(l.Price.Mul(rate.Decimal).Round(money.MinorUnits(currency)))
+		l.DisplayPrice = &display
+		l.DisplayCurrency = currency
+	}
+	return nil
+}
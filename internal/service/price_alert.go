@@ -0,0 +1,237 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"time"
+
+	"vk-inter/internal/models"
+	"vk-inter/pkg/errs"
+	"vk-inter/pkg/logger"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.uber.org/zap"
+)
+
+const (
+	priceHistoryLimit = 100
+	priceAlertBatch   = 50
+	watchersBatch     = 500
+)
+
+type PriceAlertConfig struct {
+	// DefaultThreshold is the drop in percent notified to users who favorited a listing
+	DefaultThreshold float64       `env:"PRICE_DROP_DEFAULT_THRESHOLD" env-default:"5"`
+	PollInterval     time.Duration `env:"PRICE_ALERT_POLL_INTERVAL" env-default:"10s"`
+}
+
+type PriceHistoryRepo interface {
+	GetPriceHistory(ctx context.Context, listingID primitive.ObjectID, limit int) ([]*models.PriceChange, error)
+	GetUnsentDrops(ctx context.Context, limit int) ([]*models.PriceChange, error)
+	SetAlertsSent(ctx context.Context, id primitive.ObjectID) error
+}
+
+type PriceWatchRepo interface {
+	SetWatch(ctx context.Context, userID, listingID primitive.ObjectID, threshold float64) (*models.PriceWatch, error)
+	DeleteWatch(ctx context.Context, userID, listingID primitive.ObjectID) error
+	GetWatches(ctx context.Context, listingID, after primitive.ObjectID, limit int) ([]*models.PriceWatch, error)
+	GetWatchingUsers(ctx context.Context, listingID primitive.ObjectID, userIDs []primitive.ObjectID) (map[primitive.ObjectID]bool, error)
+}
+
+type PriceFavoriteRepo interface {
+	GetListingFavorites(ctx context.Context, listingID, after primitive.ObjectID, limit int) ([]*models.Favorite, error)
+}
+
+type PriceListingRepo interface {
+	GetListingByID(ctx context.Context, id primitive.ObjectID) (*models.Listing, error)
+}
+
+// PriceAlerts keeps listing price history and notifies watchers about price drops.
+//
+// Drops are stored with alerts_sent=false and sent by a background worker,
+// so alerts are not lost on restart. A user watching a listing explicitly
+// gets alerts by their threshold, other users who favorited it by the default one.
+type PriceAlerts struct {
+	historyRepo   PriceHistoryRepo
+	watchRepo     PriceWatchRepo
+	favoriteRepo  PriceFavoriteRepo
+	listingRepo   PriceListingRepo
+	notifications NotificationCreator
+	cfg           PriceAlertConfig
+
+	notify chan struct{}
+}
+
+func NewPriceAlerts(historyRepo PriceHistoryRepo, watchRepo PriceWatchRepo, favoriteRepo PriceFavoriteRepo, listingRepo PriceListingRepo, notifications NotificationCreator, cfg PriceAlertConfig) *PriceAlerts {
+	return &PriceAlerts{
+		historyRepo:   historyRepo,
+		watchRepo:     watchRepo,
+		favoriteRepo:  favoriteRepo,
+		listingRepo:   listingRepo,
+		notifications: notifications,
+		cfg:           cfg,
+		notify:        make(chan struct{}, 1),
+	}
+}
+
+// PriceDropped wakes up the worker to send alerts about the drop saved to price history
+func (pa *PriceAlerts) PriceDropped() {
+	select {
+	case pa.notify <- struct{}{}:
+	default:
+	}
+}
+
+// GetPriceHistory returns price changes of the listing, latest first
+func (pa *PriceAlerts) GetPriceHistory(ctx context.Context, listingID primitive.ObjectID, user *models.User) ([]*models.PriceChange, error) {
+	if _, err := pa.visibleListing(ctx, listingID, user); err != nil {
+		return nil, err
+	}
+	return pa.historyRepo.GetPriceHistory(ctx, listingID, priceHistoryLimit)
+}
+
+// SetPriceAlert subscribes user to drops of the listing price by more than threshold percent
+func (pa *PriceAlerts) SetPriceAlert(ctx context.Context, listingID primitive.ObjectID, threshold float64, user *models.User) (*models.PriceWatch, error) {
+	if threshold < 0 || threshold >= 100 || math.IsNaN(threshold) {
+		return nil, errs.ErrPriceAlertInvalidThreshold
+	}
+	if _, err := pa.visibleListing(ctx, listingID, user); err != nil {
+		return nil, err
+	}
+	return pa.watchRepo.SetWatch(ctx, user.ID, listingID, threshold)
+}
+
+func (pa *PriceAlerts) DeletePriceAlert(ctx context.Context, listingID primitive.ObjectID, user *models.User) error {
+	return pa.watchRepo.DeleteWatch(ctx, user.ID, listingID)
+}
+
+func (pa *PriceAlerts) visibleListing(ctx context.Context, id primitive.ObjectID, user *models.User) (*models.Listing, error) {
+	listing, err := pa.listingRepo.GetListingByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	isOwner := user != nil && listing.OwnerID == user.ID
+	if !listing.IsPublic() && !isOwner && !user.IsAdmin() {
+		return nil, errs.ErrListingNotFound
+	}
+	return listing, nil
+}
+
+// Run sends alerts about price drops and blocks until ctx is done
+func (pa *PriceAlerts) Run(ctx context.Context) {
+	ticker := time.NewTicker(max(pa.cfg.PollInterval, time.Second))
+	defer ticker.Stop()
+	for {
+		for ctx.Err() == nil {
+			if !pa.sendBatch(ctx) {
+				break
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-pa.notify:
+		case <-ticker.C:
+		}
+	}
+}
+
+// sendBatch sends alerts of a batch of drops, returns true if there may be more
+func (pa *PriceAlerts) sendBatch(ctx context.Context) bool {
+	drops, err := pa.historyRepo.GetUnsentDrops(ctx, priceAlertBatch)
+	if err != nil {
+		if ctx.Err() == nil {
+			logger.FromContext(ctx).Warn("Get price drops error", zap.Error(err))
+		}
+		return false
+	}
+	for _, drop := range drops {
+		if err := pa.send(ctx, drop); err != nil {
+			if ctx.Err() == nil {
+				logger.FromContext(ctx).Warn("Send price alerts error", zap.String("change_id", drop.ID.Hex()), zap.Error(err))
+			}
+			return false
+		}
+	}
+	return len(drops) == priceAlertBatch
+}
+
+func (pa *PriceAlerts) send(ctx context.Context, drop *models.PriceChange) error {
+	listing, err := pa.listingRepo.GetListingByID(ctx, drop.ListingID)
+	if err != nil && !errors.Is(err, errs.ErrListingNotFound) {
+		return err
+	}
+	// Удалённые и неопубликованные объявления не рассылаются
+	if listing == nil || listing.Status != models.ListingStatusPublished {
+		return pa.historyRepo.SetAlertsSent(ctx, drop.ID)
+	}
+
+	after := primitive.NilObjectID
+	for {
+		watches, err := pa.watchRepo.GetWatches(ctx, drop.ListingID, after, watchersBatch)
+		if err != nil {
+			return err
+		}
+		for _, w := range watches {
+			if drop.DropPercent > w.ThresholdPercent {
+				if err := pa.alert(ctx, drop, listing, w.UserID); err != nil {
+					return err
+				}
+			}
+		}
+		if len(watches) < watchersBatch {
+			break
+		}
+		after = watches[len(watches)-1].ID
+	}
+
+	if drop.DropPercent > pa.cfg.DefaultThreshold {
+		after = primitive.NilObjectID
+		for {
+			favorites, err := pa.favoriteRepo.GetListingFavorites(ctx, drop.ListingID, after, watchersBatch)
+			if err != nil {
+				return err
+			}
+			userIDs := make([]primitive.ObjectID, 0, len(favorites))
+			for _, f := range favorites {
+				userIDs = append(userIDs, f.UserID)
+			}
+			// Собственный порог пользователя важнее порога по умолчанию
+			watching, err := pa.watchRepo.GetWatchingUsers(ctx, drop.ListingID, userIDs)
+			if err != nil {
+				return err
+			}
+			for _, userID := range userIDs {
+				if watching[userID] {
+					continue
+				}
+				if err := pa.alert(ctx, drop, listing, userID); err != nil {
+					return err
+				}
+			}
+			if len(favorites) < watchersBatch {
+				break
+			}
+			after = favorites[len(favorites)-1].ID
+		}
+	}
+
+	return pa.historyRepo.SetAlertsSent(ctx, drop.ID)
+}
+
+func (pa *PriceAlerts) alert(ctx context.Context, drop *models.PriceChange, listing *models.Listing, userID primitive.ObjectID) error {
+	if userID == listing.OwnerID {
+		return nil
+	}
+	_, err := pa.notifications.CreateNotification(ctx, &models.Notification{
+		UserID:    userID,
+		Type:      models.NotificationTypePriceDrop,
+		Title:     fmt.Sprintf("Price of %q dropped by %.0f%%: %s %s", listing.Title, drop.DropPercent, drop.Price, drop.Currency),
+		ListingID: &listing.ID,
+		DedupKey:  "price_drop:" + drop.ID.Hex() + ":" + userID.Hex(),
+	})
+	return err
+}
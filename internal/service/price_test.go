@@ -0,0 +1,87 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"vk-inter/internal/models"
+	"vk-inter/pkg/errs"
+	"vk-inter/pkg/money"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// fakePriceRepo implements price methods of ListingRepo, other methods are not used
+type fakePriceRepo struct {
+	ListingRepo
+	listing models.Listing
+	// concurrent is the price set by another request right before the next update
+	concurrent *money.Decimal
+	changes    []*models.PriceChange
+}
+
+func (r *fakePriceRepo) GetListingByID(_ context.Context, id primitive.ObjectID) (*models.Listing, error) {
+	if id != r.listing.ID {
+		return nil, errs.ErrListingNotFound
+	}
+	l := r.listing
+	return &l, nil
+}
+
+func (r *fakePriceRepo) UpdatePrice(_ context.Context, old *models.Listing, change *models.PriceChange, droppedAt *time.Time) error {
+	if r.concurrent != nil {
+		r.listing.Price, r.listing.PriceBase = *r.concurrent, *r.concurrent
+		r.concurrent = nil
+	}
+	if !old.Price.Equal(r.listing.Price.Decimal) || !old.PriceBase.Equal(r.listing.PriceBase.Decimal) {
+		return errs.ErrListingPriceConflict
+	}
+	r.listing.Price, r.listing.PriceBase, r.listing.PriceDroppedAt = change.Price, change.PriceBase, droppedAt
+	r.changes = append(r.changes, change)
+	return nil
+}
+
+type fakePriceRecorder struct {
+	drops int
+}
+
+func (r *fakePriceRecorder) PriceDropped() {
+	r.drops++
+}
+
+func TestUpdatePriceRetriesConcurrentChange(t *testing.T) {
+	owner := &models.User{ID: primitive.NewObjectID()}
+	price := mustDecimal(t, "1000.00")
+	concurrent := mustDecimal(t, "800.00")
+	repo := &fakePriceRepo{
+		listing:    models.Listing{ID: primitive.NewObjectID(), OwnerID: owner.ID, Price: price, PriceBase: price, Currency: "RUB"},
+		concurrent: &concurrent,
+	}
+	recorder := &fakePriceRecorder{}
+	rates, err := money.NewStaticRatesFrom("RUB", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ls := NewListingService(repo, nil, nil, nil, nil, recorder, nil, nil, nil, nil, rates, ListingConfig{}, "")
+
+	updated, err := ls.UpdatePrice(testContext(), repo.listing.ID, mustDecimal(t, "700"), "", owner)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !updated.Price.Equal(mustDecimal(t, "700").Decimal) || updated.PriceDroppedAt == nil {
+		t.Fatalf("UpdatePrice() = price %s, dropped at %v, want 700 and drop time", updated.Price, updated.PriceDroppedAt)
+	}
+
+	// Снижение считается от цены, которую заменило обновление, а не от прочитанной первой
+	if len(repo.changes) != 1 {
+		t.Fatalf("%d changes recorded, want 1", len(repo.changes))
+	}
+	change := repo.changes[0]
+	if !change.OldPrice.Equal(concurrent.Decimal) || change.DropPercent != 12.5 || change.AlertsSent {
+		t.Fatalf("change = old price %s, drop %v%%, alerts sent %v, want 800, 12.5%%, false", change.OldPrice, change.DropPercent, change.AlertsSent)
+	}
+	if recorder.drops != 1 {
+		t.Fatalf("PriceDropped() called %d times, want 1", recorder.drops)
+	}
+}
@@ -0,0 +1,144 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"unicode/utf8"
+
+	"vk-inter/internal/models"
+	"vk-inter/pkg/errs"
+	"vk-inter/pkg/logger"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.uber.org/zap"
+)
+
+const (
+	maxReviewText   = 2000
+	maxHideReason   = 200
+	reviewsPageSize = 20
+)
+
+type ReviewRepo interface {
+	CreateReview(ctx context.Context, review *models.Review) (*models.Review, error)
+	GetUserReviews(ctx context.Context, userID primitive.ObjectID, skip, limit int) ([]*models.Review, int64, error)
+	SetHidden(ctx context.Context, id primitive.ObjectID, hidden bool, reason string) (*models.Review, error)
+}
+
+type ReviewListingRepo interface {
+	GetListingByID(ctx context.Context, id primitive.ObjectID) (*models.Listing, error)
+}
+
+// ReviewService keeps reviews of sellers and buyers about each other after sold listings.
+//
+// Rating aggregate of the user is updated together with reviews, hidden reviews are not counted.
+type ReviewService struct {
+	repo          ReviewRepo
+	listingRepo   ReviewListingRepo
+	userRepo      UserRepo
+	notifications NotificationCreator
+}
+
+func NewReviewService(repo ReviewRepo, listingRepo ReviewListingRepo, userRepo UserRepo, notifications NotificationCreator) *ReviewService {
+	return &ReviewService{
+		repo:          repo,
+		listingRepo:   listingRepo,
+		userRepo:      userRepo,
+		notifications: notifications,
+	}
+}
+
+// CreateReview reviews the other side of the deal on the sold listing
+func (rs *ReviewService) CreateReview(ctx context.Context, listingID primitive.ObjectID, rating int, text string, user *models.User) (*models.Review, error) {
+	if rating < 1 || rating > 5 {
+		return nil, errs.ErrReviewInvalidRating
+	}
+	text = strings.TrimSpace(text)
+	if text == "" || utf8.RuneCountInString(text) > maxReviewText {
+		return nil, errs.ErrReviewInvalidText
+	}
+
+	listing, err := rs.listingRepo.GetListingByID(ctx, listingID)
+	if err != nil {
+		return nil, err
+	}
+	if !listing.IsPublic() {
+		return nil, errs.ErrListingNotFound
+	}
+	if listing.Status != models.ListingStatusSold || listing.BuyerID == nil {
+		return nil, errs.ErrReviewNotAllowed
+	}
+
+	review := &models.Review{
+		ListingID:    listing.ID,
+		ListingTitle: listing.Title,
+		AuthorID:     user.ID,
+		AuthorLogin:  user.Login,
+		Rating:       rating,
+		Text:         text,
+	}
+	switch user.ID {
+	case listing.OwnerID:
+		review.TargetID, review.TargetRole = *listing.BuyerID, models.ReviewTargetBuyer
+	case *listing.BuyerID:
+		review.TargetID, review.TargetRole = listing.OwnerID, models.ReviewTargetSeller
+	default:
+		return nil, errs.ErrReviewNotAllowed
+	}
+
+	review, err = rs.repo.CreateReview(ctx, review)
+	if err != nil {
+		return nil, err
+	}
+
+	_, err = rs.notifications.CreateNotification(ctx, &models.Notification{
+		UserID:    review.TargetID,
+		Type:      models.NotificationTypeReview,
+		Title:     fmt.Sprintf("%s rated the deal on %q: %d/5", review.AuthorLogin, review.ListingTitle, review.Rating),
+		ListingID: &review.ListingID,
+		DedupKey:  "review:" + review.ID.Hex(),
+	})
+	if err != nil {
+		logger.FromContext(ctx).Warn("Create review notification error", zap.String("review_id", review.ID.Hex()), zap.Error(err))
+	}
+	return review, nil
+}
+
+// GetUserReviews returns visible reviews about the user, latest first
+func (rs *ReviewService) GetUserReviews(ctx context.Context, login string, page, limit int) (*models.ReviewPage, error) {
+	user, err := rs.userRepo.GetByLogin(ctx, login)
+	if err != nil {
+		return nil, err
+	}
+	if page < 1 {
+		page = 1
+	}
+	if limit < 1 || limit > 100 {
+		limit = reviewsPageSize
+	}
+
+	reviews, total, err := rs.repo.GetUserReviews(ctx, user.ID, (page-1)*limit, limit)
+	if err != nil {
+		return nil, err
+	}
+	return &models.ReviewPage{
+		Items:   reviews,
+		Page:    page,
+		Limit:   limit,
+		Total:   total,
+		HasNext: int64(page*limit) < total,
+	}, nil
+}
+
+// SetReviewHidden hides abusive review or restores it, only moderators can do it
+func (rs *ReviewService) SetReviewHidden(ctx context.Context, id primitive.ObjectID, hidden bool, reason string, user *models.User) (*models.Review, error) {
+	if !user.IsModerator() {
+		return nil, errs.ErrForbidden
+	}
+	reason = strings.TrimSpace(reason)
+	if utf8.RuneCountInString(reason) > maxHideReason {
+		return nil, errs.ErrReviewInvalidReason
+	}
+	return rs.repo.SetHidden(ctx, id, hidden, reason)
+}
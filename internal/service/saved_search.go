@@ -0,0 +1,279 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"math"
+	"slices"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"vk-inter/internal/models"
+	"vk-inter/pkg/errs"
+	"vk-inter/pkg/money"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+const (
+	maxSavedSearchName = 64
+	// Цена в базовой валюте раскладывается по корзинам [2^(b-1), 2^b), последняя покрывает maxPrice
+	maxPriceBucket = 31
+)
+
+type SavedSearchConfig struct {
+	MaxPerUser   int           `env:"SAVED_SEARCH_MAX_PER_USER" env-default:"20"`
+	PollInterval time.Duration `env:"SAVED_SEARCH_POLL_INTERVAL" env-default:"5s"`
+}
+
+type SavedSearchRepo interface {
+	CreateSavedSearch(ctx context.Context, search *models.SavedSearch) (*models.SavedSearch, error)
+	GetSavedSearches(ctx context.Context, userID primitive.ObjectID) ([]*models.SavedSearch, error)
+	CountSavedSearches(ctx context.Context, userID primitive.ObjectID) (int64, error)
+	DeleteSavedSearch(ctx context.Context, id, userID primitive.ObjectID) error
+}
+
+type FilterNormalizer interface {
+	NormalizeFilter(ctx context.Context, filter *models.ListingFilter) (string, error)
+}
+
+type URLChecker interface {
+	CheckURL(rawURL string) error
+}
+
+type SavedSearchService struct {
+	repo       SavedSearchRepo
+	normalizer FilterNormalizer
+	urls       URLChecker
+	cfg        SavedSearchConfig
+}
+
+func NewSavedSearchService(repo SavedSearchRepo, normalizer FilterNormalizer, urls URLChecker, cfg SavedSearchConfig) *SavedSearchService {
+	return &SavedSearchService{
+		repo:       repo,
+		normalizer: normalizer,
+		urls:       urls,
+		cfg:        cfg,
+	}
+}
+
+// CreateSavedSearch saves GET /listings query, filter is the query parsed by transport
+func (ss *SavedSearchService) CreateSavedSearch(ctx context.Context, name, query string, filter *models.ListingFilter, webhookURL string, user *models.User) (*models.SavedSearch, error) {
+	name = strings.TrimSpace(name)
+	if name == "" || utf8.RuneCountInString(name) > maxSavedSearchName {
+		return nil, errs.ErrSavedSearchInvalidName
+	}
+	webhookURL = strings.TrimSpace(webhookURL)
+	if webhookURL != "" {
+		if err := ss.urls.CheckURL(webhookURL); err != nil {
+			return nil, fmt.Errorf("%w: %v", errs.ErrSavedSearchInvalidWebhook, err)
+		}
+	}
+
+	count, err := ss.repo.CountSavedSearches(ctx, user.ID)
+	if err != nil {
+		return nil, err
+	}
+	if count >= int64(ss.cfg.MaxPerUser) {
+		return nil, fmt.Errorf("%w: expected up to %d", errs.ErrSavedSearchLimit, ss.cfg.MaxPerUser)
+	}
+
+	if _, err := ss.normalizer.NormalizeFilter(ctx, filter); err != nil {
+		return nil, err
+	}
+
+	search := &models.SavedSearch{
+		UserID:       user.ID,
+		Name:         name,
+		Query:        query,
+		WebhookURL:   webhookURL,
+		Filter:       searchFilter(filter),
+		PriceBuckets: priceBuckets(filter.MinPrice, filter.MaxPrice),
+	}
+	if filter.CategoryID != primitive.NilObjectID {
+		search.CategoryID = &filter.CategoryID
+	}
+	if webhookURL != "" {
+		secret := make([]byte, 32)
+		if _, err := rand.Read(secret); err != nil {
+			return nil, err
+		}
+		search.WebhookSecret = hex.EncodeToString(secret)
+	}
+
+	return ss.repo.CreateSavedSearch(ctx, search)
+}
+
+func (ss *SavedSearchService) GetSavedSearches(ctx context.Context, user *models.User) ([]*models.SavedSearch, error) {
+	return ss.repo.GetSavedSearches(ctx, user.ID)
+}
+
+func (ss *SavedSearchService) DeleteSavedSearch(ctx context.Context, id primitive.ObjectID, user *models.User) error {
+	return ss.repo.DeleteSavedSearch(ctx, id, user.ID)
+}
+
+// searchFilter keeps conditions of normalized filter which are checked by the matcher
+func searchFilter(filter *models.ListingFilter) models.SearchFilter {
+	f := models.SearchFilter{
+		MinPrice:   filter.MinPrice,
+		MaxPrice:   filter.MaxPrice,
+		Tags:       filter.Tags,
+		TagsMode:   filter.TagsMode,
+		OwnerLogin: filter.OwnerLogin,
+		Near:       filter.Near,
+		RadiusKm:   filter.RadiusKm,
+		BBox:       filter.BBox,
+		SaleMode:   filter.SaleMode,
+
+		PriceDroppedSince: filter.PriceDroppedSince,
+	}
+	if filter.OwnerID != primitive.NilObjectID {
+		f.OwnerID = &filter.OwnerID
+	}
+	for _, af := range filter.Attributes {
+		f.Attributes = append(f.Attributes, models.SearchAttributeFilter{Name: af.Name, Op: af.Op, Values: af.Parsed})
+	}
+	return f
+}
+
+// priceBucket returns log2 bucket of the price in the base currency
+func priceBucket(price money.Decimal) int {
+	f := price.InexactFloat64()
+	if f < 1 {
+		return 0
+	}
+	return min(int(math.Log2(f))+1, maxPriceBucket)
+}
+
+// priceBuckets returns all buckets intersecting the price range, a missing bound means open range
+func priceBuckets(minPrice, maxPrice *money.Decimal) []int {
+	lo, hi := 0, maxPriceBucket
+	if minPrice != nil {
+		lo = priceBucket(*minPrice)
+	}
+	if maxPrice != nil {
+		hi = priceBucket(*maxPrice)
+	}
+	buckets := make([]int, 0, hi-lo+1)
+	for b := lo; b <= hi; b++ {
+		buckets = append(buckets, b)
+	}
+	return buckets
+}
+
+// matchSearch checks listing against saved search filter.
+//
+// Category and price bucket are already matched by the candidates query.
+func matchSearch(f *models.SearchFilter, l *models.Listing) bool {
+	if f.MinPrice != nil && l.PriceBase.LessThan(f.MinPrice.Decimal) {
+		return false
+	}
+	if f.MaxPrice != nil && l.PriceBase.GreaterThan(f.MaxPrice.Decimal) {
+		return false
+	}
+	if f.PriceDroppedSince != nil && (l.PriceDroppedAt == nil || l.PriceDroppedAt.Before(*f.PriceDroppedSince)) {
+		return false
+	}
+	if f.OwnerID != nil && *f.OwnerID != l.OwnerID {
+		return false
+	}
+	if f.OwnerLogin != "" && f.OwnerLogin != l.OwnerLogin {
+		return false
+	}
+	if f.SaleMode != "" && f.SaleMode != l.SaleMode {
+		return false
+	}
+
+	if len(f.Tags) > 0 {
+		contains := func(t string) bool { return slices.Contains(l.Tags, t) }
+		if f.TagsMode == models.TagsModeAll && !allOf(f.Tags, contains) {
+			return false
+		}
+		if f.TagsMode != models.TagsModeAll && !slices.ContainsFunc(f.Tags, contains) {
+			return false
+		}
+	}
+
+	for _, af := range f.Attributes {
+		value, ok := l.Attributes[af.Name]
+		if !ok || !matchAttribute(af, value) {
+			return false
+		}
+	}
+
+	if f.Near != nil && f.RadiusKm > 0 {
+		if l.Location == nil || distanceKm(f.Near, l.Location) > f.RadiusKm {
+			return false
+		}
+	}
+	if len(f.BBox) == 4 {
+		if l.Location == nil {
+			return false
+		}
+		lon, lat := l.Location.Lon(), l.Location.Lat()
+		if lon < f.BBox[0] || lat < f.BBox[1] || lon > f.BBox[2] || lat > f.BBox[3] {
+			return false
+		}
+	}
+	return true
+}
+
+func allOf(values []string, pred func(string) bool) bool {
+	for _, v := range values {
+		if !pred(v) {
+			return false
+		}
+	}
+	return true
+}
+
+func matchAttribute(af models.SearchAttributeFilter, value any) bool {
+	if af.Op == models.AttributeOpEq {
+		return slices.ContainsFunc(af.Values, func(v any) bool { return attributeEqual(v, value) })
+	}
+	if len(af.Values) != 1 {
+		return false
+	}
+	bound, ok1 := attributeNumber(af.Values[0])
+	n, ok2 := attributeNumber(value)
+	if !ok1 || !ok2 {
+		return false
+	}
+	switch af.Op {
+	case models.AttributeOpGt:
+		return n > bound
+	case models.AttributeOpGte:
+		return n >= bound
+	case models.AttributeOpLt:
+		return n < bound
+	case models.AttributeOpLte:
+		return n <= bound
+	}
+	return false
+}
+
+// attributeEqual compares values like Mongo does: numbers of different types are equal by value
+func attributeEqual(a, b any) bool {
+	if x, ok := attributeNumber(a); ok {
+		y, ok := attributeNumber(b)
+		return ok && x == y
+	}
+	return a == b
+}
+
+func attributeNumber(v any) (float64, bool) {
+	switch n := v.(type) {
+	case int32:
+		return float64(n), true
+	case int64:
+		return float64(n), true
+	case int:
+		return float64(n), true
+	case float64:
+		return n, true
+	}
+	return 0, false
+}
@@ -0,0 +1,240 @@
+package service
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"vk-inter/internal/models"
+	"vk-inter/pkg/logger"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.uber.org/zap"
+)
+
+const (
+	matcherBatch    = 50
+	candidatesBatch = 500
+	webhookQueue    = 100
+)
+
+type MatcherSearchRepo interface {
+	FindSearchCandidates(ctx context.Context, categoryIDs []primitive.ObjectID, bucket int, after primitive.ObjectID, limit int) ([]*models.SavedSearch, primitive.ObjectID, error)
+	IncMatches(ctx context.Context, id primitive.ObjectID, at time.Time) error
+}
+
+type MatcherListingRepo interface {
+	GetUnmatchedListings(ctx context.Context, limit int) ([]*models.Listing, error)
+	SetSearchMatched(ctx context.Context, id primitive.ObjectID) error
+}
+
+type MatcherCategoryRepo interface {
+	GetByID(ctx context.Context, id primitive.ObjectID) (*models.Category, error)
+}
+
+type NotificationCreator interface {
+	CreateNotification(ctx context.Context, n *models.Notification) (bool, error)
+}
+
+type WebhookPoster interface {
+	Post(rawURL string, header http.Header, body []byte) (*http.Response, error)
+}
+
+// WebhookEvent is the body of a saved search webhook request.
+//
+// Requests carry X-Signature header: "sha256=" and hex HMAC-SHA256 of the body with the search webhook secret.
+type WebhookEvent struct {
+	Event         string          `json:"event" example:"saved_search.match"`
+	SavedSearchID string          `json:"saved_search_id"`
+	Listing       *models.Listing `json:"listing"`
+	SentAt        time.Time       `json:"sent_at"`
+}
+
+type webhookDelivery struct {
+	url    string
+	secret string
+	body   []byte
+}
+
+// SearchMatcher checks newly published listings against saved searches in background.
+//
+// Listings are marked as matched only after all their notifications are stored,
+// so a restart repeats the listing and unique dedup keys drop repeated notifications.
+type SearchMatcher struct {
+	searchRepo    MatcherSearchRepo
+	listingRepo   MatcherListingRepo
+	categoryRepo  MatcherCategoryRepo
+	notifications NotificationCreator
+	webhooks      WebhookPoster
+	cfg           SavedSearchConfig
+
+	deliveries chan webhookDelivery
+}
+
+func NewSearchMatcher(searchRepo MatcherSearchRepo, listingRepo MatcherListingRepo, categoryRepo MatcherCategoryRepo, notifications NotificationCreator, webhooks WebhookPoster, cfg SavedSearchConfig) *SearchMatcher {
+	return &SearchMatcher{
+		searchRepo:    searchRepo,
+		listingRepo:   listingRepo,
+		categoryRepo:  categoryRepo,
+		notifications: notifications,
+		webhooks:      webhooks,
+		cfg:           cfg,
+		deliveries:    make(chan webhookDelivery, webhookQueue),
+	}
+}
+
+// Run polls new listings and blocks until ctx is done
+func (sm *SearchMatcher) Run(ctx context.Context) {
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		sm.deliver(ctx)
+	}()
+
+	ticker := time.NewTicker(max(sm.cfg.PollInterval, time.Second))
+	defer ticker.Stop()
+	for {
+		// Разбираем новые объявления, пока они не закончатся
+		for ctx.Err() == nil {
+			if !sm.matchBatch(ctx) {
+				break
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			<-done
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// matchBatch matches a batch of new listings, returns true if there may be more
+func (sm *SearchMatcher) matchBatch(ctx context.Context) bool {
+	listings, err := sm.listingRepo.GetUnmatchedListings(ctx, matcherBatch)
+	if err != nil {
+		if ctx.Err() == nil {
+			logger.FromContext(ctx).Warn("Get unmatched listings error", zap.Error(err))
+		}
+		return false
+	}
+	for _, l := range listings {
+		if err := sm.match(ctx, l); err != nil {
+			// Объявление останется непроверенным и будет повторено на следующем тике
+			if ctx.Err() == nil {
+				logger.FromContext(ctx).Warn("Match saved searches error", zap.String("listing_id", l.ID.Hex()), zap.Error(err))
+			}
+			return false
+		}
+	}
+	return len(listings) == matcherBatch
+}
+
+func (sm *SearchMatcher) match(ctx context.Context, listing *models.Listing) error {
+	categoryIDs := []primitive.ObjectID{listing.CategoryID}
+	category, err := sm.categoryRepo.GetByID(ctx, listing.CategoryID)
+	if err == nil {
+		categoryIDs = append(categoryIDs, category.Ancestors...)
+	}
+	bucket := priceBucket(listing.PriceBase)
+
+	after := primitive.NilObjectID
+	for {
+		searches, next, err := sm.searchRepo.FindSearchCandidates(ctx, categoryIDs, bucket, after, candidatesBatch)
+		if err != nil {
+			return err
+		}
+		for _, s := range searches {
+			// Свои объявления пользователю не присылаем
+			if s.UserID == listing.OwnerID || !matchSearch(&s.Filter, listing) {
+				continue
+			}
+			if err := sm.notify(ctx, s, listing); err != nil {
+				return err
+			}
+		}
+		// Пачка может быть короче из-за пропущенных поисков, продолжаем с последнего прочитанного
+		if next == primitive.NilObjectID {
+			break
+		}
+		after = next
+	}
+
+	return sm.listingRepo.SetSearchMatched(ctx, listing.ID)
+}
+
+func (sm *SearchMatcher) notify(ctx context.Context, search *models.SavedSearch, listing *models.Listing) error {
+	created, err := sm.notifications.CreateNotification(ctx, &models.Notification{
+		UserID:        search.UserID,
+		Type:          models.NotificationTypeSavedSearchMatch,
+		Title:         fmt.Sprintf("New listing for %q: %s", search.Name, listing.Title),
+		ListingID:     &listing.ID,
+		SavedSearchID: &search.ID,
+		DedupKey:      "saved_search:" + search.ID.Hex() + ":" + listing.ID.Hex(),
+	})
+	if err != nil || !created {
+		return err
+	}
+	if err := sm.searchRepo.IncMatches(ctx, search.ID, time.Now()); err != nil {
+		logger.FromContext(ctx).Warn("Update saved search counter error", zap.String("saved_search_id", search.ID.Hex()), zap.Error(err))
+	}
+
+	if search.WebhookURL == "" {
+		return nil
+	}
+	body, err := json.Marshal(WebhookEvent{
+		Event:         "saved_search.match",
+		SavedSearchID: search.ID.Hex(),
+		Listing:       listing,
+		SentAt:        time.Now().UTC(),
+	})
+	if err != nil {
+		return err
+	}
+	// Медленный вебхук не должен задерживать уведомления, при переполнении очереди доставка пропускается
+	select {
+	case sm.deliveries <- webhookDelivery{url: search.WebhookURL, secret: search.WebhookSecret, body: body}:
+	default:
+		logger.FromContext(ctx).Warn("Webhook queue is full", zap.String("saved_search_id", search.ID.Hex()))
+	}
+	return nil
+}
+
+func (sm *SearchMatcher) deliver(ctx context.Context) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case d := <-sm.deliveries:
+			if err := sm.post(d); err != nil {
+				logger.FromContext(ctx).Warn("Webhook delivery error", zap.String("url", d.url), zap.Error(err))
+			}
+		}
+	}
+}
+
+func (sm *SearchMatcher) post(d webhookDelivery) error {
+	mac := hmac.New(sha256.New, []byte(d.secret))
+	mac.Write(d.body)
+	header := http.Header{}
+	header.Set("Content-Type", "application/json")
+	header.Set("X-Signature", "sha256="+hex.EncodeToString(mac.Sum(nil)))
+
+	resp, err := sm.webhooks.Post(d.url, header, d.body)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 4096))
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("unexpected status %d", resp.StatusCode)
+	}
+	return nil
+}
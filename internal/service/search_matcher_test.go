@@ -0,0 +1,125 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"vk-inter/internal/models"
+	"vk-inter/pkg/errs"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type candidatesPage struct {
+	searches []*models.SavedSearch
+	next     primitive.ObjectID
+	err      error
+}
+
+type fakeMatcherSearchRepo struct {
+	pages []candidatesPage
+	after []primitive.ObjectID
+}
+
+func (r *fakeMatcherSearchRepo) FindSearchCandidates(_ context.Context, _ []primitive.ObjectID, _ int, after primitive.ObjectID, _ int) ([]*models.SavedSearch, primitive.ObjectID, error) {
+	r.after = append(r.after, after)
+	page := r.pages[len(r.after)-1]
+	return page.searches, page.next, page.err
+}
+
+func (r *fakeMatcherSearchRepo) IncMatches(context.Context, primitive.ObjectID, time.Time) error {
+	return nil
+}
+
+type fakeMatcherListingRepo struct {
+	matched int
+}
+
+func (r *fakeMatcherListingRepo) GetUnmatchedListings(context.Context, int) ([]*models.Listing, error) {
+	return nil, nil
+}
+
+func (r *fakeMatcherListingRepo) SetSearchMatched(context.Context, primitive.ObjectID) error {
+	r.matched++
+	return nil
+}
+
+type fakeMatcherCategoryRepo struct{}
+
+func (fakeMatcherCategoryRepo) GetByID(context.Context, primitive.ObjectID) (*models.Category, error) {
+	return nil, errs.ErrCategoryNotFound
+}
+
+type fakeNotificationCreator struct {
+	created []*models.Notification
+}
+
+func (n *fakeNotificationCreator) CreateNotification(_ context.Context, notification *models.Notification) (bool, error) {
+	n.created = append(n.created, notification)
+	return true, nil
+}
+
+func TestSearchMatcherPaging(t *testing.T) {
+	first, skipped, last := primitive.NewObjectID(), primitive.NewObjectID(), primitive.NewObjectID()
+	search := func(id primitive.ObjectID) *models.SavedSearch {
+		return &models.SavedSearch{ID: id, UserID: primitive.NewObjectID(), Name: "search"}
+	}
+
+	tests := []struct {
+		name      string
+		pages     []candidatesPage
+		wantErr   bool
+		wantAfter []primitive.ObjectID
+		wantSent  int
+	}{
+		{
+			// Нераскодированный поиск укоротил пачку, но не должен обрывать обход
+			name: "short batch with skipped search",
+			pages: []candidatesPage{
+				{searches: []*models.SavedSearch{search(first)}, next: skipped},
+				{searches: []*models.SavedSearch{search(last)}},
+			},
+			wantAfter: []primitive.ObjectID{primitive.NilObjectID, skipped},
+			wantSent:  2,
+		},
+		{
+			name: "cursor error",
+			pages: []candidatesPage{
+				{searches: []*models.SavedSearch{search(first)}, next: first},
+				{err: errors.New("cursor killed")},
+			},
+			wantErr:   true,
+			wantAfter: []primitive.ObjectID{primitive.NilObjectID, first},
+			wantSent:  1,
+		},
+	}
+	for _, tt := range tests {
+		searches := &fakeMatcherSearchRepo{pages: tt.pages}
+		listings := &fakeMatcherListingRepo{}
+		notifications := &fakeNotificationCreator{}
+		sm := NewSearchMatcher(searches, listings, fakeMatcherCategoryRepo{}, notifications, nil, SavedSearchConfig{})
+
+		listing := &models.Listing{ID: primitive.NewObjectID(), OwnerID: primitive.NewObjectID(), Title: "Велосипед"}
+		err := sm.match(testContext(), listing)
+		if (err != nil) != tt.wantErr {
+			t.Fatalf("%s: match() = %v, want error %v", tt.name, err, tt.wantErr)
+		}
+		if len(searches.after) != len(tt.wantAfter) {
+			t.Fatalf("%s: %d pages requested, want %d", tt.name, len(searches.after), len(tt.wantAfter))
+		}
+		for i, after := range tt.wantAfter {
+			if searches.after[i] != after {
+				t.Errorf("%s: page %d requested after %s, want %s", tt.name, i, searches.after[i].Hex(), after.Hex())
+			}
+		}
+		if len(notifications.created) != tt.wantSent {
+			t.Errorf("%s: %d notifications, want %d", tt.name, len(notifications.created), tt.wantSent)
+		}
+		// Объявление с ошибкой обхода останется непроверенным
+		if wantMatched := !tt.wantErr; (listings.matched == 1) != wantMatched {
+			t.Errorf("%s: listing marked matched %d times, want matched %v", tt.name, listings.matched, wantMatched)
+		}
+	}
+}
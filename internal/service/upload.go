@@ -0,0 +1,165 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"image"
+	_ "image/gif"
+	_ "image/jpeg"
+	_ "image/png"
+	"io"
+	"net/http"
+	"strings"
+
+	"vk-inter/internal/models"
+	"vk-inter/pkg/blob"
+	"vk-inter/pkg/errs"
+	"vk-inter/pkg/imaging"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// uploadExtensions are allowed image types detected by magic bytes
+var uploadExtensions = map[string]string{
+	"image/jpeg": ".jpg",
+	"image/png":  ".png",
+	"image/gif":  ".gif",
+	"image/webp": ".webp",
+}
+
+type UploadConfig struct {
+	MaxSize int64 `env:"UPLOAD_MAX_SIZE" env-default:"10485760"`
+	// MaxPixels limits width*height of images, decoding a larger one would take too much memory
+	MaxPixels int `env:"IMAGE_MAX_PIXELS" env-default:"40000000"`
+	// PublicURL is the external address of the API used in image URLs
+	PublicURL string `env:"UPLOAD_PUBLIC_URL" env-default:"http://localhost:8080"`
+}
+
+type UploadRepo interface {
+	CreateUpload(ctx context.Context, upload *models.Upload) (*models.Upload, error)
+	GetUpload(ctx context.Context, id primitive.ObjectID) (*models.Upload, error)
+}
+
+type BlobStore interface {
+	Put(ctx context.Context, key string, r io.Reader, size int64, contentType string) error
+	Get(ctx context.Context, key string) (io.ReadCloser, error)
+	Delete(ctx context.Context, key string) error
+}
+
+type VariantQueue interface {
+	Enqueue(upload *models.Upload) bool
+}
+
+type UploadService struct {
+	repo  UploadRepo
+	store BlobStore
+	queue VariantQueue
+	cfg   UploadConfig
+}
+
+func NewUploadService(repo UploadRepo, store BlobStore, queue VariantQueue, cfg UploadConfig) *UploadService {
+	return &UploadService{
+		repo:  repo,
+		store: store,
+		queue: queue,
+		cfg:   cfg,
+	}
+}
+
+// Upload stores image from r without metadata and schedules generation of its variants and hash.
+//
+// The type is detected by content, not by file name or client headers.
+func (us *UploadService) Upload(ctx context.Context, r io.Reader, user *models.User) (*models.Upload, error) {
+	data, err := io.ReadAll(io.LimitReader(r, us.cfg.MaxSize+1))
+	if err != nil {
+		return nil, err
+	}
+	if int64(len(data)) > us.cfg.MaxSize {
+		return nil, fmt.Errorf("%w: max %d bytes", errs.ErrUploadTooLarge, us.cfg.MaxSize)
+	}
+
+	mime := http.DetectContentType(data)
+	ext, ok := uploadExtensions[mime]
+	if !ok {
+		return nil, errs.ErrUploadInvalidType
+	}
+
+	data, err = stripImageMetadata(data, mime, us.cfg.MaxPixels)
+	if err != nil {
+		return nil, errs.ErrUploadInvalidType
+	}
+
+	upload := &models.Upload{
+		ID:             primitive.NewObjectID(),
+		OwnerID:        user.ID,
+		Mime:           mime,
+		Size:           int64(len(data)),
+		VariantsStatus: models.VariantsStatusPending,
+	}
+	upload.Key = "uploads/" + upload.ID.Hex() + ext
+	upload.URL = strings.TrimRight(us.cfg.PublicURL, "/") + "/uploads/" + upload.ID.Hex()
+	if cfg, _, err := image.DecodeConfig(bytes.NewReader(data)); err == nil {
+		upload.Width = cfg.Width
+		upload.Height = cfg.Height
+	}
+
+	if err := us.store.Put(ctx, upload.Key, bytes.NewReader(data), upload.Size, mime); err != nil {
+		return nil, err
+	}
+
+	created, err := us.repo.CreateUpload(ctx, upload)
+	if err != nil {
+		// Без записи в базе файл недоступен, убираем его
+		us.store.Delete(ctx, upload.Key)
+		return nil, err
+	}
+	// Не поместившиеся в очередь загрузки подберёт периодическая проверка
+	us.queue.Enqueue(created)
+	return created, nil
+}
+
+// stripImageMetadata removes EXIF (including GPS) from the original.
+//
+// Rotated JPEG is re-encoded with applied orientation, otherwise it would be shown rotated without EXIF.
+// Images larger than maxPixels are rejected, variants could not be generated for them.
+func stripImageMetadata(data []byte, mime string, maxPixels int) ([]byte, error) {
+	if _, err := imaging.Config(data, maxPixels); err != nil {
+		return nil, err
+	}
+	if mime == "image/jpeg" && imaging.Orientation(data) != 1 {
+		img, err := imaging.Decode(data, maxPixels)
+		if err != nil {
+			return nil, err
+		}
+		return imaging.EncodeJPEG(img, 92)
+	}
+	return imaging.StripMetadata(data, mime)
+}
+
+// Open returns content of the original or its variant, caller must close the content.
+//
+// Returned upload describes the opened image: its Key, Mime and Size are replaced by variant ones.
+func (us *UploadService) Open(ctx context.Context, id primitive.ObjectID, variant string) (*models.Upload, io.ReadCloser, error) {
+	upload, err := us.repo.GetUpload(ctx, id)
+	if err != nil {
+		return nil, nil, err
+	}
+	if variant != "" {
+		v, ok := upload.Variants[variant]
+		if !ok {
+			return nil, nil, errs.ErrUploadNotFound
+		}
+		upload.Key, upload.Mime, upload.Size = v.Key, "image/jpeg", v.Size
+	}
+
+	content, err := us.store.Get(ctx, upload.Key)
+	if errors.Is(err, blob.ErrNotFound) {
+		return nil, nil, errs.ErrUploadNotFound
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+	return upload, content, nil
+}
@@ -0,0 +1,61 @@
+package service
+
+import (
+	"context"
+	"math"
+
+	"vk-inter/internal/models"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type UserRepo interface {
+	GetByLogin(ctx context.Context, login string) (*models.User, error)
+}
+
+type UserListingRepo interface {
+	CountByOwnerStatus(ctx context.Context, ownerID primitive.ObjectID, statuses []string) (map[string]int64, error)
+}
+
+// Статусы, которые видны в публичном профиле: состояние модерации и проверки не раскрывается
+var profileStatuses = []string{models.ListingStatusPublished, models.ListingStatusSold}
+
+type UserService struct {
+	repo        UserRepo
+	listingRepo UserListingRepo
+}
+
+func NewUserService(repo UserRepo, listingRepo UserListingRepo) *UserService {
+	return &UserService{
+		repo:        repo,
+		listingRepo: listingRepo,
+	}
+}
+
+// GetProfile returns public profile of the user, it never contains private data
+func (us *UserService) GetProfile(ctx context.Context, login string) (*models.UserProfile, error) {
+	user, err := us.repo.GetByLogin(ctx, login)
+	if err != nil {
+		return nil, err
+	}
+
+	counts, err := us.listingRepo.CountByOwnerStatus(ctx, user.ID, profileStatuses)
+	if err != nil {
+		return nil, err
+	}
+
+	profile := &models.UserProfile{
+		Login:         user.Login,
+		RegisteredAt:  user.CreatedAt,
+		ListingCounts: counts,
+	}
+	for _, count := range counts {
+		profile.TotalListings += count
+	}
+	if user.RatingCount > 0 {
+		rating := math.Round(float64(user.RatingSum)/float64(user.RatingCount)*100) / 100
+		profile.Rating = &rating
+		profile.ReviewsCount = user.RatingCount
+	}
+	return profile, nil
+}
@@ -0,0 +1,209 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"strings"
+	"sync"
+	"time"
+
+	"vk-inter/internal/models"
+	"vk-inter/pkg/imaging"
+	"vk-inter/pkg/logger"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.uber.org/zap"
+)
+
+// imageVariants are generated for every uploaded image, MaxSide is the longest side in pixels
+var imageVariants = []struct {
+	Name    string
+	MaxSide int
+}{
+	{models.ImageVariantThumb, 200},
+	{models.ImageVariantMedium, 600},
+	{models.ImageVariantLarge, 1200},
+}
+
+const variantJPEGQuality = 82
+
+type VariantsConfig struct {
+	Workers   int `env:"IMAGE_WORKERS" env-default:"2"`
+	QueueSize int `env:"IMAGE_QUEUE_SIZE" env-default:"100"`
+}
+
+type VariantUploadRepo interface {
+	GetPendingUploads(ctx context.Context, limit int) ([]*models.Upload, error)
+	SetVariants(ctx context.Context, id primitive.ObjectID, variants map[string]models.ImageVariant, phash int64, status string) error
+}
+
+type VariantListingRepo interface {
+	SetImageVariants(ctx context.Context, uploadID primitive.ObjectID, variants map[string]models.ImageVariant, phash int64, bands []string) error
+}
+
+// ImageProcessor generates image variants and perceptual hashes in a pool of background workers.
+//
+// Uploads are queued in memory, their status is kept in DB, so uploads which
+// didn't fit into the queue or were lost on restart are picked up by periodic rescan.
+type ImageProcessor struct {
+	uploadRepo  VariantUploadRepo
+	listingRepo VariantListingRepo
+	store       BlobStore
+	cfg         VariantsConfig
+	publicURL   string
+	maxPixels   int
+
+	queue chan *models.Upload
+
+	mu       sync.Mutex
+	inFlight map[primitive.ObjectID]struct{}
+}
+
+func NewImageProcessor(uploadRepo VariantUploadRepo, listingRepo VariantListingRepo, store BlobStore, cfg VariantsConfig, uploadCfg UploadConfig) *ImageProcessor {
+	return &ImageProcessor{
+		uploadRepo:  uploadRepo,
+		listingRepo: listingRepo,
+		store:       store,
+		cfg:         cfg,
+		publicURL:   strings.TrimRight(uploadCfg.PublicURL, "/"),
+		maxPixels:   uploadCfg.MaxPixels,
+		queue:       make(chan *models.Upload, cfg.QueueSize),
+		inFlight:    map[primitive.ObjectID]struct{}{},
+	}
+}
+
+// Enqueue schedules variants generation, returns false if the queue is full
+func (p *ImageProcessor) Enqueue(upload *models.Upload) bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if _, ok := p.inFlight[upload.ID]; ok {
+		return true
+	}
+	select {
+	case p.queue <- upload:
+		p.inFlight[upload.ID] = struct{}{}
+		return true
+	default:
+		return false
+	}
+}
+
+// Run starts workers and blocks until ctx is done
+func (p *ImageProcessor) Run(ctx context.Context) {
+	var wg sync.WaitGroup
+	for range max(p.cfg.Workers, 1) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				select {
+				case <-ctx.Done():
+					return
+				case upload := <-p.queue:
+					p.process(ctx, upload)
+				}
+			}
+		}()
+	}
+
+	ticker := time.NewTicker(time.Minute)
+	defer ticker.Stop()
+	for {
+		p.rescan(ctx)
+		select {
+		case <-ctx.Done():
+			wg.Wait()
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (p *ImageProcessor) rescan(ctx context.Context) {
+	uploads, err := p.uploadRepo.GetPendingUploads(ctx, p.cfg.QueueSize)
+	if err != nil {
+		logger.FromContext(ctx).Warn("Failed to get pending uploads", zap.Error(err))
+		return
+	}
+	for _, u := range uploads {
+		if !p.Enqueue(u) {
+			return
+		}
+	}
+}
+
+func (p *ImageProcessor) process(ctx context.Context, upload *models.Upload) {
+	defer func() {
+		p.mu.Lock()
+		delete(p.inFlight, upload.ID)
+		p.mu.Unlock()
+	}()
+
+	log := logger.FromContext(ctx)
+	status := models.VariantsStatusReady
+	variants, phash, err := p.generate(ctx, upload)
+	if err != nil {
+		if ctx.Err() != nil {
+			return
+		}
+		log.Warn("Failed to generate image variants", zap.String("upload_id", upload.ID.Hex()), zap.Error(err))
+		status = models.VariantsStatusFailed
+	}
+
+	if err := p.uploadRepo.SetVariants(ctx, upload.ID, variants, phash, status); err != nil {
+		log.Warn("Failed to save image variants", zap.String("upload_id", upload.ID.Hex()), zap.Error(err))
+		return
+	}
+	if len(variants) == 0 {
+		return
+	}
+	// Объявление могло быть создано раньше, чем варианты были готовы,
+	// полосы хэша добавляются к отпечатку, чтобы по ним находились следующие дубликаты
+	if err := p.listingRepo.SetImageVariants(ctx, upload.ID, variants, phash, imageHashBands(phash)); err != nil {
+		log.Warn("Failed to set listing image variants", zap.String("upload_id", upload.ID.Hex()), zap.Error(err))
+	}
+}
+
+// generate stores variants of the upload and returns them with perceptual hash of the image
+func (p *ImageProcessor) generate(ctx context.Context, upload *models.Upload) (map[string]models.ImageVariant, int64, error) {
+	content, err := p.store.Get(ctx, upload.Key)
+	if err != nil {
+		return nil, 0, err
+	}
+	data, err := io.ReadAll(content)
+	content.Close()
+	if err != nil {
+		return nil, 0, err
+	}
+
+	img, err := imaging.Decode(data, p.maxPixels)
+	if err != nil {
+		return nil, 0, err
+	}
+	phash := int64(imaging.DHash(img))
+
+	variants := make(map[string]models.ImageVariant, len(imageVariants))
+	for _, v := range imageVariants {
+		resized := imaging.Fit(img, v.MaxSide)
+		// Перекодирование в JPEG заодно убирает все метаданные
+		encoded, err := imaging.EncodeJPEG(resized, variantJPEGQuality)
+		if err != nil {
+			return nil, 0, err
+		}
+
+		variant := models.ImageVariant{
+			Key:    "uploads/" + upload.ID.Hex() + "_" + v.Name + ".jpg",
+			URL:    p.publicURL + "/uploads/" + upload.ID.Hex() + "/" + v.Name,
+			Width:  resized.Bounds().Dx(),
+			Height: resized.Bounds().Dy(),
+			Size:   int64(len(encoded)),
+		}
+		if err := p.store.Put(ctx, variant.Key, bytes.NewReader(encoded), variant.Size, "image/jpeg"); err != nil {
+			return nil, 0, err
+		}
+		variants[v.Name] = variant
+	}
+	return variants, phash, nil
+}
@@ -0,0 +1,199 @@
+package service
+
+import (
+	"context"
+	"hash/fnv"
+	"sync"
+	"time"
+
+	"vk-inter/internal/models"
+	"vk-inter/pkg/logger"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.uber.org/zap"
+)
+
+const (
+	viewsShutdownTimeout = 5 * time.Second
+	// После стольких неудачных сохранений подряд накопленные просмотры отбрасываются
+	viewsMaxFlushFailures = 5
+)
+
+type ViewsConfig struct {
+	// DedupWindow is the time during which repeated views of the same viewer are not counted
+	DedupWindow time.Duration `env:"VIEWS_DEDUP_WINDOW" env-default:"30m"`
+	// FlushInterval is how often counted views are written to the database
+	FlushInterval time.Duration `env:"VIEWS_FLUSH_INTERVAL" env-default:"10s"`
+	// MaxPending is the number of pending listing-days which triggers flush before the interval
+	MaxPending int `env:"VIEWS_MAX_PENDING" env-default:"5000"`
+	// MaxTracked limits the number of remembered viewers, when it is reached views are deduplicated from scratch
+	MaxTracked int `env:"VIEWS_MAX_TRACKED" env-default:"100000"`
+}
+
+type ViewRepo interface {
+	AddViews(ctx context.Context, batchID primitive.ObjectID, views []models.ViewCount) error
+}
+
+type viewerKey struct {
+	listingID primitive.ObjectID
+	viewer    uint64
+}
+
+type pendingKey struct {
+	listingID primitive.ObjectID
+	day       string
+}
+
+// viewBatch is a flushed part of pending views, it keeps its id when retried,
+// so the repository doesn't count it twice
+type viewBatch struct {
+	id    primitive.ObjectID
+	views []models.ViewCount
+}
+
+// ViewCounter counts unique views of listings in memory and writes them in batches.
+//
+// Views are deduplicated per viewer within the window, views pending at shutdown are flushed by Run.
+// Failed batch is retried as is before new views, after several failures in a row it is dropped.
+type ViewCounter struct {
+	repo  ViewRepo
+	clock Clock
+	cfg   ViewsConfig
+
+	mu       sync.Mutex
+	seen     map[viewerKey]time.Time
+	pending  map[pendingKey]*models.ViewCount
+	failed   *viewBatch
+	failures int
+	full     chan struct{}
+}
+
+func NewViewCounter(repo ViewRepo, clock Clock, cfg ViewsConfig) *ViewCounter {
+	return &ViewCounter{
+		repo:    repo,
+		clock:   clock,
+		cfg:     cfg,
+		seen:    map[viewerKey]time.Time{},
+		pending: map[pendingKey]*models.ViewCount{},
+		full:    make(chan struct{}, 1),
+	}
+}
+
+// RecordView counts the view of the listing by the viewer, viewer is any stable identity
+// like user id or client address
+func (vc *ViewCounter) RecordView(listing *models.Listing, viewer string) {
+	h := fnv.New64a()
+	h.Write([]byte(viewer))
+	key := viewerKey{listingID: listing.ID, viewer: h.Sum64()}
+	now := vc.clock.Now()
+
+	vc.mu.Lock()
+	defer vc.mu.Unlock()
+	if last, ok := vc.seen[key]; ok && now.Sub(last) < vc.cfg.DedupWindow {
+		return
+	}
+	if len(vc.seen) >= vc.cfg.MaxTracked {
+		// Поток новых адресов не должен расходовать память без ограничений,
+		// устаревшие записи удаляются при каждом сохранении
+		clear(vc.seen)
+	}
+	vc.seen[key] = now
+
+	pk := pendingKey{listingID: listing.ID, day: now.UTC().Format(models.DayLayout)}
+	count, ok := vc.pending[pk]
+	if !ok {
+		count = &models.ViewCount{ListingID: listing.ID, OwnerID: listing.OwnerID, Day: pk.day}
+		vc.pending[pk] = count
+	}
+	count.Views++
+
+	if len(vc.pending) >= vc.cfg.MaxPending {
+		select {
+		case vc.full <- struct{}{}:
+		default:
+		}
+	}
+}
+
+// Run flushes views periodically and when too many are pending
+func (vc *ViewCounter) Run(ctx context.Context) {
+	ticker := time.NewTicker(max(vc.cfg.FlushInterval, time.Second))
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			// Накопленные просмотры сохраняются при остановке
+			flushCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), viewsShutdownTimeout)
+			if err := vc.Flush(flushCtx); err != nil {
+				logger.FromContext(ctx).Warn("Flush views error", zap.Error(err))
+			}
+			cancel()
+			return
+		case <-ticker.C:
+		case <-vc.full:
+		}
+
+		if err := vc.Flush(ctx); err != nil && ctx.Err() == nil {
+			logger.FromContext(ctx).Warn("Flush views error", zap.Error(err))
+		}
+	}
+}
+
+// Flush retries the failed batch and writes pending views as a new batch.
+//
+// Failed batch is kept for the next flush until viewsMaxFlushFailures flushes fail in a row.
+func (vc *ViewCounter) Flush(ctx context.Context) error {
+	vc.mu.Lock()
+	vc.purgeSeen(vc.clock.Now())
+	failed := vc.failed
+	vc.mu.Unlock()
+
+	if failed != nil {
+		// Новые просмотры ждут, пока не сохранится предыдущая пачка
+		if err := vc.write(ctx, failed); err != nil {
+			return err
+		}
+	}
+
+	vc.mu.Lock()
+	pending := vc.pending
+	vc.pending = map[pendingKey]*models.ViewCount{}
+	vc.mu.Unlock()
+
+	if len(pending) == 0 {
+		return nil
+	}
+	batch := &viewBatch{id: primitive.NewObjectID(), views: make([]models.ViewCount, 0, len(pending))}
+	for _, count := range pending {
+		batch.views = append(batch.views, *count)
+	}
+	return vc.write(ctx, batch)
+}
+
+// write saves the batch, on failure it is kept for retry or dropped after too many failures
+func (vc *ViewCounter) write(ctx context.Context, batch *viewBatch) error {
+	err := vc.repo.AddViews(ctx, batch.id, batch.views)
+
+	vc.mu.Lock()
+	defer vc.mu.Unlock()
+	if err == nil {
+		vc.failed, vc.failures = nil, 0
+		return nil
+	}
+	vc.failed = batch
+	vc.failures++
+	if vc.failures >= viewsMaxFlushFailures {
+		vc.failed, vc.failures = nil, 0
+		logger.FromContext(ctx).Warn("Views are dropped after repeated flush errors", zap.Int("listing_days", len(batch.views)))
+	}
+	return err
+}
+
+// purgeSeen forgets viewers whose dedup window has passed, mu must be held
+func (vc *ViewCounter) purgeSeen(now time.Time) {
+	for key, last := range vc.seen {
+		if now.Sub(last) >= vc.cfg.DedupWindow {
+			delete(vc.seen, key)
+		}
+	}
+}
@@ -0,0 +1,127 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"vk-inter/internal/models"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type fakeViewRepo struct {
+	err error
+	// partial saves the batch before returning err, like failed write of listing counters
+	partial bool
+	calls   int
+	views   int64
+	applied map[primitive.ObjectID]bool
+}
+
+func (r *fakeViewRepo) AddViews(_ context.Context, batchID primitive.ObjectID, views []models.ViewCount) error {
+	r.calls++
+	if r.err != nil && !r.partial {
+		return r.err
+	}
+	if r.applied == nil {
+		r.applied = map[primitive.ObjectID]bool{}
+	}
+	if !r.applied[batchID] {
+		r.applied[batchID] = true
+		for _, v := range views {
+			r.views += v.Views
+		}
+	}
+	return r.err
+}
+
+func TestViewCounterDedup(t *testing.T) {
+	clock := &fakeClock{now: time.Date(2026, 5, 1, 12, 0, 0, 0, time.UTC)}
+	repo := &fakeViewRepo{}
+	vc := NewViewCounter(repo, clock, ViewsConfig{DedupWindow: 30 * time.Minute, MaxPending: 100, MaxTracked: 3})
+	listing := &models.Listing{ID: primitive.NewObjectID(), OwnerID: primitive.NewObjectID()}
+
+	vc.RecordView(listing, "client:10.0.0.1")
+	vc.RecordView(listing, "client:10.0.0.1")
+	clock.Advance(29 * time.Minute)
+	vc.RecordView(listing, "client:10.0.0.1")
+	vc.RecordView(listing, "client:10.0.0.2")
+	clock.Advance(time.Minute)
+	vc.RecordView(listing, "client:10.0.0.1")
+	if err := vc.Flush(testContext()); err != nil {
+		t.Fatal(err)
+	}
+	if repo.views != 3 {
+		t.Fatalf("views = %d, want 3", repo.views)
+	}
+
+	// При переполнении запомненные зрители забываются
+	for _, viewer := range []string{"a", "b", "c", "d"} {
+		vc.RecordView(listing, viewer)
+	}
+	if len(vc.seen) > 3 {
+		t.Fatalf("%d viewers tracked, want at most 3", len(vc.seen))
+	}
+}
+
+func TestViewCounterDropsAfterFailures(t *testing.T) {
+	clock := &fakeClock{now: time.Date(2026, 5, 1, 12, 0, 0, 0, time.UTC)}
+	repo := &fakeViewRepo{err: errors.New("no primary")}
+	vc := NewViewCounter(repo, clock, ViewsConfig{DedupWindow: time.Minute, MaxPending: 100, MaxTracked: 100})
+	listing := &models.Listing{ID: primitive.NewObjectID()}
+	ctx := testContext()
+
+	vc.RecordView(listing, "user:1")
+	for i := 1; i < viewsMaxFlushFailures; i++ {
+		if err := vc.Flush(ctx); err == nil {
+			t.Fatal("Flush() succeeded, want error")
+		}
+		if vc.failed == nil || len(vc.failed.views) != 1 {
+			t.Fatalf("failed batch = %+v after %d failures, want views kept", vc.failed, i)
+		}
+	}
+	if err := vc.Flush(ctx); err == nil {
+		t.Fatal("Flush() succeeded, want error")
+	}
+	if vc.failed != nil || len(vc.pending) != 0 {
+		t.Fatalf("failed batch = %+v, pending = %d, want views dropped", vc.failed, len(vc.pending))
+	}
+
+	repo.err = nil
+	vc.RecordView(listing, "user:2")
+	if err := vc.Flush(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if repo.views != 1 {
+		t.Fatalf("views = %d, want 1", repo.views)
+	}
+}
+
+func TestViewCounterRetryIsIdempotent(t *testing.T) {
+	clock := &fakeClock{now: time.Date(2026, 5, 1, 12, 0, 0, 0, time.UTC)}
+	repo := &fakeViewRepo{err: errors.New("no primary"), partial: true}
+	vc := NewViewCounter(repo, clock, ViewsConfig{DedupWindow: time.Minute, MaxPending: 100, MaxTracked: 100})
+	listing := &models.Listing{ID: primitive.NewObjectID()}
+	ctx := testContext()
+
+	vc.RecordView(listing, "user:1")
+	vc.RecordView(listing, "user:2")
+	if err := vc.Flush(ctx); err == nil {
+		t.Fatal("Flush() succeeded, want error")
+	}
+
+	// Повтор отправляет ту же пачку, новые просмотры идут отдельной
+	repo.err = nil
+	vc.RecordView(listing, "user:3")
+	if err := vc.Flush(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if repo.calls != 3 {
+		t.Fatalf("AddViews() called %d times, want 3", repo.calls)
+	}
+	if repo.views != 3 {
+		t.Fatalf("views = %d, want 3", repo.views)
+	}
+}
@@ -0,0 +1,55 @@
+package controllers
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"vk-inter/internal/transport/rest/interfaces"
+	"vk-inter/pkg/errs"
+
+	"github.com/gin-gonic/gin"
+)
+
+type AnalyticsController struct {
+	ctx              *context.Context
+	analyticsService interfaces.AnalyticsService
+	authService      interfaces.AuthService
+}
+
+func NewAnalyticsController(ctx *context.Context, analyticsService interfaces.AnalyticsService, authService interfaces.AuthService) *AnalyticsController {
+	return &AnalyticsController{
+		ctx:              ctx,
+		analyticsService: analyticsService,
+		authService:      authService,
+	}
+}
+
+// @Summary		Get views, favorites and messages of my listings by day
+// @Description	Days are in UTC, the range is up to 90 days. Views are counted with a delay of several seconds.
+// @Description	Favorites are bookmarks added in the day which are still kept, messages are messages from buyers.
+// @Tags			analytics
+// @Security		BearerAuth
+// @Produce		json
+// @Param			from	query		string	false	"First day, YYYY-MM-DD (default: 29 days before to)"
+// @Param			to		query		string	false	"Last day, YYYY-MM-DD (default: today)"
+// @Success		200		{object}	models.SellerAnalytics
+// @Failure		400		{object}	ErrorResponse
+// @Failure		401		{object}	ErrorResponse
+// @Router			/me/analytics [get]
+func (ac *AnalyticsController) GetSellerAnalytics(c *gin.Context) {
+	user := requireUser(*ac.ctx, c, ac.authService, "Please login before view analytics")
+	if user == nil {
+		return
+	}
+
+	analytics, err := ac.analyticsService.GetSellerAnalytics(*ac.ctx, c.Query("from"), c.Query("to"), user)
+	if err != nil {
+		status := http.StatusInternalServerError
+		if errors.Is(err, errs.ErrAnalyticsInvalidRange) {
+			status = http.StatusBadRequest
+		}
+		c.JSON(status, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, analytics)
+}
@@ -0,0 +1,111 @@
+package controllers
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"vk-inter/internal/transport/rest/interfaces"
+	"vk-inter/pkg/errs"
+	"vk-inter/pkg/money"
+	"vk-inter/pkg/utils"
+
+	"github.com/gin-gonic/gin"
+)
+
+type AuctionController struct {
+	ctx            *context.Context
+	auctionService interfaces.AuctionService
+	authService    interfaces.AuthService
+}
+
+func NewAuctionController(ctx *context.Context, auctionService interfaces.AuctionService, authService interfaces.AuthService) *AuctionController {
+	return &AuctionController{
+		ctx:            ctx,
+		auctionService: auctionService,
+		authService:    authService,
+	}
+}
+
+type BidRequest struct {
+	// Amount is in the listing currency
+	Amount money.Decimal `json:"amount" swaggertype:"string" example:"5100.00"`
+}
+
+func auctionErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, errs.ErrListingNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, errs.ErrListingNotAuction),
+		errors.Is(err, errs.ErrBidInvalidAmount),
+		errors.Is(err, errs.ErrBidTooLow),
+		errors.Is(err, errs.ErrBidOwnListing):
+		return http.StatusBadRequest
+	case errors.Is(err, errs.ErrAuctionEnded),
+		errors.Is(err, errs.ErrListingNotAvailable),
+		errors.Is(err, errs.ErrBidConflict):
+		return http.StatusConflict
+	}
+	return http.StatusInternalServerError
+}
+
+// @Summary		Place a bid on the auction
+// @Description	The first bid must be at least the start price, next ones at least the current bid plus the minimal increment.
+// @Description	A bid placed less than extend_seconds before the end moves the end to extend_seconds after the bid.
+// @Tags			auction
+// @Security		BearerAuth
+// @Accept			json
+// @Produce		json
+// @Param			id		path		string		true	"Listing ID"
+// @Param			request	body		BidRequest	true	"Bid"
+// @Success		201		{object}	models.BidResult
+// @Failure		400		{object}	ErrorResponse
+// @Failure		401		{object}	ErrorResponse
+// @Failure		404		{object}	ErrorResponse
+// @Failure		409		{object}	ErrorResponse	"Auction has ended or changed too often during the bid"
+// @Router			/listings/{id}/bids [post]
+func (ac *AuctionController) PlaceBid(c *gin.Context) {
+	user := requireUser(*ac.ctx, c, ac.authService, "Please login before place bids")
+	if user == nil {
+		return
+	}
+	id, ok := parseIDParam(c, "id", errs.ErrListingNotFound)
+	if !ok {
+		return
+	}
+
+	var req BidRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": errs.ErrBidInvalidAmount.Error()})
+		return
+	}
+
+	result, err := ac.auctionService.PlaceBid(*ac.ctx, id, req.Amount, user)
+	if err != nil {
+		c.JSON(auctionErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusCreated, result)
+}
+
+// @Summary	Get bids of the auction, the highest first
+// @Tags		auction
+// @Produce	json
+// @Param		id		path		string	true	"Listing ID"
+// @Param		limit	query		int		false	"Number of bids (default: 50)"
+// @Success	200		{array}		models.Bid
+// @Failure	400		{object}	ErrorResponse
+// @Failure	404		{object}	ErrorResponse
+// @Router		/listings/{id}/bids [get]
+func (ac *AuctionController) GetBids(c *gin.Context) {
+	id, ok := parseIDParam(c, "id", errs.ErrListingNotFound)
+	if !ok {
+		return
+	}
+
+	bids, err := ac.auctionService.GetBids(*ac.ctx, id, utils.ParseQueryInt(c, "limit", 50))
+	if err != nil {
+		c.JSON(auctionErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, bids)
+}
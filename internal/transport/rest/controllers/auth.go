@@ -150,6 +150,9 @@ func (ac *AuthController) LogIn(c *gin.Context) {
 		if errors.Is(err, errs.ErrWrongPasswordOrLogin) {
 			status = http.StatusUnauthorized // Не 404, потому чтоб не раскрывать, по какой причине невозможно залогиниться
 		}
+		if errors.Is(err, errs.ErrUserBanned) {
+			status = http.StatusForbidden
+		}
 		c.JSON(status, gin.H{"error": err.Error()})
 		return
 	}
@@ -0,0 +1,193 @@
+package controllers
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"vk-inter/internal/models"
+	"vk-inter/internal/transport/rest/interfaces"
+	"vk-inter/pkg/errs"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type CategoryController struct {
+	ctx             *context.Context
+	categoryService interfaces.CategoryService
+	authService     interfaces.AuthService
+}
+
+func NewCategoryController(ctx *context.Context, categoryService interfaces.CategoryService, authService interfaces.AuthService) *CategoryController {
+	return &CategoryController{
+		ctx:             ctx,
+		categoryService: categoryService,
+		authService:     authService,
+	}
+}
+
+type CategoryRequest struct {
+	Name     string              `json:"name"`
+	Slug     string              `json:"slug"`
+	ParentID *primitive.ObjectID `json:"parent_id,omitempty" swaggertype:"string"`
+	// Attributes of listings in this category, subcategories inherit them
+	Attributes []models.AttributeSchema `json:"attributes,omitempty"`
+}
+
+func categoryErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, errs.ErrForbidden):
+		return http.StatusForbidden
+	case errors.Is(err, errs.ErrCategoryNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, errs.ErrCategoryAlreadyExsist),
+		errors.Is(err, errs.ErrCategoryNotEmpty):
+		return http.StatusConflict
+	case errors.Is(err, errs.ErrCategoryInvalidName),
+		errors.Is(err, errs.ErrCategoryInvalidSlug),
+		errors.Is(err, errs.ErrCategoryCycle),
+		errors.Is(err, errs.ErrCategoryInvalidSchema):
+		return http.StatusBadRequest
+	}
+	return http.StatusInternalServerError
+}
+
+// @Summary	Get categories tree with listing counts
+// @Tags		category
+// @Produce	json
+// @Success	200	{array}		models.CategoryNode
+// @Failure	500	{object}	ErrorResponse
+// @Router		/categories [get]
+func (cc *CategoryController) GetTree(c *gin.Context) {
+	tree, err := cc.categoryService.GetTree(*cc.ctx)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, tree)
+}
+
+// @Summary	Get attributes schema of category including inherited attributes
+// @Tags		category
+// @Produce	json
+// @Param		id	path		string	true	"Category ID"
+// @Success	200	{array}		models.AttributeSchema
+// @Failure	404	{object}	ErrorResponse
+// @Failure	500	{object}	ErrorResponse
+// @Router		/categories/{id}/attributes [get]
+func (cc *CategoryController) GetAttributes(c *gin.Context) {
+	id, ok := parseIDParam(c, "id", errs.ErrCategoryNotFound)
+	if !ok {
+		return
+	}
+
+	attributes, err := cc.categoryService.GetAttributes(*cc.ctx, id)
+	if err != nil {
+		c.JSON(categoryErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, attributes)
+}
+
+// @Summary	Create category (admin only)
+// @Tags		category
+// @Security	BearerAuth
+// @Accept		json
+// @Produce	json
+// @Param		request	body	CategoryRequest	true	"Category info"
+// @Success	201		{object}	models.Category
+// @Failure	400		{object}	ErrorResponse
+// @Failure	401		{object}	ErrorResponse
+// @Failure	403		{object}	ErrorResponse
+// @Failure	409		{object}	ErrorResponse
+// @Router		/categories [post]
+func (cc *CategoryController) CreateCategory(c *gin.Context) {
+	user := requireUser(*cc.ctx, c, cc.authService, "Please login before manage categories")
+	if user == nil {
+		return
+	}
+
+	var req CategoryRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	category, err := cc.categoryService.CreateCategory(*cc.ctx, req.Name, req.Slug, req.ParentID, req.Attributes, user)
+	if err != nil {
+		status := categoryErrorStatus(err)
+		// Несуществующий родитель - ошибка запроса, а не отсутствие ресурса
+		if status == http.StatusNotFound {
+			status = http.StatusBadRequest
+		}
+		c.JSON(status, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusCreated, category)
+}
+
+// @Summary	Update category (admin only)
+// @Tags		category
+// @Security	BearerAuth
+// @Accept		json
+// @Produce	json
+// @Param		id		path	string			true	"Category ID"
+// @Param		request	body	CategoryRequest	true	"Category info, omit parent_id to move category to the root"
+// @Success	200		{object}	models.Category
+// @Failure	400		{object}	ErrorResponse
+// @Failure	401		{object}	ErrorResponse
+// @Failure	403		{object}	ErrorResponse
+// @Failure	404		{object}	ErrorResponse
+// @Failure	409		{object}	ErrorResponse
+// @Router		/categories/{id} [put]
+func (cc *CategoryController) UpdateCategory(c *gin.Context) {
+	user := requireUser(*cc.ctx, c, cc.authService, "Please login before manage categories")
+	if user == nil {
+		return
+	}
+	id, ok := parseIDParam(c, "id", errs.ErrCategoryNotFound)
+	if !ok {
+		return
+	}
+
+	var req CategoryRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	category, err := cc.categoryService.UpdateCategory(*cc.ctx, id, req.Name, req.Slug, req.ParentID, req.Attributes, user)
+	if err != nil {
+		c.JSON(categoryErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, category)
+}
+
+// @Summary	Delete empty category (admin only)
+// @Tags		category
+// @Security	BearerAuth
+// @Produce	json
+// @Param		id	path	string	true	"Category ID"
+// @Success	204
+// @Failure	401	{object}	ErrorResponse
+// @Failure	403	{object}	ErrorResponse
+// @Failure	404	{object}	ErrorResponse
+// @Failure	409	{object}	ErrorResponse
+// @Router		/categories/{id} [delete]
+func (cc *CategoryController) DeleteCategory(c *gin.Context) {
+	user := requireUser(*cc.ctx, c, cc.authService, "Please login before manage categories")
+	if user == nil {
+		return
+	}
+	id, ok := parseIDParam(c, "id", errs.ErrCategoryNotFound)
+	if !ok {
+		return
+	}
+
+	if err := cc.categoryService.DeleteCategory(*cc.ctx, id, user); err != nil {
+		c.JSON(categoryErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
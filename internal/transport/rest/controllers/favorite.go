@@ -0,0 +1,106 @@
+package controllers
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"vk-inter/internal/transport/rest/interfaces"
+	"vk-inter/pkg/errs"
+	"vk-inter/pkg/utils"
+
+	"github.com/gin-gonic/gin"
+)
+
+type FavoriteController struct {
+	ctx             *context.Context
+	favoriteService interfaces.FavoriteService
+	authService     interfaces.AuthService
+}
+
+func NewFavoriteController(ctx *context.Context, favoriteService interfaces.FavoriteService, authService interfaces.AuthService) *FavoriteController {
+	return &FavoriteController{
+		ctx:             ctx,
+		favoriteService: favoriteService,
+		authService:     authService,
+	}
+}
+
+// @Summary	Add listing to favorites
+// @Tags		favorite
+// @Security	BearerAuth
+// @Produce	json
+// @Param		id	path		string	true	"Listing ID"
+// @Success	200	{object}	models.FavoriteState
+// @Failure	401	{object}	ErrorResponse
+// @Failure	404	{object}	ErrorResponse
+// @Router		/listings/{id}/favorite [post]
+func (fc *FavoriteController) AddFavorite(c *gin.Context) {
+	user := requireUser(*fc.ctx, c, fc.authService, "Please login before add favorites")
+	if user == nil {
+		return
+	}
+	id, ok := parseIDParam(c, "id", errs.ErrListingNotFound)
+	if !ok {
+		return
+	}
+
+	state, err := fc.favoriteService.AddFavorite(*fc.ctx, id, user)
+	if err != nil {
+		c.JSON(listingErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, state)
+}
+
+// @Summary	Remove listing from favorites
+// @Tags		favorite
+// @Security	BearerAuth
+// @Produce	json
+// @Param		id	path		string	true	"Listing ID"
+// @Success	200	{object}	models.FavoriteState
+// @Failure	401	{object}	ErrorResponse
+// @Failure	404	{object}	ErrorResponse
+// @Router		/listings/{id}/favorite [delete]
+func (fc *FavoriteController) RemoveFavorite(c *gin.Context) {
+	user := requireUser(*fc.ctx, c, fc.authService, "Please login before remove favorites")
+	if user == nil {
+		return
+	}
+	id, ok := parseIDParam(c, "id", errs.ErrListingNotFound)
+	if !ok {
+		return
+	}
+
+	state, err := fc.favoriteService.RemoveFavorite(*fc.ctx, id, user)
+	if err != nil {
+		c.JSON(listingErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, state)
+}
+
+// @Summary	Get my favorite listings, recently added first
+// @Tags		favorite
+// @Security	BearerAuth
+// @Produce	json
+// @Param		page	query		int	false	"Page number (default: 1)"
+// @Param		limit	query		int	false	"Items per page (default: 10)"
+// @Success	200		{object}	models.ListingPage
+// @Header		200		{integer}	X-Total-Count	"Total number of favorites"
+// @Failure	401		{object}	ErrorResponse
+// @Failure	500		{object}	ErrorResponse
+// @Router		/me/favorites [get]
+func (fc *FavoriteController) GetFavorites(c *gin.Context) {
+	user := requireUser(*fc.ctx, c, fc.authService, "Please login before view favorites")
+	if user == nil {
+		return
+	}
+
+	page, err := fc.favoriteService.GetFavorites(*fc.ctx, utils.ParseQueryInt(c, "page", 1), utils.ParseQueryInt(c, "limit", 10), user)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.Header("X-Total-Count", strconv.FormatInt(*page.Total, 10))
+	c.JSON(http.StatusOK, page)
+}
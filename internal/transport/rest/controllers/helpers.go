@@ -0,0 +1,71 @@
+package controllers
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"vk-inter/internal/models"
+	"vk-inter/internal/transport/rest/interfaces"
+	"vk-inter/pkg/errs"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// currentUser returns authenticated user or nil for anonymous requests
+func currentUser(ctx context.Context, c *gin.Context, authService interfaces.AuthService) *models.User {
+	isAuth, exists := c.Get("isAuthenticated")
+	tokenSubject, _ := c.Get("id")
+	if !exists || !isAuth.(bool) || tokenSubject == nil {
+		return nil
+	}
+	user, err := authService.GetUserById(ctx, tokenSubject.(string))
+	if err != nil {
+		return nil
+	}
+	return user
+}
+
+// requireUser works like currentUser, but responds with 401 if there is no user
+func requireUser(ctx context.Context, c *gin.Context, authService interfaces.AuthService, message string) *models.User {
+	user := currentUser(ctx, c, authService)
+	if user == nil {
+		resp := ErrorResponse{
+			Error:   errs.ErrUnauthorized.Error(),
+			Message: message,
+		}
+		c.JSON(http.StatusUnauthorized, resp)
+	}
+	return user
+}
+
+// currentUserID returns id of authenticated user or primitive.NilObjectID
+func currentUserID(ctx context.Context, c *gin.Context, authService interfaces.AuthService) primitive.ObjectID {
+	if user := currentUser(ctx, c, authService); user != nil {
+		return user.ID
+	}
+	return primitive.NilObjectID
+}
+
+// parseIDParam parses path parameter as ObjectID, responding with 404 on failure
+func parseIDParam(c *gin.Context, name string, notFound error) (primitive.ObjectID, bool) {
+	id, err := primitive.ObjectIDFromHex(c.Param(name))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": notFound.Error()})
+		return primitive.NilObjectID, false
+	}
+	return id, true
+}
+
+// clientNetwork identifies anonymous client by IP address, IPv6 clients by their /64 network
+// as they usually get the whole network from the provider
+func clientNetwork(c *gin.Context) string {
+	ip := net.ParseIP(c.ClientIP())
+	if ip == nil {
+		return ""
+	}
+	if ip.To4() == nil {
+		return ip.Mask(net.CIDRMask(64, 128)).String() + "/64"
+	}
+	return ip.String()
+}
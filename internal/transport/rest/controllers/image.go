@@ -0,0 +1,76 @@
+package controllers
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"regexp"
+	"vk-inter/internal/transport/rest/interfaces"
+	"vk-inter/pkg/errs"
+
+	"github.com/gin-gonic/gin"
+)
+
+var imageHashRe = regexp.MustCompile(`^[0-9a-f]{64}$`)
+
+type ImageController struct {
+	ctx          *context.Context
+	imageService interfaces.ImageService
+}
+
+func NewImageController(ctx *context.Context, imageService interfaces.ImageService) *ImageController {
+	return &ImageController{
+		ctx:          ctx,
+		imageService: imageService,
+	}
+}
+
+// @Summary	Get cached copy of external listing image
+// @Description	Supports Range requests, use cached_url of listing image
+// @Tags		image
+// @Produce	image/jpeg,image/png,image/gif,image/webp
+// @Param		hash	path	string	true	"SHA-256 of image content"
+// @Param		Range	header	string	false	"Byte range, e.g. bytes=0-1023"
+// @Success	200		{file}	binary
+// @Success	206		{file}	binary
+// @Failure	404		{object}	ErrorResponse
+// @Failure	416		{object}	ErrorResponse
+// @Router		/images/{hash} [get]
+func (ic *ImageController) GetImage(c *gin.Context) {
+	hash := c.Param("hash")
+	if !imageHashRe.MatchString(hash) {
+		c.JSON(http.StatusNotFound, gin.H{"error": errs.ErrImageNotFound.Error()})
+		return
+	}
+
+	image, content, err := ic.imageService.Open(*ic.ctx, hash)
+	if err != nil {
+		status := http.StatusInternalServerError
+		if errors.Is(err, errs.ErrImageNotFound) {
+			status = http.StatusNotFound
+		}
+		c.JSON(status, gin.H{"error": err.Error()})
+		return
+	}
+	defer content.Close()
+
+	// Для Range нужен произвольный доступ, объекты из S3 читаются в память
+	seeker, ok := content.(io.ReadSeeker)
+	if !ok {
+		data, err := io.ReadAll(content)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		seeker = bytes.NewReader(data)
+	}
+
+	// Содержимое определяется хэшем и никогда не меняется
+	c.Header("Content-Type", image.Mime)
+	c.Header("Cache-Control", "public, max-age=31536000, immutable")
+	c.Header("ETag", `"`+hash+`"`)
+	c.Header("X-Content-Type-Options", "nosniff")
+	http.ServeContent(c.Writer, c.Request, "", image.CreatedAt, seeker)
+}
@@ -3,11 +3,16 @@ package controllers
 import (
 	"context"
 	"errors"
-	"math"
+	"fmt"
 	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
 	"time"
+	"vk-inter/internal/models"
 	"vk-inter/internal/transport/rest/interfaces"
 	"vk-inter/pkg/errs"
+	"vk-inter/pkg/money"
 	"vk-inter/pkg/utils"
 
 	"github.com/gin-gonic/gin"
@@ -29,20 +34,60 @@ func NewListingController(ctx *context.Context, listingService interfaces.Listin
 }
 
 type CreateListingRequest struct {
-	Title       string  `json:"title"`
-	Description string  `json:"description"`
-	ImageURL    string  `json:"image_url"`
-	Price       float64 `json:"price"`
-}
-type CreateListingResponse struct {
-	ID          primitive.ObjectID `json:"_id"`
 	Title       string             `json:"title"`
 	Description string             `json:"description"`
-	ImageURL    string             `json:"image_url"`
-	Price       float64            `json:"price"`
-	OwnerID     primitive.ObjectID `json:"owner_id"`
-	OwnerLogin  string             `json:"owner_login"`
-	CreatedAt   time.Time          `json:"created_at"`
+	Images      []ImageRequest     `json:"images"`
+	Price       money.Decimal      `json:"price" swaggertype:"string" example:"1499.90"`
+	Currency    string             `json:"currency" example:"RUB"` // ISO 4217, base currency when empty
+	CategoryID  primitive.ObjectID `json:"category_id" swaggertype:"string"`
+	Tags        []string           `json:"tags"`
+	Attributes  map[string]any     `json:"attributes"`
+	Location    *models.GeoPoint   `json:"location"`
+	Address     string             `json:"address" example:"Moscow, Tverskaya st. 1"`
+	// SaleMode is fixed (default) or auction, price of the auction is its start price
+	SaleMode string          `json:"sale_mode" enums:"fixed,auction"`
+	Auction  *AuctionRequest `json:"auction,omitempty"`
+}
+
+// AuctionRequest sets up the auction, amounts are in the listing currency
+type AuctionRequest struct {
+	ReservePrice *money.Decimal `json:"reserve_price,omitempty" swaggertype:"string" example:"5000.00"`
+	MinIncrement money.Decimal  `json:"min_increment" swaggertype:"string" example:"100.00"`
+	EndsAt       time.Time      `json:"ends_at"`
+	// ExtendSeconds moves the end after a bid placed in the last seconds
+	ExtendSeconds int `json:"extend_seconds" example:"120"`
+}
+
+// ImageRequest is a listing image, the first image is the cover.
+//
+// Either URL of external image or UploadID returned by POST /uploads must be set.
+type ImageRequest struct {
+	URL      string             `json:"url" example:"https://example.com/photo.jpg"`
+	UploadID primitive.ObjectID `json:"upload_id" swaggertype:"string"`
+	Alt      string             `json:"alt" example:"Front view"`
+}
+
+type CreateListingResponse struct {
+	ID          primitive.ObjectID    `json:"_id"`
+	Title       string                `json:"title"`
+	Description string                `json:"description"`
+	ImageURL    string                `json:"image_url"`
+	Images      []models.ListingImage `json:"images"`
+	Price       money.Decimal         `json:"price" swaggertype:"string"`
+	Currency    string                `json:"currency"`
+	CategoryID  primitive.ObjectID    `json:"category_id"`
+	Tags        []string              `json:"tags"`
+	Attributes  map[string]any        `json:"attributes,omitempty"`
+	Location    *models.GeoPoint      `json:"location,omitempty"`
+	Address     string                `json:"address,omitempty"`
+	OwnerID     primitive.ObjectID    `json:"owner_id"`
+	OwnerLogin  string                `json:"owner_login"`
+	Status      string                `json:"status" enums:"published,pending_media"`
+	SaleMode    string                `json:"sale_mode" enums:"fixed,auction"`
+	Auction     *models.Auction       `json:"auction,omitempty"`
+	MediaCheck  *models.MediaCheck    `json:"media_check,omitempty"`
+	DuplicateOf *primitive.ObjectID   `json:"duplicate_of,omitempty" swaggertype:"string"`
+	CreatedAt   time.Time             `json:"created_at"`
 }
 
 // @Summary	Create listing endpoint
@@ -51,28 +96,16 @@ type CreateListingResponse struct {
 // @Accept		json
 // @Produce	json
 // @Param		request	body	CreateListingRequest	true	"Listing info"
+// @Description	Listings with external image URLs are created as pending_media and published after images are checked in background.
+// @Description	Title and description are checked by content rules: violations are rejected with 400, contacts and similar fragments may be masked with *.
 // @Success	201		{object}	CreateListingResponse
 // @Failure	400		{object}	ErrorResponse
 // @Failure	401		{object}	ErrorResponse
+// @Failure	409		{object}	ErrorResponse	"Repost of the owner's recent listing"
 // @Router		/listings [post]
 func (lc *ListingController) CreateListing(c *gin.Context) {
-	isAuth, exists := c.Get("isAuthenticated")
-	tokenSubject, _ := c.Get("id")
-	if !exists || !isAuth.(bool) || tokenSubject == nil {
-		resp := ErrorResponse{
-			Error:   errs.ErrUnauthorized.Error(),
-			Message: "Please login before create listing",
-		}
-		c.JSON(http.StatusUnauthorized, resp)
-		return
-	}
-	user, err := lc.authService.GetUserById(*lc.ctx, tokenSubject.(string))
-	if err != nil {
-		resp := ErrorResponse{
-			Error:   errs.ErrUnauthorized.Error(),
-			Message: "Please login before create listing",
-		}
-		c.JSON(http.StatusUnauthorized, resp)
+	user := requireUser(*lc.ctx, c, lc.authService, "Please login before create listing")
+	if user == nil {
 		return
 	}
 
@@ -83,19 +116,65 @@ func (lc *ListingController) CreateListing(c *gin.Context) {
 		return
 	}
 
-	listing, err := lc.listingService.CreateListing(*lc.ctx, req.Title, req.Description, req.ImageURL, req.Price, user)
+	images := make([]models.ListingImage, 0, len(req.Images))
+	for _, img := range req.Images {
+		image := models.ListingImage{URL: img.URL, Alt: img.Alt}
+		if !img.UploadID.IsZero() {
+			image.UploadID = &img.UploadID
+		}
+		images = append(images, image)
+	}
+
+	var auction *models.Auction
+	if req.Auction != nil {
+		auction = &models.Auction{
+			ReservePrice:  req.Auction.ReservePrice,
+			MinIncrement:  req.Auction.MinIncrement,
+			EndsAt:        req.Auction.EndsAt,
+			ExtendSeconds: req.Auction.ExtendSeconds,
+		}
+	}
+
+	listing, err := lc.listingService.CreateListing(*lc.ctx, &models.Listing{
+		Title:       req.Title,
+		Description: req.Description,
+		Images:      images,
+		Price:       req.Price,
+		Currency:    req.Currency,
+		CategoryID:  req.CategoryID,
+		Tags:        req.Tags,
+		Attributes:  req.Attributes,
+		Location:    req.Location,
+		Address:     req.Address,
+		SaleMode:    req.SaleMode,
+		Auction:     auction,
+	}, user)
 	if err != nil {
 		status := http.StatusInternalServerError
 
 		if errors.Is(err, errs.ErrListingInvalidTitle) ||
 			errors.Is(err, errs.ErrListingInvalidDescription) ||
 			errors.Is(err, errs.ErrListingInvalidImageURL) ||
-			errors.Is(err, errs.ErrListingInvalidPrice) {
+			errors.Is(err, errs.ErrListingInvalidImages) ||
+			errors.Is(err, errs.ErrListingInvalidPrice) ||
+			errors.Is(err, errs.ErrListingInvalidCurrency) ||
+			errors.Is(err, errs.ErrListingInvalidCategory) ||
+			errors.Is(err, errs.ErrListingInvalidTags) ||
+			errors.Is(err, errs.ErrListingInvalidAttributes) ||
+			errors.Is(err, errs.ErrListingInvalidLocation) ||
+			errors.Is(err, errs.ErrListingInvalidAddress) ||
+			errors.Is(err, errs.ErrListingInvalidSaleMode) ||
+			errors.Is(err, errs.ErrListingInvalidAuction) ||
+			errors.Is(err, errs.ErrListingContentRejected) ||
+			errors.Is(err, errs.ErrCategoryNotFound) {
 			status = http.StatusBadRequest
 		}
 		if _, ok := err.(utils.ImageError); ok {
 			status = http.StatusBadRequest
 		}
+		if errors.Is(err, errs.ErrListingDuplicate) {
+			status = http.StatusConflict
+		}
 		c.JSON(status, gin.H{"error": err.Error()})
 		return
 	}
@@ -104,9 +183,21 @@ func (lc *ListingController) CreateListing(c *gin.Context) {
 		Title:       listing.Title,
 		Description: listing.Description,
 		ImageURL:    listing.ImageURL,
+		Images:      listing.Images,
 		Price:       listing.Price,
+		Currency:    listing.Currency,
+		CategoryID:  listing.CategoryID,
+		Tags:        listing.Tags,
+		Attributes:  listing.Attributes,
+		Location:    listing.Location,
+		Address:     listing.Address,
 		OwnerID:     listing.OwnerID,
 		OwnerLogin:  listing.OwnerLogin,
+		Status:      listing.Status,
+		SaleMode:    listing.SaleMode,
+		Auction:     listing.Auction,
+		MediaCheck:  listing.MediaCheck,
+		DuplicateOf: listing.DuplicateOf,
 		CreatedAt:   listing.CreatedAt,
 	}
 	c.JSON(http.StatusCreated, resp)
@@ -117,44 +208,449 @@ func (lc *ListingController) CreateListing(c *gin.Context) {
 // @Security     BearerAuth
 // @Accept       json
 // @Produce      json
+// @Param        cursor      query     string  false  "Cursor from next_cursor or prev_cursor, switches response to models.ListingPage; pass empty value to get the first page"
+// @Param        envelope    query     bool    false  "Return models.ListingPage with page, limit, total and has_next instead of bare array"
 // @Param        page        query     int     false  "Page number (default: 1)"
 // @Param        limit       query     int     false  "Items per page (default: 10)"
-// @Param        sort_by     query     string  false  "Sort field: created_at or price"
-// @Param        order       query     string  false  "Sort order: asc or desc"
-// @Param        min_price   query     number  false  "Minimum price filter"
-// @Param        max_price   query     number  false  "Maximum price filter"
+// @Param        sort_by     query     string  false  "Sort field: created_at, price or distance (requires near)"
+// @Param        order       query     string  false  "Sort order: asc or desc (default: desc, asc for distance)"
+// @Param        min_price   query     string  false  "Minimum price filter in currency, decimal"
+// @Param        max_price   query     string  false  "Maximum price filter in currency, decimal"
+// @Param        currency    query     string  false  "Currency of price filters and display_price (default: base currency)"
+// @Param        category_id query     string  false  "Category filter, includes subcategories"
+// @Param        owner_id    query     string  false  "Seller id filter"
+// @Param        owner_login query     string  false  "Seller login filter"
+// @Param        sale_mode   query     string  false  "Sale mode filter: fixed or auction"
+// @Param        tags        query     string  false  "Comma-separated tags"
+// @Param        tags_mode   query     string  false  "Tags matching: any (default) or all"
+// @Param        near        query     string  false  "Search around point lat,lon, adds distance_km to results"
+// @Param        radius_km   query     number  false  "Search radius around near point in km"
+// @Param        bbox        query     string  false  "Bounding box min_lon,min_lat,max_lon,max_lat"
+// @Param        price_dropped_since query string false "Only listings whose price dropped after the RFC 3339 time"
+// @Param        attr.{name} query     string  false  "Attribute filter, e.g. attr.rooms=2, attr.brand=bmw,audi or attr.year_gte=2015 (also _gt, _lt, _lte)"
 // @Success      200         {array}   models.Listing
+// @Header       200         {integer} X-Total-Count  "Total number of matching listings (page mode)"
+// @Header       200         {string}  Link           "RFC 8288 links: first, prev, next, last"
+// @Header       200         {string}  X-Next-Cursor  "Cursor of the next page"
+// @Header       200         {string}  X-Prev-Cursor  "Cursor of the previous page"
+// @Failure      400         {object}  ErrorResponse
 // @Failure      401         {object}  ErrorResponse
 // @Failure      500         {object}  ErrorResponse
 // @Router       /listings [get]
 func (lc *ListingController) GetListings(c *gin.Context) {
-	var currentUserID primitive.ObjectID = primitive.NilObjectID
-
-	if isAuth, exists := c.Get("isAuthenticated"); exists && isAuth.(bool) {
-		if id, ok := c.Get("id"); ok {
-			user, err := lc.authService.GetUserById(*lc.ctx, id.(string))
-			if err == nil {
-				currentUserID = user.ID
-			}
-		}
+	filter, err := parseListingFilter(c.Request.URL.Query())
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
 	}
+	filter.CurrentUserID = currentUserID(*lc.ctx, c, lc.authService)
 
-	page := utils.ParseQueryInt(c, "page", 1)
-	limit := utils.ParseQueryInt(c, "limit", 10)
-	sortBy := c.DefaultQuery("sort_by", "created_at")
-	order := c.DefaultQuery("order", "desc")
-	minPrice := utils.ParseQueryFloat(c, "min_price", 0)
-	maxPrice := utils.ParseQueryFloat(c, "max_price", math.MaxFloat64)
+	cursorToken, cursorMode := c.GetQuery("cursor")
+	envelope := c.Query("envelope") == "true"
+	filter.CountTotal = !cursorMode || envelope
 
-	listings, err := lc.listingService.GetListings(*lc.ctx, page, limit, sortBy, order, minPrice, maxPrice, currentUserID)
+	page, err := lc.listingService.GetListings(*lc.ctx, filter, cursorToken)
 	if err != nil {
 		status := http.StatusInternalServerError
-		if errors.Is(err, errs.ErrPriceSorting) {
+		if errors.Is(err, errs.ErrPriceSorting) ||
+			errors.Is(err, errs.ErrListingInvalidPrice) ||
+			errors.Is(err, errs.ErrListingInvalidCurrency) ||
+			errors.Is(err, errs.ErrListingInvalidGeoFilter) ||
+			errors.Is(err, errs.ErrInvalidCursor) ||
+			errors.Is(err, errs.ErrListingInvalidAttrFilter) ||
+			errors.Is(err, errs.ErrCategoryNotFound) {
 			status = http.StatusBadRequest
 		}
 		c.JSON(status, gin.H{"error": err.Error()})
 		return
 	}
 
-	c.JSON(http.StatusOK, listings)
+	if page.Total != nil {
+		c.Header("X-Total-Count", strconv.FormatInt(*page.Total, 10))
+	}
+	if link := listingsLinkHeader(c, page); link != "" {
+		c.Header("Link", link)
+	}
+
+	if cursorMode || envelope {
+		c.JSON(http.StatusOK, page)
+		return
+	}
+
+	// Старый формат ответа - массив, курсоры отдаём в заголовках
+	if page.NextCursor != "" {
+		c.Header("X-Next-Cursor", page.NextCursor)
+	}
+	if page.PrevCursor != "" {
+		c.Header("X-Prev-Cursor", page.PrevCursor)
+	}
+	c.JSON(http.StatusOK, page.Items)
+}
+
+// parseListingFilter parses GET /listings query parameters
+func parseListingFilter(q url.Values) (*models.ListingFilter, error) {
+	filter := &models.ListingFilter{
+		Page:     queryInt(q, "page", 1),
+		Limit:    queryInt(q, "limit", 10),
+		SortBy:   q.Get("sort_by"),
+		Order:    q.Get("order"),
+		Currency: q.Get("currency"),
+		TagsMode: q.Get("tags_mode"),
+	}
+	if filter.SortBy == "" {
+		filter.SortBy = "created_at"
+	}
+	if filter.TagsMode == "" {
+		filter.TagsMode = models.TagsModeAny
+	}
+
+	for key, bound := range map[string]**money.Decimal{"min_price": &filter.MinPrice, "max_price": &filter.MaxPrice} {
+		v := q.Get(key)
+		if v == "" {
+			continue
+		}
+		price, err := money.NewFromString(v)
+		if err != nil {
+			return nil, errs.ErrListingInvalidPrice
+		}
+		*bound = &price
+	}
+	if v := q.Get("category_id"); v != "" {
+		categoryID, err := primitive.ObjectIDFromHex(v)
+		if err != nil {
+			return nil, errs.ErrListingInvalidCategory
+		}
+		filter.CategoryID = categoryID
+	}
+	if v := q.Get("owner_id"); v != "" {
+		ownerID, err := primitive.ObjectIDFromHex(v)
+		if err != nil {
+			return nil, errs.ErrListingInvalidOwner
+		}
+		filter.OwnerID = ownerID
+	}
+	if v := q.Get("price_dropped_since"); v != "" {
+		since, err := time.Parse(time.RFC3339, v)
+		if err != nil {
+			return nil, errs.ErrListingInvalidDropSince
+		}
+		filter.PriceDroppedSince = &since
+	}
+	if v := q.Get("owner_login"); v != "" {
+		filter.OwnerLogin = v
+	}
+	if v := q.Get("sale_mode"); v != "" {
+		if v != models.SaleModeFixed && v != models.SaleModeAuction {
+			return nil, errs.ErrListingInvalidSaleMode
+		}
+		filter.SaleMode = v
+	}
+	if v := q.Get("tags"); v != "" {
+		filter.Tags = strings.Split(v, ",")
+	}
+	if v := q.Get("near"); v != "" {
+		latLon, ok := parseFloatList(v, 2)
+		if !ok {
+			return nil, errs.ErrListingInvalidGeoFilter
+		}
+		filter.Near = models.NewGeoPoint(latLon[0], latLon[1])
+	}
+	if v := q.Get("radius_km"); v != "" {
+		radius, ok := parseFloatList(v, 1)
+		if !ok {
+			return nil, errs.ErrListingInvalidGeoFilter
+		}
+		filter.RadiusKm = radius[0]
+	}
+	if v := q.Get("bbox"); v != "" {
+		bbox, ok := parseFloatList(v, 4)
+		if !ok {
+			return nil, errs.ErrListingInvalidGeoFilter
+		}
+		filter.BBox = bbox
+	}
+	for key, values := range q {
+		param, ok := strings.CutPrefix(key, "attr.")
+		if !ok {
+			continue
+		}
+		f := models.AttributeFilter{Param: param}
+		for _, v := range values {
+			f.Values = append(f.Values, strings.Split(v, ",")...)
+		}
+		filter.Attributes = append(filter.Attributes, f)
+	}
+
+	return filter, nil
+}
+
+func queryInt(q url.Values, key string, defaultValue int) int {
+	if i, err := strconv.Atoi(q.Get(key)); err == nil {
+		return i
+	}
+	return defaultValue
+}
+
+// parseFloatList parses exactly n comma-separated numbers
+func parseFloatList(s string, n int) ([]float64, bool) {
+	parts := strings.Split(s, ",")
+	if len(parts) != n {
+		return nil, false
+	}
+	result := make([]float64, 0, n)
+	for _, p := range parts {
+		f, err := strconv.ParseFloat(strings.TrimSpace(p), 64)
+		if err != nil {
+			return nil, false
+		}
+		result = append(result, f)
+	}
+	return result, true
+}
+
+// listingsLinkHeader builds RFC 8288 Link header with first, prev, next and last pages.
+//
+// In cursor mode pages are addressed by cursors and there is no last page.
+func listingsLinkHeader(c *gin.Context, page *models.ListingPage) string {
+	var links []string
+	add := func(rel string, set map[string]string) {
+		q := c.Request.URL.Query()
+		for k, v := range set {
+			q.Set(k, v)
+		}
+		// Курсор и номер страницы взаимоисключающие
+		if _, ok := set["cursor"]; ok {
+			q.Del("page")
+		} else {
+			q.Del("cursor")
+		}
+		u := url.URL{Path: c.Request.URL.Path, RawQuery: q.Encode()}
+		links = append(links, fmt.Sprintf(`<%s>; rel="%s"`, u.String(), rel))
+	}
+
+	if page.Page == 0 {
+		add("first", map[string]string{"cursor": ""})
+		if page.PrevCursor != "" {
+			add("prev", map[string]string{"cursor": page.PrevCursor})
+		}
+		if page.NextCursor != "" {
+			add("next", map[string]string{"cursor": page.NextCursor})
+		}
+		return strings.Join(links, ", ")
+	}
+
+	add("first", map[string]string{"page": "1"})
+	if page.Page > 1 {
+		add("prev", map[string]string{"page": strconv.Itoa(page.Page - 1)})
+	}
+	if page.HasNext {
+		add("next", map[string]string{"page": strconv.Itoa(page.Page + 1)})
+	}
+	if page.Total != nil {
+		last := int((*page.Total + int64(page.Limit) - 1) / int64(page.Limit))
+		add("last", map[string]string{"page": strconv.Itoa(max(last, 1))})
+	}
+	return strings.Join(links, ", ")
+}
+
+// @Summary	Get listing with images check status
+// @Description	Unpublished listings are visible only to the owner and admins.
+// @Description	Views by other users and anonymous clients are counted once per 30 minutes, views_count is updated with a delay.
+// @Description	Anonymous clients are identified by IP address, IPv6 clients by /64 network.
+// @Tags		listing
+// @Security	BearerAuth
+// @Produce	json
+// @Param		id	path		string	true	"Listing ID"
+// @Success	200	{object}	models.Listing
+// @Failure	404	{object}	ErrorResponse
+// @Failure	500	{object}	ErrorResponse
+// @Router		/listings/{id} [get]
+func (lc *ListingController) GetListing(c *gin.Context) {
+	id, ok := parseIDParam(c, "id", errs.ErrListingNotFound)
+	if !ok {
+		return
+	}
+
+	// Анонимные просмотры различаются только по адресу: заголовки клиент может менять
+	listing, err := lc.listingService.GetListing(*lc.ctx, id, currentUser(*lc.ctx, c, lc.authService), clientNetwork(c))
+	if err != nil {
+		c.JSON(listingErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, listing)
+}
+
+func listingErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, errs.ErrForbidden):
+		return http.StatusForbidden
+	case errors.Is(err, errs.ErrListingNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, errs.ErrListingInvalidImages),
+		errors.Is(err, errs.ErrListingInvalidPrice),
+		errors.Is(err, errs.ErrListingInvalidCurrency):
+		return http.StatusBadRequest
+	case errors.Is(err, errs.ErrListingIsAuction),
+		errors.Is(err, errs.ErrListingNotReserved),
+		errors.Is(err, errs.ErrListingPriceConflict):
+		return http.StatusConflict
+	}
+	return http.StatusInternalServerError
+}
+
+type ReorderImagesRequest struct {
+	// URLs of all listing images in the new order
+	URLs []string `json:"urls"`
+}
+
+// @Summary	Reorder listing images, the first image becomes the cover
+// @Tags		listing
+// @Security	BearerAuth
+// @Accept		json
+// @Produce	json
+// @Param		id		path		string					true	"Listing ID"
+// @Param		request	body		ReorderImagesRequest	true	"New order of images"
+// @Success	200		{object}	models.Listing
+// @Failure	400		{object}	ErrorResponse
+// @Failure	401		{object}	ErrorResponse
+// @Failure	403		{object}	ErrorResponse
+// @Failure	404		{object}	ErrorResponse
+// @Router		/listings/{id}/images/order [put]
+func (lc *ListingController) ReorderImages(c *gin.Context) {
+	user := requireUser(*lc.ctx, c, lc.authService, "Please login before edit listing")
+	if user == nil {
+		return
+	}
+	id, ok := parseIDParam(c, "id", errs.ErrListingNotFound)
+	if !ok {
+		return
+	}
+
+	var req ReorderImagesRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	listing, err := lc.listingService.ReorderImages(*lc.ctx, id, req.URLs, user)
+	if err != nil {
+		c.JSON(listingErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, listing)
+}
+
+type SetCoverRequest struct {
+	URL string `json:"url"`
+}
+
+// @Summary	Set listing cover image
+// @Tags		listing
+// @Security	BearerAuth
+// @Accept		json
+// @Produce	json
+// @Param		id		path		string			true	"Listing ID"
+// @Param		request	body		SetCoverRequest	true	"URL of one of listing images"
+// @Success	200		{object}	models.Listing
+// @Failure	400		{object}	ErrorResponse
+// @Failure	401		{object}	ErrorResponse
+// @Failure	403		{object}	ErrorResponse
+// @Failure	404		{object}	ErrorResponse
+// @Router		/listings/{id}/images/cover [put]
+func (lc *ListingController) SetCoverImage(c *gin.Context) {
+	user := requireUser(*lc.ctx, c, lc.authService, "Please login before edit listing")
+	if user == nil {
+		return
+	}
+	id, ok := parseIDParam(c, "id", errs.ErrListingNotFound)
+	if !ok {
+		return
+	}
+
+	var req SetCoverRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	listing, err := lc.listingService.SetCoverImage(*lc.ctx, id, req.URL, user)
+	if err != nil {
+		c.JSON(listingErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, listing)
+}
+
+type UpdatePriceRequest struct {
+	Price    money.Decimal `json:"price" swaggertype:"string" example:"1299.90"`
+	Currency string        `json:"currency" example:"RUB"` // ISO 4217, base currency when empty
+}
+
+// @Summary		Change listing price
+// @Description	Every change is saved to the price history, users watching the listing are notified about drops.
+// @Tags			listing
+// @Security		BearerAuth
+// @Accept			json
+// @Produce		json
+// @Param			id		path		string				true	"Listing ID"
+// @Param			request	body		UpdatePriceRequest	true	"New price"
+// @Success		200		{object}	models.Listing
+// @Failure		400		{object}	ErrorResponse
+// @Failure		401		{object}	ErrorResponse
+// @Failure		403		{object}	ErrorResponse
+// @Failure		404		{object}	ErrorResponse
+// @Failure		409		{object}	ErrorResponse	"Price of auction listing is set by bids or the price keeps changing concurrently"
+// @Router			/listings/{id}/price [put]
+func (lc *ListingController) UpdatePrice(c *gin.Context) {
+	user := requireUser(*lc.ctx, c, lc.authService, "Please login before edit listing")
+	if user == nil {
+		return
+	}
+	id, ok := parseIDParam(c, "id", errs.ErrListingNotFound)
+	if !ok {
+		return
+	}
+
+	var req UpdatePriceRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": errs.ErrListingInvalidPrice.Error()})
+		return
+	}
+
+	listing, err := lc.listingService.UpdatePrice(*lc.ctx, id, req.Price, req.Currency, user)
+	if err != nil {
+		c.JSON(listingErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, listing)
+}
+
+// @Summary		Mark reserved listing as sold
+// @Description	Completes the deal with the buyer of the accepted offer or the won auction. After that the seller and the buyer can review each other.
+// @Tags			listing
+// @Security		BearerAuth
+// @Produce		json
+// @Param			id	path		string	true	"Listing ID"
+// @Success		200	{object}	models.Listing
+// @Failure		401	{object}	ErrorResponse
+// @Failure		403	{object}	ErrorResponse
+// @Failure		404	{object}	ErrorResponse
+// @Failure		409	{object}	ErrorResponse	"Listing is not reserved for a buyer"
+// @Router			/listings/{id}/sold [post]
+func (lc *ListingController) MarkSold(c *gin.Context) {
+	user := requireUser(*lc.ctx, c, lc.authService, "Please login before edit listing")
+	if user == nil {
+		return
+	}
+	id, ok := parseIDParam(c, "id", errs.ErrListingNotFound)
+	if !ok {
+		return
+	}
+
+	listing, err := lc.listingService.MarkSold(*lc.ctx, id, user)
+	if err != nil {
+		c.JSON(listingErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, listing)
 }
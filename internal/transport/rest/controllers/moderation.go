@@ -0,0 +1,254 @@
+package controllers
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"vk-inter/internal/transport/rest/interfaces"
+	"vk-inter/pkg/errs"
+	"vk-inter/pkg/utils"
+
+	"github.com/gin-gonic/gin"
+)
+
+type ModerationController struct {
+	ctx               *context.Context
+	duplicateService  interfaces.DuplicateService
+	moderationService interfaces.ModerationService
+	authService       interfaces.AuthService
+}
+
+func NewModerationController(ctx *context.Context, duplicateService interfaces.DuplicateService, moderationService interfaces.ModerationService, authService interfaces.AuthService) *ModerationController {
+	return &ModerationController{
+		ctx:               ctx,
+		duplicateService:  duplicateService,
+		moderationService: moderationService,
+		authService:       authService,
+	}
+}
+
+type ReportRequest struct {
+	Reason  string `json:"reason" enums:"scam,prohibited,spam,offensive,wrong_category,other" example:"scam"`
+	Comment string `json:"comment,omitempty" example:"Asks for prepayment to a card"`
+}
+
+type ResolveCaseRequest struct {
+	Action  string `json:"action" enums:"dismiss,hide,remove,ban_owner" example:"hide"`
+	Comment string `json:"comment,omitempty" example:"Prepayment scam"`
+}
+
+func moderationErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, errs.ErrCaseNotFound),
+		errors.Is(err, errs.ErrListingNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, errs.ErrReportInvalidReason),
+		errors.Is(err, errs.ErrReportInvalidComment),
+		errors.Is(err, errs.ErrCaseInvalidAction),
+		errors.Is(err, errs.ErrCaseInvalidStatus),
+		errors.Is(err, errs.ErrCaseInvalidComment):
+		return http.StatusBadRequest
+	case errors.Is(err, errs.ErrReportOwnListing),
+		errors.Is(err, errs.ErrForbidden):
+		return http.StatusForbidden
+	case errors.Is(err, errs.ErrReportExists),
+		errors.Is(err, errs.ErrCaseClaimed),
+		errors.Is(err, errs.ErrCaseNotClaimed),
+		errors.Is(err, errs.ErrCaseResolved):
+		return http.StatusConflict
+	}
+	return http.StatusInternalServerError
+}
+
+// @Summary	Get probable duplicate listings of different owners (moderators only)
+// @Tags		moderation
+// @Security	BearerAuth
+// @Produce	json
+// @Param		limit	query		int	false	"Number of reports (default: 50, max: 100)"
+// @Success	200		{array}		models.DuplicateReport
+// @Failure	401		{object}	ErrorResponse
+// @Failure	403		{object}	ErrorResponse
+// @Router		/moderation/duplicates [get]
+func (mc *ModerationController) GetDuplicates(c *gin.Context) {
+	user := requireUser(*mc.ctx, c, mc.authService, "Please login before moderate listings")
+	if user == nil {
+		return
+	}
+
+	reports, err := mc.duplicateService.GetReports(*mc.ctx, utils.ParseQueryInt(c, "limit", 50), user)
+	if err != nil {
+		status := http.StatusInternalServerError
+		if errors.Is(err, errs.ErrForbidden) {
+			status = http.StatusForbidden
+		}
+		c.JSON(status, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, reports)
+}
+
+// @Summary		Report the listing to moderators
+// @Description	A user can report the listing once. The listing is hidden until review after several distinct reports.
+// @Tags			moderation
+// @Security		BearerAuth
+// @Accept			json
+// @Produce		json
+// @Param			id		path		string			true	"Listing ID"
+// @Param			request	body		ReportRequest	true	"Report"
+// @Success		201		{object}	models.Report
+// @Failure		400		{object}	ErrorResponse
+// @Failure		401		{object}	ErrorResponse
+// @Failure		403		{object}	ErrorResponse
+// @Failure		404		{object}	ErrorResponse
+// @Failure		409		{object}	ErrorResponse
+// @Router			/listings/{id}/report [post]
+func (mc *ModerationController) ReportListing(c *gin.Context) {
+	user := requireUser(*mc.ctx, c, mc.authService, "Please login before report listings")
+	if user == nil {
+		return
+	}
+	id, ok := parseIDParam(c, "id", errs.ErrListingNotFound)
+	if !ok {
+		return
+	}
+
+	var req ReportRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": errs.ErrReportInvalidReason.Error()})
+		return
+	}
+
+	report, err := mc.moderationService.ReportListing(*mc.ctx, id, req.Reason, req.Comment, user)
+	if err != nil {
+		c.JSON(moderationErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusCreated, report)
+}
+
+// @Summary	Get moderation queue, the most reported cases first (moderators only)
+// @Tags		moderation
+// @Security	BearerAuth
+// @Produce	json
+// @Param		status	query		string	false	"Case status (default: open)"	Enums(open, claimed, resolved)
+// @Param		page	query		int		false	"Page number (default: 1)"
+// @Param		limit	query		int		false	"Items per page (default: 20)"
+// @Success	200		{object}	models.CasePage
+// @Failure	400		{object}	ErrorResponse
+// @Failure	401		{object}	ErrorResponse
+// @Failure	403		{object}	ErrorResponse
+// @Router		/moderation/cases [get]
+func (mc *ModerationController) GetCases(c *gin.Context) {
+	user := requireUser(*mc.ctx, c, mc.authService, "Please login before moderate listings")
+	if user == nil {
+		return
+	}
+
+	page, err := mc.moderationService.GetCases(*mc.ctx, c.Query("status"),
+		utils.ParseQueryInt(c, "page", 1),
+		utils.ParseQueryInt(c, "limit", 20),
+		user,
+	)
+	if err != nil {
+		c.JSON(moderationErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, page)
+}
+
+// @Summary	Get the moderation case with its reports and audit trail (moderators only)
+// @Tags		moderation
+// @Security	BearerAuth
+// @Produce	json
+// @Param		id	path		string	true	"Case ID"
+// @Success	200	{object}	models.CaseDetails
+// @Failure	401	{object}	ErrorResponse
+// @Failure	403	{object}	ErrorResponse
+// @Failure	404	{object}	ErrorResponse
+// @Router		/moderation/cases/{id} [get]
+func (mc *ModerationController) GetCase(c *gin.Context) {
+	user := requireUser(*mc.ctx, c, mc.authService, "Please login before moderate listings")
+	if user == nil {
+		return
+	}
+	id, ok := parseIDParam(c, "id", errs.ErrCaseNotFound)
+	if !ok {
+		return
+	}
+
+	details, err := mc.moderationService.GetCase(*mc.ctx, id, user)
+	if err != nil {
+		c.JSON(moderationErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, details)
+}
+
+// @Summary		Claim the moderation case (moderators only)
+// @Description	The case can be resolved only by the moderator who claimed it. Stale claims can be taken over.
+// @Tags			moderation
+// @Security		BearerAuth
+// @Produce		json
+// @Param			id	path		string	true	"Case ID"
+// @Success		200	{object}	models.ModerationCase
+// @Failure		401	{object}	ErrorResponse
+// @Failure		403	{object}	ErrorResponse
+// @Failure		404	{object}	ErrorResponse
+// @Failure		409	{object}	ErrorResponse
+// @Router			/moderation/cases/{id}/claim [post]
+func (mc *ModerationController) ClaimCase(c *gin.Context) {
+	user := requireUser(*mc.ctx, c, mc.authService, "Please login before moderate listings")
+	if user == nil {
+		return
+	}
+	id, ok := parseIDParam(c, "id", errs.ErrCaseNotFound)
+	if !ok {
+		return
+	}
+
+	moderationCase, err := mc.moderationService.ClaimCase(*mc.ctx, id, user)
+	if err != nil {
+		c.JSON(moderationErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, moderationCase)
+}
+
+// @Summary		Resolve the claimed moderation case (moderators only)
+// @Description	dismiss restores the auto-hidden listing, hide and remove change its status, ban_owner also bans the owner and hides their listings.
+// @Tags			moderation
+// @Security		BearerAuth
+// @Accept			json
+// @Produce		json
+// @Param			id		path		string				true	"Case ID"
+// @Param			request	body		ResolveCaseRequest	true	"Resolution"
+// @Success		200		{object}	models.ModerationCase
+// @Failure		400		{object}	ErrorResponse
+// @Failure		401		{object}	ErrorResponse
+// @Failure		403		{object}	ErrorResponse
+// @Failure		404		{object}	ErrorResponse
+// @Failure		409		{object}	ErrorResponse
+// @Router			/moderation/cases/{id}/resolve [post]
+func (mc *ModerationController) ResolveCase(c *gin.Context) {
+	user := requireUser(*mc.ctx, c, mc.authService, "Please login before moderate listings")
+	if user == nil {
+		return
+	}
+	id, ok := parseIDParam(c, "id", errs.ErrCaseNotFound)
+	if !ok {
+		return
+	}
+
+	var req ResolveCaseRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": errs.ErrCaseInvalidAction.Error()})
+		return
+	}
+
+	moderationCase, err := mc.moderationService.ResolveCase(*mc.ctx, id, req.Action, req.Comment, user)
+	if err != nil {
+		c.JSON(moderationErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, moderationCase)
+}
@@ -0,0 +1,291 @@
+package controllers
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"vk-inter/internal/models"
+	"vk-inter/internal/transport/rest/interfaces"
+	"vk-inter/pkg/errs"
+	"vk-inter/pkg/money"
+	"vk-inter/pkg/utils"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type OfferController struct {
+	ctx          *context.Context
+	offerService interfaces.OfferService
+	authService  interfaces.AuthService
+}
+
+func NewOfferController(ctx *context.Context, offerService interfaces.OfferService, authService interfaces.AuthService) *OfferController {
+	return &OfferController{
+		ctx:          ctx,
+		offerService: offerService,
+		authService:  authService,
+	}
+}
+
+type OfferRequest struct {
+	// Amount is in the listing currency
+	Amount  money.Decimal `json:"amount" swaggertype:"string" example:"1500.00"`
+	Message string        `json:"message,omitempty" example:"Can pick it up today"`
+}
+
+func offerErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, errs.ErrOfferNotFound),
+		errors.Is(err, errs.ErrListingNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, errs.ErrOfferInvalidAmount),
+		errors.Is(err, errs.ErrOfferInvalidMessage),
+		errors.Is(err, errs.ErrOfferInvalidStatus),
+		errors.Is(err, errs.ErrOfferOwnListing):
+		return http.StatusBadRequest
+	case errors.Is(err, errs.ErrOfferNotAllowed),
+		errors.Is(err, errs.ErrForbidden):
+		return http.StatusForbidden
+	case errors.Is(err, errs.ErrOfferExists),
+		errors.Is(err, errs.ErrOfferNotPending),
+		errors.Is(err, errs.ErrListingNotAvailable),
+		errors.Is(err, errs.ErrListingIsAuction):
+		return http.StatusConflict
+	}
+	return http.StatusInternalServerError
+}
+
+// @Summary		Make an offer on the listing
+// @Description	Seller can accept, reject or counter the offer until it expires. A buyer has one pending offer per listing.
+// @Tags			offer
+// @Security		BearerAuth
+// @Accept			json
+// @Produce		json
+// @Param			id		path		string			true	"Listing ID"
+// @Param			request	body		OfferRequest	true	"Offer"
+// @Success		201		{object}	models.Offer
+// @Failure		400		{object}	ErrorResponse
+// @Failure		401		{object}	ErrorResponse
+// @Failure		404		{object}	ErrorResponse
+// @Failure		409		{object}	ErrorResponse
+// @Router			/listings/{id}/offers [post]
+func (oc *OfferController) MakeOffer(c *gin.Context) {
+	user := requireUser(*oc.ctx, c, oc.authService, "Please login before make offers")
+	if user == nil {
+		return
+	}
+	id, ok := parseIDParam(c, "id", errs.ErrListingNotFound)
+	if !ok {
+		return
+	}
+
+	var req OfferRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": errs.ErrOfferInvalidAmount.Error()})
+		return
+	}
+
+	offer, err := oc.offerService.MakeOffer(*oc.ctx, id, req.Amount, req.Message, user)
+	if err != nil {
+		c.JSON(offerErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusCreated, offer)
+}
+
+// @Summary	Get offers on my listing, latest first
+// @Tags		offer
+// @Security	BearerAuth
+// @Produce	json
+// @Param		id		path		string	true	"Listing ID"
+// @Param		status	query		string	false	"Offer status"	Enums(pending, accepted, rejected, countered, withdrawn, expired)
+// @Param		page	query		int		false	"Page number (default: 1)"
+// @Param		limit	query		int		false	"Items per page (default: 20)"
+// @Success	200		{object}	models.OfferPage
+// @Failure	400		{object}	ErrorResponse
+// @Failure	401		{object}	ErrorResponse
+// @Failure	403		{object}	ErrorResponse
+// @Failure	404		{object}	ErrorResponse
+// @Router		/listings/{id}/offers [get]
+func (oc *OfferController) GetListingOffers(c *gin.Context) {
+	user := requireUser(*oc.ctx, c, oc.authService, "Please login before view offers")
+	if user == nil {
+		return
+	}
+	id, ok := parseIDParam(c, "id", errs.ErrListingNotFound)
+	if !ok {
+		return
+	}
+
+	page, err := oc.offerService.GetListingOffers(*oc.ctx, id, c.Query("status"),
+		utils.ParseQueryInt(c, "page", 1),
+		utils.ParseQueryInt(c, "limit", 20),
+		user,
+	)
+	if err != nil {
+		c.JSON(offerErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, page)
+}
+
+// @Summary	Get offers of my negotiations as a buyer, latest first
+// @Tags		offer
+// @Security	BearerAuth
+// @Produce	json
+// @Param		status	query		string	false	"Offer status"	Enums(pending, accepted, rejected, countered, withdrawn, expired)
+// @Param		page	query		int		false	"Page number (default: 1)"
+// @Param		limit	query		int		false	"Items per page (default: 20)"
+// @Success	200		{object}	models.OfferPage
+// @Failure	400		{object}	ErrorResponse
+// @Failure	401		{object}	ErrorResponse
+// @Router		/me/offers [get]
+func (oc *OfferController) GetMyOffers(c *gin.Context) {
+	user := requireUser(*oc.ctx, c, oc.authService, "Please login before view offers")
+	if user == nil {
+		return
+	}
+
+	page, err := oc.offerService.GetMyOffers(*oc.ctx, c.Query("status"),
+		utils.ParseQueryInt(c, "page", 1),
+		utils.ParseQueryInt(c, "limit", 20),
+		user,
+	)
+	if err != nil {
+		c.JSON(offerErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, page)
+}
+
+// @Summary	Get offer
+// @Tags		offer
+// @Security	BearerAuth
+// @Produce	json
+// @Param		id	path		string	true	"Offer ID"
+// @Success	200	{object}	models.Offer
+// @Failure	401	{object}	ErrorResponse
+// @Failure	404	{object}	ErrorResponse
+// @Router		/offers/{id} [get]
+func (oc *OfferController) GetOffer(c *gin.Context) {
+	user := requireUser(*oc.ctx, c, oc.authService, "Please login before view offers")
+	if user == nil {
+		return
+	}
+	id, ok := parseIDParam(c, "id", errs.ErrOfferNotFound)
+	if !ok {
+		return
+	}
+
+	offer, err := oc.offerService.GetOffer(*oc.ctx, id, user)
+	if err != nil {
+		c.JSON(offerErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, offer)
+}
+
+// @Summary		Counter the offer
+// @Description	The offer becomes countered and a new pending offer of the other party is created in the same chain.
+// @Tags			offer
+// @Security		BearerAuth
+// @Accept			json
+// @Produce		json
+// @Param			id		path		string			true	"Offer ID"
+// @Param			request	body		OfferRequest	true	"Counter-offer"
+// @Success		201		{object}	models.Offer
+// @Failure		400		{object}	ErrorResponse
+// @Failure		401		{object}	ErrorResponse
+// @Failure		403		{object}	ErrorResponse
+// @Failure		404		{object}	ErrorResponse
+// @Failure		409		{object}	ErrorResponse
+// @Router			/offers/{id}/counter [post]
+func (oc *OfferController) CounterOffer(c *gin.Context) {
+	user := requireUser(*oc.ctx, c, oc.authService, "Please login before answer offers")
+	if user == nil {
+		return
+	}
+	id, ok := parseIDParam(c, "id", errs.ErrOfferNotFound)
+	if !ok {
+		return
+	}
+
+	var req OfferRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": errs.ErrOfferInvalidAmount.Error()})
+		return
+	}
+
+	offer, err := oc.offerService.CounterOffer(*oc.ctx, id, req.Amount, req.Message, user)
+	if err != nil {
+		c.JSON(offerErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusCreated, offer)
+}
+
+// @Summary		Accept the offer
+// @Description	Listing becomes reserved, other pending offers on it are rejected.
+// @Tags			offer
+// @Security		BearerAuth
+// @Produce		json
+// @Param			id	path		string	true	"Offer ID"
+// @Success		200	{object}	models.Offer
+// @Failure		401	{object}	ErrorResponse
+// @Failure		403	{object}	ErrorResponse
+// @Failure		404	{object}	ErrorResponse
+// @Failure		409	{object}	ErrorResponse
+// @Router			/offers/{id}/accept [post]
+func (oc *OfferController) AcceptOffer(c *gin.Context) {
+	oc.respond(c, oc.offerService.AcceptOffer)
+}
+
+// @Summary	Reject the offer
+// @Tags		offer
+// @Security	BearerAuth
+// @Produce	json
+// @Param		id	path		string	true	"Offer ID"
+// @Success	200	{object}	models.Offer
+// @Failure	401	{object}	ErrorResponse
+// @Failure	403	{object}	ErrorResponse
+// @Failure	404	{object}	ErrorResponse
+// @Failure	409	{object}	ErrorResponse
+// @Router		/offers/{id}/reject [post]
+func (oc *OfferController) RejectOffer(c *gin.Context) {
+	oc.respond(c, oc.offerService.RejectOffer)
+}
+
+// @Summary	Withdraw my offer
+// @Tags		offer
+// @Security	BearerAuth
+// @Produce	json
+// @Param		id	path		string	true	"Offer ID"
+// @Success	200	{object}	models.Offer
+// @Failure	401	{object}	ErrorResponse
+// @Failure	403	{object}	ErrorResponse
+// @Failure	404	{object}	ErrorResponse
+// @Failure	409	{object}	ErrorResponse
+// @Router		/offers/{id}/withdraw [post]
+func (oc *OfferController) WithdrawOffer(c *gin.Context) {
+	oc.respond(c, oc.offerService.WithdrawOffer)
+}
+
+// respond handles offer actions without request body
+func (oc *OfferController) respond(c *gin.Context, action func(context.Context, primitive.ObjectID, *models.User) (*models.Offer, error)) {
+	user := requireUser(*oc.ctx, c, oc.authService, "Please login before answer offers")
+	if user == nil {
+		return
+	}
+	id, ok := parseIDParam(c, "id", errs.ErrOfferNotFound)
+	if !ok {
+		return
+	}
+
+	offer, err := action(*oc.ctx, id, user)
+	if err != nil {
+		c.JSON(offerErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, offer)
+}
@@ -0,0 +1,126 @@
+package controllers
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"vk-inter/internal/transport/rest/interfaces"
+	"vk-inter/pkg/errs"
+
+	"github.com/gin-gonic/gin"
+)
+
+type PriceAlertController struct {
+	ctx               *context.Context
+	priceAlertService interfaces.PriceAlertService
+	authService       interfaces.AuthService
+}
+
+func NewPriceAlertController(ctx *context.Context, priceAlertService interfaces.PriceAlertService, authService interfaces.AuthService) *PriceAlertController {
+	return &PriceAlertController{
+		ctx:               ctx,
+		priceAlertService: priceAlertService,
+		authService:       authService,
+	}
+}
+
+type PriceAlertRequest struct {
+	// ThresholdPercent is the minimal drop of the price to notify about, 0 notifies about any drop
+	ThresholdPercent float64 `json:"threshold_percent" example:"10"`
+}
+
+func priceAlertErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, errs.ErrListingNotFound),
+		errors.Is(err, errs.ErrPriceAlertNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, errs.ErrPriceAlertInvalidThreshold):
+		return http.StatusBadRequest
+	}
+	return http.StatusInternalServerError
+}
+
+// @Summary	Get listing price history, latest changes first
+// @Tags		listing
+// @Produce	json
+// @Param		id	path		string	true	"Listing ID"
+// @Success	200	{array}		models.PriceChange
+// @Failure	404	{object}	ErrorResponse
+// @Failure	500	{object}	ErrorResponse
+// @Router		/listings/{id}/price-history [get]
+func (pc *PriceAlertController) GetPriceHistory(c *gin.Context) {
+	id, ok := parseIDParam(c, "id", errs.ErrListingNotFound)
+	if !ok {
+		return
+	}
+
+	history, err := pc.priceAlertService.GetPriceHistory(*pc.ctx, id, currentUser(*pc.ctx, c, pc.authService))
+	if err != nil {
+		c.JSON(priceAlertErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, history)
+}
+
+// @Summary		Subscribe to listing price drops
+// @Description	Favorite listings are watched with the default threshold, this sets user's own threshold.
+// @Tags			listing
+// @Security		BearerAuth
+// @Accept			json
+// @Produce		json
+// @Param			id		path		string				true	"Listing ID"
+// @Param			request	body		PriceAlertRequest	true	"Alert threshold"
+// @Success		200		{object}	models.PriceWatch
+// @Failure		400		{object}	ErrorResponse
+// @Failure		401		{object}	ErrorResponse
+// @Failure		404		{object}	ErrorResponse
+// @Router			/listings/{id}/price-alert [put]
+func (pc *PriceAlertController) SetPriceAlert(c *gin.Context) {
+	user := requireUser(*pc.ctx, c, pc.authService, "Please login before subscribe to price drops")
+	if user == nil {
+		return
+	}
+	id, ok := parseIDParam(c, "id", errs.ErrListingNotFound)
+	if !ok {
+		return
+	}
+
+	var req PriceAlertRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": errs.ErrPriceAlertInvalidThreshold.Error()})
+		return
+	}
+
+	watch, err := pc.priceAlertService.SetPriceAlert(*pc.ctx, id, req.ThresholdPercent, user)
+	if err != nil {
+		c.JSON(priceAlertErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, watch)
+}
+
+// @Summary	Unsubscribe from listing price drops
+// @Tags		listing
+// @Security	BearerAuth
+// @Produce	json
+// @Param		id	path	string	true	"Listing ID"
+// @Success	204
+// @Failure	401	{object}	ErrorResponse
+// @Failure	404	{object}	ErrorResponse
+// @Router		/listings/{id}/price-alert [delete]
+func (pc *PriceAlertController) DeletePriceAlert(c *gin.Context) {
+	user := requireUser(*pc.ctx, c, pc.authService, "Please login before unsubscribe from price drops")
+	if user == nil {
+		return
+	}
+	id, ok := parseIDParam(c, "id", errs.ErrPriceAlertNotFound)
+	if !ok {
+		return
+	}
+
+	if err := pc.priceAlertService.DeletePriceAlert(*pc.ctx, id, user); err != nil {
+		c.JSON(priceAlertErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
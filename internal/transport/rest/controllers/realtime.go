@@ -0,0 +1,168 @@
+package controllers
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"time"
+	"vk-inter/internal/transport/rest/interfaces"
+	"vk-inter/pkg/errs"
+	"vk-inter/pkg/logger"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gorilla/websocket"
+	"go.uber.org/zap"
+)
+
+const (
+	wsWriteTimeout = 10 * time.Second
+	wsReadLimit    = 512
+)
+
+type RealtimeController struct {
+	ctx             *context.Context
+	realtimeService interfaces.RealtimeService
+	authService     interfaces.AuthService
+	upgrader        websocket.Upgrader
+}
+
+func NewRealtimeController(ctx *context.Context, realtimeService interfaces.RealtimeService, authService interfaces.AuthService) *RealtimeController {
+	return &RealtimeController{
+		ctx:             ctx,
+		realtimeService: realtimeService,
+		authService:     authService,
+		upgrader: websocket.Upgrader{
+			ReadBufferSize:  1024,
+			WriteBufferSize: 1024,
+			// Аутентификация по токену, а не по cookie, поэтому запросы с других origin безопасны
+			CheckOrigin: func(r *http.Request) bool { return true },
+		},
+	}
+}
+
+func realtimeErrorStatus(err error) int {
+	if errors.Is(err, errs.ErrRealtimeTooManyConns) {
+		return http.StatusTooManyRequests
+	}
+	return http.StatusInternalServerError
+}
+
+// @Summary		Receive events over WebSocket
+// @Description	Server sends models.Event JSON messages: new messages, notifications and listing status changes.
+// @Description	Token is passed in Authorization header or access_token query parameter. Connection is pinged
+// @Description	every heartbeat interval and closed with code 1013 if the client reads events too slowly.
+// @Tags			realtime
+// @Security		BearerAuth
+// @Param			access_token	query		string	false	"Access token"
+// @Success		101				{object}	models.Event
+// @Failure		401				{object}	ErrorResponse
+// @Failure		429				{object}	ErrorResponse
+// @Router			/realtime/ws [get]
+func (rc *RealtimeController) WebSocket(c *gin.Context) {
+	user := requireUser(*rc.ctx, c, rc.authService, "Please login before subscribe to events")
+	if user == nil {
+		return
+	}
+	events, cancel, err := rc.realtimeService.Subscribe(*rc.ctx, user.ID)
+	if err != nil {
+		c.JSON(realtimeErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	defer cancel()
+
+	conn, err := rc.upgrader.Upgrade(c.Writer, c.Request, nil)
+	if err != nil {
+		// Upgrader уже ответил клиенту
+		return
+	}
+	defer conn.Close()
+
+	heartbeat := rc.realtimeService.Heartbeat()
+	closed := make(chan struct{})
+	go func() {
+		// Клиент ничего не присылает, читаем только control-сообщения
+		defer close(closed)
+		conn.SetReadLimit(wsReadLimit)
+		conn.SetReadDeadline(time.Now().Add(2 * heartbeat))
+		conn.SetPongHandler(func(string) error {
+			return conn.SetReadDeadline(time.Now().Add(2 * heartbeat))
+		})
+		for {
+			if _, _, err := conn.NextReader(); err != nil {
+				return
+			}
+		}
+	}()
+
+	ticker := time.NewTicker(heartbeat)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-closed:
+			return
+		case event, ok := <-events:
+			conn.SetWriteDeadline(time.Now().Add(wsWriteTimeout))
+			if !ok {
+				conn.WriteMessage(websocket.CloseMessage, websocket.FormatCloseMessage(websocket.CloseTryAgainLater, "reconnect"))
+				return
+			}
+			if err := conn.WriteJSON(event); err != nil {
+				logger.FromContext(*rc.ctx).Debug("WebSocket write error", zap.Error(err))
+				return
+			}
+		case <-ticker.C:
+			if err := conn.WriteControl(websocket.PingMessage, nil, time.Now().Add(wsWriteTimeout)); err != nil {
+				return
+			}
+		}
+	}
+}
+
+// @Summary		Receive events as Server-Sent Events
+// @Description	Fallback for clients without WebSocket. Each event is named by its type and has models.Event JSON as data,
+// @Description	comments are sent as heartbeats. Stream ends if the client reads events too slowly.
+// @Tags			realtime
+// @Security		BearerAuth
+// @Produce		text/event-stream
+// @Param			access_token	query		string	false	"Access token"
+// @Success		200				{object}	models.Event
+// @Failure		401				{object}	ErrorResponse
+// @Failure		429				{object}	ErrorResponse
+// @Router			/realtime/sse [get]
+func (rc *RealtimeController) SSE(c *gin.Context) {
+	user := requireUser(*rc.ctx, c, rc.authService, "Please login before subscribe to events")
+	if user == nil {
+		return
+	}
+	events, cancel, err := rc.realtimeService.Subscribe(*rc.ctx, user.ID)
+	if err != nil {
+		c.JSON(realtimeErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	defer cancel()
+
+	c.Header("Content-Type", "text/event-stream")
+	c.Header("Cache-Control", "no-cache")
+	c.Header("X-Accel-Buffering", "no")
+	c.Status(http.StatusOK)
+	c.Writer.Flush()
+
+	ticker := time.NewTicker(rc.realtimeService.Heartbeat())
+	defer ticker.Stop()
+	for {
+		select {
+		case <-c.Request.Context().Done():
+			return
+		case event, ok := <-events:
+			if !ok {
+				return
+			}
+			c.SSEvent(event.Type, event)
+		case <-ticker.C:
+			if _, err := c.Writer.WriteString(": ping\n\n"); err != nil {
+				return
+			}
+		}
+		c.Writer.Flush()
+	}
+}
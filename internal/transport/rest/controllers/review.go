@@ -0,0 +1,154 @@
+package controllers
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"vk-inter/internal/transport/rest/interfaces"
+	"vk-inter/pkg/errs"
+	"vk-inter/pkg/utils"
+
+	"github.com/gin-gonic/gin"
+)
+
+type ReviewController struct {
+	ctx           *context.Context
+	reviewService interfaces.ReviewService
+	authService   interfaces.AuthService
+}
+
+func NewReviewController(ctx *context.Context, reviewService interfaces.ReviewService, authService interfaces.AuthService) *ReviewController {
+	return &ReviewController{
+		ctx:           ctx,
+		reviewService: reviewService,
+		authService:   authService,
+	}
+}
+
+type ReviewRequest struct {
+	Rating int    `json:"rating" minimum:"1" maximum:"5" example:"5"`
+	Text   string `json:"text" example:"Fast and polite, item as described"`
+}
+
+type HideReviewRequest struct {
+	// Hidden hides the review, false restores it
+	Hidden bool   `json:"hidden"`
+	Reason string `json:"reason,omitempty" example:"Insults"`
+}
+
+func reviewErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, errs.ErrReviewNotFound),
+		errors.Is(err, errs.ErrListingNotFound),
+		errors.Is(err, errs.ErrUserNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, errs.ErrReviewInvalidRating),
+		errors.Is(err, errs.ErrReviewInvalidText),
+		errors.Is(err, errs.ErrReviewInvalidReason):
+		return http.StatusBadRequest
+	case errors.Is(err, errs.ErrReviewNotAllowed),
+		errors.Is(err, errs.ErrForbidden):
+		return http.StatusForbidden
+	case errors.Is(err, errs.ErrReviewExists):
+		return http.StatusConflict
+	}
+	return http.StatusInternalServerError
+}
+
+// @Summary		Review the deal on the sold listing
+// @Description	The seller and the buyer of the sold listing can leave one review about each other.
+// @Tags			review
+// @Security		BearerAuth
+// @Accept			json
+// @Produce		json
+// @Param			id		path		string			true	"Listing ID"
+// @Param			request	body		ReviewRequest	true	"Review"
+// @Success		201		{object}	models.Review
+// @Failure		400		{object}	ErrorResponse
+// @Failure		401		{object}	ErrorResponse
+// @Failure		403		{object}	ErrorResponse
+// @Failure		404		{object}	ErrorResponse
+// @Failure		409		{object}	ErrorResponse
+// @Router			/listings/{id}/reviews [post]
+func (rc *ReviewController) CreateReview(c *gin.Context) {
+	user := requireUser(*rc.ctx, c, rc.authService, "Please login before leave reviews")
+	if user == nil {
+		return
+	}
+	id, ok := parseIDParam(c, "id", errs.ErrListingNotFound)
+	if !ok {
+		return
+	}
+
+	var req ReviewRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": errs.ErrReviewInvalidRating.Error()})
+		return
+	}
+
+	review, err := rc.reviewService.CreateReview(*rc.ctx, id, req.Rating, req.Text, user)
+	if err != nil {
+		c.JSON(reviewErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusCreated, review)
+}
+
+// @Summary	Get reviews about the user, latest first
+// @Tags		review
+// @Produce	json
+// @Param		login	path		string	true	"User login"
+// @Param		page	query		int		false	"Page number (default: 1)"
+// @Param		limit	query		int		false	"Items per page (default: 20)"
+// @Success	200		{object}	models.ReviewPage
+// @Failure	404		{object}	ErrorResponse
+// @Router		/users/{login}/reviews [get]
+func (rc *ReviewController) GetUserReviews(c *gin.Context) {
+	page, err := rc.reviewService.GetUserReviews(*rc.ctx, c.Param("login"),
+		utils.ParseQueryInt(c, "page", 1),
+		utils.ParseQueryInt(c, "limit", 20),
+	)
+	if err != nil {
+		c.JSON(reviewErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, page)
+}
+
+// @Summary		Hide or restore the review (moderators only)
+// @Description	Hidden reviews are removed from the profile and the rating of the reviewed user.
+// @Tags			moderation
+// @Security		BearerAuth
+// @Accept			json
+// @Produce		json
+// @Param			id		path		string				true	"Review ID"
+// @Param			request	body		HideReviewRequest	true	"Visibility"
+// @Success		200		{object}	models.Review
+// @Failure		400		{object}	ErrorResponse
+// @Failure		401		{object}	ErrorResponse
+// @Failure		403		{object}	ErrorResponse
+// @Failure		404		{object}	ErrorResponse
+// @Router			/moderation/reviews/{id} [put]
+func (rc *ReviewController) SetReviewHidden(c *gin.Context) {
+	user := requireUser(*rc.ctx, c, rc.authService, "Please login before moderate reviews")
+	if user == nil {
+		return
+	}
+	id, ok := parseIDParam(c, "id", errs.ErrReviewNotFound)
+	if !ok {
+		return
+	}
+
+	var req HideReviewRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	review, err := rc.reviewService.SetReviewHidden(*rc.ctx, id, req.Hidden, req.Reason, user)
+	if err != nil {
+		c.JSON(reviewErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, review)
+}
@@ -0,0 +1,232 @@
+package controllers
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/url"
+	"strings"
+	"vk-inter/internal/transport/rest/interfaces"
+	"vk-inter/pkg/errs"
+	"vk-inter/pkg/utils"
+
+	"github.com/gin-gonic/gin"
+)
+
+type SavedSearchController struct {
+	ctx                 *context.Context
+	savedSearchService  interfaces.SavedSearchService
+	notificationService interfaces.NotificationService
+	authService         interfaces.AuthService
+}
+
+func NewSavedSearchController(ctx *context.Context, savedSearchService interfaces.SavedSearchService, notificationService interfaces.NotificationService, authService interfaces.AuthService) *SavedSearchController {
+	return &SavedSearchController{
+		ctx:                 ctx,
+		savedSearchService:  savedSearchService,
+		notificationService: notificationService,
+		authService:         authService,
+	}
+}
+
+type SavedSearchRequest struct {
+	Name string `json:"name" example:"Cheap bikes"`
+	// Query is GET /listings query string, e.g. "category_id=...&max_price=50000&tags=bmx"
+	Query string `json:"query" example:"max_price=50000&tags=bmx"`
+	// WebhookURL optionally receives matches as signed POST requests
+	WebhookURL string `json:"webhook_url,omitempty"`
+}
+
+type MarkAllReadResponse struct {
+	Updated int64 `json:"updated"`
+}
+
+func savedSearchErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, errs.ErrSavedSearchNotFound),
+		errors.Is(err, errs.ErrNotificationNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, errs.ErrSavedSearchLimit):
+		return http.StatusConflict
+	case errors.Is(err, errs.ErrSavedSearchInvalidName),
+		errors.Is(err, errs.ErrSavedSearchInvalidWebhook),
+		errors.Is(err, errs.ErrPriceSorting),
+		errors.Is(err, errs.ErrListingInvalidPrice),
+		errors.Is(err, errs.ErrListingInvalidCurrency),
+		errors.Is(err, errs.ErrListingInvalidGeoFilter),
+		errors.Is(err, errs.ErrListingInvalidAttrFilter),
+		errors.Is(err, errs.ErrCategoryNotFound):
+		return http.StatusBadRequest
+	}
+	return http.StatusInternalServerError
+}
+
+// @Summary		Save listings search, new matching listings are sent to notifications
+// @Description	Query uses the same parameters as GET /listings. With webhook_url matches are also
+// @Description	POSTed there, X-Signature header is "sha256=" and hex HMAC-SHA256 of the body with webhook_secret.
+// @Tags			saved-search
+// @Security		BearerAuth
+// @Accept			json
+// @Produce		json
+// @Param			request	body		SavedSearchRequest	true	"Search"
+// @Success		201		{object}	models.SavedSearch
+// @Failure		400		{object}	ErrorResponse
+// @Failure		401		{object}	ErrorResponse
+// @Failure		409		{object}	ErrorResponse
+// @Router			/me/searches [post]
+func (sc *SavedSearchController) CreateSavedSearch(c *gin.Context) {
+	user := requireUser(*sc.ctx, c, sc.authService, "Please login before save searches")
+	if user == nil {
+		return
+	}
+
+	var req SavedSearchRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	query := strings.TrimPrefix(strings.TrimSpace(req.Query), "?")
+	values, err := url.ParseQuery(query)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	filter, err := parseListingFilter(values)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	search, err := sc.savedSearchService.CreateSavedSearch(*sc.ctx, req.Name, query, filter, req.WebhookURL, user)
+	if err != nil {
+		c.JSON(savedSearchErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusCreated, search)
+}
+
+// @Summary	Get my saved searches
+// @Tags		saved-search
+// @Security	BearerAuth
+// @Produce	json
+// @Success	200	{array}		models.SavedSearch
+// @Failure	401	{object}	ErrorResponse
+// @Failure	500	{object}	ErrorResponse
+// @Router		/me/searches [get]
+func (sc *SavedSearchController) GetSavedSearches(c *gin.Context) {
+	user := requireUser(*sc.ctx, c, sc.authService, "Please login before view saved searches")
+	if user == nil {
+		return
+	}
+
+	searches, err := sc.savedSearchService.GetSavedSearches(*sc.ctx, user)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, searches)
+}
+
+// @Summary	Delete my saved search
+// @Tags		saved-search
+// @Security	BearerAuth
+// @Produce	json
+// @Param		id	path	string	true	"Saved search ID"
+// @Success	204
+// @Failure	401	{object}	ErrorResponse
+// @Failure	404	{object}	ErrorResponse
+// @Router		/me/searches/{id} [delete]
+func (sc *SavedSearchController) DeleteSavedSearch(c *gin.Context) {
+	user := requireUser(*sc.ctx, c, sc.authService, "Please login before delete saved searches")
+	if user == nil {
+		return
+	}
+	id, ok := parseIDParam(c, "id", errs.ErrSavedSearchNotFound)
+	if !ok {
+		return
+	}
+
+	if err := sc.savedSearchService.DeleteSavedSearch(*sc.ctx, id, user); err != nil {
+		c.JSON(savedSearchErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+// @Summary	Get my notifications, latest first
+// @Tags		notification
+// @Security	BearerAuth
+// @Produce	json
+// @Param		page	query		int		false	"Page number (default: 1)"
+// @Param		limit	query		int		false	"Items per page (default: 20)"
+// @Param		unread	query		bool	false	"Only unread notifications"
+// @Success	200		{object}	models.NotificationPage
+// @Failure	401		{object}	ErrorResponse
+// @Failure	500		{object}	ErrorResponse
+// @Router		/me/notifications [get]
+func (sc *SavedSearchController) GetNotifications(c *gin.Context) {
+	user := requireUser(*sc.ctx, c, sc.authService, "Please login before view notifications")
+	if user == nil {
+		return
+	}
+
+	page, err := sc.notificationService.GetNotifications(*sc.ctx,
+		utils.ParseQueryInt(c, "page", 1),
+		utils.ParseQueryInt(c, "limit", 20),
+		c.Query("unread") == "true",
+		user,
+	)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, page)
+}
+
+// @Summary	Mark notification as read
+// @Tags		notification
+// @Security	BearerAuth
+// @Produce	json
+// @Param		id	path	string	true	"Notification ID"
+// @Success	204
+// @Failure	401	{object}	ErrorResponse
+// @Failure	404	{object}	ErrorResponse
+// @Router		/me/notifications/{id}/read [post]
+func (sc *SavedSearchController) MarkRead(c *gin.Context) {
+	user := requireUser(*sc.ctx, c, sc.authService, "Please login before manage notifications")
+	if user == nil {
+		return
+	}
+	id, ok := parseIDParam(c, "id", errs.ErrNotificationNotFound)
+	if !ok {
+		return
+	}
+
+	if err := sc.notificationService.MarkRead(*sc.ctx, id, user); err != nil {
+		c.JSON(savedSearchErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+// @Summary	Mark all my notifications as read
+// @Tags		notification
+// @Security	BearerAuth
+// @Produce	json
+// @Success	200	{object}	MarkAllReadResponse
+// @Failure	401	{object}	ErrorResponse
+// @Failure	500	{object}	ErrorResponse
+// @Router		/me/notifications/read [post]
+func (sc *SavedSearchController) MarkAllRead(c *gin.Context) {
+	user := requireUser(*sc.ctx, c, sc.authService, "Please login before manage notifications")
+	if user == nil {
+		return
+	}
+
+	updated, err := sc.notificationService.MarkAllRead(*sc.ctx, user)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, MarkAllReadResponse{Updated: updated})
+}
@@ -0,0 +1,228 @@
+package controllers
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"vk-inter/internal/transport/rest/interfaces"
+	"vk-inter/pkg/errs"
+	"vk-inter/pkg/utils"
+
+	"github.com/gin-gonic/gin"
+)
+
+type ThreadController struct {
+	ctx              *context.Context
+	messagingService interfaces.MessagingService
+	authService      interfaces.AuthService
+}
+
+func NewThreadController(ctx *context.Context, messagingService interfaces.MessagingService, authService interfaces.AuthService) *ThreadController {
+	return &ThreadController{
+		ctx:              ctx,
+		messagingService: messagingService,
+		authService:      authService,
+	}
+}
+
+type MessageRequest struct {
+	Text string `json:"text" example:"Hi! Is it still available?"`
+}
+
+func messagingErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, errs.ErrThreadNotFound),
+		errors.Is(err, errs.ErrListingNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, errs.ErrMessageInvalidText),
+		errors.Is(err, errs.ErrThreadOwnListing),
+		errors.Is(err, errs.ErrInvalidCursor):
+		return http.StatusBadRequest
+	case errors.Is(err, errs.ErrThreadRateLimited):
+		return http.StatusTooManyRequests
+	}
+	return http.StatusInternalServerError
+}
+
+// @Summary		Write to the seller of the listing
+// @Description	Starts a thread about the listing, or posts to the existing one. New threads are rate limited.
+// @Tags			messaging
+// @Security		BearerAuth
+// @Accept			json
+// @Produce		json
+// @Param			id		path		string			true	"Listing ID"
+// @Param			request	body		MessageRequest	true	"First message"
+// @Success		201		{object}	models.ThreadMessage
+// @Failure		400		{object}	ErrorResponse
+// @Failure		401		{object}	ErrorResponse
+// @Failure		404		{object}	ErrorResponse
+// @Failure		429		{object}	ErrorResponse
+// @Router			/listings/{id}/threads [post]
+func (tc *ThreadController) StartThread(c *gin.Context) {
+	user := requireUser(*tc.ctx, c, tc.authService, "Please login before write to sellers")
+	if user == nil {
+		return
+	}
+	id, ok := parseIDParam(c, "id", errs.ErrListingNotFound)
+	if !ok {
+		return
+	}
+
+	var req MessageRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": errs.ErrMessageInvalidText.Error()})
+		return
+	}
+
+	result, err := tc.messagingService.StartThread(*tc.ctx, id, req.Text, user)
+	if err != nil {
+		c.JSON(messagingErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusCreated, result)
+}
+
+// @Summary	Get my threads, latest conversations first
+// @Tags		messaging
+// @Security	BearerAuth
+// @Produce	json
+// @Param		cursor	query		string	false	"Cursor from the previous page"
+// @Param		limit	query		int		false	"Items per page (default: 20)"
+// @Success	200		{object}	models.ThreadPage
+// @Failure	400		{object}	ErrorResponse
+// @Failure	401		{object}	ErrorResponse
+// @Router		/me/threads [get]
+func (tc *ThreadController) GetThreads(c *gin.Context) {
+	user := requireUser(*tc.ctx, c, tc.authService, "Please login before view messages")
+	if user == nil {
+		return
+	}
+
+	page, err := tc.messagingService.GetThreads(*tc.ctx, c.Query("cursor"), utils.ParseQueryInt(c, "limit", 20), user)
+	if err != nil {
+		c.JSON(messagingErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, page)
+}
+
+// @Summary	Get thread
+// @Tags		messaging
+// @Security	BearerAuth
+// @Produce	json
+// @Param		id	path		string	true	"Thread ID"
+// @Success	200	{object}	models.Thread
+// @Failure	401	{object}	ErrorResponse
+// @Failure	404	{object}	ErrorResponse
+// @Router		/threads/{id} [get]
+func (tc *ThreadController) GetThread(c *gin.Context) {
+	user := requireUser(*tc.ctx, c, tc.authService, "Please login before view messages")
+	if user == nil {
+		return
+	}
+	id, ok := parseIDParam(c, "id", errs.ErrThreadNotFound)
+	if !ok {
+		return
+	}
+
+	thread, err := tc.messagingService.GetThread(*tc.ctx, id, user)
+	if err != nil {
+		c.JSON(messagingErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, thread)
+}
+
+// @Summary		Get thread messages, newest first
+// @Description	read is set for own messages only and tells if the other participant has read them.
+// @Tags			messaging
+// @Security		BearerAuth
+// @Produce		json
+// @Param			id		path		string	true	"Thread ID"
+// @Param			cursor	query		string	false	"Cursor from the previous page"
+// @Param			limit	query		int		false	"Items per page (default: 20)"
+// @Success		200		{object}	models.MessagePage
+// @Failure		400		{object}	ErrorResponse
+// @Failure		401		{object}	ErrorResponse
+// @Failure		404		{object}	ErrorResponse
+// @Router			/threads/{id}/messages [get]
+func (tc *ThreadController) GetMessages(c *gin.Context) {
+	user := requireUser(*tc.ctx, c, tc.authService, "Please login before view messages")
+	if user == nil {
+		return
+	}
+	id, ok := parseIDParam(c, "id", errs.ErrThreadNotFound)
+	if !ok {
+		return
+	}
+
+	page, err := tc.messagingService.GetMessages(*tc.ctx, id, c.Query("cursor"), utils.ParseQueryInt(c, "limit", 20), user)
+	if err != nil {
+		c.JSON(messagingErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, page)
+}
+
+// @Summary	Post message to the thread
+// @Tags		messaging
+// @Security	BearerAuth
+// @Accept		json
+// @Produce	json
+// @Param		id		path		string			true	"Thread ID"
+// @Param		request	body		MessageRequest	true	"Message"
+// @Success	201		{object}	models.ThreadMessage
+// @Failure	400		{object}	ErrorResponse
+// @Failure	401		{object}	ErrorResponse
+// @Failure	404		{object}	ErrorResponse
+// @Router		/threads/{id}/messages [post]
+func (tc *ThreadController) PostMessage(c *gin.Context) {
+	user := requireUser(*tc.ctx, c, tc.authService, "Please login before write messages")
+	if user == nil {
+		return
+	}
+	id, ok := parseIDParam(c, "id", errs.ErrThreadNotFound)
+	if !ok {
+		return
+	}
+
+	var req MessageRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": errs.ErrMessageInvalidText.Error()})
+		return
+	}
+
+	result, err := tc.messagingService.PostMessage(*tc.ctx, id, req.Text, user)
+	if err != nil {
+		c.JSON(messagingErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusCreated, result)
+}
+
+// @Summary	Mark thread messages as read
+// @Tags		messaging
+// @Security	BearerAuth
+// @Produce	json
+// @Param		id	path		string	true	"Thread ID"
+// @Success	200	{object}	models.Thread
+// @Failure	401	{object}	ErrorResponse
+// @Failure	404	{object}	ErrorResponse
+// @Router		/threads/{id}/read [post]
+func (tc *ThreadController) MarkRead(c *gin.Context) {
+	user := requireUser(*tc.ctx, c, tc.authService, "Please login before view messages")
+	if user == nil {
+		return
+	}
+	id, ok := parseIDParam(c, "id", errs.ErrThreadNotFound)
+	if !ok {
+		return
+	}
+
+	thread, err := tc.messagingService.MarkRead(*tc.ctx, id, user)
+	if err != nil {
+		c.JSON(messagingErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, thread)
+}
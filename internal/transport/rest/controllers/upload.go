@@ -0,0 +1,128 @@
+package controllers
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"vk-inter/internal/transport/rest/interfaces"
+	"vk-inter/pkg/errs"
+
+	"github.com/gin-gonic/gin"
+)
+
+type UploadController struct {
+	ctx           *context.Context
+	uploadService interfaces.UploadService
+	authService   interfaces.AuthService
+}
+
+func NewUploadController(ctx *context.Context, uploadService interfaces.UploadService, authService interfaces.AuthService) *UploadController {
+	return &UploadController{
+		ctx:           ctx,
+		uploadService: uploadService,
+		authService:   authService,
+	}
+}
+
+// @Summary	Upload image, use returned id as upload_id of listing image
+// @Tags		upload
+// @Security	BearerAuth
+// @Accept		multipart/form-data
+// @Produce	json
+// @Param		file	formData	file	true	"JPEG, PNG, GIF or WebP image"
+// @Success	201		{object}	models.Upload
+// @Failure	400		{object}	ErrorResponse
+// @Failure	401		{object}	ErrorResponse
+// @Failure	413		{object}	ErrorResponse
+// @Failure	415		{object}	ErrorResponse
+// @Router		/uploads [post]
+func (uc *UploadController) Upload(c *gin.Context) {
+	user := requireUser(*uc.ctx, c, uc.authService, "Please login before upload images")
+	if user == nil {
+		return
+	}
+
+	// Файл читается потоком, без буферизации всей формы на диске
+	mr, err := c.Request.MultipartReader()
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": errs.ErrUploadMissingFile.Error()})
+		return
+	}
+	var file io.Reader
+	for {
+		part, err := mr.NextPart()
+		if err != nil {
+			break
+		}
+		if part.FormName() == "file" {
+			file = part
+			break
+		}
+	}
+	if file == nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": errs.ErrUploadMissingFile.Error()})
+		return
+	}
+
+	upload, err := uc.uploadService.Upload(*uc.ctx, file, user)
+	if err != nil {
+		status := http.StatusInternalServerError
+		switch {
+		case errors.Is(err, errs.ErrUploadTooLarge):
+			status = http.StatusRequestEntityTooLarge
+		case errors.Is(err, errs.ErrUploadInvalidType):
+			status = http.StatusUnsupportedMediaType
+		}
+		c.JSON(status, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusCreated, upload)
+}
+
+// @Summary	Get uploaded image
+// @Tags		upload
+// @Produce	image/jpeg,image/png,image/gif,image/webp
+// @Param		id	path	string	true	"Upload ID"
+// @Success	200	{file}	binary
+// @Failure	404	{object}	ErrorResponse
+// @Router		/uploads/{id} [get]
+func (uc *UploadController) GetUpload(c *gin.Context) {
+	uc.serveUpload(c, "")
+}
+
+// @Summary	Get resized JPEG variant of uploaded image
+// @Tags		upload
+// @Produce	image/jpeg
+// @Param		id		path	string	true	"Upload ID"
+// @Param		variant	path	string	true	"Variant"	Enums(thumb, medium, large)
+// @Success	200		{file}	binary
+// @Failure	404		{object}	ErrorResponse
+// @Router		/uploads/{id}/{variant} [get]
+func (uc *UploadController) GetUploadVariant(c *gin.Context) {
+	uc.serveUpload(c, c.Param("variant"))
+}
+
+func (uc *UploadController) serveUpload(c *gin.Context, variant string) {
+	id, ok := parseIDParam(c, "id", errs.ErrUploadNotFound)
+	if !ok {
+		return
+	}
+
+	upload, content, err := uc.uploadService.Open(*uc.ctx, id, variant)
+	if err != nil {
+		status := http.StatusInternalServerError
+		if errors.Is(err, errs.ErrUploadNotFound) {
+			status = http.StatusNotFound
+		}
+		c.JSON(status, gin.H{"error": err.Error()})
+		return
+	}
+	defer content.Close()
+
+	// Загрузки не изменяются, их можно кэшировать навсегда
+	c.DataFromReader(http.StatusOK, upload.Size, upload.Mime, content, map[string]string{
+		"Cache-Control":          "public, max-age=31536000, immutable",
+		"X-Content-Type-Options": "nosniff",
+	})
+}
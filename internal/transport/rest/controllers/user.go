@@ -0,0 +1,44 @@
+package controllers
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"vk-inter/internal/transport/rest/interfaces"
+	"vk-inter/pkg/errs"
+
+	"github.com/gin-gonic/gin"
+)
+
+type UserController struct {
+	ctx         *context.Context
+	userService interfaces.UserService
+}
+
+func NewUserController(ctx *context.Context, userService interfaces.UserService) *UserController {
+	return &UserController{
+		ctx:         ctx,
+		userService: userService,
+	}
+}
+
+// @Summary	Public seller profile
+// @Tags		user
+// @Produce	json
+// @Param		login	path		string	true	"User login"
+// @Success	200		{object}	models.UserProfile
+// @Failure	404		{object}	ErrorResponse
+// @Failure	500		{object}	ErrorResponse
+// @Router		/users/{login} [get]
+func (uc *UserController) GetProfile(c *gin.Context) {
+	profile, err := uc.userService.GetProfile(*uc.ctx, c.Param("login"))
+	if err != nil {
+		status := http.StatusInternalServerError
+		if errors.Is(err, errs.ErrUserNotFound) {
+			status = http.StatusNotFound
+		}
+		c.JSON(status, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, profile)
+}
@@ -0,0 +1,10 @@
+package interfaces
+
+import (
+	"context"
+	"vk-inter/internal/models"
+)
+
+type AnalyticsService interface {
+	GetSellerAnalytics(ctx context.Context, from, to string, user *models.User) (*models.SellerAnalytics, error)
+}
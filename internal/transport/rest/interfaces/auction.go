@@ -0,0 +1,14 @@
+package interfaces
+
+import (
+	"context"
+	"vk-inter/internal/models"
+	"vk-inter/pkg/money"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type AuctionService interface {
+	PlaceBid(ctx context.Context, listingID primitive.ObjectID, amount money.Decimal, user *models.User) (*models.BidResult, error)
+	GetBids(ctx context.Context, listingID primitive.ObjectID, limit int) ([]*models.Bid, error)
+}
@@ -0,0 +1,16 @@
+package interfaces
+
+import (
+	"context"
+	"vk-inter/internal/models"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type CategoryService interface {
+	CreateCategory(ctx context.Context, name, slug string, parentID *primitive.ObjectID, attributes []models.AttributeSchema, user *models.User) (*models.Category, error)
+	UpdateCategory(ctx context.Context, id primitive.ObjectID, name, slug string, parentID *primitive.ObjectID, attributes []models.AttributeSchema, user *models.User) (*models.Category, error)
+	DeleteCategory(ctx context.Context, id primitive.ObjectID, user *models.User) error
+	GetAttributes(ctx context.Context, id primitive.ObjectID) ([]models.AttributeSchema, error)
+	GetTree(ctx context.Context) ([]*models.CategoryNode, error)
+}
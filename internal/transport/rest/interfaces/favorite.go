@@ -0,0 +1,14 @@
+package interfaces
+
+import (
+	"context"
+	"vk-inter/internal/models"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type FavoriteService interface {
+	AddFavorite(ctx context.Context, listingID primitive.ObjectID, user *models.User) (*models.FavoriteState, error)
+	RemoveFavorite(ctx context.Context, listingID primitive.ObjectID, user *models.User) (*models.FavoriteState, error)
+	GetFavorites(ctx context.Context, page, limit int, user *models.User) (*models.ListingPage, error)
+}
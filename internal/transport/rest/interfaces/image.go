@@ -0,0 +1,11 @@
+package interfaces
+
+import (
+	"context"
+	"io"
+	"vk-inter/internal/models"
+)
+
+type ImageService interface {
+	Open(ctx context.Context, hash string) (*models.CachedImage, io.ReadCloser, error)
+}
@@ -3,11 +3,17 @@ package interfaces
 import (
 	"context"
 	"vk-inter/internal/models"
+	"vk-inter/pkg/money"
 
 	"go.mongodb.org/mongo-driver/bson/primitive"
 )
 
 type ListingService interface {
-	CreateListing(ctx context.Context, title, description, imageURL string, price float64, user *models.User) (*models.Listing, error)
-	GetListings(ctx context.Context, page, limit int, sortBy, order string, minPrice, maxPrice float64, currentUserID primitive.ObjectID) ([]*models.Listing, error)
+	CreateListing(ctx context.Context, listing *models.Listing, user *models.User) (*models.Listing, error)
+	GetListings(ctx context.Context, filter *models.ListingFilter, cursorToken string) (*models.ListingPage, error)
+	GetListing(ctx context.Context, id primitive.ObjectID, user *models.User, client string) (*models.Listing, error)
+	ReorderImages(ctx context.Context, id primitive.ObjectID, urls []string, user *models.User) (*models.Listing, error)
+	SetCoverImage(ctx context.Context, id primitive.ObjectID, url string, user *models.User) (*models.Listing, error)
+	UpdatePrice(ctx context.Context, id primitive.ObjectID, price money.Decimal, currency string, user *models.User) (*models.Listing, error)
+	MarkSold(ctx context.Context, id primitive.ObjectID, user *models.User) (*models.Listing, error)
 }
@@ -0,0 +1,17 @@
+package interfaces
+
+import (
+	"context"
+	"vk-inter/internal/models"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type MessagingService interface {
+	StartThread(ctx context.Context, listingID primitive.ObjectID, text string, user *models.User) (*models.ThreadMessage, error)
+	PostMessage(ctx context.Context, threadID primitive.ObjectID, text string, user *models.User) (*models.ThreadMessage, error)
+	GetThread(ctx context.Context, id primitive.ObjectID, user *models.User) (*models.Thread, error)
+	GetThreads(ctx context.Context, cursorToken string, limit int, user *models.User) (*models.ThreadPage, error)
+	GetMessages(ctx context.Context, threadID primitive.ObjectID, cursorToken string, limit int, user *models.User) (*models.MessagePage, error)
+	MarkRead(ctx context.Context, threadID primitive.ObjectID, user *models.User) (*models.Thread, error)
+}
@@ -0,0 +1,20 @@
+package interfaces
+
+import (
+	"context"
+	"vk-inter/internal/models"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type DuplicateService interface {
+	GetReports(ctx context.Context, limit int, user *models.User) ([]*models.DuplicateReport, error)
+}
+
+type ModerationService interface {
+	ReportListing(ctx context.Context, listingID primitive.ObjectID, reason, comment string, user *models.User) (*models.Report, error)
+	GetCases(ctx context.Context, status string, page, limit int, user *models.User) (*models.CasePage, error)
+	GetCase(ctx context.Context, id primitive.ObjectID, user *models.User) (*models.CaseDetails, error)
+	ClaimCase(ctx context.Context, id primitive.ObjectID, user *models.User) (*models.ModerationCase, error)
+	ResolveCase(ctx context.Context, id primitive.ObjectID, action, comment string, user *models.User) (*models.ModerationCase, error)
+}
@@ -0,0 +1,20 @@
+package interfaces
+
+import (
+	"context"
+	"vk-inter/internal/models"
+	"vk-inter/pkg/money"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type OfferService interface {
+	MakeOffer(ctx context.Context, listingID primitive.ObjectID, amount money.Decimal, message string, user *models.User) (*models.Offer, error)
+	CounterOffer(ctx context.Context, id primitive.ObjectID, amount money.Decimal, message string, user *models.User) (*models.Offer, error)
+	AcceptOffer(ctx context.Context, id primitive.ObjectID, user *models.User) (*models.Offer, error)
+	RejectOffer(ctx context.Context, id primitive.ObjectID, user *models.User) (*models.Offer, error)
+	WithdrawOffer(ctx context.Context, id primitive.ObjectID, user *models.User) (*models.Offer, error)
+	GetOffer(ctx context.Context, id primitive.ObjectID, user *models.User) (*models.Offer, error)
+	GetListingOffers(ctx context.Context, listingID primitive.ObjectID, status string, page, limit int, user *models.User) (*models.OfferPage, error)
+	GetMyOffers(ctx context.Context, status string, page, limit int, user *models.User) (*models.OfferPage, error)
+}
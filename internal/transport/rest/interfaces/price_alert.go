@@ -0,0 +1,14 @@
+package interfaces
+
+import (
+	"context"
+	"vk-inter/internal/models"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type PriceAlertService interface {
+	GetPriceHistory(ctx context.Context, listingID primitive.ObjectID, user *models.User) ([]*models.PriceChange, error)
+	SetPriceAlert(ctx context.Context, listingID primitive.ObjectID, threshold float64, user *models.User) (*models.PriceWatch, error)
+	DeletePriceAlert(ctx context.Context, listingID primitive.ObjectID, user *models.User) error
+}
@@ -0,0 +1,14 @@
+package interfaces
+
+import (
+	"context"
+	"time"
+	"vk-inter/internal/models"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type RealtimeService interface {
+	Subscribe(ctx context.Context, userID primitive.ObjectID) (<-chan *models.Event, func(), error)
+	Heartbeat() time.Duration
+}
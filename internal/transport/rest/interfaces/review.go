@@ -0,0 +1,14 @@
+package interfaces
+
+import (
+	"context"
+	"vk-inter/internal/models"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type ReviewService interface {
+	CreateReview(ctx context.Context, listingID primitive.ObjectID, rating int, text string, user *models.User) (*models.Review, error)
+	GetUserReviews(ctx context.Context, login string, page, limit int) (*models.ReviewPage, error)
+	SetReviewHidden(ctx context.Context, id primitive.ObjectID, hidden bool, reason string, user *models.User) (*models.Review, error)
+}
@@ -0,0 +1,20 @@
+package interfaces
+
+import (
+	"context"
+	"vk-inter/internal/models"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type SavedSearchService interface {
+	CreateSavedSearch(ctx context.Context, name, query string, filter *models.ListingFilter, webhookURL string, user *models.User) (*models.SavedSearch, error)
+	GetSavedSearches(ctx context.Context, user *models.User) ([]*models.SavedSearch, error)
+	DeleteSavedSearch(ctx context.Context, id primitive.ObjectID, user *models.User) error
+}
+
+type NotificationService interface {
+	GetNotifications(ctx context.Context, page, limit int, unreadOnly bool, user *models.User) (*models.NotificationPage, error)
+	MarkRead(ctx context.Context, id primitive.ObjectID, user *models.User) error
+	MarkAllRead(ctx context.Context, user *models.User) (int64, error)
+}
@@ -0,0 +1,14 @@
+package interfaces
+
+import (
+	"context"
+	"io"
+	"vk-inter/internal/models"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type UploadService interface {
+	Upload(ctx context.Context, r io.Reader, user *models.User) (*models.Upload, error)
+	Open(ctx context.Context, id primitive.ObjectID, variant string) (*models.Upload, io.ReadCloser, error)
+}
@@ -0,0 +1,10 @@
+package interfaces
+
+import (
+	"context"
+	"vk-inter/internal/models"
+)
+
+type UserService interface {
+	GetProfile(ctx context.Context, login string) (*models.UserProfile, error)
+}
@@ -27,15 +27,31 @@ func AuthMiddleware(secret string) gin.HandlerFunc {
 			return
 		}
 
-		tokens, err := jwt.ValidateToken(tokenParts[1], secret)
-		if err != nil {
-			c.Next()
-			return
-		}
+		authenticate(c, tokenParts[1], secret)
+		c.Next()
+	}
+}
 
-		// Токен валиден
-		c.Set("isAuthenticated", true)
-		c.Set("id", tokens.Subject)
+// QueryTokenAuth accepts access token from access_token query parameter,
+// browsers can not set headers of WebSocket and EventSource requests
+func QueryTokenAuth(secret string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if isAuth, _ := c.Get("isAuthenticated"); isAuth != true {
+			if token := c.Query("access_token"); token != "" {
+				authenticate(c, token, secret)
+			}
+		}
 		c.Next()
 	}
 }
+
+func authenticate(c *gin.Context, token, secret string) {
+	tokens, err := jwt.ValidateToken(token, secret)
+	if err != nil {
+		return
+	}
+
+	// Токен валиден
+	c.Set("isAuthenticated", true)
+	c.Set("id", tokens.Subject)
+}
@@ -0,0 +1,14 @@
+package routes
+
+import (
+	"context"
+	"vk-inter/internal/transport/rest/controllers"
+	"vk-inter/internal/transport/rest/interfaces"
+
+	"github.com/gin-gonic/gin"
+)
+
+func AnalyticsRoute(ctx *context.Context, r *gin.RouterGroup, analyticsService interfaces.AnalyticsService, authService interfaces.AuthService) {
+	analyticsController := controllers.NewAnalyticsController(ctx, analyticsService, authService)
+	r.GET("/me/analytics", analyticsController.GetSellerAnalytics)
+}
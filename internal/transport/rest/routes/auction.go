@@ -0,0 +1,15 @@
+package routes
+
+import (
+	"context"
+	"vk-inter/internal/transport/rest/controllers"
+	"vk-inter/internal/transport/rest/interfaces"
+
+	"github.com/gin-gonic/gin"
+)
+
+func AuctionRoute(ctx *context.Context, r *gin.RouterGroup, auctionService interfaces.AuctionService, authService interfaces.AuthService) {
+	auctionController := controllers.NewAuctionController(ctx, auctionService, authService)
+	r.POST("/listings/:id/bids", auctionController.PlaceBid)
+	r.GET("/listings/:id/bids", auctionController.GetBids)
+}
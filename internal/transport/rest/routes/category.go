@@ -0,0 +1,21 @@
+package routes
+
+import (
+	"context"
+	"vk-inter/internal/transport/rest/controllers"
+	"vk-inter/internal/transport/rest/interfaces"
+
+	"github.com/gin-gonic/gin"
+)
+
+func CategoryRoute(ctx *context.Context, r *gin.RouterGroup, categoryService interfaces.CategoryService, authService interfaces.AuthService) {
+	categoryController := controllers.NewCategoryController(ctx, categoryService, authService)
+	categoryGroup := r.Group("/categories")
+	{
+		categoryGroup.GET("/", categoryController.GetTree)
+		categoryGroup.GET("/:id/attributes", categoryController.GetAttributes)
+		categoryGroup.POST("/", categoryController.CreateCategory)
+		categoryGroup.PUT("/:id", categoryController.UpdateCategory)
+		categoryGroup.DELETE("/:id", categoryController.DeleteCategory)
+	}
+}
@@ -0,0 +1,22 @@
+package routes
+
+import (
+	"context"
+	"vk-inter/internal/transport/rest/controllers"
+	"vk-inter/internal/transport/rest/interfaces"
+
+	"github.com/gin-gonic/gin"
+)
+
+func FavoriteRoute(ctx *context.Context, r *gin.RouterGroup, favoriteService interfaces.FavoriteService, authService interfaces.AuthService) {
+	favoriteController := controllers.NewFavoriteController(ctx, favoriteService, authService)
+	listingGroup := r.Group("/listings")
+	{
+		listingGroup.POST("/:id/favorite", favoriteController.AddFavorite)
+		listingGroup.DELETE("/:id/favorite", favoriteController.RemoveFavorite)
+	}
+	meGroup := r.Group("/me")
+	{
+		meGroup.GET("/favorites", favoriteController.GetFavorites)
+	}
+}
@@ -0,0 +1,17 @@
+package routes
+
+import (
+	"context"
+	"vk-inter/internal/transport/rest/controllers"
+	"vk-inter/internal/transport/rest/interfaces"
+
+	"github.com/gin-gonic/gin"
+)
+
+func ImageRoute(ctx *context.Context, r *gin.RouterGroup, imageService interfaces.ImageService) {
+	imageController := controllers.NewImageController(ctx, imageService)
+	imageGroup := r.Group("/images")
+	{
+		imageGroup.GET("/:hash", imageController.GetImage)
+	}
+}
@@ -14,5 +14,10 @@ func ListingRoute(ctx *context.Context, r *gin.RouterGroup, listingService inter
 	{
 		authGroup.POST("/", listingController.CreateListing)
 		authGroup.GET("/", listingController.GetListings)
+		authGroup.GET("/:id", listingController.GetListing)
+		authGroup.PUT("/:id/images/order", listingController.ReorderImages)
+		authGroup.PUT("/:id/images/cover", listingController.SetCoverImage)
+		authGroup.PUT("/:id/price", listingController.UpdatePrice)
+		authGroup.POST("/:id/sold", listingController.MarkSold)
 	}
 }
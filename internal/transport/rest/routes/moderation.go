@@ -0,0 +1,22 @@
+package routes
+
+import (
+	"context"
+	"vk-inter/internal/transport/rest/controllers"
+	"vk-inter/internal/transport/rest/interfaces"
+
+	"github.com/gin-gonic/gin"
+)
+
+func ModerationRoute(ctx *context.Context, r *gin.RouterGroup, duplicateService interfaces.DuplicateService, moderationService interfaces.ModerationService, authService interfaces.AuthService) {
+	moderationController := controllers.NewModerationController(ctx, duplicateService, moderationService, authService)
+	r.POST("/listings/:id/report", moderationController.ReportListing)
+	moderationGroup := r.Group("/moderation")
+	{
+		moderationGroup.GET("/duplicates", moderationController.GetDuplicates)
+		moderationGroup.GET("/cases", moderationController.GetCases)
+		moderationGroup.GET("/cases/:id", moderationController.GetCase)
+		moderationGroup.POST("/cases/:id/claim", moderationController.ClaimCase)
+		moderationGroup.POST("/cases/:id/resolve", moderationController.ResolveCase)
+	}
+}
@@ -0,0 +1,24 @@
+package routes
+
+import (
+	"context"
+	"vk-inter/internal/transport/rest/controllers"
+	"vk-inter/internal/transport/rest/interfaces"
+
+	"github.com/gin-gonic/gin"
+)
+
+func OfferRoute(ctx *context.Context, r *gin.RouterGroup, offerService interfaces.OfferService, authService interfaces.AuthService) {
+	offerController := controllers.NewOfferController(ctx, offerService, authService)
+	r.POST("/listings/:id/offers", offerController.MakeOffer)
+	r.GET("/listings/:id/offers", offerController.GetListingOffers)
+	r.GET("/me/offers", offerController.GetMyOffers)
+	offerGroup := r.Group("/offers")
+	{
+		offerGroup.GET("/:id", offerController.GetOffer)
+		offerGroup.POST("/:id/counter", offerController.CounterOffer)
+		offerGroup.POST("/:id/accept", offerController.AcceptOffer)
+		offerGroup.POST("/:id/reject", offerController.RejectOffer)
+		offerGroup.POST("/:id/withdraw", offerController.WithdrawOffer)
+	}
+}
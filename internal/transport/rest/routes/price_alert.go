@@ -0,0 +1,19 @@
+package routes
+
+import (
+	"context"
+	"vk-inter/internal/transport/rest/controllers"
+	"vk-inter/internal/transport/rest/interfaces"
+
+	"github.com/gin-gonic/gin"
+)
+
+func PriceAlertRoute(ctx *context.Context, r *gin.RouterGroup, priceAlertService interfaces.PriceAlertService, authService interfaces.AuthService) {
+	priceAlertController := controllers.NewPriceAlertController(ctx, priceAlertService, authService)
+	listingGroup := r.Group("/listings")
+	{
+		listingGroup.GET("/:id/price-history", priceAlertController.GetPriceHistory)
+		listingGroup.PUT("/:id/price-alert", priceAlertController.SetPriceAlert)
+		listingGroup.DELETE("/:id/price-alert", priceAlertController.DeletePriceAlert)
+	}
+}
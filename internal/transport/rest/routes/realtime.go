@@ -0,0 +1,19 @@
+package routes
+
+import (
+	"context"
+	"vk-inter/internal/transport/rest/controllers"
+	"vk-inter/internal/transport/rest/interfaces"
+	"vk-inter/internal/transport/rest/middlewares"
+
+	"github.com/gin-gonic/gin"
+)
+
+func RealtimeRoute(ctx *context.Context, r *gin.RouterGroup, realtimeService interfaces.RealtimeService, authService interfaces.AuthService, secret string) {
+	realtimeController := controllers.NewRealtimeController(ctx, realtimeService, authService)
+	realtimeGroup := r.Group("/realtime", middlewares.QueryTokenAuth(secret))
+	{
+		realtimeGroup.GET("/ws", realtimeController.WebSocket)
+		realtimeGroup.GET("/sse", realtimeController.SSE)
+	}
+}
@@ -0,0 +1,16 @@
+package routes
+
+import (
+	"context"
+	"vk-inter/internal/transport/rest/controllers"
+	"vk-inter/internal/transport/rest/interfaces"
+
+	"github.com/gin-gonic/gin"
+)
+
+func ReviewRoute(ctx *context.Context, r *gin.RouterGroup, reviewService interfaces.ReviewService, authService interfaces.AuthService) {
+	reviewController := controllers.NewReviewController(ctx, reviewService, authService)
+	r.POST("/listings/:id/reviews", reviewController.CreateReview)
+	r.GET("/users/:login/reviews", reviewController.GetUserReviews)
+	r.PUT("/moderation/reviews/:id", reviewController.SetReviewHidden)
+}
@@ -0,0 +1,22 @@
+package routes
+
+import (
+	"context"
+	"vk-inter/internal/transport/rest/controllers"
+	"vk-inter/internal/transport/rest/interfaces"
+
+	"github.com/gin-gonic/gin"
+)
+
+func SavedSearchRoute(ctx *context.Context, r *gin.RouterGroup, savedSearchService interfaces.SavedSearchService, notificationService interfaces.NotificationService, authService interfaces.AuthService) {
+	savedSearchController := controllers.NewSavedSearchController(ctx, savedSearchService, notificationService, authService)
+	meGroup := r.Group("/me")
+	{
+		meGroup.POST("/searches", savedSearchController.CreateSavedSearch)
+		meGroup.GET("/searches", savedSearchController.GetSavedSearches)
+		meGroup.DELETE("/searches/:id", savedSearchController.DeleteSavedSearch)
+		meGroup.GET("/notifications", savedSearchController.GetNotifications)
+		meGroup.POST("/notifications/:id/read", savedSearchController.MarkRead)
+		meGroup.POST("/notifications/read", savedSearchController.MarkAllRead)
+	}
+}
@@ -0,0 +1,22 @@
+package routes
+
+import (
+	"context"
+	"vk-inter/internal/transport/rest/controllers"
+	"vk-inter/internal/transport/rest/interfaces"
+
+	"github.com/gin-gonic/gin"
+)
+
+func ThreadRoute(ctx *context.Context, r *gin.RouterGroup, messagingService interfaces.MessagingService, authService interfaces.AuthService) {
+	threadController := controllers.NewThreadController(ctx, messagingService, authService)
+	r.POST("/listings/:id/threads", threadController.StartThread)
+	r.GET("/me/threads", threadController.GetThreads)
+	threadGroup := r.Group("/threads")
+	{
+		threadGroup.GET("/:id", threadController.GetThread)
+		threadGroup.GET("/:id/messages", threadController.GetMessages)
+		threadGroup.POST("/:id/messages", threadController.PostMessage)
+		threadGroup.POST("/:id/read", threadController.MarkRead)
+	}
+}
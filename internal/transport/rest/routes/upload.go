@@ -0,0 +1,19 @@
+package routes
+
+import (
+	"context"
+	"vk-inter/internal/transport/rest/controllers"
+	"vk-inter/internal/transport/rest/interfaces"
+
+	"github.com/gin-gonic/gin"
+)
+
+func UploadRoute(ctx *context.Context, r *gin.RouterGroup, uploadService interfaces.UploadService, authService interfaces.AuthService) {
+	uploadController := controllers.NewUploadController(ctx, uploadService, authService)
+	uploadGroup := r.Group("/uploads")
+	{
+		uploadGroup.POST("/", uploadController.Upload)
+		uploadGroup.GET("/:id", uploadController.GetUpload)
+		uploadGroup.GET("/:id/:variant", uploadController.GetUploadVariant)
+	}
+}
@@ -0,0 +1,17 @@
+package routes
+
+import (
+	"context"
+	"vk-inter/internal/transport/rest/controllers"
+	"vk-inter/internal/transport/rest/interfaces"
+
+	"github.com/gin-gonic/gin"
+)
+
+func UserRoute(ctx *context.Context, r *gin.RouterGroup, userService interfaces.UserService) {
+	userController := controllers.NewUserController(ctx, userService)
+	userGroup := r.Group("/users")
+	{
+		userGroup.GET("/:login", userController.GetProfile)
+	}
+}
@@ -19,13 +19,36 @@ type RestConfig struct {
 	Port string `env:"REST_PORT" env-default:"8080"`
 }
 
+// Services are business services used by REST handlers
+type Services struct {
+	Auth      interfaces.AuthService
+	Listing   interfaces.ListingService
+	Category  interfaces.CategoryService
+	User      interfaces.UserService
+	Upload    interfaces.UploadService
+	Image     interfaces.ImageService
+	Duplicate interfaces.DuplicateService
+	Favorite  interfaces.FavoriteService
+
+	SavedSearch  interfaces.SavedSearchService
+	Notification interfaces.NotificationService
+	PriceAlert   interfaces.PriceAlertService
+	Messaging    interfaces.MessagingService
+	Realtime     interfaces.RealtimeService
+	Offer        interfaces.OfferService
+	Auction      interfaces.AuctionService
+	Review       interfaces.ReviewService
+	Moderation   interfaces.ModerationService
+	Analytics    interfaces.AnalyticsService
+}
+
 type Server struct {
 	ctx *context.Context
 	cfg RestConfig
 	r   *gin.Engine
 }
 
-func New(ctx *context.Context, cfg RestConfig, debug bool, secret string, authService interfaces.AuthService, listingService interfaces.ListingService) *Server {
+func New(ctx *context.Context, cfg RestConfig, debug bool, secret string, services Services) *Server {
 	if !debug {
 		gin.SetMode(gin.ReleaseMode)
 	}
@@ -45,8 +68,22 @@ func New(ctx *context.Context, cfg RestConfig, debug bool, secret string, authSe
 	docs.SwaggerInfo.Description = "API for auth and listings"
 	docs.SwaggerInfo.Version = "0.1.0"
 
-	routes.AuthRoutes(ctx, r.Group("/"), authService)
-	routes.ListingRoute(ctx, r.Group("/"), listingService, authService)
+	routes.AuthRoutes(ctx, r.Group("/"), services.Auth)
+	routes.ListingRoute(ctx, r.Group("/"), services.Listing, services.Auth)
+	routes.CategoryRoute(ctx, r.Group("/"), services.Category, services.Auth)
+	routes.UserRoute(ctx, r.Group("/"), services.User)
+	routes.UploadRoute(ctx, r.Group("/"), services.Upload, services.Auth)
+	routes.ImageRoute(ctx, r.Group("/"), services.Image)
+	routes.FavoriteRoute(ctx, r.Group("/"), services.Favorite, services.Auth)
+	routes.PriceAlertRoute(ctx, r.Group("/"), services.PriceAlert, services.Auth)
+	routes.SavedSearchRoute(ctx, r.Group("/"), services.SavedSearch, services.Notification, services.Auth)
+	routes.ThreadRoute(ctx, r.Group("/"), services.Messaging, services.Auth)
+	routes.OfferRoute(ctx, r.Group("/"), services.Offer, services.Auth)
+	routes.AuctionRoute(ctx, r.Group("/"), services.Auction, services.Auth)
+	routes.ReviewRoute(ctx, r.Group("/"), services.Review, services.Auth)
+	routes.RealtimeRoute(ctx, r.Group("/"), services.Realtime, services.Auth, secret)
+	routes.ModerationRoute(ctx, r.Group("/"), services.Duplicate, services.Moderation, services.Auth)
+	routes.AnalyticsRoute(ctx, r.Group("/"), services.Analytics, services.Auth)
 	r.GET("/swagger/*any", ginSwagger.WrapHandler(swaggerFiles.Handler))
 
 	return &Server{ctx: ctx, cfg: cfg, r: r}
@@ -0,0 +1,59 @@
+// Package blob stores uploaded files in local filesystem or S3-compatible storage
+package blob
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+)
+
+var ErrNotFound = errors.New("blob not found")
+
+type BlobConfig struct {
+	Driver   string `env:"BLOB_DRIVER" env-default:"local"`
+	LocalDir string `env:"BLOB_LOCAL_DIR" env-default:"./uploads"`
+
+	S3Endpoint  string `env:"S3_ENDPOINT" env-default:"http://localhost:9000"`
+	S3Region    string `env:"S3_REGION" env-default:"us-east-1"`
+	S3Bucket    string `env:"S3_BUCKET" env-default:"vk-inter"`
+	S3AccessKey string `env:"S3_ACCESS_KEY"`
+	S3SecretKey string `env:"S3_SECRET_KEY"`
+}
+
+// Store is a key-value storage of binary objects
+type Store interface {
+	Put(ctx context.Context, key string, r io.Reader, size int64, contentType string) error
+	// Get returns object content, caller must close it
+	Get(ctx context.Context, key string) (io.ReadCloser, error)
+	Delete(ctx context.Context, key string) error
+}
+
+// New creates store selected by cfg.Driver
+func New(ctx context.Context, cfg BlobConfig) (Store, error) {
+	switch cfg.Driver {
+	case "local":
+		return NewLocalStore(cfg.LocalDir)
+	case "s3":
+		s := NewS3Store(cfg.S3Endpoint, cfg.S3Region, cfg.S3Bucket, cfg.S3AccessKey, cfg.S3SecretKey)
+		if err := s.EnsureBucket(ctx); err != nil {
+			return nil, err
+		}
+		return s, nil
+	}
+	return nil, fmt.Errorf("unknown blob driver %q", cfg.Driver)
+}
+
+// validKey rejects keys which could escape the storage root
+func validKey(key string) bool {
+	if key == "" || strings.HasPrefix(key, "/") || strings.Contains(key, "\\") {
+		return false
+	}
+	for _, part := range strings.Split(key, "/") {
+		if part == "" || part == "." || part == ".." {
+			return false
+		}
+	}
+	return true
+}
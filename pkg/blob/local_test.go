@@ -0,0 +1,155 @@
+package blob
+
+import (
+	"context"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestValidKey(t *testing.T) {
+	tests := []struct {
+		key   string
+		valid bool
+	}{
+		{key: "image.png", valid: true},
+		{key: "images/ab/cd/image.webp", valid: true},
+		{key: "images/..hidden", valid: true},
+		{key: ""},
+		{key: "/etc/passwd"},
+		{key: "../secret"},
+		{key: "images/../../secret"},
+		{key: "images/.."},
+		{key: "./image.png"},
+		{key: "images//image.png"},
+		{key: "images/"},
+		{key: `..\secret`},
+		{key: `images\image.png`},
+	}
+	for _, tt := range tests {
+		if got := validKey(tt.key); got != tt.valid {
+			t.Errorf("validKey(%q) = %v, want %v", tt.key, got, tt.valid)
+		}
+	}
+}
+
+func readBlob(t *testing.T, s Store, key string) string {
+	t.Helper()
+	r, err := s.Get(context.Background(), key)
+	if err != nil {
+		t.Fatalf("Get(%q) = %v", key, err)
+	}
+	defer r.Close()
+	data, err := io.ReadAll(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(data)
+}
+
+func TestLocalStoreRoundTrip(t *testing.T) {
+	ctx := context.Background()
+	root := filepath.Join(t.TempDir(), "uploads")
+	s, err := NewLocalStore(root)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	key := "images/ab/image.png"
+	if err := s.Put(ctx, key, strings.NewReader("first"), 5, "image/png"); err != nil {
+		t.Fatalf("Put() = %v", err)
+	}
+	if got := readBlob(t, s, key); got != "first" {
+		t.Fatalf("Get() = %q, want first", got)
+	}
+	if err := s.Put(ctx, key, strings.NewReader("second"), 6, "image/png"); err != nil {
+		t.Fatalf("Put() overwrite = %v", err)
+	}
+	if got := readBlob(t, s, key); got != "second" {
+		t.Fatalf("Get() after overwrite = %q, want second", got)
+	}
+
+	if err := s.Delete(ctx, key); err != nil {
+		t.Fatalf("Delete() = %v", err)
+	}
+	if _, err := s.Get(ctx, key); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("Get() after delete = %v, want ErrNotFound", err)
+	}
+	if err := s.Delete(ctx, key); err != nil {
+		t.Fatalf("Delete() of missing key = %v, want nil", err)
+	}
+}
+
+type failingReader struct{}
+
+func (failingReader) Read([]byte) (int, error) {
+	return 0, errors.New("connection reset")
+}
+
+func TestLocalStorePutIsAtomic(t *testing.T) {
+	ctx := context.Background()
+	root := t.TempDir()
+	s, err := NewLocalStore(root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	key := "images/image.png"
+	if err := s.Put(ctx, key, strings.NewReader("complete"), 8, "image/png"); err != nil {
+		t.Fatal(err)
+	}
+
+	// Оборванная загрузка не заменяет объект и не оставляет временных файлов
+	r := io.MultiReader(strings.NewReader("partial"), failingReader{})
+	if err := s.Put(ctx, key, r, 100, "image/png"); err == nil {
+		t.Fatal("Put() from failing reader succeeded")
+	}
+	if got := readBlob(t, s, key); got != "complete" {
+		t.Fatalf("Get() after failed Put = %q, want complete", got)
+	}
+	entries, err := os.ReadDir(filepath.Join(root, "images"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 1 {
+		names := make([]string, 0, len(entries))
+		for _, e := range entries {
+			names = append(names, e.Name())
+		}
+		t.Fatalf("files after failed Put = %v, want only image.png", names)
+	}
+}
+
+func TestLocalStoreRejectsTraversal(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+	s, err := NewLocalStore(filepath.Join(dir, "uploads"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	secret := filepath.Join(dir, "secret")
+	if err := os.WriteFile(secret, []byte("secret"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, key := range []string{"../secret", "images/../../secret", secret, "../outside/new"} {
+		if err := s.Put(ctx, key, strings.NewReader("x"), 1, ""); err == nil {
+			t.Errorf("Put(%q) succeeded", key)
+		}
+		if r, err := s.Get(ctx, key); err == nil {
+			r.Close()
+			t.Errorf("Get(%q) succeeded", key)
+		}
+		if err := s.Delete(ctx, key); err == nil {
+			t.Errorf("Delete(%q) succeeded", key)
+		}
+	}
+	if data, err := os.ReadFile(secret); err != nil || string(data) != "secret" {
+		t.Fatalf("file outside root = %q, %v, want untouched", data, err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "outside")); !errors.Is(err, os.ErrNotExist) {
+		t.Fatalf("directory outside root was created: %v", err)
+	}
+}
@@ -0,0 +1,196 @@
+package blob
+
+import (
+	"bytes"
+	"context"
+	"encoding/hex"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+const (
+	testAccessKey = "minio"
+	testSecretKey = "minio-secret"
+	testRegion    = "us-east-1"
+)
+
+// fakeS3 is an in-memory S3 with path-style URLs which checks SigV4 signatures like MinIO
+type fakeS3 struct {
+	mu      sync.Mutex
+	buckets map[string]map[string][]byte
+	types   map[string]string
+}
+
+func newFakeS3(t *testing.T) (*fakeS3, *httptest.Server) {
+	t.Helper()
+	f := &fakeS3{buckets: map[string]map[string][]byte{}, types: map[string]string{}}
+	srv := httptest.NewServer(f)
+	t.Cleanup(srv.Close)
+	return f, srv
+}
+
+func (f *fakeS3) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	body, _ := io.ReadAll(r.Body)
+	if !f.verify(r, body) {
+		http.Error(w, "SignatureDoesNotMatch", http.StatusForbidden)
+		return
+	}
+
+	bucket, key, _ := strings.Cut(strings.TrimPrefix(r.URL.Path, "/"), "/")
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	objects, ok := f.buckets[bucket]
+	if key == "" {
+		switch r.Method {
+		case http.MethodHead:
+			if !ok {
+				w.WriteHeader(http.StatusNotFound)
+			}
+		case http.MethodPut:
+			if ok {
+				http.Error(w, "BucketAlreadyOwnedByYou", http.StatusConflict)
+				return
+			}
+			f.buckets[bucket] = map[string][]byte{}
+		default:
+			w.WriteHeader(http.StatusMethodNotAllowed)
+		}
+		return
+	}
+	if !ok {
+		http.Error(w, "NoSuchBucket", http.StatusNotFound)
+		return
+	}
+
+	switch r.Method {
+	case http.MethodPut:
+		objects[key] = body
+		f.types[bucket+"/"+key] = r.Header.Get("Content-Type")
+	case http.MethodGet:
+		data, ok := objects[key]
+		if !ok {
+			http.Error(w, "NoSuchKey", http.StatusNotFound)
+			return
+		}
+		w.Write(data)
+	case http.MethodDelete:
+		delete(objects, key)
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		w.WriteHeader(http.StatusMethodNotAllowed)
+	}
+}
+
+// verify recomputes the signature from the request as it arrived at the server
+func (f *fakeS3) verify(r *http.Request, body []byte) bool {
+	auth, ok := strings.CutPrefix(r.Header.Get("Authorization"), "AWS4-HMAC-SHA256 ")
+	if !ok {
+		return false
+	}
+	fields := map[string]string{}
+	for _, part := range strings.Split(auth, ", ") {
+		k, v, _ := strings.Cut(part, "=")
+		fields[k] = v
+	}
+	amzDate := r.Header.Get("X-Amz-Date")
+	if len(amzDate) != len("20060102T150405Z") {
+		return false
+	}
+	scope := amzDate[:8] + "/" + testRegion + "/s3/aws4_request"
+	if fields["Credential"] != testAccessKey+"/"+scope || fields["SignedHeaders"] != "host;x-amz-content-sha256;x-amz-date" {
+		return false
+	}
+	payloadHash := sha256Hex(body)
+	if r.Header.Get("X-Amz-Content-Sha256") != payloadHash {
+		return false
+	}
+
+	canonical := r.Method + "\n" + r.URL.EscapedPath() + "\n" + r.URL.RawQuery + "\n" +
+		"host:" + r.Host + "\n" +
+		"x-amz-content-sha256:" + payloadHash + "\n" +
+		"x-amz-date:" + amzDate + "\n\n" +
+		"host;x-amz-content-sha256;x-amz-date\n" + payloadHash
+	stringToSign := "AWS4-HMAC-SHA256\n" + amzDate + "\n" + scope + "\n" + sha256Hex([]byte(canonical))
+	key := []byte("AWS4" + testSecretKey)
+	for _, part := range []string{amzDate[:8], testRegion, "s3", "aws4_request"} {
+		key = hmacSHA256(key, part)
+	}
+	return fields["Signature"] == hex.EncodeToString(hmacSHA256(key, stringToSign))
+}
+
+func TestS3StoreRoundTrip(t *testing.T) {
+	ctx := context.Background()
+	fake, srv := newFakeS3(t)
+	s := NewS3Store(srv.URL+"/", testRegion, "uploads", testAccessKey, testSecretKey)
+
+	if err := s.EnsureBucket(ctx); err != nil {
+		t.Fatalf("EnsureBucket() = %v", err)
+	}
+	if _, ok := fake.buckets["uploads"]; !ok {
+		t.Fatal("bucket is not created")
+	}
+	if err := s.EnsureBucket(ctx); err != nil {
+		t.Fatalf("EnsureBucket() for existing bucket = %v", err)
+	}
+
+	// Ключи с пробелами и спецсимволами подписываются в экранированном виде
+	for _, key := range []string{"images/ab/image.png", "images/фото 1+2.webp"} {
+		if err := s.Put(ctx, key, strings.NewReader("data:"+key), -1, "image/png"); err != nil {
+			t.Fatalf("Put(%q) = %v", key, err)
+		}
+		if got := readBlob(t, s, key); got != "data:"+key {
+			t.Fatalf("Get(%q) = %q", key, got)
+		}
+		if ct := fake.types["uploads/"+key]; ct != "image/png" {
+			t.Fatalf("Content-Type of %q = %q, want image/png", key, ct)
+		}
+		if err := s.Delete(ctx, key); err != nil {
+			t.Fatalf("Delete(%q) = %v", key, err)
+		}
+		if _, err := s.Get(ctx, key); !errors.Is(err, ErrNotFound) {
+			t.Fatalf("Get(%q) after delete = %v, want ErrNotFound", key, err)
+		}
+	}
+}
+
+func TestS3StoreSignature(t *testing.T) {
+	ctx := context.Background()
+	_, srv := newFakeS3(t)
+	s := NewS3Store(srv.URL, testRegion, "uploads", testAccessKey, testSecretKey)
+	s.now = func() time.Time { return time.Date(2026, 5, 1, 23, 59, 59, 0, time.FixedZone("MSK", 3*3600)) }
+	if err := s.EnsureBucket(ctx); err != nil {
+		t.Fatalf("EnsureBucket() = %v", err)
+	}
+	if err := s.Put(ctx, "empty", bytes.NewReader(nil), 0, ""); err != nil {
+		t.Fatalf("Put() of empty object = %v", err)
+	}
+
+	wrong := NewS3Store(srv.URL, testRegion, "uploads", testAccessKey, "wrong-secret")
+	err := wrong.Put(ctx, "image.png", strings.NewReader("data"), 4, "image/png")
+	if err == nil || !strings.Contains(err.Error(), "403") {
+		t.Fatalf("Put() with wrong secret = %v, want 403 error", err)
+	}
+}
+
+func TestS3StoreRejectsInvalidKeys(t *testing.T) {
+	ctx := context.Background()
+	_, srv := newFakeS3(t)
+	s := NewS3Store(srv.URL, testRegion, "uploads", testAccessKey, testSecretKey)
+	for _, key := range []string{"", "../image.png", "/image.png", "images//image.png"} {
+		if err := s.Put(ctx, key, strings.NewReader("x"), 1, ""); err == nil {
+			t.Errorf("Put(%q) succeeded", key)
+		}
+		if _, err := s.Get(ctx, key); err == nil {
+			t.Errorf("Get(%q) succeeded", key)
+		}
+		if err := s.Delete(ctx, key); err == nil {
+			t.Errorf("Delete(%q) succeeded", key)
+		}
+	}
+}
@@ -0,0 +1,51 @@
+// Package cursor provides opaque signed tokens for keyset pagination
+package cursor
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"strings"
+)
+
+var ErrInvalidCursor = errors.New("invalid cursor")
+
+// Encode serializes payload to JSON and signs it with HMAC-SHA256.
+//
+// Token format is base64url(payload) + "." + base64url(signature)
+func Encode(payload any, secret string) (string, error) {
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return "", err
+	}
+	body := base64.RawURLEncoding.EncodeToString(data)
+	return body + "." + base64.RawURLEncoding.EncodeToString(sign(body, secret)), nil
+}
+
+// Decode verifies token signature and unmarshals payload
+func Decode(token, secret string, payload any) error {
+	body, sig, ok := strings.Cut(token, ".")
+	if !ok {
+		return ErrInvalidCursor
+	}
+	gotSig, err := base64.RawURLEncoding.DecodeString(sig)
+	if err != nil || !hmac.Equal(gotSig, sign(body, secret)) {
+		return ErrInvalidCursor
+	}
+	data, err := base64.RawURLEncoding.DecodeString(body)
+	if err != nil {
+		return ErrInvalidCursor
+	}
+	if err := json.Unmarshal(data, payload); err != nil {
+		return ErrInvalidCursor
+	}
+	return nil
+}
+
+func sign(body, secret string) []byte {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(body))
+	return mac.Sum(nil)
+}
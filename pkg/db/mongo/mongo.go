@@ -70,19 +70,52 @@ func (m *MongoDB) CreateUniqueIndex(ctx context.Context, collectionName, field s
 	return err
 }
 
+// CreateIndex creates non-unique index with arbitrary keys, e.g. compound, wildcard or geo index
+func (m *MongoDB) CreateIndex(ctx context.Context, collectionName string, keys bson.D) error {
+	collection := m.Database.Collection(collectionName)
+
+	_, err := collection.Indexes().CreateOne(ctx, mongo.IndexModel{Keys: keys})
+
+	return err
+}
+
 func (m *MongoDB) Collection(name string) *mongo.Collection {
 	return m.Database.Collection(name)
 }
 
-func (m *MongoDB) IsDuplicateKeyError(err error) bool {
-	if we, ok := err.(mongo.WriteException); ok {
-		for _, e := range we.WriteErrors {
-			if e.Code == 11000 {
-				return true
-			}
-		}
+// WithTransaction runs fn in a transaction, retrying it on transient errors.
+//
+// Transactions require MongoDB replica set, fn must use the passed context.
+func (m *MongoDB) WithTransaction(ctx context.Context, fn func(ctx context.Context) error) error {
+	session, err := m.Client.StartSession()
+	if err != nil {
+		return fmt.Errorf("mongo start session error: %w", err)
 	}
-	return false
+	defer session.EndSession(ctx)
+
+	_, err = session.WithTransaction(ctx, func(sc mongo.SessionContext) (any, error) {
+		return nil, fn(sc)
+	})
+	return err
+}
+
+// SupportsTransactions reports whether the server is a replica set member or mongos
+func (m *MongoDB) SupportsTransactions(ctx context.Context) (bool, error) {
+	var hello struct {
+		SetName string `bson:"setName"`
+		Msg     string `bson:"msg"`
+	}
+	if err := m.Database.RunCommand(ctx, bson.D{{Key: "hello", Value: 1}}).Decode(&hello); err != nil {
+		return false, fmt.Errorf("mongo hello error: %w", err)
+	}
+	return hello.SetName != "" || hello.Msg == "isdbgrid", nil
+}
+
+// IsDuplicateKeyError reports whether err is a unique index violation.
+//
+// Besides write errors it matches command errors, e.g. of upsert by findAndModify in a transaction.
+func (m *MongoDB) IsDuplicateKeyError(err error) bool {
+	return mongo.IsDuplicateKeyError(err)
 }
 
 func (m *MongoDB) SetupValidation(ctx context.Context, schema bson.M, collectionName string) error {
@@ -0,0 +1,33 @@
+package mongo
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+func TestIsDuplicateKeyError(t *testing.T) {
+	duplicate := mongo.WriteError{Code: 11000, Message: "E11000 duplicate key error"}
+	tests := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{name: "insert", err: mongo.WriteException{WriteErrors: mongo.WriteErrors{duplicate}}, want: true},
+		// Так возвращается гонка upsert через findAndModify внутри транзакции
+		{name: "upsert in transaction", err: mongo.CommandError{Code: 11000, Name: "DuplicateKey"}, want: true},
+		{name: "wrapped", err: fmt.Errorf("create report: %w", mongo.CommandError{Code: 11000}), want: true},
+		{name: "bulk write", err: mongo.BulkWriteException{WriteErrors: []mongo.BulkWriteError{{WriteError: duplicate}}}, want: true},
+		{name: "write conflict", err: mongo.CommandError{Code: 112, Name: "WriteConflict"}},
+		{name: "other", err: errors.New("E11000")},
+		{name: "nil"},
+	}
+	m := &MongoDB{}
+	for _, tt := range tests {
+		if got := m.IsDuplicateKeyError(tt.err); got != tt.want {
+			t.Errorf("%s: IsDuplicateKeyError(%v) = %v, want %v", tt.name, tt.err, got, tt.want)
+		}
+	}
+}
@@ -16,11 +16,103 @@ var (
 	ErrUserNotFound      = errors.New("user not found")
 
 	ErrUnauthorized = errors.New("unauthorized")
+	ErrForbidden    = errors.New("forbidden")
 
+	ErrListingNotFound           = errors.New("listing not found")
 	ErrListingInvalidTitle       = errors.New("invalid title format, expected 3-100 chars")
 	ErrListingInvalidDescription = errors.New("invalid description format, expected be 10-5000 chars")
 	ErrListingInvalidImageURL    = errors.New("invalid image URL, expected valid image valid URL")
+	ErrListingInvalidImages      = errors.New("invalid images, expected unique image URLs with alt text up to 200 chars")
 	ErrListingInvalidPrice       = errors.New("invalid price, expected decimal with up to 2 decimal places between 0 and 1_000_000_000")
+	ErrListingInvalidCurrency    = errors.New("invalid currency, expected supported ISO 4217 code")
+	ErrListingInvalidCategory    = errors.New("invalid category, expected id of existing category")
+	ErrListingInvalidTags        = errors.New("invalid tags, expected up to 10 tags of 1-32 chars")
+	ErrListingInvalidAttributes  = errors.New("invalid attributes")
+	ErrListingInvalidAttrFilter  = errors.New("invalid attribute filter")
+	ErrListingInvalidOwner       = errors.New("invalid owner id")
+	ErrListingInvalidLocation    = errors.New("invalid location, expected GeoJSON point with longitude -180..180 and latitude -90..90")
+	ErrListingInvalidAddress     = errors.New("invalid address, expected up to 200 chars")
+	ErrListingInvalidGeoFilter   = errors.New("invalid geo filter")
+	ErrListingDuplicate          = errors.New("listing duplicates your recent listing")
+	ErrListingContentRejected    = errors.New("listing text violates the rules")
+	ErrListingInvalidDropSince   = errors.New("invalid price_dropped_since, expected RFC 3339 time")
+	ErrListingPriceConflict      = errors.New("price has been changed concurrently, update it again")
 
-	ErrPriceSorting = errors.New("Max price must be greater then min pirce")
+	ErrCategoryNotFound      = errors.New("category not found")
+	ErrCategoryInvalidName   = errors.New("invalid category name, expected 2-64 chars")
+	ErrCategoryInvalidSlug   = errors.New("invalid category slug, expected 2-64 lowercase latin letters, numbers and '-'")
+	ErrCategoryAlreadyExsist = errors.New("category with this slug already exists")
+	ErrCategoryNotEmpty      = errors.New("category has subcategories or listings")
+	ErrCategoryCycle         = errors.New("category cannot be moved into itself or its subcategory")
+	ErrCategoryInvalidSchema = errors.New("invalid category attributes schema")
+
+	ErrUploadNotFound    = errors.New("upload not found")
+	ErrUploadMissingFile = errors.New("multipart field 'file' is required")
+	ErrUploadTooLarge    = errors.New("file is too large")
+	ErrUploadInvalidType = errors.New("unsupported file type, expected jpeg, png, gif or webp image")
+
+	ErrImageNotFound = errors.New("image not found")
+
+	ErrSavedSearchNotFound       = errors.New("saved search not found")
+	ErrSavedSearchInvalidName    = errors.New("invalid saved search name, expected 1-64 chars")
+	ErrSavedSearchInvalidWebhook = errors.New("invalid webhook URL, expected public http or https URL")
+	ErrSavedSearchLimit          = errors.New("too many saved searches")
+
+	ErrNotificationNotFound = errors.New("notification not found")
+
+	ErrPriceAlertNotFound         = errors.New("price alert not found")
+	ErrPriceAlertInvalidThreshold = errors.New("invalid threshold, expected percent between 0 and 100")
+
+	ErrThreadNotFound     = errors.New("thread not found")
+	ErrThreadOwnListing   = errors.New("cannot start a thread about your own listing")
+	ErrThreadRateLimited  = errors.New("too many new threads, try again later")
+	ErrMessageInvalidText = errors.New("invalid message, expected 1-2000 chars")
+
+	ErrRealtimeTooManyConns = errors.New("too many realtime connections")
+
+	ErrOfferNotFound       = errors.New("offer not found")
+	ErrOfferInvalidAmount  = errors.New("invalid offer amount, expected positive price in listing currency")
+	ErrOfferInvalidMessage = errors.New("invalid offer message, expected up to 500 chars")
+	ErrOfferOwnListing     = errors.New("cannot make an offer on your own listing")
+	ErrOfferExists         = errors.New("you already have a pending offer on this listing")
+	ErrOfferNotPending     = errors.New("offer is not pending")
+	ErrOfferNotAllowed     = errors.New("only the other party can respond to the offer")
+	ErrOfferInvalidStatus  = errors.New("invalid offer status")
+	ErrListingNotAvailable = errors.New("listing is not available")
+
+	ErrListingInvalidSaleMode = errors.New("invalid sale mode, expected fixed or auction")
+	ErrListingInvalidAuction  = errors.New("invalid auction")
+	ErrListingIsAuction       = errors.New("not available for auction listings")
+	ErrListingNotAuction      = errors.New("listing is not an auction")
+	ErrAuctionEnded           = errors.New("auction has ended")
+	ErrBidInvalidAmount       = errors.New("invalid bid amount, expected positive price in listing currency")
+	ErrBidTooLow              = errors.New("bid is too low")
+	ErrBidOwnListing          = errors.New("cannot bid on your own listing")
+	ErrBidConflict            = errors.New("auction has changed, place the bid again")
+	ErrListingNotReserved     = errors.New("listing is not reserved for a buyer")
+
+	ErrReviewNotFound      = errors.New("review not found")
+	ErrReviewInvalidRating = errors.New("invalid rating, expected integer from 1 to 5")
+	ErrReviewInvalidText   = errors.New("invalid review text, expected 1-2000 chars")
+	ErrReviewInvalidReason = errors.New("invalid hide reason, expected up to 200 chars")
+	ErrReviewNotAllowed    = errors.New("only the seller and the buyer of the sold listing can review the deal")
+	ErrReviewExists        = errors.New("you have already reviewed this deal")
+
+	ErrReportInvalidReason  = errors.New("invalid report reason, expected scam, prohibited, spam, offensive, wrong_category or other")
+	ErrReportInvalidComment = errors.New("invalid report comment, expected up to 1000 chars")
+	ErrReportOwnListing     = errors.New("cannot report your own listing")
+	ErrReportExists         = errors.New("you have already reported this listing")
+	ErrCaseNotFound         = errors.New("moderation case not found")
+	ErrCaseClaimed          = errors.New("moderation case is claimed by another moderator")
+	ErrCaseNotClaimed       = errors.New("claim the moderation case before resolving it")
+	ErrCaseResolved         = errors.New("moderation case is already resolved")
+	ErrCaseInvalidAction    = errors.New("invalid action, expected dismiss, hide, remove or ban_owner")
+	ErrCaseInvalidStatus    = errors.New("invalid case status, expected open, claimed or resolved")
+	ErrCaseInvalidComment   = errors.New("invalid comment, expected up to 1000 chars")
+	ErrUserBanned           = errors.New("user is banned")
+
+	ErrAnalyticsInvalidRange = errors.New("invalid date range, expected from and to as YYYY-MM-DD, from not after to, up to 90 days")
+
+	ErrPriceSorting  = errors.New("Max price must be greater then min pirce")
+	ErrInvalidCursor = errors.New("invalid cursor")
 )
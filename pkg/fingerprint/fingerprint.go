@@ -0,0 +1,117 @@
+// Package fingerprint estimates similarity of texts with MinHash of character shingles
+package fingerprint
+
+import (
+	"encoding/binary"
+	"hash/fnv"
+	"math"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+const (
+	// SignatureSize is the number of hash functions, estimation error is about 1/sqrt(SignatureSize)
+	SignatureSize = 64
+	shingleSize   = 5
+)
+
+// seeds make independent hash functions from one base hash
+var seeds = func() [SignatureSize]uint64 {
+	var s [SignatureSize]uint64
+	x := uint64(0x9e3779b97f4a7c15)
+	for i := range s {
+		// splitmix64
+		x += 0x9e3779b97f4a7c15
+		z := x
+		z = (z ^ (z >> 30)) * 0xbf58476d1ce4e5b9
+		z = (z ^ (z >> 27)) * 0x94d049bb133111eb
+		s[i] = z ^ (z >> 31)
+	}
+	return s
+}()
+
+// Normalize lowercases text, replaces punctuation with spaces and collapses whitespace
+func Normalize(text string) string {
+	var b strings.Builder
+	space := true
+	for _, r := range strings.ToLower(text) {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			b.WriteRune(r)
+			space = false
+			continue
+		}
+		if !space {
+			b.WriteByte(' ')
+			space = true
+		}
+	}
+	return strings.TrimSpace(b.String())
+}
+
+// MinHash returns MinHash signature of normalized text, nil for empty text
+func MinHash(text string) []uint32 {
+	runes := []rune(Normalize(text))
+	if len(runes) == 0 {
+		return nil
+	}
+
+	sig := make([]uint32, SignatureSize)
+	for i := range sig {
+		sig[i] = math.MaxUint32
+	}
+	n := max(len(runes)-shingleSize+1, 1)
+	for i := 0; i < n; i++ {
+		h := fnv.New64a()
+		h.Write([]byte(string(runes[i:min(i+shingleSize, len(runes))])))
+		base := h.Sum64()
+		for j, seed := range seeds {
+			v := uint32(mix(base^seed) >> 32)
+			if v < sig[j] {
+				sig[j] = v
+			}
+		}
+	}
+	return sig
+}
+
+func mix(z uint64) uint64 {
+	z = (z ^ (z >> 33)) * 0xff51afd7ed558ccd
+	z = (z ^ (z >> 33)) * 0xc4ceb9fe1a85ec53
+	return z ^ (z >> 33)
+}
+
+// Similarity estimates Jaccard similarity of shingle sets by two signatures
+func Similarity(a, b []uint32) float64 {
+	if len(a) != SignatureSize || len(b) != SignatureSize {
+		return 0
+	}
+	equal := 0
+	for i := range a {
+		if a[i] == b[i] {
+			equal++
+		}
+	}
+	return float64(equal) / SignatureSize
+}
+
+// Bands splits signature into n bands and returns their keys for locality-sensitive lookup.
+//
+// Similar texts very likely share at least one band key.
+func Bands(sig []uint32, n int) []string {
+	if len(sig) == 0 || n <= 0 {
+		return nil
+	}
+	rows := len(sig) / n
+	keys := make([]string, 0, n)
+	buf := make([]byte, 4*rows)
+	for band := 0; band < n; band++ {
+		for r := 0; r < rows; r++ {
+			binary.LittleEndian.PutUint32(buf[4*r:], sig[band*rows+r])
+		}
+		h := fnv.New64a()
+		h.Write(buf)
+		keys = append(keys, "t"+strconv.Itoa(band)+":"+strconv.FormatUint(h.Sum64(), 36))
+	}
+	return keys
+}
@@ -0,0 +1,33 @@
+package imaging
+
+import (
+	"image"
+	"math/bits"
+
+	xdraw "golang.org/x/image/draw"
+)
+
+// DHash computes 64-bit difference hash of the image.
+//
+// The image is scaled to 9x8 grayscale and each bit tells whether a pixel is brighter
+// than its right neighbour, so the hash survives resizing, recompression and small edits.
+func DHash(img image.Image) uint64 {
+	gray := image.NewGray(image.Rect(0, 0, 9, 8))
+	xdraw.CatmullRom.Scale(gray, gray.Bounds(), img, img.Bounds(), xdraw.Src, nil)
+
+	var hash uint64
+	for y := 0; y < 8; y++ {
+		for x := 0; x < 8; x++ {
+			hash <<= 1
+			if gray.GrayAt(x, y).Y > gray.GrayAt(x+1, y).Y {
+				hash |= 1
+			}
+		}
+	}
+	return hash
+}
+
+// HashDistance returns number of differing bits of two hashes
+func HashDistance(a, b uint64) int {
+	return bits.OnesCount64(a ^ b)
+}
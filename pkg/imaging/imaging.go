@@ -0,0 +1,79 @@
+// Package imaging resizes images and strips their metadata using pure-Go codecs
+package imaging
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	_ "image/gif"
+	"image/jpeg"
+	_ "image/png"
+
+	xdraw "golang.org/x/image/draw"
+	_ "golang.org/x/image/webp"
+)
+
+var ErrUnsupported = errors.New("unsupported image format")
+
+// Config returns dimensions of the image, images with more than maxPixels pixels are rejected.
+//
+// Header is read without decoding pixels, so a small file declaring huge size is rejected cheaply.
+func Config(data []byte, maxPixels int) (image.Config, error) {
+	cfg, _, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return image.Config{}, errors.Join(ErrUnsupported, err)
+	}
+	if maxPixels > 0 && int64(cfg.Width)*int64(cfg.Height) > int64(maxPixels) {
+		return image.Config{}, fmt.Errorf("%w: %dx%d is more than %d pixels", ErrUnsupported, cfg.Width, cfg.Height, maxPixels)
+	}
+	return cfg, nil
+}
+
+// Decode decodes image of at most maxPixels pixels and rotates it according to EXIF orientation
+func Decode(data []byte, maxPixels int) (image.Image, error) {
+	if _, err := Config(data, maxPixels); err != nil {
+		return nil, err
+	}
+	img, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, errors.Join(ErrUnsupported, err)
+	}
+	return Orient(img, Orientation(data)), nil
+}
+
+// Fit scales image down so that its longest side is at most maxSide, smaller images are not upscaled
+func Fit(img image.Image, maxSide int) image.Image {
+	b := img.Bounds()
+	w, h := b.Dx(), b.Dy()
+	if w <= maxSide && h <= maxSide {
+		return img
+	}
+	if w >= h {
+		h = max(1, h*maxSide/w)
+		w = maxSide
+	} else {
+		w = max(1, w*maxSide/h)
+		h = maxSide
+	}
+
+	dst := image.NewRGBA(image.Rect(0, 0, w, h))
+	xdraw.CatmullRom.Scale(dst, dst.Bounds(), img, b, xdraw.Src, nil)
+	return dst
+}
+
+// EncodeJPEG encodes image as JPEG without metadata, transparent pixels become white
+func EncodeJPEG(img image.Image, quality int) ([]byte, error) {
+	b := img.Bounds()
+	flat := image.NewRGBA(image.Rect(0, 0, b.Dx(), b.Dy()))
+	draw.Draw(flat, flat.Bounds(), image.NewUniform(color.White), image.Point{}, draw.Src)
+	draw.Draw(flat, flat.Bounds(), img, b.Min, draw.Over)
+
+	var buf bytes.Buffer
+	if err := jpeg.Encode(&buf, flat, &jpeg.Options{Quality: quality}); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
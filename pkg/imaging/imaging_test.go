@@ -0,0 +1,62 @@
+package imaging
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"hash/crc32"
+	"image"
+	"image/png"
+	"testing"
+)
+
+// bombPNG returns a tiny PNG whose header declares width x height pixels
+func bombPNG(t *testing.T, width, height uint32) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, image.NewGray(image.Rect(0, 0, 1, 1))); err != nil {
+		t.Fatal(err)
+	}
+	data := buf.Bytes()
+	// Сигнатура 8 байт, затем длина, тип и данные IHDR, ширина и высота идут первыми
+	ihdr := data[8+4 : 8+4+4+13]
+	binary.BigEndian.PutUint32(ihdr[4:], width)
+	binary.BigEndian.PutUint32(ihdr[8:], height)
+	binary.BigEndian.PutUint32(data[8+4+4+13:], crc32.ChecksumIEEE(ihdr))
+	return data
+}
+
+func TestDecodeRejectsTooManyPixels(t *testing.T) {
+	const maxPixels = 40_000_000
+	tests := []struct {
+		name          string
+		width, height uint32
+		ok            bool
+	}{
+		{name: "small", width: 1, height: 1, ok: true},
+		{name: "bomb", width: 50000, height: 50000},
+		{name: "just above limit", width: 8001, height: 5000},
+		{name: "overflowing product", width: 1<<31 - 1, height: 1<<31 - 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data := bombPNG(t, tt.width, tt.height)
+			cfg, err := Config(data, maxPixels)
+			if tt.ok {
+				if err != nil || cfg.Width != int(tt.width) {
+					t.Fatalf("Config() = %+v, %v", cfg, err)
+				}
+				if _, err := Decode(data, maxPixels); err != nil {
+					t.Fatalf("Decode() = %v", err)
+				}
+				return
+			}
+			if !errors.Is(err, ErrUnsupported) {
+				t.Fatalf("Config() = %v, want ErrUnsupported", err)
+			}
+			if _, err := Decode(data, maxPixels); !errors.Is(err, ErrUnsupported) {
+				t.Fatalf("Decode() = %v, want ErrUnsupported", err)
+			}
+		})
+	}
+}
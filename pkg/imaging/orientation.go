@@ -0,0 +1,95 @@
+package imaging
+
+import (
+	"encoding/binary"
+	"image"
+)
+
+// Orientation returns EXIF orientation (1-8) of JPEG image, 1 if it is unknown
+func Orientation(data []byte) int {
+	exif := jpegExif(data)
+	if len(exif) < 8 {
+		return 1
+	}
+
+	var order binary.ByteOrder
+	switch string(exif[:2]) {
+	case "II":
+		order = binary.LittleEndian
+	case "MM":
+		order = binary.BigEndian
+	default:
+		return 1
+	}
+
+	// IFD0: число записей и записи по 12 байт
+	ifd := int(order.Uint32(exif[4:8]))
+	if ifd+2 > len(exif) {
+		return 1
+	}
+	count := int(order.Uint16(exif[ifd : ifd+2]))
+	for i := 0; i < count; i++ {
+		entry := ifd + 2 + i*12
+		if entry+12 > len(exif) {
+			return 1
+		}
+		if order.Uint16(exif[entry:entry+2]) == 0x0112 {
+			o := int(order.Uint16(exif[entry+8 : entry+10]))
+			if o >= 1 && o <= 8 {
+				return o
+			}
+			return 1
+		}
+	}
+	return 1
+}
+
+// jpegExif returns TIFF structure from the APP1 Exif segment
+func jpegExif(data []byte) []byte {
+	for _, seg := range jpegSegments(data) {
+		if seg.marker == 0xE1 && len(seg.payload) > 6 && string(seg.payload[:6]) == "Exif\x00\x00" {
+			return seg.payload[6:]
+		}
+	}
+	return nil
+}
+
+// Orient applies EXIF orientation to pixels
+func Orient(img image.Image, orientation int) image.Image {
+	if orientation <= 1 || orientation > 8 {
+		return img
+	}
+
+	b := img.Bounds()
+	w, h := b.Dx(), b.Dy()
+	// Ориентации 5-8 меняют ширину и высоту местами
+	dw, dh := w, h
+	if orientation >= 5 {
+		dw, dh = h, w
+	}
+	dst := image.NewRGBA(image.Rect(0, 0, dw, dh))
+
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			var dx, dy int
+			switch orientation {
+			case 2:
+				dx, dy = w-1-x, y
+			case 3:
+				dx, dy = w-1-x, h-1-y
+			case 4:
+				dx, dy = x, h-1-y
+			case 5:
+				dx, dy = y, x
+			case 6:
+				dx, dy = h-1-y, x
+			case 7:
+				dx, dy = h-1-y, w-1-x
+			case 8:
+				dx, dy = y, w-1-x
+			}
+			dst.Set(dx, dy, img.At(b.Min.X+x, b.Min.Y+y))
+		}
+	}
+	return dst
+}
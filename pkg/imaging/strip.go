@@ -0,0 +1,137 @@
+package imaging
+
+import (
+	"bytes"
+	"encoding/binary"
+)
+
+// StripMetadata removes EXIF, XMP and text metadata from JPEG, PNG and WebP images
+// without re-encoding pixels. Other formats are returned as is.
+//
+// EXIF orientation is lost, so JPEG with orientation other than 1 should be re-encoded instead.
+func StripMetadata(data []byte, mime string) ([]byte, error) {
+	switch mime {
+	case "image/jpeg":
+		return stripJPEG(data)
+	case "image/png":
+		return stripPNG(data)
+	case "image/webp":
+		return stripWebP(data)
+	}
+	return data, nil
+}
+
+type jpegSegment struct {
+	marker  byte
+	raw     []byte
+	payload []byte
+}
+
+// jpegSegments splits JPEG header into segments up to the start of scan
+func jpegSegments(data []byte) []jpegSegment {
+	if len(data) < 4 || data[0] != 0xFF || data[1] != 0xD8 {
+		return nil
+	}
+	var segments []jpegSegment
+	pos := 2
+	for pos+4 <= len(data) {
+		if data[pos] != 0xFF {
+			return segments
+		}
+		marker := data[pos+1]
+		if marker == 0xDA {
+			return segments
+		}
+		length := int(binary.BigEndian.Uint16(data[pos+2 : pos+4]))
+		end := pos + 2 + length
+		if length < 2 || end > len(data) {
+			return segments
+		}
+		segments = append(segments, jpegSegment{marker: marker, raw: data[pos:end], payload: data[pos+4 : end]})
+		pos = end
+	}
+	return segments
+}
+
+func stripJPEG(data []byte) ([]byte, error) {
+	segments := jpegSegments(data)
+	if segments == nil {
+		return nil, ErrUnsupported
+	}
+
+	out := bytes.NewBuffer(make([]byte, 0, len(data)))
+	out.Write(data[:2])
+	pos := 2
+	for _, seg := range segments {
+		pos += len(seg.raw)
+		// APP1 (EXIF, XMP), APP13 (IPTC) и комментарии содержат метаданные,
+		// APP0 (JFIF), APP2 (ICC профиль) и APP14 (Adobe) нужны для отображения
+		if seg.marker == 0xE1 || seg.marker == 0xED || seg.marker == 0xFE {
+			continue
+		}
+		out.Write(seg.raw)
+	}
+	out.Write(data[pos:])
+	return out.Bytes(), nil
+}
+
+var pngSignature = []byte("\x89PNG\r\n\x1a\n")
+
+func stripPNG(data []byte) ([]byte, error) {
+	if !bytes.HasPrefix(data, pngSignature) {
+		return nil, ErrUnsupported
+	}
+
+	out := bytes.NewBuffer(make([]byte, 0, len(data)))
+	out.Write(pngSignature)
+	pos := len(pngSignature)
+	for pos+12 <= len(data) {
+		length := int(binary.BigEndian.Uint32(data[pos : pos+4]))
+		end := pos + 12 + length
+		if end > len(data) {
+			return nil, ErrUnsupported
+		}
+		switch string(data[pos+4 : pos+8]) {
+		case "eXIf", "tEXt", "iTXt", "zTXt", "tIME":
+		default:
+			out.Write(data[pos:end])
+		}
+		pos = end
+	}
+	return out.Bytes(), nil
+}
+
+func stripWebP(data []byte) ([]byte, error) {
+	if len(data) < 12 || string(data[:4]) != "RIFF" || string(data[8:12]) != "WEBP" {
+		return nil, ErrUnsupported
+	}
+
+	var chunks bytes.Buffer
+	pos := 12
+	for pos+8 <= len(data) {
+		fourCC := string(data[pos : pos+4])
+		size := int(binary.LittleEndian.Uint32(data[pos+4 : pos+8]))
+		end := pos + 8 + size + size%2
+		if end > len(data) {
+			return nil, ErrUnsupported
+		}
+		switch fourCC {
+		case "EXIF", "XMP ":
+		case "VP8X":
+			chunk := bytes.Clone(data[pos:end])
+			// Флаги наличия EXIF (0x08) и XMP (0x04)
+			chunk[8] &^= 0x08 | 0x04
+			chunks.Write(chunk)
+		default:
+			chunks.Write(data[pos:end])
+		}
+		pos = end
+	}
+
+	out := bytes.NewBuffer(make([]byte, 0, 12+chunks.Len()))
+	out.WriteString("RIFF")
+	binary.Write(out, binary.LittleEndian, uint32(4+chunks.Len()))
+	out.WriteString("WEBP")
+	out.Write(chunks.Bytes())
+	return out.Bytes(), nil
+}
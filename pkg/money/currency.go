@@ -0,0 +1,29 @@
+package money
+
+import "strings"
+
+// minorUnits maps supported ISO 4217 currency codes to number of digits after the decimal point
+var minorUnits = map[string]int32{
+	"AED": 2, "AMD": 2, "AZN": 2, "BYN": 2, "CAD": 2,
+	"CHF": 2, "CNY": 2, "CZK": 2, "EUR": 2, "GBP": 2,
+	"GEL": 2, "HKD": 2, "ILS": 2, "INR": 2, "JPY": 0,
+	"KGS": 2, "KRW": 0, "KWD": 3, "KZT": 2, "MDL": 2,
+	"NOK": 2, "PLN": 2, "RSD": 2, "RUB": 2, "SEK": 2,
+	"TJS": 2, "TMT": 2, "TRY": 2, "UAH": 2, "USD": 2,
+	"UZS": 2,
+}
+
+// NormalizeCurrency uppercases currency code and reports whether it is a known ISO 4217 code
+func NormalizeCurrency(code string) (string, bool) {
+	code = strings.ToUpper(strings.TrimSpace(code))
+	_, ok := minorUnits[code]
+	return code, ok
+}
+
+// MinorUnits returns number of digits after the decimal point of the currency, 2 for unknown ones
+func MinorUnits(code string) int32 {
+	if units, ok := minorUnits[code]; ok {
+		return units
+	}
+	return 2
+}
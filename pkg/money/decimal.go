@@ -0,0 +1,125 @@
+// Package money provides exact decimal amounts, ISO 4217 currencies and exchange rates
+package money
+
+import (
+	"fmt"
+	"math/big"
+	"strconv"
+
+	"github.com/shopspring/decimal"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/bsontype"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/x/bsonx/bsoncore"
+)
+
+// Decimal is an exact decimal number.
+//
+// It is stored in Mongo as Decimal128 and serialized to JSON as a string, e.g. "1999.90".
+// JSON numbers are accepted on input for backward compatibility.
+type Decimal struct {
+	decimal.Decimal
+}
+
+var Zero = Decimal{decimal.Zero}
+
+func New(d decimal.Decimal) Decimal {
+	return Decimal{d}
+}
+
+func NewFromString(s string) (Decimal, error) {
+	d, err := decimal.NewFromString(s)
+	if err != nil {
+		return Zero, err
+	}
+	return Decimal{d}, nil
+}
+
+func NewFromInt(i int64) Decimal {
+	return Decimal{decimal.NewFromInt(i)}
+}
+
+// String keeps trailing zeros of the value scale, so price 10.50 stays "10.50"
+func (d Decimal) String() string {
+	if d.Exponent() >= 0 {
+		return d.Decimal.String()
+	}
+	return d.StringFixed(-d.Exponent())
+}
+
+func (d Decimal) MarshalJSON() ([]byte, error) {
+	return []byte(strconv.Quote(d.String())), nil
+}
+
+// Decimal128 converts value for use in Mongo queries, Decimal128 keeps up to 34 significant digits
+func (d Decimal) Decimal128() (primitive.Decimal128, error) {
+	d128, err := primitive.ParseDecimal128(d.String())
+	if err != nil {
+		return primitive.Decimal128{}, fmt.Errorf("money: %s does not fit into Decimal128: %w", d.String(), err)
+	}
+	return d128, nil
+}
+
+// Scale returns number of significant digits after the decimal point, e.g. 1 for 10.50
+func (d Decimal) Scale() int32 {
+	coef, exp := d.Coefficient(), d.Exponent()
+	ten, rem := big.NewInt(10), new(big.Int)
+	for exp < 0 {
+		quo, r := new(big.Int).QuoRem(coef, ten, rem)
+		if r.Sign() != 0 {
+			break
+		}
+		coef, exp = quo, exp+1
+	}
+	if exp >= 0 {
+		return 0
+	}
+	return -exp
+}
+
+func (d Decimal) MarshalBSONValue() (bsontype.Type, []byte, error) {
+	d128, err := d.Decimal128()
+	if err != nil {
+		return 0, nil, err
+	}
+	return bson.MarshalValue(d128)
+}
+
+func (d *Decimal) UnmarshalBSONValue(t bsontype.Type, data []byte) error {
+	switch t {
+	case bsontype.Decimal128:
+		d128, _, ok := bsoncore.ReadDecimal128(data)
+		if !ok {
+			return fmt.Errorf("money: invalid Decimal128 value")
+		}
+		parsed, err := decimal.NewFromString(d128.String())
+		if err != nil {
+			return err
+		}
+		d.Decimal = parsed
+	// Старые документы хранили цену в double
+	case bsontype.Double:
+		f, _, ok := bsoncore.ReadDouble(data)
+		if !ok {
+			return fmt.Errorf("money: invalid double value")
+		}
+		d.Decimal = decimal.NewFromFloat(f)
+	case bsontype.Int32:
+		i, _, ok := bsoncore.ReadInt32(data)
+		if !ok {
+			return fmt.Errorf("money: invalid int32 value")
+		}
+		d.Decimal = decimal.NewFromInt32(i)
+	case bsontype.Int64:
+		i, _, ok := bsoncore.ReadInt64(data)
+		if !ok {
+			return fmt.Errorf("money: invalid int64 value")
+		}
+		d.Decimal = decimal.NewFromInt(i)
+	case bsontype.Null, bsontype.Undefined:
+		d.Decimal = decimal.Zero
+	default:
+		return fmt.Errorf("money: cannot decode %s into Decimal", t)
+	}
+	return nil
+}
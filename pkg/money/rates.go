@@ -0,0 +1,104 @@
+package money
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+
+	"github.com/shopspring/decimal"
+)
+
+type RatesConfig struct {
+	RatesFile string `env:"RATES_FILE" env-default:"./rates.json"`
+}
+
+// StaticRates is an exchange rate provider backed by a JSON file:
+//
+//	{"base": "RUB", "rates": {"USD": "92.50", "EUR": "100.10"}}
+//
+// Rates are prices of one unit of currency in the base currency.
+type StaticRates struct {
+	base  string
+	rates map[string]decimal.Decimal
+}
+
+type ratesFile struct {
+	Base  string             `json:"base"`
+	Rates map[string]Decimal `json:"rates"`
+}
+
+// NewStaticRates loads rates from the file
+func NewStaticRates(path string) (*StaticRates, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("read rates file: %w", err)
+	}
+
+	var f ratesFile
+	if err := json.Unmarshal(data, &f); err != nil {
+		return nil, fmt.Errorf("parse rates file: %w", err)
+	}
+
+	rates := make(map[string]decimal.Decimal, len(f.Rates))
+	for code, rate := range f.Rates {
+		rates[code] = rate.Decimal
+	}
+	return NewStaticRatesFrom(f.Base, rates)
+}
+
+// NewStaticRatesFrom creates provider from base currency and rates to it
+func NewStaticRatesFrom(base string, rates map[string]decimal.Decimal) (*StaticRates, error) {
+	base, ok := NormalizeCurrency(base)
+	if !ok {
+		return nil, fmt.Errorf("unknown base currency %q", base)
+	}
+
+	sr := &StaticRates{
+		base:  base,
+		rates: map[string]decimal.Decimal{base: decimal.NewFromInt(1)},
+	}
+	for code, rate := range rates {
+		code, ok := NormalizeCurrency(code)
+		if !ok {
+			return nil, fmt.Errorf("unknown currency %q", code)
+		}
+		if !rate.IsPositive() {
+			return nil, fmt.Errorf("rate of %s must be positive", code)
+		}
+		if code != base {
+			sr.rates[code] = rate
+		}
+	}
+	return sr, nil
+}
+
+func (sr *StaticRates) Base() string {
+	return sr.base
+}
+
+// Currencies returns supported currency codes in alphabetical order
+func (sr *StaticRates) Currencies() []string {
+	codes := make([]string, 0, len(sr.rates))
+	for code := range sr.rates {
+		codes = append(codes, code)
+	}
+	sort.Strings(codes)
+	return codes
+}
+
+// Rate returns how many units of currency to one unit of currency from costs
+func (sr *StaticRates) Rate(from, to string) (Decimal, error) {
+	fromRate, ok := sr.rates[from]
+	if !ok {
+		return Zero, fmt.Errorf("unsupported currency %q", from)
+	}
+	toRate, ok := sr.rates[to]
+	if !ok {
+		return Zero, fmt.Errorf("unsupported currency %q", to)
+	}
+	if from == to {
+		return NewFromInt(1), nil
+	}
+	return Decimal{fromRate.DivRound(toRate, 12)}, nil
+}
@@ -0,0 +1,53 @@
+package pubsub
+
+import (
+	"context"
+	"sync"
+)
+
+const memoryBuffer = 1024
+
+// Memory delivers messages to subscribers of the same process
+type Memory struct {
+	mu     sync.RWMutex
+	topics map[string]map[chan []byte]struct{}
+}
+
+func NewMemory() *Memory {
+	return &Memory{topics: make(map[string]map[chan []byte]struct{})}
+}
+
+// Publish never blocks, a message is dropped for subscribers with full buffer
+func (m *Memory) Publish(ctx context.Context, topic string, payload []byte) error {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	for ch := range m.topics[topic] {
+		select {
+		case ch <- payload:
+		default:
+		}
+	}
+	return nil
+}
+
+func (m *Memory) Subscribe(ctx context.Context, topic string) (<-chan []byte, error) {
+	ch := make(chan []byte, memoryBuffer)
+	m.mu.Lock()
+	if m.topics[topic] == nil {
+		m.topics[topic] = make(map[chan []byte]struct{})
+	}
+	m.topics[topic][ch] = struct{}{}
+	m.mu.Unlock()
+
+	go func() {
+		<-ctx.Done()
+		m.mu.Lock()
+		delete(m.topics[topic], ch)
+		if len(m.topics[topic]) == 0 {
+			delete(m.topics, topic)
+		}
+		m.mu.Unlock()
+		close(ch)
+	}()
+	return ch, nil
+}
@@ -0,0 +1,31 @@
+// Package pubsub delivers messages between instances of the service
+package pubsub
+
+import (
+	"context"
+	"fmt"
+)
+
+type PubSubConfig struct {
+	Driver string `env:"PUBSUB_DRIVER" env-default:"memory"`
+}
+
+// PubSub broadcasts messages to all subscribers of a topic.
+//
+// Delivery is at most once, subscribers which do not keep up may lose messages.
+type PubSub interface {
+	Publish(ctx context.Context, topic string, payload []byte) error
+	// Subscribe returns channel of topic messages, it is closed when ctx is done
+	Subscribe(ctx context.Context, topic string) (<-chan []byte, error)
+}
+
+// New creates pubsub selected by cfg.Driver.
+//
+// The memory driver works within a single instance only.
+func New(cfg PubSubConfig) (PubSub, error) {
+	switch cfg.Driver {
+	case "memory":
+		return NewMemory(), nil
+	}
+	return nil, fmt.Errorf("unknown pubsub driver %q", cfg.Driver)
+}
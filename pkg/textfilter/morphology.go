@@ -0,0 +1,97 @@
+package textfilter
+
+import (
+	"sort"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+const minStemLen = 3
+
+// Латинские буквы и цифры, которыми подменяют похожие кириллические, и ё
+var homoglyphs = map[rune]rune{
+	'a': 'а', 'b': 'в', 'c': 'с', 'e': 'е', 'h': 'н', 'k': 'к', 'm': 'м',
+	'o': 'о', 'p': 'р', 't': 'т', 'x': 'х', 'y': 'у', 'ё': 'е',
+	'0': 'о', '3': 'з', '6': 'б',
+}
+
+// Окончания существительных, прилагательных и глаголов, длинные раньше коротких
+var endings = func() []string {
+	e := []string{
+		"иями", "ями", "ами", "иях", "ях", "ах", "ием", "ьми",
+		"ов", "ев", "ей", "ий", "ый", "ой", "ая", "яя", "ое", "ее", "ие", "ые",
+		"ого", "его", "ому", "ему", "ым", "им", "ых", "их", "ую", "юю", "ом", "ем", "ам", "ям",
+		"ью", "ия", "ию", "ии", "ье", "ья",
+		"ться", "тся", "ть", "ешь", "ишь", "ете", "ите", "ет", "ит", "ут", "ют", "ат", "ят",
+		"ла", "ло", "ли", "л",
+		"а", "я", "о", "е", "и", "ы", "у", "ю", "ь", "й",
+	}
+	// Снимается самое длинное подходящее окончание
+	sort.SliceStable(e, func(i, j int) bool {
+		return utf8.RuneCountInString(e[i]) > utf8.RuneCountInString(e[j])
+	})
+	return e
+}()
+
+// Fold lowercases the word and replaces look-alike latin letters and digits with cyrillic ones
+// if the word contains cyrillic letters
+func Fold(word string) string {
+	word = strings.ToLower(word)
+	cyrillic := false
+	for _, r := range word {
+		if unicode.Is(unicode.Cyrillic, r) {
+			cyrillic = true
+			break
+		}
+	}
+	if !cyrillic {
+		return word
+	}
+	return strings.Map(func(r rune) rune {
+		if c, ok := homoglyphs[r]; ok {
+			return c
+		}
+		return r
+	}, word)
+}
+
+// Stem strips the inflectional ending of the folded russian word,
+// so that different forms of the word have the same stem
+func Stem(word string) string {
+	n := utf8.RuneCountInString(word)
+	for _, e := range endings {
+		if strings.HasSuffix(word, e) && n-utf8.RuneCountInString(e) >= minStemLen {
+			return strings.TrimSuffix(word, e)
+		}
+	}
+	return word
+}
+
+type token struct {
+	stem       string
+	start, end int
+}
+
+// tokenize splits text into words with their byte offsets
+func tokenize(text string) []token {
+	var tokens []token
+	start := -1
+	flush := func(end int) {
+		if start >= 0 {
+			tokens = append(tokens, token{stem: Stem(Fold(text[start:end])), start: start, end: end})
+			start = -1
+		}
+	}
+	for i, r := range text {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			if start < 0 {
+				start = i
+			}
+			continue
+		}
+		flush(i)
+	}
+	flush(len(text))
+	return tokens
+}
@@ -0,0 +1,356 @@
+// Package textfilter checks user texts with stop-word, contact and style rules.
+//
+// Rules are described in JSON:
+//
+//	{"rules": [
+//	  {"name": "drugs", "type": "stop_words", "action": "reject", "words": ["наркотик", "закладк*"]},
+//	  {"type": "phone", "action": "mask"},
+//	  {"type": "caps", "action": "flag", "max_ratio": 0.6, "min_letters": 20}
+//	]}
+//
+// Stop words match any form of the word, words ending with * match by prefix.
+package textfilter
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+const (
+	ActionReject = "reject"
+	ActionMask   = "mask"
+	ActionFlag   = "flag"
+)
+
+const (
+	TypeStopWords = "stop_words"
+	TypePhone     = "phone"
+	TypeEmail     = "email"
+	TypeURL       = "url"
+	TypeCaps      = "caps"
+	TypeEmoji     = "emoji"
+)
+
+const (
+	defaultCapsRatio   = 0.5
+	defaultCapsLetters = 20
+	defaultMaxEmoji    = 3
+	maskRune           = '*'
+)
+
+var (
+	phoneRe = regexp.MustCompile(`(?:(?:\+7|[78])[\s\-]*)?\(?[1-9]\d{2}[\s\-)]*\d{3}[\s\-]*\d{2}[\s\-]*\d{2}`)
+	emailRe = regexp.MustCompile(`(?i)[a-z0-9._%+\-]+\s*(?:@|\(at\)|\[at\])\s*[a-z0-9\-]+(?:\.[a-z0-9\-]+)*\.[a-z]{2,}`)
+	urlRe   = regexp.MustCompile(`(?i)(?:https?://|www\.)[^\s]+|(?:[\p{L}0-9\-]+\.)+(?:ru|com|net|org|su|me|io|info|biz|рф)(?:/[^\s]*)?`)
+)
+
+type Rule struct {
+	// Name is reported in results, type by default
+	Name   string `json:"name,omitempty"`
+	Type   string `json:"type"`
+	Action string `json:"action"`
+	// Words are stop words and phrases of stop_words rule
+	Words []string `json:"words,omitempty"`
+	// MaxRatio is maximal share of upper-case letters of caps rule
+	MaxRatio float64 `json:"max_ratio,omitempty"`
+	// MinLetters is the number of letters from which caps rule is checked
+	MinLetters int `json:"min_letters,omitempty"`
+	// MaxCount is maximal number of emoji of emoji rule
+	MaxCount int `json:"max_count,omitempty"`
+}
+
+type rulesFile struct {
+	Rules []Rule `json:"rules"`
+}
+
+// Result is the result of filtering the text.
+//
+// Text has fragments masked by mask rules, Rules are names of all matched rules.
+type Result struct {
+	Text    string
+	Reject  bool
+	Flagged bool
+	Rules   []string
+}
+
+// Filter is a compiled set of rules, safe for concurrent use
+type Filter struct {
+	rules []compiledRule
+}
+
+type compiledRule struct {
+	Rule
+	// phrases are stems of stop words, a stem ending with * matches by prefix
+	phrases [][]string
+}
+
+// Parse compiles rules from JSON
+func Parse(data []byte) (*Filter, error) {
+	var f rulesFile
+	if err := json.Unmarshal(data, &f); err != nil {
+		return nil, fmt.Errorf("parse rules: %w", err)
+	}
+	return Compile(f.Rules)
+}
+
+// Compile validates rules and prepares stop words for matching
+func Compile(rules []Rule) (*Filter, error) {
+	f := &Filter{rules: make([]compiledRule, 0, len(rules))}
+	for i, r := range rules {
+		if r.Name == "" {
+			r.Name = r.Type
+		}
+		switch r.Action {
+		case ActionReject, ActionMask, ActionFlag:
+		default:
+			return nil, fmt.Errorf("rule %d %q: unknown action %q", i, r.Name, r.Action)
+		}
+
+		c := compiledRule{Rule: r}
+		switch r.Type {
+		case TypeStopWords:
+			for _, w := range r.Words {
+				if phrase := stemPhrase(w); len(phrase) > 0 {
+					c.phrases = append(c.phrases, phrase)
+				}
+			}
+			if len(c.phrases) == 0 {
+				return nil, fmt.Errorf("rule %d %q: no stop words", i, r.Name)
+			}
+		case TypeCaps:
+			if c.MaxRatio <= 0 || c.MaxRatio >= 1 {
+				c.MaxRatio = defaultCapsRatio
+			}
+			if c.MinLetters <= 0 {
+				c.MinLetters = defaultCapsLetters
+			}
+		case TypeEmoji:
+			if c.MaxCount <= 0 {
+				c.MaxCount = defaultMaxEmoji
+			}
+		case TypePhone, TypeEmail, TypeURL:
+		default:
+			return nil, fmt.Errorf("rule %d %q: unknown type %q", i, r.Name, r.Type)
+		}
+		f.rules = append(f.rules, c)
+	}
+	return f, nil
+}
+
+// Len returns the number of rules
+func (f *Filter) Len() int {
+	return len(f.rules)
+}
+
+// Apply checks the text with all rules in order, each mask rule sees the text masked by previous ones
+func (f *Filter) Apply(text string) Result {
+	res := Result{Text: text}
+	for _, r := range f.rules {
+		matched, masked := r.apply(res.Text)
+		if !matched {
+			continue
+		}
+		res.Rules = append(res.Rules, r.Name)
+		switch r.Action {
+		case ActionReject:
+			res.Reject = true
+		case ActionFlag:
+			res.Flagged = true
+		case ActionMask:
+			res.Text = masked
+		}
+	}
+	return res
+}
+
+// apply reports whether the rule matches the text and returns the text masked by the rule
+func (r *compiledRule) apply(text string) (bool, string) {
+	switch r.Type {
+	case TypeStopWords:
+		return maskSpans(text, r.matchStopWords(text))
+	case TypePhone:
+		return maskSpans(text, findIsolated(phoneRe, text, func(s string) bool {
+			digits := 0
+			for _, c := range s {
+				if c >= '0' && c <= '9' {
+					digits++
+				}
+			}
+			return digits >= 10 && digits <= 11
+		}))
+	case TypeEmail:
+		return maskSpans(text, findIsolated(emailRe, text, nil))
+	case TypeURL:
+		return maskSpans(text, findIsolated(urlRe, text, nil))
+	case TypeCaps:
+		if !isShouting(text, r.MaxRatio, r.MinLetters) {
+			return false, text
+		}
+		return true, sentenceCase(text)
+	case TypeEmoji:
+		return limitEmoji(text, r.MaxCount)
+	}
+	return false, text
+}
+
+func (r *compiledRule) matchStopWords(text string) [][2]int {
+	tokens := tokenize(text)
+	var spans [][2]int
+	for i := range tokens {
+		for _, phrase := range r.phrases {
+			if i+len(phrase) > len(tokens) {
+				continue
+			}
+			matched := true
+			for j, stem := range phrase {
+				if !matchStem(tokens[i+j].stem, stem) {
+					matched = false
+					break
+				}
+			}
+			if matched {
+				spans = append(spans, [2]int{tokens[i].start, tokens[i+len(phrase)-1].end})
+			}
+		}
+	}
+	return spans
+}
+
+func stemPhrase(phrase string) []string {
+	var stems []string
+	for _, w := range strings.Fields(phrase) {
+		if prefix, ok := strings.CutSuffix(w, "*"); ok {
+			if prefix = Fold(prefix); prefix != "" {
+				stems = append(stems, prefix+"*")
+			}
+			continue
+		}
+		stems = append(stems, Stem(Fold(w)))
+	}
+	return stems
+}
+
+func matchStem(word, stem string) bool {
+	if prefix, ok := strings.CutSuffix(stem, "*"); ok {
+		return strings.HasPrefix(word, prefix)
+	}
+	return word == stem
+}
+
+// findIsolated returns spans of regexp matches not glued to neighbouring letters and digits
+func findIsolated(re *regexp.Regexp, text string, valid func(string) bool) [][2]int {
+	var spans [][2]int
+	for _, m := range re.FindAllStringIndex(text, -1) {
+		before, _ := utf8.DecodeLastRuneInString(text[:m[0]])
+		after, _ := utf8.DecodeRuneInString(text[m[1]:])
+		if isWordRune(before) || before == '@' || isWordRune(after) {
+			continue
+		}
+		if valid != nil && !valid(text[m[0]:m[1]]) {
+			continue
+		}
+		spans = append(spans, [2]int{m[0], m[1]})
+	}
+	return spans
+}
+
+func isWordRune(r rune) bool {
+	return r != utf8.RuneError && (unicode.IsLetter(r) || unicode.IsDigit(r))
+}
+
+// maskSpans replaces non-space runes of the spans with *
+func maskSpans(text string, spans [][2]int) (bool, string) {
+	if len(spans) == 0 {
+		return false, text
+	}
+	b := []byte(text)
+	var out strings.Builder
+	last := 0
+	for _, s := range spans {
+		if s[0] < last {
+			s[0] = last
+		}
+		if s[0] >= s[1] {
+			continue
+		}
+		out.Write(b[last:s[0]])
+		for _, r := range text[s[0]:s[1]] {
+			if unicode.IsSpace(r) {
+				out.WriteRune(r)
+			} else {
+				out.WriteRune(maskRune)
+			}
+		}
+		last = s[1]
+	}
+	out.Write(b[last:])
+	return true, out.String()
+}
+
+func isShouting(text string, maxRatio float64, minLetters int) bool {
+	letters, upper := 0, 0
+	for _, r := range text {
+		if !unicode.IsLetter(r) {
+			continue
+		}
+		letters++
+		if unicode.IsUpper(r) {
+			upper++
+		}
+	}
+	return letters >= minLetters && float64(upper) > maxRatio*float64(letters)
+}
+
+// sentenceCase lowercases the text and capitalizes the first letter of each sentence
+func sentenceCase(text string) string {
+	var b strings.Builder
+	start := true
+	for _, r := range strings.ToLower(text) {
+		if start && unicode.IsLetter(r) {
+			r = unicode.ToUpper(r)
+			start = false
+		}
+		if r == '.' || r == '!' || r == '?' || r == '\n' {
+			start = true
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
+// limitEmoji keeps the first maxCount emoji and removes the rest
+func limitEmoji(text string, maxCount int) (bool, string) {
+	var b strings.Builder
+	count := 0
+	dropped := false
+	for _, r := range text {
+		switch {
+		case isEmoji(r):
+			count++
+			if count > maxCount {
+				dropped = true
+				continue
+			}
+		case r == '\u200d' || r == '\ufe0f':
+			// Модификаторы удалённых эмодзи удаляются вместе с ними
+			if count > maxCount {
+				continue
+			}
+		}
+		b.WriteRune(r)
+	}
+	if !dropped {
+		return false, text
+	}
+	return true, b.String()
+}
+
+func isEmoji(r rune) bool {
+	return (r >= 0x1f300 && r <= 0x1faff) ||
+		(r >= 0x2600 && r <= 0x27bf) ||
+		(r >= 0x1f000 && r <= 0x1f2ff)
+}
@@ -0,0 +1,253 @@
+package textfilter
+
+import (
+	"slices"
+	"testing"
+)
+
+func mustCompile(t *testing.T, rules ...Rule) *Filter {
+	t.Helper()
+	f, err := Compile(rules)
+	if err != nil {
+		t.Fatalf("Compile() = %v", err)
+	}
+	return f
+}
+
+func TestStem(t *testing.T) {
+	tests := []struct {
+		words []string
+		stem  string
+	}{
+		{words: []string{"наркотик", "наркотики", "наркотиков", "наркотиками", "наркотику"}, stem: "наркотик"},
+		{words: []string{"документ", "документы", "документов", "документами"}, stem: "документ"},
+		{words: []string{"поддельный", "поддельные", "поддельных", "поддельного"}, stem: "поддельн"},
+		{words: []string{"оружие", "оружия", "оружием"}, stem: "оруж"},
+		// Короткие слова не обрезаются до бессмысленной основы
+		{words: []string{"кот"}, stem: "кот"},
+		{words: []string{"коты"}, stem: "кот"},
+	}
+	for _, tt := range tests {
+		for _, w := range tt.words {
+			if got := Stem(Fold(w)); got != tt.stem {
+				t.Errorf("Stem(%q) = %q, want %q", w, got, tt.stem)
+			}
+		}
+	}
+}
+
+func TestFold(t *testing.T) {
+	tests := []struct {
+		word string
+		want string
+	}{
+		{word: "НАРКОТИК", want: "наркотик"},
+		{word: "нaркoтик", want: "наркотик"},
+		{word: "з4кл4дк1", want: "з4кл4дк1"},
+		{word: "3акладка", want: "закладка"},
+		{word: "ёлка", want: "елка"},
+		// Латинские слова без кириллицы не меняются
+		{word: "Spice", want: "spice"},
+		{word: "book", want: "book"},
+	}
+	for _, tt := range tests {
+		if got := Fold(tt.word); got != tt.want {
+			t.Errorf("Fold(%q) = %q, want %q", tt.word, got, tt.want)
+		}
+	}
+}
+
+func TestStopWords(t *testing.T) {
+	f := mustCompile(t, Rule{
+		Name:   "prohibited",
+		Type:   TypeStopWords,
+		Action: ActionReject,
+		Words:  []string{"наркотик", "закладк*", "оружие", "поддельн документ", "фальшив*"},
+	})
+	tests := []struct {
+		text    string
+		matched bool
+	}{
+		{text: "Продам наркотики", matched: true},
+		{text: "НАРКОТИКОВ много", matched: true},
+		{text: "нaркoтики с доставкой", matched: true},
+		{text: "сделаю закладку", matched: true},
+		{text: "Закладки в городе", matched: true},
+		{text: "поддельные документы недорого", matched: true},
+		{text: "Поддельных документов нет", matched: true},
+		{text: "фальшивые купюры", matched: true},
+		{text: "оружием", matched: true},
+		// Похожие слова и части фраз не совпадают
+		{text: "книга по наркотикологии"},
+		{text: "оружейный магазин"},
+		{text: "нефальшивый мёд"},
+		{text: "документы на машину"},
+		{text: "поддельный автограф"},
+		{text: "документы поддельные"},
+		{text: "закладная на квартиру"},
+		{text: "Spice Girls, виниловая пластинка"},
+	}
+	for _, tt := range tests {
+		res := f.Apply(tt.text)
+		if res.Reject != tt.matched {
+			t.Errorf("Apply(%q).Reject = %v, want %v", tt.text, res.Reject, tt.matched)
+		}
+		if res.Text != tt.text {
+			t.Errorf("Apply(%q).Text = %q, reject rules must not change text", tt.text, res.Text)
+		}
+	}
+}
+
+func TestMask(t *testing.T) {
+	tests := []struct {
+		name string
+		rule Rule
+		text string
+		want string
+	}{
+		{
+			name: "stop word",
+			rule: Rule{Type: TypeStopWords, Words: []string{"наркотик"}},
+			text: "Продам наркотики оптом",
+			want: "Продам ********* оптом",
+		},
+		{
+			name: "phrase keeps spaces",
+			rule: Rule{Type: TypeStopWords, Words: []string{"перевод на карту"}},
+			text: "Только перевод на карту!",
+			want: "Только ******* ** *****!",
+		},
+		{
+			name: "phone",
+			rule: Rule{Type: TypePhone},
+			text: "Звоните +7 (912) 345-67-89 вечером",
+			want: "Звоните ** ***** ********* вечером",
+		},
+		{
+			name: "phone without code",
+			rule: Rule{Type: TypePhone},
+			text: "тел. 89123456789",
+			want: "тел. ***********",
+		},
+		{
+			name: "phone-like numbers",
+			rule: Rule{Type: TypePhone},
+			text: "Артикул 12345678901234, цена 1500, 2020-2023 г.",
+			want: "Артикул 12345678901234, цена 1500, 2020-2023 г.",
+		},
+		{
+			name: "email",
+			rule: Rule{Type: TypeEmail},
+			text: "пишите на ivan.petrov@mail.ru или ivan (at) mail.ru",
+			want: "пишите на ******************* или **** **** *******",
+		},
+		{
+			name: "url",
+			rule: Rule{Type: TypeURL},
+			text: "Подробнее на https://example.com/lot?id=1 и avito.ru",
+			want: "Подробнее на **************************** и ********",
+		},
+		{
+			name: "caps",
+			rule: Rule{Type: TypeCaps},
+			text: "СРОЧНО ПРОДАМ ВЕЛОСИПЕД НЕДОРОГО. ТОРГ",
+			want: "Срочно продам велосипед недорого. Торг",
+		},
+		{
+			name: "short caps",
+			rule: Rule{Type: TypeCaps},
+			text: "ПРОДАМ КОТА",
+			want: "ПРОДАМ КОТА",
+		},
+		{
+			name: "abbreviations",
+			rule: Rule{Type: TypeCaps},
+			text: "Продам ноутбук HP, SSD 512 ГБ, ОЗУ 16 ГБ, в хорошем состоянии",
+			want: "Продам ноутбук HP, SSD 512 ГБ, ОЗУ 16 ГБ, в хорошем состоянии",
+		},
+		{
+			name: "emoji",
+			rule: Rule{Type: TypeEmoji, MaxCount: 2},
+			text: "Продам 🔥🔥🔥 кота 😺",
+			want: "Продам 🔥🔥 кота ",
+		},
+		{
+			name: "emoji within limit",
+			rule: Rule{Type: TypeEmoji, MaxCount: 2},
+			text: "Продам кота 😺",
+			want: "Продам кота 😺",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.rule.Action = ActionMask
+			res := mustCompile(t, tt.rule).Apply(tt.text)
+			if res.Text != tt.want {
+				t.Fatalf("Apply(%q).Text = %q, want %q", tt.text, res.Text, tt.want)
+			}
+			if matched := tt.text != tt.want; matched != (len(res.Rules) > 0) {
+				t.Fatalf("Apply(%q).Rules = %v", tt.text, res.Rules)
+			}
+			if res.Reject || res.Flagged {
+				t.Fatalf("mask rule rejected or flagged %q", tt.text)
+			}
+		})
+	}
+}
+
+func TestApplyActions(t *testing.T) {
+	f, err := Parse([]byte(`{"rules": [
+		{"name": "prohibited", "type": "stop_words", "action": "reject", "words": ["наркотик"]},
+		{"name": "prepayment", "type": "stop_words", "action": "flag", "words": ["предоплата на карту"]},
+		{"type": "phone", "action": "mask"},
+		{"type": "url", "action": "flag"}
+	]}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if f.Len() != 4 {
+		t.Fatalf("Len() = %d, want 4", f.Len())
+	}
+
+	tests := []struct {
+		text    string
+		reject  bool
+		flagged bool
+		rules   []string
+		masked  string
+	}{
+		{text: "Продам велосипед", masked: "Продам велосипед"},
+		{text: "Продам наркотики", reject: true, rules: []string{"prohibited"}, masked: "Продам наркотики"},
+		{text: "Только предоплата на карту", flagged: true, rules: []string{"prepayment"}, masked: "Только предоплата на карту"},
+		{
+			text:    "Предоплата на карту, звоните 8 912 345 67 89, фото на avito.ru",
+			flagged: true,
+			rules:   []string{"prepayment", "phone", "url"},
+			masked:  "Предоплата на карту, звоните * *** *** ** **, фото на avito.ru",
+		},
+	}
+	for _, tt := range tests {
+		res := f.Apply(tt.text)
+		if res.Reject != tt.reject || res.Flagged != tt.flagged || !slices.Equal(res.Rules, tt.rules) || res.Text != tt.masked {
+			t.Errorf("Apply(%q) = %+v, want reject %v, flagged %v, rules %v, text %q",
+				tt.text, res, tt.reject, tt.flagged, tt.rules, tt.masked)
+		}
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		data string
+	}{
+		{name: "invalid json", data: `{"rules": [`},
+		{name: "unknown action", data: `{"rules": [{"type": "phone", "action": "drop"}]}`},
+		{name: "unknown type", data: `{"rules": [{"type": "phone_number", "action": "mask"}]}`},
+		{name: "no stop words", data: `{"rules": [{"type": "stop_words", "action": "reject", "words": ["", " * "]}]}`},
+	}
+	for _, tt := range tests {
+		if _, err := Parse([]byte(tt.data)); err == nil {
+			t.Errorf("Parse(%s) succeeded, want error", tt.name)
+		}
+	}
+}
@@ -26,6 +26,7 @@ const (
 	ErrInvalidImageURL ErrorCode = iota
 	ErrImageTooLarge
 	ErrInvalidImageMimeType
+	ErrForbiddenImageURL
 )
 
 func (e PasswordError) Error() string {
@@ -69,10 +70,10 @@ func newContainsLogin() PasswordError {
 	}
 }
 
-func newImageTooLarge() ImageError {
+func newImageTooLarge(maxSize int64) ImageError {
 	return ImageError{
 		Code:    ErrImageTooLarge,
-		Message: "image is too large (max 50MB)",
+		Message: fmt.Sprintf("image is too large (max %d bytes)", maxSize),
 	}
 }
 
@@ -89,3 +90,10 @@ func newInvalidImageMimeType() ImageError {
 		Message: "invalid image mime type",
 	}
 }
+
+func newForbiddenImageURL(reason string) ImageError {
+	return ImageError{
+		Code:    ErrForbiddenImageURL,
+		Message: "image URL is not allowed: " + reason,
+	}
+}
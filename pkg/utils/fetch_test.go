@@ -0,0 +1,204 @@
+package utils
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"net/url"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+// loopbackConfig allows connections to httptest servers
+func loopbackConfig() ImageFetchConfig {
+	return ImageFetchConfig{AllowedNetworks: []string{"127.0.0.0/8"}, MaxRedirects: 3}
+}
+
+func isForbidden(err error) bool {
+	var imageErr ImageError
+	return errors.As(err, &imageErr) && imageErr.Code == ErrForbiddenImageURL
+}
+
+// redirectServer redirects /hop/N to /hop/N-1 and serves the image at /hop/0
+func redirectServer(t *testing.T) *httptest.Server {
+	t.Helper()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.URL.Path == "/hop/0":
+			w.Header().Set("Content-Type", "image/png")
+			w.Write([]byte("png"))
+		case strings.HasPrefix(r.URL.Path, "/hop/"):
+			n, err := strconv.Atoi(strings.TrimPrefix(r.URL.Path, "/hop/"))
+			if err != nil {
+				http.NotFound(w, r)
+				return
+			}
+			http.Redirect(w, r, "/hop/"+strconv.Itoa(n-1), http.StatusFound)
+		case r.URL.Path == "/to":
+			http.Redirect(w, r, r.URL.Query().Get("url"), http.StatusFound)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func TestImageFetcherBlocksPrivateAddressesOnConnect(t *testing.T) {
+	srv := redirectServer(t)
+	f := NewImageFetcher(ImageFetchConfig{MaxRedirects: 3})
+
+	// Имя проходит проверку URL, адрес отклоняется при подключении
+	u, _ := url.Parse(srv.URL)
+	for _, rawURL := range []string{
+		srv.URL + "/hop/0",
+		"http://localhost:" + u.Port() + "/hop/0",
+	} {
+		resp, err := f.Get(rawURL)
+		if err == nil {
+			resp.Body.Close()
+			t.Fatalf("Get(%q) succeeded, want forbidden", rawURL)
+		}
+		if !isForbidden(err) {
+			t.Fatalf("Get(%q) error = %v, want forbidden", rawURL, err)
+		}
+	}
+
+	resp, err := NewImageFetcher(loopbackConfig()).Get(srv.URL + "/hop/0")
+	if err != nil {
+		t.Fatalf("Get with allowed network: %v", err)
+	}
+	resp.Body.Close()
+}
+
+func TestImageFetcherValidatesRedirectHops(t *testing.T) {
+	srv := redirectServer(t)
+	cfg := loopbackConfig()
+	cfg.DeniedHosts = []string{"denied.example"}
+	f := NewImageFetcher(cfg)
+
+	tests := []struct {
+		name   string
+		target string
+	}{
+		{name: "private address", target: "http://10.0.0.1/img.png"},
+		{name: "metadata address", target: "http://169.254.169.254/latest/meta-data"},
+		{name: "denied host", target: "http://img.denied.example/img.png"},
+		{name: "scheme", target: "file:///etc/passwd"},
+		{name: "credentials", target: "http://user:pass@" + strings.TrimPrefix(srv.URL, "http://") + "/hop/0"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp, err := f.Get(srv.URL + "/to?url=" + url.QueryEscape(tt.target))
+			if err == nil {
+				resp.Body.Close()
+				t.Fatalf("redirect to %q succeeded, want forbidden", tt.target)
+			}
+			if !isForbidden(err) {
+				t.Fatalf("redirect to %q error = %v, want forbidden", tt.target, err)
+			}
+		})
+	}
+}
+
+func TestImageFetcherMaxRedirects(t *testing.T) {
+	srv := redirectServer(t)
+	f := NewImageFetcher(loopbackConfig())
+
+	resp, err := f.Get(srv.URL + "/hop/3")
+	if err != nil {
+		t.Fatalf("Get with 3 redirects: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("status = %d, want 200", resp.StatusCode)
+	}
+
+	resp, err = f.Get(srv.URL + "/hop/4")
+	if err == nil {
+		resp.Body.Close()
+		t.Fatal("Get with 4 redirects succeeded, want forbidden")
+	}
+	if !isForbidden(err) {
+		t.Fatalf("Get with 4 redirects error = %v, want forbidden", err)
+	}
+}
+
+func TestImageFetcherHostLists(t *testing.T) {
+	tests := []struct {
+		name    string
+		allowed []string
+		denied  []string
+		url     string
+		ok      bool
+	}{
+		{name: "any host", url: "https://cdn.example.com/a.png", ok: true},
+		{name: "allowed host", allowed: []string{"example.com"}, url: "https://example.com/a.png", ok: true},
+		{name: "allowed subdomain", allowed: []string{"Example.com."}, url: "https://cdn.EXAMPLE.com./a.png", ok: true},
+		{name: "not allowed", allowed: []string{"example.com"}, url: "https://example.org/a.png"},
+		{name: "suffix is not subdomain", allowed: []string{"example.com"}, url: "https://badexample.com/a.png"},
+		{name: "denied host", denied: []string{"example.com"}, url: "https://example.com/a.png"},
+		{name: "denied subdomain", denied: []string{"example.com"}, url: "https://cdn.example.com/a.png"},
+		{name: "deny wins", allowed: []string{"example.com"}, denied: []string{"cdn.example.com"}, url: "https://cdn.example.com/a.png"},
+		{name: "scheme", url: "ftp://example.com/a.png"},
+		{name: "credentials", url: "https://user@example.com/a.png"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := NewImageFetcher(ImageFetchConfig{AllowedHosts: tt.allowed, DeniedHosts: tt.denied})
+			err := f.CheckURL(tt.url)
+			if tt.ok && err != nil {
+				t.Fatalf("CheckURL(%q) = %v, want nil", tt.url, err)
+			}
+			if !tt.ok && !isForbidden(err) {
+				t.Fatalf("CheckURL(%q) = %v, want forbidden", tt.url, err)
+			}
+		})
+	}
+}
+
+func TestImageFetcherPublicAddr(t *testing.T) {
+	f := NewImageFetcher(ImageFetchConfig{AllowedNetworks: []string{"10.1.0.0/16"}})
+	tests := []struct {
+		addr   string
+		public bool
+	}{
+		{addr: "93.184.216.34", public: true},
+		{addr: "2a00:1450:4010::64", public: true},
+		{addr: "127.0.0.1"},
+		{addr: "10.0.0.1"},
+		{addr: "10.1.2.3", public: true},
+		{addr: "169.254.169.254"},
+		{addr: "100.64.0.1"},
+		{addr: "0.0.0.0"},
+		{addr: "::1"},
+		{addr: "::ffff:127.0.0.1"},
+		{addr: "fc00::1"},
+		{addr: "fe80::1"},
+		{addr: "64:ff9b::a00:1"},
+		{addr: "2001:0:4136:e378:8000:63bf:3fff:fdd2"},
+		{addr: "2002:a00:1::1"},
+	}
+	for _, tt := range tests {
+		if got := f.publicAddr(netip.MustParseAddr(tt.addr)); got != tt.public {
+			t.Errorf("publicAddr(%s) = %v, want %v", tt.addr, got, tt.public)
+		}
+	}
+}
+
+func TestFetchImageTooLarge(t *testing.T) {
+	srv := redirectServer(t)
+	f := NewImageFetcher(loopbackConfig())
+
+	_, _, err := f.FetchImage(srv.URL+"/hop/0", 2)
+	var imageErr ImageError
+	if !errors.As(err, &imageErr) || imageErr.Code != ErrImageTooLarge {
+		t.Fatalf("FetchImage() error = %v, want too large", err)
+	}
+	// В сообщении настроенный предел, а не константа
+	if want := "max 2 bytes"; !strings.Contains(imageErr.Message, want) {
+		t.Fatalf("FetchImage() error = %q, want it to contain %q", imageErr.Message, want)
+	}
+}
@@ -1,47 +1,70 @@
 package utils
 
 import (
+	"bytes"
+	"errors"
+	"image"
+	_ "image/gif"
+	_ "image/jpeg"
+	_ "image/png"
 	"io"
 	"net/http"
-	"time"
+	"strings"
 )
 
-func ValidateImageURL(imageURL string) error {
-	const maxContentLength int64 = 50 * 1024 * 1024 // 50 MB
-	const maxBytesToRead int64 = 512 * 1024
-
-	client := &http.Client{
-		Timeout: 10 * time.Second,
-	}
+// ImageInfo describes image detected by FetchImage.
+//
+// Width and Height are zero if the format is not decodable.
+type ImageInfo struct {
+	Mime   string
+	Width  int
+	Height int
+}
 
-	resp, err := client.Get(imageURL)
+// FetchImage downloads the whole image up to maxSize bytes and detects its type and dimensions
+func (f *ImageFetcher) FetchImage(imageURL string, maxSize int64) ([]byte, *ImageInfo, error) {
+	resp, err := f.Get(imageURL)
 	if err != nil {
-		return newInvalidImageURL()
+		var imageErr ImageError
+		if errors.As(err, &imageErr) {
+			return nil, nil, imageErr
+		}
+		return nil, nil, newInvalidImageURL()
 	}
 	defer resp.Body.Close()
 
-	// Check HTTP status
 	if resp.StatusCode < 200 || resp.StatusCode >= 400 {
-		return newInvalidImageURL()
+		return nil, nil, newInvalidImageURL()
+	}
+	if resp.ContentLength > maxSize {
+		return nil, nil, newImageTooLarge(maxSize)
 	}
 
-	// Optional size check
-	if resp.ContentLength > 0 && resp.ContentLength > maxContentLength {
-		return newImageTooLarge()
+	data, err := io.ReadAll(io.LimitReader(resp.Body, maxSize+1))
+	if err != nil {
+		return nil, nil, newInvalidImageURL()
+	}
+	if int64(len(data)) > maxSize {
+		return nil, nil, newImageTooLarge(maxSize)
 	}
 
-	// Read limited bytes to detect content type
-	limitedReader := io.LimitReader(resp.Body, maxBytesToRead)
-	buffer := make([]byte, 512)
-	n, err := limitedReader.Read(buffer)
-	if err != nil && err != io.EOF {
-		return newInvalidImageURL()
+	info, err := detectImage(data)
+	if err != nil {
+		return nil, nil, err
 	}
+	return data, info, nil
+}
 
-	contentType := http.DetectContentType(buffer[:n])
-	if contentType == "" || contentType[:6] != "image/" {
-		return newInvalidImageMimeType()
+func detectImage(data []byte) (*ImageInfo, error) {
+	contentType := http.DetectContentType(data)
+	if !strings.HasPrefix(contentType, "image/") {
+		return nil, newInvalidImageMimeType()
 	}
 
-	return nil
+	info := &ImageInfo{Mime: contentType}
+	if cfg, _, err := image.DecodeConfig(bytes.NewReader(data)); err == nil {
+		info.Width = cfg.Width
+		info.Height = cfg.Height
+	}
+	return info, nil
 }